@@ -0,0 +1,3 @@
+-- 0001_init.down.sql
+
+DROP TABLE IF EXISTS public.packet_max;
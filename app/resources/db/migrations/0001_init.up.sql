@@ -1,4 +1,4 @@
--- 0001_init.sql
+-- 0001_init.up.sql
 
 CREATE TABLE IF NOT EXISTS public.packet_max (
   packet_id UUID NOT NULL,
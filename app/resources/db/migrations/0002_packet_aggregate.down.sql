@@ -0,0 +1,3 @@
+-- 0002_packet_aggregate.down.sql
+
+DROP TABLE IF EXISTS public.packet_aggregate;
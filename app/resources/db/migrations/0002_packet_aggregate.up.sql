@@ -0,0 +1,13 @@
+-- 0002_packet_aggregate.up.sql
+
+CREATE TABLE IF NOT EXISTS public.packet_aggregate (
+  packet_id     UUID NOT NULL,
+  function_name TEXT NOT NULL,
+  source_id     UUID NULL,
+  value         DOUBLE PRECISION NOT NULL,
+  ts            TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (packet_id, function_name)
+);
+
+CREATE INDEX IF NOT EXISTS packet_aggregate_function_ts_idx
+  ON public.packet_aggregate (function_name, ts DESC);
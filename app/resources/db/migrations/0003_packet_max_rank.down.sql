@@ -0,0 +1,6 @@
+-- 0003_packet_max_rank.down.sql
+
+DROP INDEX IF EXISTS public.packet_max_packet_rank_idx;
+
+ALTER TABLE public.packet_max
+  DROP COLUMN IF EXISTS rank;
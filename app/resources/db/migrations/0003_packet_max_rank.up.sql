@@ -0,0 +1,7 @@
+-- 0003_packet_max_rank.up.sql
+
+ALTER TABLE public.packet_max
+  ADD COLUMN IF NOT EXISTS rank SMALLINT NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS packet_max_packet_rank_idx
+  ON public.packet_max (packet_id, rank);
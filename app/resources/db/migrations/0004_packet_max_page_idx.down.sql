@@ -0,0 +1,3 @@
+-- 0004_packet_max_page_idx.down.sql
+
+DROP INDEX IF EXISTS public.packet_max_ts_packet_idx;
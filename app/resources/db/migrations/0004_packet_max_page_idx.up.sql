@@ -0,0 +1,5 @@
+-- 0004_packet_max_page_idx.up.sql
+
+CREATE INDEX IF NOT EXISTS packet_max_ts_packet_idx
+  ON public.packet_max (ts, packet_id)
+  WHERE rank = 1;
@@ -0,0 +1,47 @@
+-- 0005_packet_max_partitioned.down.sql
+--
+-- Rebuilds public.packet_max as a plain table keyed by (packet_id, source_id). Should a packet
+-- and source have rows at several timestamps, the row with the greatest value is kept whole, the
+-- latest of those on a tie. Detached partitions are left alone.
+
+DO $$
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1
+    FROM pg_class c
+    JOIN pg_namespace n ON n.oid = c.relnamespace
+    WHERE n.nspname = 'public' AND c.relname = 'packet_max' AND c.relkind = 'p'
+  ) THEN
+    RETURN;
+  END IF;
+
+  CREATE TABLE public.packet_max_unpartitioned (
+    packet_id UUID NOT NULL,
+    source_id UUID NOT NULL,
+    value     DOUBLE PRECISION NOT NULL,
+    ts        TIMESTAMPTZ NOT NULL,
+    rank      SMALLINT NOT NULL DEFAULT 1,
+    CONSTRAINT packet_max_unpartitioned_pkey PRIMARY KEY (packet_id, source_id)
+  );
+
+  INSERT INTO public.packet_max_unpartitioned (packet_id, source_id, value, ts, rank)
+  SELECT DISTINCT ON (packet_id, source_id) packet_id, source_id, value, ts, rank
+  FROM public.packet_max
+  ORDER BY packet_id, source_id, value DESC, ts DESC;
+
+  DROP TABLE public.packet_max;
+  ALTER TABLE public.packet_max_unpartitioned RENAME TO packet_max;
+  ALTER TABLE public.packet_max
+    RENAME CONSTRAINT packet_max_unpartitioned_pkey TO packet_max_pkey;
+
+  CREATE INDEX packet_max_ts_idx
+    ON public.packet_max (ts DESC);
+  CREATE UNIQUE INDEX packet_max_packet_source_uidx
+    ON public.packet_max (packet_id, source_id);
+  CREATE INDEX packet_max_packet_rank_idx
+    ON public.packet_max (packet_id, rank);
+  CREATE INDEX packet_max_ts_packet_idx
+    ON public.packet_max (ts, packet_id)
+    WHERE rank = 1;
+END
+$$;
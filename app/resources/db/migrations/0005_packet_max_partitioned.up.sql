@@ -0,0 +1,70 @@
+-- 0005_packet_max_partitioned.up.sql
+--
+-- Rebuilds public.packet_max as a table range-partitioned by ts. Existing rows are copied into
+-- monthly partitions named packet_max_pYYYYMM (UTC); partitions for new data are created by the
+-- service's partition maintainer, daily or monthly as configured. Rows outside every partition
+-- land in packet_max_default.
+--
+-- Postgres requires the partition key in every unique constraint, so the primary key becomes
+-- (packet_id, source_id, ts). It keeps the name of the unique index from 0001, which turns that
+-- CREATE UNIQUE INDEX IF NOT EXISTS into a no-op on the partitioned table. The service still
+-- keeps one row per (packet_id, source_id): its upsert deletes the stored row and reinserts it
+-- at the new ts, whichever partition that falls into.
+
+DO $$
+DECLARE
+  month_start timestamp;
+BEGIN
+  IF EXISTS (
+    SELECT 1
+    FROM pg_class c
+    JOIN pg_namespace n ON n.oid = c.relnamespace
+    WHERE n.nspname = 'public' AND c.relname = 'packet_max' AND c.relkind = 'p'
+  ) THEN
+    RETURN;
+  END IF;
+
+  CREATE TABLE public.packet_max_partitioned (
+    packet_id UUID NOT NULL,
+    source_id UUID NOT NULL,
+    value     DOUBLE PRECISION NOT NULL,
+    ts        TIMESTAMPTZ NOT NULL,
+    rank      SMALLINT NOT NULL DEFAULT 1,
+    CONSTRAINT packet_max_partitioned_pkey PRIMARY KEY (packet_id, source_id, ts)
+  ) PARTITION BY RANGE (ts);
+
+  CREATE TABLE public.packet_max_default
+    PARTITION OF public.packet_max_partitioned DEFAULT;
+
+  FOR month_start IN
+    SELECT generate_series(
+      date_trunc('month', min(ts) AT TIME ZONE 'UTC'),
+      date_trunc('month', max(ts) AT TIME ZONE 'UTC'),
+      interval '1 month')
+    FROM public.packet_max
+  LOOP
+    EXECUTE format(
+      'CREATE TABLE public.%I PARTITION OF public.packet_max_partitioned FOR VALUES FROM (%L) TO (%L)',
+      'packet_max_p' || to_char(month_start, 'YYYYMM'),
+      month_start AT TIME ZONE 'UTC',
+      (month_start + interval '1 month') AT TIME ZONE 'UTC');
+  END LOOP;
+
+  INSERT INTO public.packet_max_partitioned (packet_id, source_id, value, ts, rank)
+  SELECT packet_id, source_id, value, ts, rank
+  FROM public.packet_max;
+
+  DROP TABLE public.packet_max;
+  ALTER TABLE public.packet_max_partitioned RENAME TO packet_max;
+  ALTER TABLE public.packet_max
+    RENAME CONSTRAINT packet_max_partitioned_pkey TO packet_max_packet_source_uidx;
+
+  CREATE INDEX packet_max_ts_idx
+    ON public.packet_max (ts DESC);
+  CREATE INDEX packet_max_packet_rank_idx
+    ON public.packet_max (packet_id, rank);
+  CREATE INDEX packet_max_ts_packet_idx
+    ON public.packet_max (ts, packet_id)
+    WHERE rank = 1;
+END
+$$;
@@ -0,0 +1,6 @@
+-- 0006_packet_aggregate_page_idx.up.sql
+
+CREATE INDEX IF NOT EXISTS packet_aggregate_function_ts_packet_idx
+  ON public.packet_aggregate (function_name, ts, packet_id);
+
+DROP INDEX IF EXISTS public.packet_aggregate_function_ts_idx;
@@ -56,6 +56,14 @@ func (m *Mux) Get(pattern string, handler http.HandlerFunc) {
 	m.Method(http.MethodGet, pattern, handler)
 }
 
+func (m *Mux) Post(pattern string, handler http.HandlerFunc) {
+	m.Method(http.MethodPost, pattern, handler)
+}
+
+func (m *Mux) Patch(pattern string, handler http.HandlerFunc) {
+	m.Method(http.MethodPatch, pattern, handler)
+}
+
 func (m *Mux) Method(method, pattern string, handler http.HandlerFunc) {
 	if method == "" || pattern == "" || handler == nil {
 		return
@@ -71,6 +71,22 @@ func TestMethodRegistersHandlers(t *testing.T) {
 	rr := httptest.NewRecorder()
 	mux.ServeHTTP(rr, req)
 	assert.Equal(t, http.StatusAccepted, rr.Code)
+
+	t.Log("регистрируем обработчик через Post")
+	mux.Post("/submit-post", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/submit-post", nil))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	t.Log("регистрируем обработчик через Patch")
+	mux.Patch("/submit-patch", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPatch, "/submit-patch", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
 }
 
 func TestServeHTTPHandlesNotFoundAndMethodNotAllowed(t *testing.T) {
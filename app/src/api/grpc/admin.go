@@ -0,0 +1,150 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	pb "aggregator-service/app/src/api/grpc/pb"
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/infra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type generatorAdminServer struct {
+	pb.UnimplementedGeneratorAdminServer
+	controller domain.GeneratorController
+	token      string
+	logger     *infra.Logger
+}
+
+func (s *generatorAdminServer) GetGeneratorState(ctx context.Context, _ *pb.GetGeneratorStateRequest) (*pb.GeneratorState, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return toProtoGeneratorState(s.controller.State()), nil
+}
+
+func (s *generatorAdminServer) PauseGenerator(ctx context.Context, _ *pb.PauseGeneratorRequest) (*pb.GeneratorState, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	s.controller.Pause()
+	s.logf(ctx, "admin: генератор поставлен на паузу")
+	return toProtoGeneratorState(s.controller.State()), nil
+}
+
+func (s *generatorAdminServer) ResumeGenerator(ctx context.Context, _ *pb.ResumeGeneratorRequest) (*pb.GeneratorState, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	s.controller.Resume()
+	s.logf(ctx, "admin: генератор возобновлён")
+	return toProtoGeneratorState(s.controller.State()), nil
+}
+
+// UpdateGenerator changes only the fields that are set. packet_size fixes the size; the range
+// bounds keep their current value when omitted. The packet size is applied first so a rejected
+// request leaves the generator unchanged.
+func (s *generatorAdminServer) UpdateGenerator(ctx context.Context, req *pb.UpdateGeneratorRequest) (*pb.GeneratorState, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "request must not be nil")
+	}
+	if req.IntervalMs != nil && req.GetIntervalMs() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "interval_ms must be positive")
+	}
+	if req.PacketSize != nil && (req.MinPacketSize != nil || req.MaxPacketSize != nil) {
+		return nil, status.Error(codes.InvalidArgument, "packet_size cannot be combined with min_packet_size or max_packet_size")
+	}
+
+	if req.PacketSize != nil || req.MinPacketSize != nil || req.MaxPacketSize != nil {
+		state := s.controller.State()
+		minSize, maxSize := state.MinPacketSize, state.MaxPacketSize
+		switch {
+		case req.PacketSize != nil:
+			minSize, maxSize = int(req.GetPacketSize()), int(req.GetPacketSize())
+		default:
+			if req.MinPacketSize != nil {
+				minSize = int(req.GetMinPacketSize())
+			}
+			if req.MaxPacketSize != nil {
+				maxSize = int(req.GetMaxPacketSize())
+			}
+		}
+		if err := s.controller.SetPacketSize(minSize, maxSize); err != nil {
+			return nil, translateGeneratorError(err)
+		}
+	}
+
+	if req.IntervalMs != nil {
+		if err := s.controller.SetInterval(time.Duration(req.GetIntervalMs()) * time.Millisecond); err != nil {
+			return nil, translateGeneratorError(err)
+		}
+	}
+
+	s.logf(ctx, "admin: параметры генератора обновлены")
+	return toProtoGeneratorState(s.controller.State()), nil
+}
+
+func (s *generatorAdminServer) authorize(ctx context.Context) error {
+	if s.token == "" {
+		return status.Error(codes.Unavailable, "admin API is disabled")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	authorized := false
+	for _, value := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(value, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(s.token)) == 1 {
+			authorized = true
+			break
+		}
+	}
+	if !authorized {
+		return status.Error(codes.Unauthenticated, "invalid or missing admin token")
+	}
+
+	if s.controller == nil {
+		return status.Error(codes.Unavailable, "generator control is unavailable")
+	}
+	return nil
+}
+
+func (s *generatorAdminServer) logf(ctx context.Context, format string, v ...any) {
+	if s.logger != nil {
+		s.logger.Printf(ctx, format, v...)
+	}
+}
+
+func translateGeneratorError(err error) error {
+	if errors.Is(err, domain.ErrInvalidGeneratorSetting) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, "internal server error")
+}
+
+func toProtoGeneratorState(state domain.GeneratorState) *pb.GeneratorState {
+	changes := make([]*pb.GeneratorChange, len(state.Changes))
+	for i, change := range state.Changes {
+		changes[i] = &pb.GeneratorChange{
+			At:     timestamppb.New(change.At.UTC()),
+			Action: change.Action,
+			Detail: change.Detail,
+		}
+	}
+	return &pb.GeneratorState{
+		Paused:        state.Paused,
+		IntervalMs:    state.Interval.Milliseconds(),
+		MinPacketSize: int32(state.MinPacketSize),
+		MaxPacketSize: int32(state.MaxPacketSize),
+		Changes:       changes,
+	}
+}
@@ -0,0 +1,141 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	pb "aggregator-service/app/src/api/grpc/pb"
+	"aggregator-service/app/src/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const testAdminToken = "s3cret"
+
+type stubGeneratorController struct {
+	state   domain.GeneratorState
+	calls   []string
+	sizeErr error
+}
+
+func (s *stubGeneratorController) State() domain.GeneratorState {
+	return s.state
+}
+
+func (s *stubGeneratorController) Pause() {
+	s.calls = append(s.calls, "pause")
+	s.state.Paused = true
+}
+
+func (s *stubGeneratorController) Resume() {
+	s.calls = append(s.calls, "resume")
+	s.state.Paused = false
+}
+
+func (s *stubGeneratorController) SetInterval(interval time.Duration) error {
+	s.calls = append(s.calls, fmt.Sprintf("interval %s", interval))
+	s.state.Interval = interval
+	return nil
+}
+
+func (s *stubGeneratorController) SetPacketSize(min, max int) error {
+	if s.sizeErr != nil {
+		return s.sizeErr
+	}
+	s.calls = append(s.calls, fmt.Sprintf("size %d..%d", min, max))
+	s.state.MinPacketSize, s.state.MaxPacketSize = min, max
+	return nil
+}
+
+func adminContext(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+func TestGeneratorAdminAuthorization(t *testing.T) {
+	controller := &stubGeneratorController{}
+	server := &generatorAdminServer{controller: controller, token: testAdminToken}
+
+	t.Log("Шаг 1: вызов без метаданных отклоняется")
+	_, err := server.PauseGenerator(context.Background(), &pb.PauseGeneratorRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	t.Log("Шаг 2: вызов с неверным токеном отклоняется")
+	_, err = server.PauseGenerator(adminContext("wrong"), &pb.PauseGeneratorRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Empty(t, controller.calls)
+
+	t.Log("Шаг 3: без токена сервис выключен, без генератора — недоступен")
+	_, err = (&generatorAdminServer{controller: controller}).GetGeneratorState(adminContext(""), &pb.GetGeneratorStateRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	_, err = (&generatorAdminServer{token: testAdminToken}).GetGeneratorState(adminContext(testAdminToken), &pb.GetGeneratorStateRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestGeneratorAdminPauseResumeAndState(t *testing.T) {
+	at := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	controller := &stubGeneratorController{state: domain.GeneratorState{
+		Interval:      1500 * time.Millisecond,
+		MinPacketSize: 2,
+		MaxPacketSize: 6,
+		Changes:       []domain.GeneratorChange{{At: at, Action: "interval", Detail: "1s -> 1.5s"}},
+	}}
+	server := &generatorAdminServer{controller: controller, token: testAdminToken}
+	ctx := adminContext(testAdminToken)
+
+	state, err := server.PauseGenerator(ctx, &pb.PauseGeneratorRequest{})
+	require.NoError(t, err)
+	assert.True(t, state.GetPaused())
+	assert.Equal(t, int64(1500), state.GetIntervalMs())
+	assert.Equal(t, int32(6), state.GetMaxPacketSize())
+	require.Len(t, state.GetChanges(), 1)
+	assert.Equal(t, at, state.GetChanges()[0].GetAt().AsTime())
+	assert.Equal(t, "1s -> 1.5s", state.GetChanges()[0].GetDetail())
+
+	state, err = server.ResumeGenerator(ctx, &pb.ResumeGeneratorRequest{})
+	require.NoError(t, err)
+	assert.False(t, state.GetPaused())
+
+	state, err = server.GetGeneratorState(ctx, &pb.GetGeneratorStateRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), state.GetMinPacketSize())
+	assert.Equal(t, []string{"pause", "resume"}, controller.calls)
+}
+
+func TestGeneratorAdminUpdate(t *testing.T) {
+	controller := &stubGeneratorController{state: domain.GeneratorState{Interval: time.Second, MinPacketSize: 4, MaxPacketSize: 4}}
+	server := &generatorAdminServer{controller: controller, token: testAdminToken}
+	ctx := adminContext(testAdminToken)
+
+	t.Log("Шаг 1: меняем интервал и нижнюю границу размера")
+	state, err := server.UpdateGenerator(ctx, &pb.UpdateGeneratorRequest{IntervalMs: proto.Int64(200), MinPacketSize: proto.Int32(1)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(200), state.GetIntervalMs())
+	assert.Equal(t, []string{"size 1..4", "interval 200ms"}, controller.calls)
+
+	t.Log("Шаг 2: packet_size фиксирует размер")
+	controller.calls = nil
+	_, err = server.UpdateGenerator(ctx, &pb.UpdateGeneratorRequest{PacketSize: proto.Int32(7)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"size 7..7"}, controller.calls)
+
+	t.Log("Шаг 3: некорректные запросы отклоняются без изменений")
+	controller.calls = nil
+	for _, req := range []*pb.UpdateGeneratorRequest{
+		{IntervalMs: proto.Int64(0)},
+		{PacketSize: proto.Int32(2), MaxPacketSize: proto.Int32(3)},
+	} {
+		_, err = server.UpdateGenerator(ctx, req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+	controller.sizeErr = fmt.Errorf("%w: packet size must be positive", domain.ErrInvalidGeneratorSetting)
+	_, err = server.UpdateGenerator(ctx, &pb.UpdateGeneratorRequest{IntervalMs: proto.Int64(50), MinPacketSize: proto.Int32(-1)})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "packet size must be positive")
+	assert.Empty(t, controller.calls)
+}
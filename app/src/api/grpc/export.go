@@ -0,0 +1,39 @@
+package grpcapi
+
+import (
+	pb "aggregator-service/app/src/api/grpc/pb"
+	"aggregator-service/app/src/domain"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StreamMaxByTimeRange sends every packet maximum within the range as its own message. Rows are
+// read from the repository as they are sent, and gRPC flow control holds the query back while the
+// client is slow, so memory stays bounded however large the range is. When the client cancels,
+// the stream context ends and the repository cursor is closed.
+func (s *aggregatorServer) StreamMaxByTimeRange(req *pb.StreamByTimeRangeRequest, stream grpc.ServerStreamingServer[pb.GetByIDResponse]) error {
+	if req == nil {
+		return status.Error(codes.InvalidArgument, "request must not be nil")
+	}
+
+	from, to, err := parseTimeRange(req.GetFrom(), req.GetTo())
+	if err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	err = s.service.StreamMaxInRange(ctx, from, to, func(result domain.AggregatorResult) error {
+		return stream.Send(toProtoResult(result))
+	})
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return translateServiceError(err)
+}
@@ -0,0 +1,90 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "aggregator-service/app/src/api/grpc/pb"
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/shared/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type stubExportStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	sendErr error
+	results []*pb.GetByIDResponse
+}
+
+func (s *stubExportStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *stubExportStream) Send(result *pb.GetByIDResponse) error {
+	if s.sendErr != nil {
+		return s.sendErr
+	}
+	s.results = append(s.results, result)
+	return nil
+}
+
+func TestStreamMaxByTimeRangeValidatesRequest(t *testing.T) {
+	server := &aggregatorServer{service: &stubService{}}
+	stream := &stubExportStream{ctx: context.Background()}
+
+	t.Log("Шаг 1: пустой запрос и запрос без границ отклоняются")
+	assert.Equal(t, codes.InvalidArgument, status.Code(server.StreamMaxByTimeRange(nil, stream)))
+	assert.Equal(t, codes.InvalidArgument, status.Code(server.StreamMaxByTimeRange(&pb.StreamByTimeRangeRequest{}, stream)))
+
+	t.Log("Шаг 2: перевёрнутый диапазон отклоняется")
+	now := time.Now()
+	req := &pb.StreamByTimeRangeRequest{From: timestamppb.New(now), To: timestamppb.New(now.Add(-time.Hour))}
+	assert.Equal(t, codes.InvalidArgument, status.Code(server.StreamMaxByTimeRange(req, stream)))
+	assert.Empty(t, stream.results)
+}
+
+func TestStreamMaxByTimeRangeSendsEveryResult(t *testing.T) {
+	now := time.Now().UTC()
+	results := []domain.AggregatorResult{
+		{PacketID: constants.GenerateUUID(), Value: 1, Timestamp: now},
+		{PacketID: constants.GenerateUUID(), Value: 2, Timestamp: now},
+	}
+	service := &stubService{resultInRange: results}
+	server := &aggregatorServer{service: service}
+	stream := &stubExportStream{ctx: context.Background()}
+
+	req := &pb.StreamByTimeRangeRequest{From: timestamppb.New(now.Add(-time.Hour)), To: timestamppb.New(now)}
+	require.NoError(t, server.StreamMaxByTimeRange(req, stream))
+
+	require.Len(t, stream.results, 2)
+	assert.Equal(t, results[0].PacketID, stream.results[0].GetId())
+	assert.Equal(t, 2.0, stream.results[1].GetMaxValue())
+	assert.True(t, service.lastFrom.Equal(now.Add(-time.Hour)))
+}
+
+func TestStreamMaxByTimeRangeErrors(t *testing.T) {
+	now := time.Now().UTC()
+	req := &pb.StreamByTimeRangeRequest{From: timestamppb.New(now.Add(-time.Hour)), To: timestamppb.New(now)}
+	results := []domain.AggregatorResult{{PacketID: constants.GenerateUUID(), Timestamp: now}}
+
+	t.Log("Шаг 1: ошибка чтения из хранилища становится Internal")
+	server := &aggregatorServer{service: &stubService{resultInRange: results, errStream: errors.New("connection reset")}}
+	err := server.StreamMaxByTimeRange(req, &stubExportStream{ctx: context.Background()})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	t.Log("Шаг 2: отмена клиентом возвращает Canceled")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	stream := &stubExportStream{ctx: ctx, sendErr: status.Error(codes.Canceled, "context canceled")}
+	server = &aggregatorServer{service: &stubService{resultInRange: results}}
+	err = server.StreamMaxByTimeRange(req, stream)
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
@@ -0,0 +1,124 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	pb "aggregator-service/app/src/api/grpc/pb"
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/shared/constants"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// defaultIngestWait bounds how long a stream waits for free queue space before a packet is
+	// rejected. While it waits the stream stops reading, so HTTP/2 flow control slows the client.
+	defaultIngestWait = time.Second
+	// maxReportedRejections caps the rejection details returned in a summary; the counter is exact.
+	maxReportedRejections = 100
+)
+
+// IngestPackets consumes a client stream of packets and queues each valid packet for the worker
+// pool. Invalid packets and packets that do not fit into the queue in time are rejected and
+// reported in the summary instead of failing the whole stream.
+func (s *aggregatorServer) IngestPackets(stream grpc.ClientStreamingServer[pb.DataPacket, pb.IngestSummary]) error {
+	if s.ingestor == nil {
+		return status.Error(codes.Unavailable, "packet ingestion is disabled")
+	}
+
+	ctx := stream.Context()
+	summary := &pb.IngestSummary{}
+	reject := func(index uint64, id, reason string) {
+		summary.Rejected++
+		if len(summary.Rejections) < maxReportedRejections {
+			summary.Rejections = append(summary.Rejections, &pb.IngestRejection{Index: index, Id: id, Reason: reason})
+		}
+	}
+
+	for index := uint64(0); ; index++ {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(summary)
+		}
+		if err != nil {
+			return err
+		}
+
+		packet, err := fromProtoPacket(msg, time.Now().UTC())
+		if err != nil {
+			reject(index, msg.GetId(), err.Error())
+			continue
+		}
+
+		if err := s.enqueue(ctx, packet); err != nil {
+			switch {
+			case errors.Is(err, domain.ErrBackpressure):
+				reject(index, packet.ID, "packet queue is full")
+			case errors.Is(err, domain.ErrIngestClosed):
+				return status.Error(codes.Unavailable, "packet ingestion is closed")
+			default:
+				return status.FromContextError(err).Err()
+			}
+			continue
+		}
+		summary.Accepted++
+	}
+}
+
+// enqueue waits up to ingestWait for queue space. A timeout that is not caused by the stream
+// itself is reported as domain.ErrBackpressure.
+func (s *aggregatorServer) enqueue(ctx context.Context, packet domain.DataPacket) error {
+	wait := s.ingestWait
+	if wait <= 0 {
+		wait = defaultIngestWait
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	err := s.ingestor.Enqueue(waitCtx, packet)
+	if err != nil && ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+		return domain.ErrBackpressure
+	}
+	return err
+}
+
+func fromProtoPacket(msg *pb.DataPacket, now time.Time) (domain.DataPacket, error) {
+	id, err := constants.ParseUUID(msg.GetId())
+	if err != nil {
+		return domain.DataPacket{}, errors.New("invalid id format")
+	}
+	if len(msg.GetMeasurements()) == 0 {
+		return domain.DataPacket{}, errors.New("measurements must not be empty")
+	}
+
+	measurements := make([]domain.Measurement, len(msg.GetMeasurements()))
+	for i, m := range msg.GetMeasurements() {
+		sourceID, err := constants.ParseUUID(m.GetSourceId())
+		if err != nil {
+			return domain.DataPacket{}, fmt.Errorf("measurement %d: invalid source_id format", i)
+		}
+
+		timestamp := now
+		if m.GetTimestamp() != nil {
+			if err := m.GetTimestamp().CheckValid(); err != nil {
+				return domain.DataPacket{}, fmt.Errorf("measurement %d: invalid timestamp", i)
+			}
+			timestamp = m.GetTimestamp().AsTime().UTC()
+		}
+
+		measurements[i] = domain.Measurement{
+			PacketID:  id,
+			SourceID:  sourceID,
+			Value:     m.GetValue(),
+			Timestamp: timestamp,
+		}
+	}
+
+	return domain.DataPacket{ID: id, Measurements: measurements}, nil
+}
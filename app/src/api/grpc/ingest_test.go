@@ -0,0 +1,182 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	pb "aggregator-service/app/src/api/grpc/pb"
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/infra"
+	"aggregator-service/app/src/shared/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type stubIngestStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	messages []*pb.DataPacket
+	recvErr  error
+	summary  *pb.IngestSummary
+}
+
+func (s *stubIngestStream) Context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+func (s *stubIngestStream) Recv() (*pb.DataPacket, error) {
+	if len(s.messages) == 0 {
+		if s.recvErr != nil {
+			return nil, s.recvErr
+		}
+		return nil, io.EOF
+	}
+	msg := s.messages[0]
+	s.messages = s.messages[1:]
+	return msg, nil
+}
+
+func (s *stubIngestStream) SendAndClose(summary *pb.IngestSummary) error {
+	s.summary = summary
+	return nil
+}
+
+type stubStreamIngestor struct {
+	errs    []error
+	packets []domain.DataPacket
+}
+
+func (s *stubStreamIngestor) Ingest(ctx context.Context, packets []domain.DataPacket) (int, error) {
+	return 0, nil
+}
+
+func (s *stubStreamIngestor) Enqueue(ctx context.Context, packet domain.DataPacket) error {
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		if err != nil {
+			return err
+		}
+	}
+	s.packets = append(s.packets, packet)
+	return nil
+}
+
+func validProtoPacket() *pb.DataPacket {
+	return &pb.DataPacket{Id: constants.GenerateUUID(), Measurements: []*pb.Measurement{{SourceId: constants.GenerateUUID(), Value: 1.5}}}
+}
+
+func TestIngestPacketsRequiresIngestor(t *testing.T) {
+	t.Log("Шаг 1: без ингестора RPC недоступен")
+	server := &aggregatorServer{service: &stubService{}}
+	err := server.IngestPackets(&stubIngestStream{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestIngestPacketsSummarisesStream(t *testing.T) {
+	t.Log("Шаг 1: готовим поток из валидного, невалидного и не поместившегося пакета")
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	first := validProtoPacket()
+	first.Measurements[0].Timestamp = timestamppb.New(ts)
+	invalid := &pb.DataPacket{Id: "bad"}
+	overflow := validProtoPacket()
+
+	ingestor := &stubStreamIngestor{errs: []error{nil, context.DeadlineExceeded}}
+	server := &aggregatorServer{service: &stubService{}, ingestor: ingestor, ingestWait: time.Millisecond}
+	stream := &stubIngestStream{messages: []*pb.DataPacket{first, invalid, overflow}}
+
+	t.Log("Шаг 2: обрабатываем поток и проверяем итог")
+	require.NoError(t, server.IngestPackets(stream))
+	require.NotNil(t, stream.summary)
+	assert.Equal(t, uint64(1), stream.summary.GetAccepted())
+	assert.Equal(t, uint64(2), stream.summary.GetRejected())
+	require.Len(t, stream.summary.GetRejections(), 2)
+	assert.Equal(t, uint64(1), stream.summary.GetRejections()[0].GetIndex())
+	assert.Equal(t, "invalid id format", stream.summary.GetRejections()[0].GetReason())
+	assert.Equal(t, "packet queue is full", stream.summary.GetRejections()[1].GetReason())
+
+	require.Len(t, ingestor.packets, 1)
+	assert.Equal(t, first.GetId(), ingestor.packets[0].ID)
+	assert.Equal(t, ts, ingestor.packets[0].Measurements[0].Timestamp)
+}
+
+func TestIngestPacketsCapsRejectionDetails(t *testing.T) {
+	t.Log("Шаг 1: отправляем больше невалидных пакетов, чем попадает в отчёт")
+	messages := make([]*pb.DataPacket, maxReportedRejections+5)
+	for i := range messages {
+		messages[i] = &pb.DataPacket{Id: "bad"}
+	}
+	server := &aggregatorServer{service: &stubService{}, ingestor: &stubStreamIngestor{}}
+	stream := &stubIngestStream{messages: messages}
+
+	require.NoError(t, server.IngestPackets(stream))
+	assert.Equal(t, uint64(len(messages)), stream.summary.GetRejected())
+	assert.Len(t, stream.summary.GetRejections(), maxReportedRejections)
+}
+
+func TestIngestPacketsStopsWhenClosed(t *testing.T) {
+	t.Log("Шаг 1: ингестор закрыт во время остановки сервиса")
+	ingestor := &stubStreamIngestor{errs: []error{domain.ErrIngestClosed}}
+	server := &aggregatorServer{service: &stubService{}, ingestor: ingestor}
+
+	err := server.IngestPackets(&stubIngestStream{messages: []*pb.DataPacket{validProtoPacket()}})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestIngestPacketsPropagatesCancellation(t *testing.T) {
+	t.Log("Шаг 1: клиент отменил поток во время ожидания очереди")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ingestor := &stubStreamIngestor{errs: []error{context.Canceled}}
+	server := &aggregatorServer{service: &stubService{}, ingestor: ingestor}
+
+	err := server.IngestPackets(&stubIngestStream{ctx: ctx, messages: []*pb.DataPacket{validProtoPacket()}})
+	assert.Equal(t, codes.Canceled, status.Code(err))
+
+	t.Log("Шаг 2: ошибка чтения потока возвращается как есть")
+	recvErr := status.Error(codes.Aborted, "broken")
+	err = server.IngestPackets(&stubIngestStream{recvErr: recvErr})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+}
+
+func TestFromProtoPacketValidation(t *testing.T) {
+	now := time.Now().UTC()
+
+	_, err := fromProtoPacket(&pb.DataPacket{Id: constants.GenerateUUID()}, now)
+	assert.EqualError(t, err, "measurements must not be empty")
+
+	_, err = fromProtoPacket(&pb.DataPacket{Id: constants.GenerateUUID(), Measurements: []*pb.Measurement{{SourceId: "bad"}}}, now)
+	assert.EqualError(t, err, "measurement 0: invalid source_id format")
+
+	badTS := &pb.Measurement{SourceId: constants.GenerateUUID(), Timestamp: &timestamppb.Timestamp{Nanos: -1}}
+	_, err = fromProtoPacket(&pb.DataPacket{Id: constants.GenerateUUID(), Measurements: []*pb.Measurement{badTS}}, now)
+	assert.EqualError(t, err, "measurement 0: invalid timestamp")
+
+	packet, err := fromProtoPacket(validProtoPacket(), now)
+	require.NoError(t, err)
+	assert.Equal(t, now, packet.Measurements[0].Timestamp)
+}
+
+func TestLoggingStreamInterceptorLogs(t *testing.T) {
+	t.Log("Шаг 1: запускаем потоковый интерсептор и проверяем появление логов")
+	var buf bytes.Buffer
+	interceptor := loggingStreamInterceptor(infra.NewLogger(&buf, "grpc"))
+
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		return status.Error(codes.Internal, "boom")
+	}
+
+	err := interceptor(nil, &stubIngestStream{}, &grpc.StreamServerInfo{FullMethod: "/service/Stream"}, handler)
+	assert.Error(t, err)
+	assert.Contains(t, buf.String(), "/service/Stream")
+}
@@ -130,6 +130,8 @@ type GetByTimeRangeRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	From          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
 	To            *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Cursor        string                 `protobuf:"bytes,4,opt,name=cursor,proto3" json:"cursor,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -178,28 +180,862 @@ func (x *GetByTimeRangeRequest) GetTo() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *GetByTimeRangeRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *GetByTimeRangeRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
 type GetByTimeRangeResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Results       []*GetByIDResponse     `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	Results       []*GetByIDResponse     `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetByTimeRangeResponse) Reset() {
+	*x = GetByTimeRangeResponse{}
+	mi := &file_aggregator_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetByTimeRangeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetByTimeRangeResponse) ProtoMessage() {}
+
+func (x *GetByTimeRangeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetByTimeRangeResponse.ProtoReflect.Descriptor instead.
+func (*GetByTimeRangeResponse) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetByTimeRangeResponse) GetResults() []*GetByIDResponse {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *GetByTimeRangeResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type StreamByTimeRangeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamByTimeRangeRequest) Reset() {
+	*x = StreamByTimeRangeRequest{}
+	mi := &file_aggregator_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamByTimeRangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamByTimeRangeRequest) ProtoMessage() {}
+
+func (x *StreamByTimeRangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamByTimeRangeRequest.ProtoReflect.Descriptor instead.
+func (*StreamByTimeRangeRequest) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *StreamByTimeRangeRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *StreamByTimeRangeRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+type GetTopByIDRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	K             int32                  `protobuf:"varint,2,opt,name=k,proto3" json:"k,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTopByIDRequest) Reset() {
+	*x = GetTopByIDRequest{}
+	mi := &file_aggregator_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTopByIDRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTopByIDRequest) ProtoMessage() {}
+
+func (x *GetTopByIDRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTopByIDRequest.ProtoReflect.Descriptor instead.
+func (*GetTopByIDRequest) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetTopByIDRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *GetTopByIDRequest) GetK() int32 {
+	if x != nil {
+		return x.K
+	}
+	return 0
+}
+
+type RankedResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	SourceId      string                 `protobuf:"bytes,2,opt,name=source_id,json=sourceId,proto3" json:"source_id,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Value         float64                `protobuf:"fixed64,4,opt,name=value,proto3" json:"value,omitempty"`
+	Rank          int32                  `protobuf:"varint,5,opt,name=rank,proto3" json:"rank,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RankedResult) Reset() {
+	*x = RankedResult{}
+	mi := &file_aggregator_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RankedResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RankedResult) ProtoMessage() {}
+
+func (x *RankedResult) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RankedResult.ProtoReflect.Descriptor instead.
+func (*RankedResult) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RankedResult) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RankedResult) GetSourceId() string {
+	if x != nil {
+		return x.SourceId
+	}
+	return ""
+}
+
+func (x *RankedResult) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *RankedResult) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *RankedResult) GetRank() int32 {
+	if x != nil {
+		return x.Rank
+	}
+	return 0
+}
+
+type GetTopByIDResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*RankedResult        `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTopByIDResponse) Reset() {
+	*x = GetTopByIDResponse{}
+	mi := &file_aggregator_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTopByIDResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTopByIDResponse) ProtoMessage() {}
+
+func (x *GetTopByIDResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTopByIDResponse.ProtoReflect.Descriptor instead.
+func (*GetTopByIDResponse) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetTopByIDResponse) GetResults() []*RankedResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type GetAggregateByIDRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Function      string                 `protobuf:"bytes,2,opt,name=function,proto3" json:"function,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAggregateByIDRequest) Reset() {
+	*x = GetAggregateByIDRequest{}
+	mi := &file_aggregator_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAggregateByIDRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAggregateByIDRequest) ProtoMessage() {}
+
+func (x *GetAggregateByIDRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAggregateByIDRequest.ProtoReflect.Descriptor instead.
+func (*GetAggregateByIDRequest) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetAggregateByIDRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *GetAggregateByIDRequest) GetFunction() string {
+	if x != nil {
+		return x.Function
+	}
+	return ""
+}
+
+type AggregateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Function      string                 `protobuf:"bytes,2,opt,name=function,proto3" json:"function,omitempty"`
+	SourceId      string                 `protobuf:"bytes,3,opt,name=source_id,json=sourceId,proto3" json:"source_id,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Value         float64                `protobuf:"fixed64,5,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AggregateResponse) Reset() {
+	*x = AggregateResponse{}
+	mi := &file_aggregator_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AggregateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AggregateResponse) ProtoMessage() {}
+
+func (x *AggregateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AggregateResponse.ProtoReflect.Descriptor instead.
+func (*AggregateResponse) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AggregateResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AggregateResponse) GetFunction() string {
+	if x != nil {
+		return x.Function
+	}
+	return ""
+}
+
+func (x *AggregateResponse) GetSourceId() string {
+	if x != nil {
+		return x.SourceId
+	}
+	return ""
+}
+
+func (x *AggregateResponse) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *AggregateResponse) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+type GetAggregateByTimeRangeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Function      string                 `protobuf:"bytes,1,opt,name=function,proto3" json:"function,omitempty"`
+	From          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To            *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	Limit         int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	Cursor        string                 `protobuf:"bytes,5,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAggregateByTimeRangeRequest) Reset() {
+	*x = GetAggregateByTimeRangeRequest{}
+	mi := &file_aggregator_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAggregateByTimeRangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAggregateByTimeRangeRequest) ProtoMessage() {}
+
+func (x *GetAggregateByTimeRangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAggregateByTimeRangeRequest.ProtoReflect.Descriptor instead.
+func (*GetAggregateByTimeRangeRequest) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetAggregateByTimeRangeRequest) GetFunction() string {
+	if x != nil {
+		return x.Function
+	}
+	return ""
+}
+
+func (x *GetAggregateByTimeRangeRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *GetAggregateByTimeRangeRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *GetAggregateByTimeRangeRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *GetAggregateByTimeRangeRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+type GetAggregateByTimeRangeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*AggregateResponse   `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAggregateByTimeRangeResponse) Reset() {
+	*x = GetAggregateByTimeRangeResponse{}
+	mi := &file_aggregator_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAggregateByTimeRangeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAggregateByTimeRangeResponse) ProtoMessage() {}
+
+func (x *GetAggregateByTimeRangeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAggregateByTimeRangeResponse.ProtoReflect.Descriptor instead.
+func (*GetAggregateByTimeRangeResponse) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetAggregateByTimeRangeResponse) GetResults() []*AggregateResponse {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *GetAggregateByTimeRangeResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type Measurement struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SourceId      string                 `protobuf:"bytes,1,opt,name=source_id,json=sourceId,proto3" json:"source_id,omitempty"`
+	Value         float64                `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Measurement) Reset() {
+	*x = Measurement{}
+	mi := &file_aggregator_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Measurement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Measurement) ProtoMessage() {}
+
+func (x *Measurement) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Measurement.ProtoReflect.Descriptor instead.
+func (*Measurement) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Measurement) GetSourceId() string {
+	if x != nil {
+		return x.SourceId
+	}
+	return ""
+}
+
+func (x *Measurement) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *Measurement) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+type DataPacket struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Measurements  []*Measurement         `protobuf:"bytes,2,rep,name=measurements,proto3" json:"measurements,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DataPacket) Reset() {
+	*x = DataPacket{}
+	mi := &file_aggregator_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DataPacket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DataPacket) ProtoMessage() {}
+
+func (x *DataPacket) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DataPacket.ProtoReflect.Descriptor instead.
+func (*DataPacket) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *DataPacket) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DataPacket) GetMeasurements() []*Measurement {
+	if x != nil {
+		return x.Measurements
+	}
+	return nil
+}
+
+type IngestRejection struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         uint64                 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestRejection) Reset() {
+	*x = IngestRejection{}
+	mi := &file_aggregator_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestRejection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestRejection) ProtoMessage() {}
+
+func (x *IngestRejection) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestRejection.ProtoReflect.Descriptor instead.
+func (*IngestRejection) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *IngestRejection) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *IngestRejection) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *IngestRejection) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type IngestSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Accepted      uint64                 `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Rejected      uint64                 `protobuf:"varint,2,opt,name=rejected,proto3" json:"rejected,omitempty"`
+	Rejections    []*IngestRejection     `protobuf:"bytes,3,rep,name=rejections,proto3" json:"rejections,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestSummary) Reset() {
+	*x = IngestSummary{}
+	mi := &file_aggregator_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestSummary) ProtoMessage() {}
+
+func (x *IngestSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestSummary.ProtoReflect.Descriptor instead.
+func (*IngestSummary) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *IngestSummary) GetAccepted() uint64 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *IngestSummary) GetRejected() uint64 {
+	if x != nil {
+		return x.Rejected
+	}
+	return 0
+}
+
+func (x *IngestSummary) GetRejections() []*IngestRejection {
+	if x != nil {
+		return x.Rejections
+	}
+	return nil
+}
+
+type WatchMaximaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MinValue      *float64               `protobuf:"fixed64,1,opt,name=min_value,json=minValue,proto3,oneof" json:"min_value,omitempty"`
+	Since         *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=since,proto3" json:"since,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchMaximaRequest) Reset() {
+	*x = WatchMaximaRequest{}
+	mi := &file_aggregator_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchMaximaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchMaximaRequest) ProtoMessage() {}
+
+func (x *WatchMaximaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchMaximaRequest.ProtoReflect.Descriptor instead.
+func (*WatchMaximaRequest) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *WatchMaximaRequest) GetMinValue() float64 {
+	if x != nil && x.MinValue != nil {
+		return *x.MinValue
+	}
+	return 0
+}
+
+func (x *WatchMaximaRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+type MaxEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	SourceId      string                 `protobuf:"bytes,2,opt,name=source_id,json=sourceId,proto3" json:"source_id,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Value         float64                `protobuf:"fixed64,4,opt,name=value,proto3" json:"value,omitempty"`
+	Replayed      bool                   `protobuf:"varint,5,opt,name=replayed,proto3" json:"replayed,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetByTimeRangeResponse) Reset() {
-	*x = GetByTimeRangeResponse{}
-	mi := &file_aggregator_proto_msgTypes[3]
+func (x *MaxEvent) Reset() {
+	*x = MaxEvent{}
+	mi := &file_aggregator_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetByTimeRangeResponse) String() string {
+func (x *MaxEvent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetByTimeRangeResponse) ProtoMessage() {}
+func (*MaxEvent) ProtoMessage() {}
 
-func (x *GetByTimeRangeResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_aggregator_proto_msgTypes[3]
+func (x *MaxEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -210,14 +1046,354 @@ func (x *GetByTimeRangeResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetByTimeRangeResponse.ProtoReflect.Descriptor instead.
-func (*GetByTimeRangeResponse) Descriptor() ([]byte, []int) {
-	return file_aggregator_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use MaxEvent.ProtoReflect.Descriptor instead.
+func (*MaxEvent) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{17}
 }
 
-func (x *GetByTimeRangeResponse) GetResults() []*GetByIDResponse {
+func (x *MaxEvent) GetId() string {
 	if x != nil {
-		return x.Results
+		return x.Id
+	}
+	return ""
+}
+
+func (x *MaxEvent) GetSourceId() string {
+	if x != nil {
+		return x.SourceId
+	}
+	return ""
+}
+
+func (x *MaxEvent) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *MaxEvent) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *MaxEvent) GetReplayed() bool {
+	if x != nil {
+		return x.Replayed
+	}
+	return false
+}
+
+type GetGeneratorStateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetGeneratorStateRequest) Reset() {
+	*x = GetGeneratorStateRequest{}
+	mi := &file_aggregator_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGeneratorStateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGeneratorStateRequest) ProtoMessage() {}
+
+func (x *GetGeneratorStateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGeneratorStateRequest.ProtoReflect.Descriptor instead.
+func (*GetGeneratorStateRequest) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{18}
+}
+
+type PauseGeneratorRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PauseGeneratorRequest) Reset() {
+	*x = PauseGeneratorRequest{}
+	mi := &file_aggregator_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PauseGeneratorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PauseGeneratorRequest) ProtoMessage() {}
+
+func (x *PauseGeneratorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PauseGeneratorRequest.ProtoReflect.Descriptor instead.
+func (*PauseGeneratorRequest) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{19}
+}
+
+type ResumeGeneratorRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeGeneratorRequest) Reset() {
+	*x = ResumeGeneratorRequest{}
+	mi := &file_aggregator_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeGeneratorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeGeneratorRequest) ProtoMessage() {}
+
+func (x *ResumeGeneratorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeGeneratorRequest.ProtoReflect.Descriptor instead.
+func (*ResumeGeneratorRequest) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{20}
+}
+
+type UpdateGeneratorRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IntervalMs    *int64                 `protobuf:"varint,1,opt,name=interval_ms,json=intervalMs,proto3,oneof" json:"interval_ms,omitempty"`
+	PacketSize    *int32                 `protobuf:"varint,2,opt,name=packet_size,json=packetSize,proto3,oneof" json:"packet_size,omitempty"`
+	MinPacketSize *int32                 `protobuf:"varint,3,opt,name=min_packet_size,json=minPacketSize,proto3,oneof" json:"min_packet_size,omitempty"`
+	MaxPacketSize *int32                 `protobuf:"varint,4,opt,name=max_packet_size,json=maxPacketSize,proto3,oneof" json:"max_packet_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateGeneratorRequest) Reset() {
+	*x = UpdateGeneratorRequest{}
+	mi := &file_aggregator_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateGeneratorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateGeneratorRequest) ProtoMessage() {}
+
+func (x *UpdateGeneratorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateGeneratorRequest.ProtoReflect.Descriptor instead.
+func (*UpdateGeneratorRequest) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *UpdateGeneratorRequest) GetIntervalMs() int64 {
+	if x != nil && x.IntervalMs != nil {
+		return *x.IntervalMs
+	}
+	return 0
+}
+
+func (x *UpdateGeneratorRequest) GetPacketSize() int32 {
+	if x != nil && x.PacketSize != nil {
+		return *x.PacketSize
+	}
+	return 0
+}
+
+func (x *UpdateGeneratorRequest) GetMinPacketSize() int32 {
+	if x != nil && x.MinPacketSize != nil {
+		return *x.MinPacketSize
+	}
+	return 0
+}
+
+func (x *UpdateGeneratorRequest) GetMaxPacketSize() int32 {
+	if x != nil && x.MaxPacketSize != nil {
+		return *x.MaxPacketSize
+	}
+	return 0
+}
+
+type GeneratorChange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	At            *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=at,proto3" json:"at,omitempty"`
+	Action        string                 `protobuf:"bytes,2,opt,name=action,proto3" json:"action,omitempty"`
+	Detail        string                 `protobuf:"bytes,3,opt,name=detail,proto3" json:"detail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GeneratorChange) Reset() {
+	*x = GeneratorChange{}
+	mi := &file_aggregator_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GeneratorChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GeneratorChange) ProtoMessage() {}
+
+func (x *GeneratorChange) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GeneratorChange.ProtoReflect.Descriptor instead.
+func (*GeneratorChange) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *GeneratorChange) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *GeneratorChange) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *GeneratorChange) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+type GeneratorState struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Paused        bool                   `protobuf:"varint,1,opt,name=paused,proto3" json:"paused,omitempty"`
+	IntervalMs    int64                  `protobuf:"varint,2,opt,name=interval_ms,json=intervalMs,proto3" json:"interval_ms,omitempty"`
+	MinPacketSize int32                  `protobuf:"varint,3,opt,name=min_packet_size,json=minPacketSize,proto3" json:"min_packet_size,omitempty"`
+	MaxPacketSize int32                  `protobuf:"varint,4,opt,name=max_packet_size,json=maxPacketSize,proto3" json:"max_packet_size,omitempty"`
+	Changes       []*GeneratorChange     `protobuf:"bytes,5,rep,name=changes,proto3" json:"changes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GeneratorState) Reset() {
+	*x = GeneratorState{}
+	mi := &file_aggregator_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GeneratorState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GeneratorState) ProtoMessage() {}
+
+func (x *GeneratorState) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GeneratorState.ProtoReflect.Descriptor instead.
+func (*GeneratorState) Descriptor() ([]byte, []int) {
+	return file_aggregator_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *GeneratorState) GetPaused() bool {
+	if x != nil {
+		return x.Paused
+	}
+	return false
+}
+
+func (x *GeneratorState) GetIntervalMs() int64 {
+	if x != nil {
+		return x.IntervalMs
+	}
+	return 0
+}
+
+func (x *GeneratorState) GetMinPacketSize() int32 {
+	if x != nil {
+		return x.MinPacketSize
+	}
+	return 0
+}
+
+func (x *GeneratorState) GetMaxPacketSize() int32 {
+	if x != nil {
+		return x.MaxPacketSize
+	}
+	return 0
+}
+
+func (x *GeneratorState) GetChanges() []*GeneratorChange {
+	if x != nil {
+		return x.Changes
 	}
 	return nil
 }
@@ -233,16 +1409,119 @@ const file_aggregator_proto_rawDesc = "" +
 	"\x0fGetByIDResponse\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x128\n" +
 	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1b\n" +
-	"\tmax_value\x18\x03 \x01(\x01R\bmaxValue\"s\n" +
+	"\tmax_value\x18\x03 \x01(\x01R\bmaxValue\"\xa1\x01\n" +
 	"\x15GetByTimeRangeRequest\x12.\n" +
 	"\x04from\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
-	"\x02to\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\"O\n" +
+	"\x02to\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06cursor\x18\x04 \x01(\tR\x06cursor\"p\n" +
 	"\x16GetByTimeRangeResponse\x125\n" +
-	"\aresults\x18\x01 \x03(\v2\x1b.aggregator.GetByIDResponseR\aresults2\xb6\x01\n" +
+	"\aresults\x18\x01 \x03(\v2\x1b.aggregator.GetByIDResponseR\aresults\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"v\n" +
+	"\x18StreamByTimeRangeRequest\x12.\n" +
+	"\x04from\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\"1\n" +
+	"\x11GetTopByIDRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\f\n" +
+	"\x01k\x18\x02 \x01(\x05R\x01k\"\x9f\x01\n" +
+	"\fRankedResult\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\tsource_id\x18\x02 \x01(\tR\bsourceId\x128\n" +
+	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\x01R\x05value\x12\x12\n" +
+	"\x04rank\x18\x05 \x01(\x05R\x04rank\"H\n" +
+	"\x12GetTopByIDResponse\x122\n" +
+	"\aresults\x18\x01 \x03(\v2\x18.aggregator.RankedResultR\aresults\"E\n" +
+	"\x17GetAggregateByIDRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bfunction\x18\x02 \x01(\tR\bfunction\"\xac\x01\n" +
+	"\x11AggregateResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bfunction\x18\x02 \x01(\tR\bfunction\x12\x1b\n" +
+	"\tsource_id\x18\x03 \x01(\tR\bsourceId\x128\n" +
+	"\ttimestamp\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x14\n" +
+	"\x05value\x18\x05 \x01(\x01R\x05value\"\xc6\x01\n" +
+	"\x1eGetAggregateByTimeRangeRequest\x12\x1a\n" +
+	"\bfunction\x18\x01 \x01(\tR\bfunction\x12.\n" +
+	"\x04from\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06cursor\x18\x05 \x01(\tR\x06cursor\"{\n" +
+	"\x1fGetAggregateByTimeRangeResponse\x127\n" +
+	"\aresults\x18\x01 \x03(\v2\x1d.aggregator.AggregateResponseR\aresults\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"z\n" +
+	"\vMeasurement\x12\x1b\n" +
+	"\tsource_id\x18\x01 \x01(\tR\bsourceId\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value\x128\n" +
+	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"Y\n" +
+	"\n" +
+	"DataPacket\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12;\n" +
+	"\fmeasurements\x18\x02 \x03(\v2\x17.aggregator.MeasurementR\fmeasurements\"O\n" +
+	"\x0fIngestRejection\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x04R\x05index\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"\x84\x01\n" +
+	"\rIngestSummary\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\x04R\baccepted\x12\x1a\n" +
+	"\brejected\x18\x02 \x01(\x04R\brejected\x12;\n" +
+	"\n" +
+	"rejections\x18\x03 \x03(\v2\x1b.aggregator.IngestRejectionR\n" +
+	"rejections\"v\n" +
+	"\x12WatchMaximaRequest\x12 \n" +
+	"\tmin_value\x18\x01 \x01(\x01H\x00R\bminValue\x88\x01\x01\x120\n" +
+	"\x05since\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x05sinceB\f\n" +
+	"\n" +
+	"_min_value\"\xa3\x01\n" +
+	"\bMaxEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\tsource_id\x18\x02 \x01(\tR\bsourceId\x128\n" +
+	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\x01R\x05value\x12\x1a\n" +
+	"\breplayed\x18\x05 \x01(\bR\breplayed\"\x1a\n" +
+	"\x18GetGeneratorStateRequest\"\x17\n" +
+	"\x15PauseGeneratorRequest\"\x18\n" +
+	"\x16ResumeGeneratorRequest\"\x86\x02\n" +
+	"\x16UpdateGeneratorRequest\x12$\n" +
+	"\vinterval_ms\x18\x01 \x01(\x03H\x00R\n" +
+	"intervalMs\x88\x01\x01\x12$\n" +
+	"\vpacket_size\x18\x02 \x01(\x05H\x01R\n" +
+	"packetSize\x88\x01\x01\x12+\n" +
+	"\x0fmin_packet_size\x18\x03 \x01(\x05H\x02R\rminPacketSize\x88\x01\x01\x12+\n" +
+	"\x0fmax_packet_size\x18\x04 \x01(\x05H\x03R\rmaxPacketSize\x88\x01\x01B\x0e\n" +
+	"\f_interval_msB\x0e\n" +
+	"\f_packet_sizeB\x12\n" +
+	"\x10_min_packet_sizeB\x12\n" +
+	"\x10_max_packet_size\"m\n" +
+	"\x0fGeneratorChange\x12*\n" +
+	"\x02at\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x16\n" +
+	"\x06action\x18\x02 \x01(\tR\x06action\x12\x16\n" +
+	"\x06detail\x18\x03 \x01(\tR\x06detail\"\xd0\x01\n" +
+	"\x0eGeneratorState\x12\x16\n" +
+	"\x06paused\x18\x01 \x01(\bR\x06paused\x12\x1f\n" +
+	"\vinterval_ms\x18\x02 \x01(\x03R\n" +
+	"intervalMs\x12&\n" +
+	"\x0fmin_packet_size\x18\x03 \x01(\x05R\rminPacketSize\x12&\n" +
+	"\x0fmax_packet_size\x18\x04 \x01(\x05R\rmaxPacketSize\x125\n" +
+	"\achanges\x18\x05 \x03(\v2\x1b.aggregator.GeneratorChangeR\achanges2\xb9\x05\n" +
 	"\x11AggregatorService\x12E\n" +
 	"\n" +
 	"GetMaxByID\x12\x1a.aggregator.GetByIDRequest\x1a\x1b.aggregator.GetByIDResponse\x12Z\n" +
-	"\x11GetMaxByTimeRange\x12!.aggregator.GetByTimeRangeRequest\x1a\".aggregator.GetByTimeRangeResponseB/Z-aggregator-service/app/src/api/grpc/pb;grpcpbb\x06proto3"
+	"\x11GetMaxByTimeRange\x12!.aggregator.GetByTimeRangeRequest\x1a\".aggregator.GetByTimeRangeResponse\x12[\n" +
+	"\x14StreamMaxByTimeRange\x12$.aggregator.StreamByTimeRangeRequest\x1a\x1b.aggregator.GetByIDResponse0\x01\x12K\n" +
+	"\n" +
+	"GetTopByID\x12\x1d.aggregator.GetTopByIDRequest\x1a\x1e.aggregator.GetTopByIDResponse\x12V\n" +
+	"\x10GetAggregateByID\x12#.aggregator.GetAggregateByIDRequest\x1a\x1d.aggregator.AggregateResponse\x12r\n" +
+	"\x17GetAggregateByTimeRange\x12*.aggregator.GetAggregateByTimeRangeRequest\x1a+.aggregator.GetAggregateByTimeRangeResponse\x12D\n" +
+	"\rIngestPackets\x12\x16.aggregator.DataPacket\x1a\x19.aggregator.IngestSummary(\x01\x12E\n" +
+	"\vWatchMaxima\x12\x1e.aggregator.WatchMaximaRequest\x1a\x14.aggregator.MaxEvent0\x012\xde\x02\n" +
+	"\x0eGeneratorAdmin\x12U\n" +
+	"\x11GetGeneratorState\x12$.aggregator.GetGeneratorStateRequest\x1a\x1a.aggregator.GeneratorState\x12O\n" +
+	"\x0ePauseGenerator\x12!.aggregator.PauseGeneratorRequest\x1a\x1a.aggregator.GeneratorState\x12Q\n" +
+	"\x0fResumeGenerator\x12\".aggregator.ResumeGeneratorRequest\x1a\x1a.aggregator.GeneratorState\x12Q\n" +
+	"\x0fUpdateGenerator\x12\".aggregator.UpdateGeneratorRequest\x1a\x1a.aggregator.GeneratorStateB/Z-aggregator-service/app/src/api/grpc/pb;grpcpbb\x06proto3"
 
 var (
 	file_aggregator_proto_rawDescOnce sync.Once
@@ -256,28 +1535,83 @@ func file_aggregator_proto_rawDescGZIP() []byte {
 	return file_aggregator_proto_rawDescData
 }
 
-var file_aggregator_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_aggregator_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
 var file_aggregator_proto_goTypes = []any{
-	(*GetByIDRequest)(nil),         // 0: aggregator.GetByIDRequest
-	(*GetByIDResponse)(nil),        // 1: aggregator.GetByIDResponse
-	(*GetByTimeRangeRequest)(nil),  // 2: aggregator.GetByTimeRangeRequest
-	(*GetByTimeRangeResponse)(nil), // 3: aggregator.GetByTimeRangeResponse
-	(*timestamppb.Timestamp)(nil),  // 4: google.protobuf.Timestamp
+	(*GetByIDRequest)(nil),                  // 0: aggregator.GetByIDRequest
+	(*GetByIDResponse)(nil),                 // 1: aggregator.GetByIDResponse
+	(*GetByTimeRangeRequest)(nil),           // 2: aggregator.GetByTimeRangeRequest
+	(*GetByTimeRangeResponse)(nil),          // 3: aggregator.GetByTimeRangeResponse
+	(*StreamByTimeRangeRequest)(nil),        // 4: aggregator.StreamByTimeRangeRequest
+	(*GetTopByIDRequest)(nil),               // 5: aggregator.GetTopByIDRequest
+	(*RankedResult)(nil),                    // 6: aggregator.RankedResult
+	(*GetTopByIDResponse)(nil),              // 7: aggregator.GetTopByIDResponse
+	(*GetAggregateByIDRequest)(nil),         // 8: aggregator.GetAggregateByIDRequest
+	(*AggregateResponse)(nil),               // 9: aggregator.AggregateResponse
+	(*GetAggregateByTimeRangeRequest)(nil),  // 10: aggregator.GetAggregateByTimeRangeRequest
+	(*GetAggregateByTimeRangeResponse)(nil), // 11: aggregator.GetAggregateByTimeRangeResponse
+	(*Measurement)(nil),                     // 12: aggregator.Measurement
+	(*DataPacket)(nil),                      // 13: aggregator.DataPacket
+	(*IngestRejection)(nil),                 // 14: aggregator.IngestRejection
+	(*IngestSummary)(nil),                   // 15: aggregator.IngestSummary
+	(*WatchMaximaRequest)(nil),              // 16: aggregator.WatchMaximaRequest
+	(*MaxEvent)(nil),                        // 17: aggregator.MaxEvent
+	(*GetGeneratorStateRequest)(nil),        // 18: aggregator.GetGeneratorStateRequest
+	(*PauseGeneratorRequest)(nil),           // 19: aggregator.PauseGeneratorRequest
+	(*ResumeGeneratorRequest)(nil),          // 20: aggregator.ResumeGeneratorRequest
+	(*UpdateGeneratorRequest)(nil),          // 21: aggregator.UpdateGeneratorRequest
+	(*GeneratorChange)(nil),                 // 22: aggregator.GeneratorChange
+	(*GeneratorState)(nil),                  // 23: aggregator.GeneratorState
+	(*timestamppb.Timestamp)(nil),           // 24: google.protobuf.Timestamp
 }
 var file_aggregator_proto_depIdxs = []int32{
-	4, // 0: aggregator.GetByIDResponse.timestamp:type_name -> google.protobuf.Timestamp
-	4, // 1: aggregator.GetByTimeRangeRequest.from:type_name -> google.protobuf.Timestamp
-	4, // 2: aggregator.GetByTimeRangeRequest.to:type_name -> google.protobuf.Timestamp
-	1, // 3: aggregator.GetByTimeRangeResponse.results:type_name -> aggregator.GetByIDResponse
-	0, // 4: aggregator.AggregatorService.GetMaxByID:input_type -> aggregator.GetByIDRequest
-	2, // 5: aggregator.AggregatorService.GetMaxByTimeRange:input_type -> aggregator.GetByTimeRangeRequest
-	1, // 6: aggregator.AggregatorService.GetMaxByID:output_type -> aggregator.GetByIDResponse
-	3, // 7: aggregator.AggregatorService.GetMaxByTimeRange:output_type -> aggregator.GetByTimeRangeResponse
-	6, // [6:8] is the sub-list for method output_type
-	4, // [4:6] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	24, // 0: aggregator.GetByIDResponse.timestamp:type_name -> google.protobuf.Timestamp
+	24, // 1: aggregator.GetByTimeRangeRequest.from:type_name -> google.protobuf.Timestamp
+	24, // 2: aggregator.GetByTimeRangeRequest.to:type_name -> google.protobuf.Timestamp
+	1,  // 3: aggregator.GetByTimeRangeResponse.results:type_name -> aggregator.GetByIDResponse
+	24, // 4: aggregator.StreamByTimeRangeRequest.from:type_name -> google.protobuf.Timestamp
+	24, // 5: aggregator.StreamByTimeRangeRequest.to:type_name -> google.protobuf.Timestamp
+	24, // 6: aggregator.RankedResult.timestamp:type_name -> google.protobuf.Timestamp
+	6,  // 7: aggregator.GetTopByIDResponse.results:type_name -> aggregator.RankedResult
+	24, // 8: aggregator.AggregateResponse.timestamp:type_name -> google.protobuf.Timestamp
+	24, // 9: aggregator.GetAggregateByTimeRangeRequest.from:type_name -> google.protobuf.Timestamp
+	24, // 10: aggregator.GetAggregateByTimeRangeRequest.to:type_name -> google.protobuf.Timestamp
+	9,  // 11: aggregator.GetAggregateByTimeRangeResponse.results:type_name -> aggregator.AggregateResponse
+	24, // 12: aggregator.Measurement.timestamp:type_name -> google.protobuf.Timestamp
+	12, // 13: aggregator.DataPacket.measurements:type_name -> aggregator.Measurement
+	14, // 14: aggregator.IngestSummary.rejections:type_name -> aggregator.IngestRejection
+	24, // 15: aggregator.WatchMaximaRequest.since:type_name -> google.protobuf.Timestamp
+	24, // 16: aggregator.MaxEvent.timestamp:type_name -> google.protobuf.Timestamp
+	24, // 17: aggregator.GeneratorChange.at:type_name -> google.protobuf.Timestamp
+	22, // 18: aggregator.GeneratorState.changes:type_name -> aggregator.GeneratorChange
+	0,  // 19: aggregator.AggregatorService.GetMaxByID:input_type -> aggregator.GetByIDRequest
+	2,  // 20: aggregator.AggregatorService.GetMaxByTimeRange:input_type -> aggregator.GetByTimeRangeRequest
+	4,  // 21: aggregator.AggregatorService.StreamMaxByTimeRange:input_type -> aggregator.StreamByTimeRangeRequest
+	5,  // 22: aggregator.AggregatorService.GetTopByID:input_type -> aggregator.GetTopByIDRequest
+	8,  // 23: aggregator.AggregatorService.GetAggregateByID:input_type -> aggregator.GetAggregateByIDRequest
+	10, // 24: aggregator.AggregatorService.GetAggregateByTimeRange:input_type -> aggregator.GetAggregateByTimeRangeRequest
+	13, // 25: aggregator.AggregatorService.IngestPackets:input_type -> aggregator.DataPacket
+	16, // 26: aggregator.AggregatorService.WatchMaxima:input_type -> aggregator.WatchMaximaRequest
+	18, // 27: aggregator.GeneratorAdmin.GetGeneratorState:input_type -> aggregator.GetGeneratorStateRequest
+	19, // 28: aggregator.GeneratorAdmin.PauseGenerator:input_type -> aggregator.PauseGeneratorRequest
+	20, // 29: aggregator.GeneratorAdmin.ResumeGenerator:input_type -> aggregator.ResumeGeneratorRequest
+	21, // 30: aggregator.GeneratorAdmin.UpdateGenerator:input_type -> aggregator.UpdateGeneratorRequest
+	1,  // 31: aggregator.AggregatorService.GetMaxByID:output_type -> aggregator.GetByIDResponse
+	3,  // 32: aggregator.AggregatorService.GetMaxByTimeRange:output_type -> aggregator.GetByTimeRangeResponse
+	1,  // 33: aggregator.AggregatorService.StreamMaxByTimeRange:output_type -> aggregator.GetByIDResponse
+	7,  // 34: aggregator.AggregatorService.GetTopByID:output_type -> aggregator.GetTopByIDResponse
+	9,  // 35: aggregator.AggregatorService.GetAggregateByID:output_type -> aggregator.AggregateResponse
+	11, // 36: aggregator.AggregatorService.GetAggregateByTimeRange:output_type -> aggregator.GetAggregateByTimeRangeResponse
+	15, // 37: aggregator.AggregatorService.IngestPackets:output_type -> aggregator.IngestSummary
+	17, // 38: aggregator.AggregatorService.WatchMaxima:output_type -> aggregator.MaxEvent
+	23, // 39: aggregator.GeneratorAdmin.GetGeneratorState:output_type -> aggregator.GeneratorState
+	23, // 40: aggregator.GeneratorAdmin.PauseGenerator:output_type -> aggregator.GeneratorState
+	23, // 41: aggregator.GeneratorAdmin.ResumeGenerator:output_type -> aggregator.GeneratorState
+	23, // 42: aggregator.GeneratorAdmin.UpdateGenerator:output_type -> aggregator.GeneratorState
+	31, // [31:43] is the sub-list for method output_type
+	19, // [19:31] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
 }
 
 func init() { file_aggregator_proto_init() }
@@ -285,15 +1619,17 @@ func file_aggregator_proto_init() {
 	if File_aggregator_proto != nil {
 		return
 	}
+	file_aggregator_proto_msgTypes[16].OneofWrappers = []any{}
+	file_aggregator_proto_msgTypes[21].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_aggregator_proto_rawDesc), len(file_aggregator_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   24,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   2,
 		},
 		GoTypes:           file_aggregator_proto_goTypes,
 		DependencyIndexes: file_aggregator_proto_depIdxs,
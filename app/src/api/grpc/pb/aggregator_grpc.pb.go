@@ -19,8 +19,14 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	AggregatorService_GetMaxByID_FullMethodName        = "/aggregator.AggregatorService/GetMaxByID"
-	AggregatorService_GetMaxByTimeRange_FullMethodName = "/aggregator.AggregatorService/GetMaxByTimeRange"
+	AggregatorService_GetMaxByID_FullMethodName              = "/aggregator.AggregatorService/GetMaxByID"
+	AggregatorService_GetMaxByTimeRange_FullMethodName       = "/aggregator.AggregatorService/GetMaxByTimeRange"
+	AggregatorService_StreamMaxByTimeRange_FullMethodName    = "/aggregator.AggregatorService/StreamMaxByTimeRange"
+	AggregatorService_GetTopByID_FullMethodName              = "/aggregator.AggregatorService/GetTopByID"
+	AggregatorService_GetAggregateByID_FullMethodName        = "/aggregator.AggregatorService/GetAggregateByID"
+	AggregatorService_GetAggregateByTimeRange_FullMethodName = "/aggregator.AggregatorService/GetAggregateByTimeRange"
+	AggregatorService_IngestPackets_FullMethodName           = "/aggregator.AggregatorService/IngestPackets"
+	AggregatorService_WatchMaxima_FullMethodName             = "/aggregator.AggregatorService/WatchMaxima"
 )
 
 // AggregatorServiceClient is the client API for AggregatorService service.
@@ -29,6 +35,12 @@ const (
 type AggregatorServiceClient interface {
 	GetMaxByID(ctx context.Context, in *GetByIDRequest, opts ...grpc.CallOption) (*GetByIDResponse, error)
 	GetMaxByTimeRange(ctx context.Context, in *GetByTimeRangeRequest, opts ...grpc.CallOption) (*GetByTimeRangeResponse, error)
+	StreamMaxByTimeRange(ctx context.Context, in *StreamByTimeRangeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GetByIDResponse], error)
+	GetTopByID(ctx context.Context, in *GetTopByIDRequest, opts ...grpc.CallOption) (*GetTopByIDResponse, error)
+	GetAggregateByID(ctx context.Context, in *GetAggregateByIDRequest, opts ...grpc.CallOption) (*AggregateResponse, error)
+	GetAggregateByTimeRange(ctx context.Context, in *GetAggregateByTimeRangeRequest, opts ...grpc.CallOption) (*GetAggregateByTimeRangeResponse, error)
+	IngestPackets(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[DataPacket, IngestSummary], error)
+	WatchMaxima(ctx context.Context, in *WatchMaximaRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MaxEvent], error)
 }
 
 type aggregatorServiceClient struct {
@@ -59,12 +71,99 @@ func (c *aggregatorServiceClient) GetMaxByTimeRange(ctx context.Context, in *Get
 	return out, nil
 }
 
+func (c *aggregatorServiceClient) StreamMaxByTimeRange(ctx context.Context, in *StreamByTimeRangeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GetByIDResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AggregatorService_ServiceDesc.Streams[0], AggregatorService_StreamMaxByTimeRange_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamByTimeRangeRequest, GetByIDResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AggregatorService_StreamMaxByTimeRangeClient = grpc.ServerStreamingClient[GetByIDResponse]
+
+func (c *aggregatorServiceClient) GetTopByID(ctx context.Context, in *GetTopByIDRequest, opts ...grpc.CallOption) (*GetTopByIDResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTopByIDResponse)
+	err := c.cc.Invoke(ctx, AggregatorService_GetTopByID_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aggregatorServiceClient) GetAggregateByID(ctx context.Context, in *GetAggregateByIDRequest, opts ...grpc.CallOption) (*AggregateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AggregateResponse)
+	err := c.cc.Invoke(ctx, AggregatorService_GetAggregateByID_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aggregatorServiceClient) GetAggregateByTimeRange(ctx context.Context, in *GetAggregateByTimeRangeRequest, opts ...grpc.CallOption) (*GetAggregateByTimeRangeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetAggregateByTimeRangeResponse)
+	err := c.cc.Invoke(ctx, AggregatorService_GetAggregateByTimeRange_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aggregatorServiceClient) IngestPackets(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[DataPacket, IngestSummary], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AggregatorService_ServiceDesc.Streams[1], AggregatorService_IngestPackets_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DataPacket, IngestSummary]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AggregatorService_IngestPacketsClient = grpc.ClientStreamingClient[DataPacket, IngestSummary]
+
+func (c *aggregatorServiceClient) WatchMaxima(ctx context.Context, in *WatchMaximaRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MaxEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AggregatorService_ServiceDesc.Streams[2], AggregatorService_WatchMaxima_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchMaximaRequest, MaxEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AggregatorService_WatchMaximaClient = grpc.ServerStreamingClient[MaxEvent]
+
 // AggregatorServiceServer is the server API for AggregatorService service.
 // All implementations must embed UnimplementedAggregatorServiceServer
 // for forward compatibility.
 type AggregatorServiceServer interface {
 	GetMaxByID(context.Context, *GetByIDRequest) (*GetByIDResponse, error)
 	GetMaxByTimeRange(context.Context, *GetByTimeRangeRequest) (*GetByTimeRangeResponse, error)
+	StreamMaxByTimeRange(*StreamByTimeRangeRequest, grpc.ServerStreamingServer[GetByIDResponse]) error
+	GetTopByID(context.Context, *GetTopByIDRequest) (*GetTopByIDResponse, error)
+	GetAggregateByID(context.Context, *GetAggregateByIDRequest) (*AggregateResponse, error)
+	GetAggregateByTimeRange(context.Context, *GetAggregateByTimeRangeRequest) (*GetAggregateByTimeRangeResponse, error)
+	IngestPackets(grpc.ClientStreamingServer[DataPacket, IngestSummary]) error
+	WatchMaxima(*WatchMaximaRequest, grpc.ServerStreamingServer[MaxEvent]) error
 	mustEmbedUnimplementedAggregatorServiceServer()
 }
 
@@ -81,6 +180,24 @@ func (UnimplementedAggregatorServiceServer) GetMaxByID(context.Context, *GetByID
 func (UnimplementedAggregatorServiceServer) GetMaxByTimeRange(context.Context, *GetByTimeRangeRequest) (*GetByTimeRangeResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetMaxByTimeRange not implemented")
 }
+func (UnimplementedAggregatorServiceServer) StreamMaxByTimeRange(*StreamByTimeRangeRequest, grpc.ServerStreamingServer[GetByIDResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamMaxByTimeRange not implemented")
+}
+func (UnimplementedAggregatorServiceServer) GetTopByID(context.Context, *GetTopByIDRequest) (*GetTopByIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTopByID not implemented")
+}
+func (UnimplementedAggregatorServiceServer) GetAggregateByID(context.Context, *GetAggregateByIDRequest) (*AggregateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAggregateByID not implemented")
+}
+func (UnimplementedAggregatorServiceServer) GetAggregateByTimeRange(context.Context, *GetAggregateByTimeRangeRequest) (*GetAggregateByTimeRangeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAggregateByTimeRange not implemented")
+}
+func (UnimplementedAggregatorServiceServer) IngestPackets(grpc.ClientStreamingServer[DataPacket, IngestSummary]) error {
+	return status.Errorf(codes.Unimplemented, "method IngestPackets not implemented")
+}
+func (UnimplementedAggregatorServiceServer) WatchMaxima(*WatchMaximaRequest, grpc.ServerStreamingServer[MaxEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchMaxima not implemented")
+}
 func (UnimplementedAggregatorServiceServer) mustEmbedUnimplementedAggregatorServiceServer() {}
 func (UnimplementedAggregatorServiceServer) testEmbeddedByValue()                           {}
 
@@ -138,6 +255,89 @@ func _AggregatorService_GetMaxByTimeRange_Handler(srv interface{}, ctx context.C
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AggregatorService_StreamMaxByTimeRange_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamByTimeRangeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AggregatorServiceServer).StreamMaxByTimeRange(m, &grpc.GenericServerStream[StreamByTimeRangeRequest, GetByIDResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AggregatorService_StreamMaxByTimeRangeServer = grpc.ServerStreamingServer[GetByIDResponse]
+
+func _AggregatorService_GetTopByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTopByIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AggregatorServiceServer).GetTopByID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AggregatorService_GetTopByID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AggregatorServiceServer).GetTopByID(ctx, req.(*GetTopByIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AggregatorService_GetAggregateByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAggregateByIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AggregatorServiceServer).GetAggregateByID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AggregatorService_GetAggregateByID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AggregatorServiceServer).GetAggregateByID(ctx, req.(*GetAggregateByIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AggregatorService_GetAggregateByTimeRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAggregateByTimeRangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AggregatorServiceServer).GetAggregateByTimeRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AggregatorService_GetAggregateByTimeRange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AggregatorServiceServer).GetAggregateByTimeRange(ctx, req.(*GetAggregateByTimeRangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AggregatorService_IngestPackets_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AggregatorServiceServer).IngestPackets(&grpc.GenericServerStream[DataPacket, IngestSummary]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AggregatorService_IngestPacketsServer = grpc.ClientStreamingServer[DataPacket, IngestSummary]
+
+func _AggregatorService_WatchMaxima_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchMaximaRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AggregatorServiceServer).WatchMaxima(m, &grpc.GenericServerStream[WatchMaximaRequest, MaxEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AggregatorService_WatchMaximaServer = grpc.ServerStreamingServer[MaxEvent]
+
 // AggregatorService_ServiceDesc is the grpc.ServiceDesc for AggregatorService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,6 +353,250 @@ var AggregatorService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetMaxByTimeRange",
 			Handler:    _AggregatorService_GetMaxByTimeRange_Handler,
 		},
+		{
+			MethodName: "GetTopByID",
+			Handler:    _AggregatorService_GetTopByID_Handler,
+		},
+		{
+			MethodName: "GetAggregateByID",
+			Handler:    _AggregatorService_GetAggregateByID_Handler,
+		},
+		{
+			MethodName: "GetAggregateByTimeRange",
+			Handler:    _AggregatorService_GetAggregateByTimeRange_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamMaxByTimeRange",
+			Handler:       _AggregatorService_StreamMaxByTimeRange_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "IngestPackets",
+			Handler:       _AggregatorService_IngestPackets_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "WatchMaxima",
+			Handler:       _AggregatorService_WatchMaxima_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "aggregator.proto",
+}
+
+const (
+	GeneratorAdmin_GetGeneratorState_FullMethodName = "/aggregator.GeneratorAdmin/GetGeneratorState"
+	GeneratorAdmin_PauseGenerator_FullMethodName    = "/aggregator.GeneratorAdmin/PauseGenerator"
+	GeneratorAdmin_ResumeGenerator_FullMethodName   = "/aggregator.GeneratorAdmin/ResumeGenerator"
+	GeneratorAdmin_UpdateGenerator_FullMethodName   = "/aggregator.GeneratorAdmin/UpdateGenerator"
+)
+
+// GeneratorAdminClient is the client API for GeneratorAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GeneratorAdminClient interface {
+	GetGeneratorState(ctx context.Context, in *GetGeneratorStateRequest, opts ...grpc.CallOption) (*GeneratorState, error)
+	PauseGenerator(ctx context.Context, in *PauseGeneratorRequest, opts ...grpc.CallOption) (*GeneratorState, error)
+	ResumeGenerator(ctx context.Context, in *ResumeGeneratorRequest, opts ...grpc.CallOption) (*GeneratorState, error)
+	UpdateGenerator(ctx context.Context, in *UpdateGeneratorRequest, opts ...grpc.CallOption) (*GeneratorState, error)
+}
+
+type generatorAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGeneratorAdminClient(cc grpc.ClientConnInterface) GeneratorAdminClient {
+	return &generatorAdminClient{cc}
+}
+
+func (c *generatorAdminClient) GetGeneratorState(ctx context.Context, in *GetGeneratorStateRequest, opts ...grpc.CallOption) (*GeneratorState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GeneratorState)
+	err := c.cc.Invoke(ctx, GeneratorAdmin_GetGeneratorState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *generatorAdminClient) PauseGenerator(ctx context.Context, in *PauseGeneratorRequest, opts ...grpc.CallOption) (*GeneratorState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GeneratorState)
+	err := c.cc.Invoke(ctx, GeneratorAdmin_PauseGenerator_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *generatorAdminClient) ResumeGenerator(ctx context.Context, in *ResumeGeneratorRequest, opts ...grpc.CallOption) (*GeneratorState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GeneratorState)
+	err := c.cc.Invoke(ctx, GeneratorAdmin_ResumeGenerator_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *generatorAdminClient) UpdateGenerator(ctx context.Context, in *UpdateGeneratorRequest, opts ...grpc.CallOption) (*GeneratorState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GeneratorState)
+	err := c.cc.Invoke(ctx, GeneratorAdmin_UpdateGenerator_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GeneratorAdminServer is the server API for GeneratorAdmin service.
+// All implementations must embed UnimplementedGeneratorAdminServer
+// for forward compatibility.
+type GeneratorAdminServer interface {
+	GetGeneratorState(context.Context, *GetGeneratorStateRequest) (*GeneratorState, error)
+	PauseGenerator(context.Context, *PauseGeneratorRequest) (*GeneratorState, error)
+	ResumeGenerator(context.Context, *ResumeGeneratorRequest) (*GeneratorState, error)
+	UpdateGenerator(context.Context, *UpdateGeneratorRequest) (*GeneratorState, error)
+	mustEmbedUnimplementedGeneratorAdminServer()
+}
+
+// UnimplementedGeneratorAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGeneratorAdminServer struct{}
+
+func (UnimplementedGeneratorAdminServer) GetGeneratorState(context.Context, *GetGeneratorStateRequest) (*GeneratorState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGeneratorState not implemented")
+}
+func (UnimplementedGeneratorAdminServer) PauseGenerator(context.Context, *PauseGeneratorRequest) (*GeneratorState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PauseGenerator not implemented")
+}
+func (UnimplementedGeneratorAdminServer) ResumeGenerator(context.Context, *ResumeGeneratorRequest) (*GeneratorState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeGenerator not implemented")
+}
+func (UnimplementedGeneratorAdminServer) UpdateGenerator(context.Context, *UpdateGeneratorRequest) (*GeneratorState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateGenerator not implemented")
+}
+func (UnimplementedGeneratorAdminServer) mustEmbedUnimplementedGeneratorAdminServer() {}
+func (UnimplementedGeneratorAdminServer) testEmbeddedByValue()                        {}
+
+// UnsafeGeneratorAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GeneratorAdminServer will
+// result in compilation errors.
+type UnsafeGeneratorAdminServer interface {
+	mustEmbedUnimplementedGeneratorAdminServer()
+}
+
+func RegisterGeneratorAdminServer(s grpc.ServiceRegistrar, srv GeneratorAdminServer) {
+	// If the following call pancis, it indicates UnimplementedGeneratorAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&GeneratorAdmin_ServiceDesc, srv)
+}
+
+func _GeneratorAdmin_GetGeneratorState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGeneratorStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeneratorAdminServer).GetGeneratorState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GeneratorAdmin_GetGeneratorState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeneratorAdminServer).GetGeneratorState(ctx, req.(*GetGeneratorStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeneratorAdmin_PauseGenerator_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseGeneratorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeneratorAdminServer).PauseGenerator(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GeneratorAdmin_PauseGenerator_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeneratorAdminServer).PauseGenerator(ctx, req.(*PauseGeneratorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeneratorAdmin_ResumeGenerator_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeGeneratorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeneratorAdminServer).ResumeGenerator(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GeneratorAdmin_ResumeGenerator_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeneratorAdminServer).ResumeGenerator(ctx, req.(*ResumeGeneratorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeneratorAdmin_UpdateGenerator_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateGeneratorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeneratorAdminServer).UpdateGenerator(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GeneratorAdmin_UpdateGenerator_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeneratorAdminServer).UpdateGenerator(ctx, req.(*UpdateGeneratorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// GeneratorAdmin_ServiceDesc is the grpc.ServiceDesc for GeneratorAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GeneratorAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "aggregator.GeneratorAdmin",
+	HandlerType: (*GeneratorAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetGeneratorState",
+			Handler:    _GeneratorAdmin_GetGeneratorState_Handler,
+		},
+		{
+			MethodName: "PauseGenerator",
+			Handler:    _GeneratorAdmin_PauseGenerator_Handler,
+		},
+		{
+			MethodName: "ResumeGenerator",
+			Handler:    _GeneratorAdmin_ResumeGenerator_Handler,
+		},
+		{
+			MethodName: "UpdateGenerator",
+			Handler:    _GeneratorAdmin_UpdateGenerator_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "aggregator.proto",
@@ -15,21 +15,65 @@ import (
 	"time"
 )
 
-// NewServer constructs a gRPC server exposing the AggregatorService transport.
-func NewServer(service domain.AggregatorService, logger *infra.Logger) *grpc.Server {
+// Option customises the AggregatorService implementation registered by NewServer.
+type Option func(*aggregatorServer)
+
+// WithIngestor enables the IngestPackets RPC and queues received packets through ingestor.
+func WithIngestor(ingestor domain.PacketIngestor) Option {
+	return func(s *aggregatorServer) {
+		s.ingestor = ingestor
+	}
+}
+
+// WithWatcher enables the WatchMaxima RPC backed by subscriber.
+func WithWatcher(subscriber domain.PacketMaxSubscriber) Option {
+	return func(s *aggregatorServer) {
+		s.watcher = subscriber
+	}
+}
+
+// WithGeneratorAdmin enables the GeneratorAdmin service. Calls must carry the
+// "authorization: Bearer <token>" metadata; an empty token keeps the service disabled.
+func WithGeneratorAdmin(controller domain.GeneratorController, token string) Option {
+	return func(s *aggregatorServer) {
+		s.generator = controller
+		s.adminToken = token
+	}
+}
+
+// NewServer constructs a gRPC server exposing the AggregatorService and GeneratorAdmin transports.
+func NewServer(service domain.AggregatorService, logger *infra.Logger, opts ...Option) *grpc.Server {
 	interceptors := []grpc.UnaryServerInterceptor{
 		loggingInterceptor(logger),
 		infra.GRPCUnaryInterceptor(),
 	}
+	streamInterceptors := []grpc.StreamServerInterceptor{
+		loggingStreamInterceptor(logger),
+		infra.GRPCStreamInterceptor(),
+	}
 
-	server := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
-	pb.RegisterAggregatorServiceServer(server, &aggregatorServer{service: service})
+	impl := &aggregatorServer{service: service, ingestWait: defaultIngestWait}
+	for _, opt := range opts {
+		opt(impl)
+	}
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(interceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+	)
+	pb.RegisterAggregatorServiceServer(server, impl)
+	pb.RegisterGeneratorAdminServer(server, &generatorAdminServer{controller: impl.generator, token: impl.adminToken, logger: logger})
 	return server
 }
 
 type aggregatorServer struct {
 	pb.UnimplementedAggregatorServiceServer
-	service domain.AggregatorService
+	service    domain.AggregatorService
+	ingestor   domain.PacketIngestor
+	ingestWait time.Duration
+	watcher    domain.PacketMaxSubscriber
+	generator  domain.GeneratorController
+	adminToken string
 }
 
 func (s *aggregatorServer) GetMaxByID(ctx context.Context, req *pb.GetByIDRequest) (*pb.GetByIDResponse, error) {
@@ -58,36 +102,138 @@ func (s *aggregatorServer) GetMaxByTimeRange(ctx context.Context, req *pb.GetByT
 		return nil, status.Error(codes.InvalidArgument, "request must not be nil")
 	}
 
-	if req.GetFrom() == nil || req.GetTo() == nil {
-		return nil, status.Error(codes.InvalidArgument, "both from and to parameters are required")
+	from, to, err := parseTimeRange(req.GetFrom(), req.GetTo())
+	if err != nil {
+		return nil, err
 	}
 
-	if err := req.GetFrom().CheckValid(); err != nil {
-		return nil, status.Error(codes.InvalidArgument, "invalid from timestamp")
+	if req.GetLimit() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit must not be negative")
 	}
 
-	if err := req.GetTo().CheckValid(); err != nil {
-		return nil, status.Error(codes.InvalidArgument, "invalid to timestamp")
+	page := domain.PageRequest{Limit: int(req.GetLimit()), Cursor: req.GetCursor()}
+	results, err := s.service.MaxInRange(ctx, from, to, page)
+	if err != nil {
+		return nil, translateServiceError(err)
 	}
 
-	from := req.GetFrom().AsTime().UTC()
-	to := req.GetTo().AsTime().UTC()
+	payload := make([]*pb.GetByIDResponse, len(results.Results))
+	for i, result := range results.Results {
+		payload[i] = toProtoResult(result)
+	}
 
-	if from.After(to) {
-		return nil, status.Error(codes.InvalidArgument, "from must be before to")
+	return &pb.GetByTimeRangeResponse{Results: payload, NextCursor: results.NextCursor}, nil
+}
+
+func (s *aggregatorServer) GetTopByID(ctx context.Context, req *pb.GetTopByIDRequest) (*pb.GetTopByIDResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "request must not be nil")
 	}
 
-	results, err := s.service.MaxInRange(ctx, from, to)
+	id, err := constants.ParseUUID(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid packet_id format")
+	}
+
+	if req.GetK() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "k must not be negative")
+	}
+
+	results, err := s.service.TopByPacketID(ctx, id, int(req.GetK()))
 	if err != nil {
 		return nil, translateServiceError(err)
 	}
 
-	payload := make([]*pb.GetByIDResponse, len(results))
+	payload := make([]*pb.RankedResult, len(results))
 	for i, result := range results {
-		payload[i] = toProtoResult(result)
+		payload[i] = &pb.RankedResult{
+			Id:        result.PacketID,
+			SourceId:  result.SourceID,
+			Timestamp: timestamppb.New(result.Timestamp.UTC()),
+			Value:     result.Value,
+			Rank:      int32(result.Rank),
+		}
+	}
+
+	return &pb.GetTopByIDResponse{Results: payload}, nil
+}
+
+func (s *aggregatorServer) GetAggregateByID(ctx context.Context, req *pb.GetAggregateByIDRequest) (*pb.AggregateResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "request must not be nil")
+	}
+
+	if req.GetFunction() == "" {
+		return nil, status.Error(codes.InvalidArgument, "function is required")
+	}
+
+	id, err := constants.ParseUUID(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid packet_id format")
 	}
 
-	return &pb.GetByTimeRangeResponse{Results: payload}, nil
+	result, err := s.service.AggregateByPacketID(ctx, req.GetFunction(), id)
+	if err != nil {
+		return nil, translateServiceError(err)
+	}
+
+	return toProtoAggregate(result), nil
+}
+
+func (s *aggregatorServer) GetAggregateByTimeRange(ctx context.Context, req *pb.GetAggregateByTimeRangeRequest) (*pb.GetAggregateByTimeRangeResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "request must not be nil")
+	}
+
+	if req.GetFunction() == "" {
+		return nil, status.Error(codes.InvalidArgument, "function is required")
+	}
+
+	from, to, err := parseTimeRange(req.GetFrom(), req.GetTo())
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetLimit() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit must not be negative")
+	}
+
+	page := domain.PageRequest{Limit: int(req.GetLimit()), Cursor: req.GetCursor()}
+	results, err := s.service.AggregateInRange(ctx, req.GetFunction(), from, to, page)
+	if err != nil {
+		return nil, translateServiceError(err)
+	}
+
+	payload := make([]*pb.AggregateResponse, len(results.Results))
+	for i, result := range results.Results {
+		payload[i] = toProtoAggregate(result)
+	}
+
+	return &pb.GetAggregateByTimeRangeResponse{Results: payload, NextCursor: results.NextCursor}, nil
+}
+
+// parseTimeRange validates the bounds of a time range request and returns them in UTC. Errors are
+// InvalidArgument statuses ready to be returned to the client.
+func parseTimeRange(fromTS, toTS *timestamppb.Timestamp) (time.Time, time.Time, error) {
+	if fromTS == nil || toTS == nil {
+		return time.Time{}, time.Time{}, status.Error(codes.InvalidArgument, "both from and to parameters are required")
+	}
+
+	if err := fromTS.CheckValid(); err != nil {
+		return time.Time{}, time.Time{}, status.Error(codes.InvalidArgument, "invalid from timestamp")
+	}
+
+	if err := toTS.CheckValid(); err != nil {
+		return time.Time{}, time.Time{}, status.Error(codes.InvalidArgument, "invalid to timestamp")
+	}
+
+	from := fromTS.AsTime().UTC()
+	to := toTS.AsTime().UTC()
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, status.Error(codes.InvalidArgument, "from must be before to")
+	}
+	return from, to, nil
 }
 
 func toProtoResult(result domain.AggregatorResult) *pb.GetByIDResponse {
@@ -99,15 +245,45 @@ func toProtoResult(result domain.AggregatorResult) *pb.GetByIDResponse {
 	}
 }
 
+func toProtoAggregate(result domain.AggregatorResult) *pb.AggregateResponse {
+	return &pb.AggregateResponse{
+		Id:        result.PacketID,
+		Function:  result.Function,
+		SourceId:  result.SourceID,
+		Timestamp: timestamppb.New(result.Timestamp.UTC()),
+		Value:     result.Value,
+	}
+}
+
 func translateServiceError(err error) error {
 	switch {
 	case errors.Is(err, domain.ErrNotFound):
 		return status.Error(codes.NotFound, "measurement not found")
+	case errors.Is(err, domain.ErrUnknownAggregation):
+		return status.Error(codes.InvalidArgument, "unknown aggregation function")
+	case errors.Is(err, domain.ErrInvalidCursor):
+		return status.Error(codes.InvalidArgument, "invalid cursor")
 	default:
 		return status.Error(codes.Internal, "internal server error")
 	}
 }
 
+func loggingStreamInterceptor(logger *infra.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		duration := time.Since(start)
+		if logger != nil {
+			if err != nil {
+				logger.Printf(ss.Context(), "gRPC stream %s failed in %s: %v", info.FullMethod, duration, err)
+			} else {
+				logger.Printf(ss.Context(), "gRPC stream %s completed in %s", info.FullMethod, duration)
+			}
+		}
+		return err
+	}
+}
+
 func loggingInterceptor(logger *infra.Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		start := time.Now()
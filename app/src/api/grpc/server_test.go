@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -23,11 +24,21 @@ type stubService struct {
 	resultByID    domain.AggregatorResult
 	errByID       error
 	resultInRange []domain.AggregatorResult
+	nextCursor    string
 	errInRange    error
-
-	lastID   string
-	lastFrom time.Time
-	lastTo   time.Time
+	errStream     error
+	resultAgg     domain.AggregatorResult
+	resultsAgg    []domain.AggregatorResult
+	errAgg        error
+	resultsTop    []domain.AggregatorResult
+	errTop        error
+
+	lastK        int
+	lastID       string
+	lastFunction string
+	lastFrom     time.Time
+	lastTo       time.Time
+	lastPage     domain.PageRequest
 }
 
 func (s *stubService) MaxByPacketID(ctx context.Context, packetID string) (domain.AggregatorResult, error) {
@@ -35,10 +46,45 @@ func (s *stubService) MaxByPacketID(ctx context.Context, packetID string) (domai
 	return s.resultByID, s.errByID
 }
 
-func (s *stubService) MaxInRange(ctx context.Context, from, to time.Time) ([]domain.AggregatorResult, error) {
+func (s *stubService) MaxInRange(ctx context.Context, from, to time.Time, page domain.PageRequest) (domain.ResultPage, error) {
 	s.lastFrom = from
 	s.lastTo = to
-	return s.resultInRange, s.errInRange
+	s.lastPage = page
+	return domain.ResultPage{Results: s.resultInRange, NextCursor: s.nextCursor}, s.errInRange
+}
+
+func (s *stubService) StreamMaxInRange(ctx context.Context, from, to time.Time, emit func(domain.AggregatorResult) error) error {
+	s.lastFrom = from
+	s.lastTo = to
+	if s.errInRange != nil {
+		return s.errInRange
+	}
+	for _, result := range s.resultInRange {
+		if err := emit(result); err != nil {
+			return err
+		}
+	}
+	return s.errStream
+}
+
+func (s *stubService) AggregateByPacketID(ctx context.Context, function, packetID string) (domain.AggregatorResult, error) {
+	s.lastFunction = function
+	s.lastID = packetID
+	return s.resultAgg, s.errAgg
+}
+
+func (s *stubService) AggregateInRange(ctx context.Context, function string, from, to time.Time, page domain.PageRequest) (domain.ResultPage, error) {
+	s.lastFunction = function
+	s.lastFrom = from
+	s.lastTo = to
+	s.lastPage = page
+	return domain.ResultPage{Results: s.resultsAgg, NextCursor: s.nextCursor}, s.errAgg
+}
+
+func (s *stubService) TopByPacketID(ctx context.Context, packetID string, k int) ([]domain.AggregatorResult, error) {
+	s.lastID = packetID
+	s.lastK = k
+	return s.resultsTop, s.errTop
 }
 
 func TestNewServerRegistersService(t *testing.T) {
@@ -133,6 +179,30 @@ func TestGetMaxByTimeRangeSuccess(t *testing.T) {
 	assert.True(t, service.lastTo.Equal(now))
 }
 
+func TestGetMaxByTimeRangePaginates(t *testing.T) {
+	now := time.Now().UTC()
+	service := &stubService{resultInRange: []domain.AggregatorResult{{PacketID: constants.GenerateUUID(), Timestamp: now}}, nextCursor: "next"}
+	server := &aggregatorServer{service: service}
+
+	t.Log("Шаг 1: лимит и курсор передаются сервису, курсор следующей страницы возвращается клиенту")
+	req := &pb.GetByTimeRangeRequest{From: timestamppb.New(now.Add(-time.Hour)), To: timestamppb.New(now), Limit: 25, Cursor: "current"}
+	resp, err := server.GetMaxByTimeRange(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PageRequest{Limit: 25, Cursor: "current"}, service.lastPage)
+	assert.Equal(t, "next", resp.GetNextCursor())
+
+	t.Log("Шаг 2: отрицательный лимит отклоняется")
+	req.Limit = -1
+	_, err = server.GetMaxByTimeRange(context.Background(), req)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	t.Log("Шаг 3: испорченный курсор превращается в InvalidArgument")
+	req.Limit = 0
+	service.errInRange = fmt.Errorf("repository: %w", domain.ErrInvalidCursor)
+	_, err = server.GetMaxByTimeRange(context.Background(), req)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
 func TestGetMaxByTimeRangeServiceError(t *testing.T) {
 	t.Log("Шаг 1: сервис возвращает ошибку NotFound для диапазона")
 	now := time.Now().UTC()
@@ -144,6 +214,76 @@ func TestGetMaxByTimeRangeServiceError(t *testing.T) {
 	assert.Equal(t, codes.NotFound, status.Code(err))
 }
 
+func TestGetAggregateByIDValidatesRequest(t *testing.T) {
+	t.Log("Шаг 1: проверяем отказ без имени функции")
+	server := &aggregatorServer{service: &stubService{}}
+
+	_, err := server.GetAggregateByID(context.Background(), &pb.GetAggregateByIDRequest{Id: constants.GenerateUUID()})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	t.Log("Шаг 2: проверяем отказ при некорректном UUID")
+	_, err = server.GetAggregateByID(context.Background(), &pb.GetAggregateByIDRequest{Id: "invalid", Function: "min"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGetAggregateByIDSuccess(t *testing.T) {
+	t.Log("Шаг 1: настраиваем ответ сервиса для функции min")
+	id := constants.GenerateUUID()
+	now := time.Now().UTC()
+	service := &stubService{resultAgg: domain.AggregatorResult{PacketID: id, Function: "min", SourceID: "source", Value: 0.5, Timestamp: now}}
+	server := &aggregatorServer{service: service}
+
+	t.Log("Шаг 2: выполняем запрос и проверяем результат")
+	resp, err := server.GetAggregateByID(context.Background(), &pb.GetAggregateByIDRequest{Id: id, Function: "min"})
+	assert.NoError(t, err)
+	assert.Equal(t, "min", service.lastFunction)
+	assert.Equal(t, "min", resp.GetFunction())
+	assert.Equal(t, "source", resp.GetSourceId())
+	assert.Equal(t, 0.5, resp.GetValue())
+}
+
+func TestGetAggregateByTimeRangeUnknownFunction(t *testing.T) {
+	t.Log("Шаг 1: сервис не знает запрошенную функцию")
+	now := time.Now().UTC()
+	service := &stubService{errAgg: domain.ErrUnknownAggregation}
+	server := &aggregatorServer{service: service}
+
+	req := &pb.GetAggregateByTimeRangeRequest{Function: "median", From: timestamppb.New(now.Add(-time.Hour)), To: timestamppb.New(now)}
+	_, err := server.GetAggregateByTimeRange(context.Background(), req)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGetAggregateByTimeRangeSuccess(t *testing.T) {
+	t.Log("Шаг 1: готовим результаты функции count в диапазоне")
+	now := time.Now().UTC()
+	from := now.Add(-time.Hour)
+	result := domain.AggregatorResult{PacketID: constants.GenerateUUID(), Function: "count", Value: 3, Timestamp: now}
+	service := &stubService{resultsAgg: []domain.AggregatorResult{result}}
+	server := &aggregatorServer{service: service}
+
+	req := &pb.GetAggregateByTimeRangeRequest{Function: "count", From: timestamppb.New(from), To: timestamppb.New(now)}
+	t.Log("Шаг 2: вызываем метод и проверяем ответ")
+	resp, err := server.GetAggregateByTimeRange(context.Background(), req)
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.GetResults(), 1)
+	assert.Equal(t, "count", service.lastFunction)
+	assert.True(t, service.lastFrom.Equal(from))
+	assert.Empty(t, resp.GetNextCursor())
+
+	t.Log("Шаг 3: лимит и курсор передаются сервису, курсор следующей страницы возвращается")
+	service.nextCursor = "next"
+	req.Limit, req.Cursor = 10, "current"
+	resp, err = server.GetAggregateByTimeRange(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PageRequest{Limit: 10, Cursor: "current"}, service.lastPage)
+	assert.Equal(t, "next", resp.GetNextCursor())
+
+	req.Limit = -1
+	_, err = server.GetAggregateByTimeRange(context.Background(), req)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
 func TestToProtoResult(t *testing.T) {
 	t.Log("Шаг 1: конвертируем результат домена в protobuf")
 	now := time.Now().UTC()
@@ -185,3 +325,41 @@ func TestTranslateInvalidUUIDError(t *testing.T) {
 	err := translateServiceError(sharederrors.ErrInvalidUUID)
 	assert.Equal(t, codes.Internal, status.Code(err))
 }
+
+func TestGetTopByIDValidatesRequest(t *testing.T) {
+	t.Log("Шаг 1: проверяем отказ при некорректном UUID и отрицательном k")
+	server := &aggregatorServer{service: &stubService{}}
+
+	_, err := server.GetTopByID(context.Background(), &pb.GetTopByIDRequest{Id: "invalid"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = server.GetTopByID(context.Background(), &pb.GetTopByIDRequest{Id: constants.GenerateUUID(), K: -1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGetTopByIDSuccess(t *testing.T) {
+	t.Log("Шаг 1: настраиваем ранжированный ответ сервиса")
+	id := constants.GenerateUUID()
+	now := time.Now().UTC()
+	service := &stubService{resultsTop: []domain.AggregatorResult{
+		{PacketID: id, SourceID: "s1", Value: 9, Timestamp: now, Rank: 1},
+		{PacketID: id, SourceID: "s2", Value: 7, Timestamp: now, Rank: 2},
+	}}
+	server := &aggregatorServer{service: service}
+
+	t.Log("Шаг 2: выполняем запрос и проверяем ранги")
+	resp, err := server.GetTopByID(context.Background(), &pb.GetTopByIDRequest{Id: id, K: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, service.lastK)
+	assert.Len(t, resp.GetResults(), 2)
+	assert.Equal(t, int32(2), resp.GetResults()[1].GetRank())
+	assert.Equal(t, "s2", resp.GetResults()[1].GetSourceId())
+}
+
+func TestGetTopByIDServiceError(t *testing.T) {
+	t.Log("Шаг 1: сервис возвращает ErrNotFound")
+	server := &aggregatorServer{service: &stubService{errTop: domain.ErrNotFound}}
+
+	_, err := server.GetTopByID(context.Background(), &pb.GetTopByIDRequest{Id: constants.GenerateUUID()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,117 @@
+package grpcapi
+
+import (
+	"errors"
+	"time"
+
+	pb "aggregator-service/app/src/api/grpc/pb"
+	"aggregator-service/app/src/domain"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// WatchMaxima streams every packet maximum persisted after the call started. When since is set,
+// maxima stored between since and now are replayed from the repository first; packets already
+// replayed are not sent again when they arrive live.
+func (s *aggregatorServer) WatchMaxima(req *pb.WatchMaximaRequest, stream grpc.ServerStreamingServer[pb.MaxEvent]) error {
+	if s.watcher == nil {
+		return status.Error(codes.Unavailable, "maxima watch is disabled")
+	}
+	if req == nil {
+		return status.Error(codes.InvalidArgument, "request must not be nil")
+	}
+
+	filter := domain.MaxFilter{}
+	if req.MinValue != nil {
+		filter.MinValue = req.GetMinValue()
+		filter.HasMinValue = true
+	}
+	if req.GetSince() != nil {
+		if err := req.GetSince().CheckValid(); err != nil {
+			return status.Error(codes.InvalidArgument, "invalid since timestamp")
+		}
+		filter.Since = req.GetSince().AsTime().UTC()
+	}
+
+	ctx := stream.Context()
+	sub := s.watcher.Subscribe(filter)
+	defer sub.Close()
+
+	replayed, err := s.replayMaxima(stream, filter)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case packetMax, ok := <-sub.Updates():
+			if !ok {
+				if errors.Is(sub.Err(), domain.ErrSlowConsumer) {
+					return status.Error(codes.ResourceExhausted, "subscriber evicted: too slow to consume updates")
+				}
+				return status.Error(codes.Unavailable, "maxima watch closed")
+			}
+			if _, seen := replayed[packetMax.PacketID]; seen {
+				delete(replayed, packetMax.PacketID)
+				continue
+			}
+			if err := stream.Send(toMaxEvent(packetMax, false)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// replayMaxima sends stored maxima matching filter, one page at a time, and returns the ids it sent.
+func (s *aggregatorServer) replayMaxima(stream grpc.ServerStreamingServer[pb.MaxEvent], filter domain.MaxFilter) (map[string]struct{}, error) {
+	replayed := make(map[string]struct{})
+	if filter.Since.IsZero() {
+		return replayed, nil
+	}
+
+	until := time.Now().UTC()
+	var page domain.PageRequest
+	for {
+		results, err := s.service.MaxInRange(stream.Context(), filter.Since, until, page)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return replayed, nil
+			}
+			return nil, translateServiceError(err)
+		}
+
+		for _, result := range results.Results {
+			packetMax := domain.PacketMax{
+				PacketID:  result.PacketID,
+				SourceID:  result.SourceID,
+				Value:     result.Value,
+				Timestamp: result.Timestamp,
+			}
+			if !filter.Match(packetMax) {
+				continue
+			}
+			if err := stream.Send(toMaxEvent(packetMax, true)); err != nil {
+				return nil, err
+			}
+			replayed[packetMax.PacketID] = struct{}{}
+		}
+		if results.NextCursor == "" {
+			return replayed, nil
+		}
+		page.Cursor = results.NextCursor
+	}
+}
+
+func toMaxEvent(packetMax domain.PacketMax, replayed bool) *pb.MaxEvent {
+	return &pb.MaxEvent{
+		Id:        packetMax.PacketID,
+		SourceId:  packetMax.SourceID,
+		Timestamp: timestamppb.New(packetMax.Timestamp.UTC()),
+		Value:     packetMax.Value,
+		Replayed:  replayed,
+	}
+}
@@ -0,0 +1,157 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	pb "aggregator-service/app/src/api/grpc/pb"
+	"aggregator-service/app/src/core"
+	"aggregator-service/app/src/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type stubWatchStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	sendErr error
+
+	mu     sync.Mutex
+	events []*pb.MaxEvent
+}
+
+func (s *stubWatchStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *stubWatchStream) Send(event *pb.MaxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sendErr != nil {
+		return s.sendErr
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *stubWatchStream) sent() []*pb.MaxEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*pb.MaxEvent(nil), s.events...)
+}
+
+func runWatch(server *aggregatorServer, req *pb.WatchMaximaRequest, stream *stubWatchStream) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- server.WatchMaxima(req, stream) }()
+	return done
+}
+
+func TestWatchMaximaRequiresWatcher(t *testing.T) {
+	t.Log("Шаг 1: без хаба подписка недоступна")
+	server := &aggregatorServer{service: &stubService{}}
+	err := server.WatchMaxima(&pb.WatchMaximaRequest{}, &stubWatchStream{ctx: context.Background()})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	t.Log("Шаг 2: некорректная метка since отклоняется")
+	server.watcher = core.NewMaxHub(1, nil)
+	err = server.WatchMaxima(&pb.WatchMaximaRequest{Since: &timestamppb.Timestamp{Nanos: -1}}, &stubWatchStream{ctx: context.Background()})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestWatchMaximaStreamsFilteredLiveUpdates(t *testing.T) {
+	t.Log("Шаг 1: подписываемся с порогом значения")
+	hub := core.NewMaxHub(4, nil)
+	server := &aggregatorServer{service: &stubService{}, watcher: hub}
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &stubWatchStream{ctx: ctx}
+	done := runWatch(server, &pb.WatchMaximaRequest{MinValue: proto.Float64(10)}, stream)
+	require.Eventually(t, func() bool { return hub.Subscribers() == 1 }, time.Second, time.Millisecond)
+
+	t.Log("Шаг 2: публикуем максимумы ниже и выше порога")
+	hub.Publish(domain.PacketMax{PacketID: "low", Value: 5, Timestamp: time.Now()})
+	hub.Publish(domain.PacketMax{PacketID: "high", Value: 15, Timestamp: time.Now()})
+	require.Eventually(t, func() bool { return len(stream.sent()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, "high", stream.sent()[0].GetId())
+	assert.False(t, stream.sent()[0].GetReplayed())
+
+	t.Log("Шаг 3: отмена клиента завершает поток и снимает подписку")
+	cancel()
+	assert.Equal(t, codes.Canceled, status.Code(<-done))
+	assert.Zero(t, hub.Subscribers())
+}
+
+func TestWatchMaximaReplaysSinceWithoutDuplicates(t *testing.T) {
+	t.Log("Шаг 1: в базе уже есть максимум после since")
+	now := time.Now().UTC()
+	service := &stubService{resultInRange: []domain.AggregatorResult{{PacketID: "stored", SourceID: "s1", Value: 7, Timestamp: now}}}
+	hub := core.NewMaxHub(4, nil)
+	server := &aggregatorServer{service: service, watcher: hub}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &stubWatchStream{ctx: ctx}
+
+	done := runWatch(server, &pb.WatchMaximaRequest{Since: timestamppb.New(now.Add(-time.Minute))}, stream)
+	require.Eventually(t, func() bool { return len(stream.sent()) == 1 }, time.Second, time.Millisecond)
+	assert.True(t, stream.sent()[0].GetReplayed())
+	assert.Equal(t, now.Add(-time.Minute), service.lastFrom)
+
+	t.Log("Шаг 2: тот же пакет приходит вживую и не дублируется")
+	hub.Publish(domain.PacketMax{PacketID: "stored", Value: 7, Timestamp: now})
+	hub.Publish(domain.PacketMax{PacketID: "fresh", Value: 8, Timestamp: now})
+	require.Eventually(t, func() bool { return len(stream.sent()) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, "fresh", stream.sent()[1].GetId())
+
+	t.Log("Шаг 3: остановка хаба закрывает поток")
+	hub.Close()
+	assert.Equal(t, codes.Unavailable, status.Code(<-done))
+}
+
+func TestWatchMaximaReplayErrors(t *testing.T) {
+	since := timestamppb.New(time.Now().Add(-time.Minute))
+
+	t.Log("Шаг 1: пустая история не мешает подписке")
+	hub := core.NewMaxHub(1, nil)
+	server := &aggregatorServer{service: &stubService{errInRange: domain.ErrNotFound}, watcher: hub}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := runWatch(server, &pb.WatchMaximaRequest{Since: since}, &stubWatchStream{ctx: ctx})
+	require.Eventually(t, func() bool { return hub.Subscribers() == 1 }, time.Second, time.Millisecond)
+	cancel()
+	<-done
+
+	t.Log("Шаг 2: ошибка репозитория завершает поток")
+	server.service = &stubService{errInRange: errors.New("db down")}
+	err := server.WatchMaxima(&pb.WatchMaximaRequest{Since: since}, &stubWatchStream{ctx: context.Background()})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestWatchMaximaEvictsSlowConsumer(t *testing.T) {
+	t.Log("Шаг 1: буфер подписчика переполняется, пока поток блокирован")
+	hub := core.NewMaxHub(1, nil)
+	sub := hub.Subscribe(domain.MaxFilter{})
+	hub.Publish(domain.PacketMax{PacketID: "p1"})
+	hub.Publish(domain.PacketMax{PacketID: "p2"})
+	<-sub.Updates()
+	_, ok := <-sub.Updates()
+	require.False(t, ok)
+
+	t.Log("Шаг 2: сервер переводит вытеснение в ResourceExhausted")
+	server := &aggregatorServer{service: &stubService{}, watcher: evictedWatcher{sub: sub}}
+	err := server.WatchMaxima(&pb.WatchMaximaRequest{}, &stubWatchStream{ctx: context.Background()})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+type evictedWatcher struct {
+	sub domain.MaxSubscription
+}
+
+func (w evictedWatcher) Subscribe(domain.MaxFilter) domain.MaxSubscription {
+	return w.sub
+}
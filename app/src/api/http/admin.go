@@ -0,0 +1,161 @@
+package httpapi
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/shared/constants"
+)
+
+const maxAdminBodyBytes = 64 << 10
+
+type generatorChangeResponse struct {
+	At     string `json:"at"`
+	Action string `json:"action"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type generatorStateResponse struct {
+	Paused        bool                      `json:"paused"`
+	IntervalMS    int64                     `json:"interval_ms"`
+	MinPacketSize int                       `json:"min_packet_size"`
+	MaxPacketSize int                       `json:"max_packet_size"`
+	Changes       []generatorChangeResponse `json:"changes"`
+}
+
+// generatorUpdateRequest changes only the fields that are present. packet_size fixes the size;
+// min_packet_size and max_packet_size set a range, a missing bound keeps its current value.
+type generatorUpdateRequest struct {
+	IntervalMS    *int64 `json:"interval_ms"`
+	PacketSize    *int   `json:"packet_size"`
+	MinPacketSize *int   `json:"min_packet_size"`
+	MaxPacketSize *int   `json:"max_packet_size"`
+}
+
+// requireAdmin rejects requests without the admin bearer token before they reach next.
+func (h *handler) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.adminToken == "" {
+			h.writeError(w, http.StatusServiceUnavailable, "admin API is disabled")
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(h.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			h.writeError(w, http.StatusUnauthorized, "invalid or missing admin token")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// requireGenerator answers 503 when the running generator cannot be controlled at runtime.
+func (h *handler) requireGenerator(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.generator == nil {
+			h.writeError(w, http.StatusServiceUnavailable, "generator control is unavailable")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (h *handler) handleGetGenerator(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, toGeneratorStateResponse(h.generator.State()))
+}
+
+func (h *handler) handlePauseGenerator(w http.ResponseWriter, r *http.Request) {
+	h.generator.Pause()
+	h.logf(r, "admin: генератор поставлен на паузу")
+	h.writeJSON(w, http.StatusOK, toGeneratorStateResponse(h.generator.State()))
+}
+
+func (h *handler) handleResumeGenerator(w http.ResponseWriter, r *http.Request) {
+	h.generator.Resume()
+	h.logf(r, "admin: генератор возобновлён")
+	h.writeJSON(w, http.StatusOK, toGeneratorStateResponse(h.generator.State()))
+}
+
+func (h *handler) handleUpdateGenerator(w http.ResponseWriter, r *http.Request) {
+	var req generatorUpdateRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdminBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.IntervalMS != nil && *req.IntervalMS <= 0 {
+		h.writeError(w, http.StatusBadRequest, "interval_ms must be positive")
+		return
+	}
+	if req.PacketSize != nil && (req.MinPacketSize != nil || req.MaxPacketSize != nil) {
+		h.writeError(w, http.StatusBadRequest, "packet_size cannot be combined with min_packet_size or max_packet_size")
+		return
+	}
+
+	// The packet size goes first: it is the only change the controller may still reject, so a
+	// failed request leaves the generator untouched.
+	if req.PacketSize != nil || req.MinPacketSize != nil || req.MaxPacketSize != nil {
+		state := h.generator.State()
+		minSize, maxSize := state.MinPacketSize, state.MaxPacketSize
+		switch {
+		case req.PacketSize != nil:
+			minSize, maxSize = *req.PacketSize, *req.PacketSize
+		default:
+			if req.MinPacketSize != nil {
+				minSize = *req.MinPacketSize
+			}
+			if req.MaxPacketSize != nil {
+				maxSize = *req.MaxPacketSize
+			}
+		}
+		if err := h.generator.SetPacketSize(minSize, maxSize); err != nil {
+			h.writeGeneratorError(w, err)
+			return
+		}
+	}
+
+	if req.IntervalMS != nil {
+		if err := h.generator.SetInterval(time.Duration(*req.IntervalMS) * time.Millisecond); err != nil {
+			h.writeGeneratorError(w, err)
+			return
+		}
+	}
+
+	h.logf(r, "admin: параметры генератора обновлены")
+	h.writeJSON(w, http.StatusOK, toGeneratorStateResponse(h.generator.State()))
+}
+
+func (h *handler) writeGeneratorError(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrInvalidGeneratorSetting) {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.writeError(w, http.StatusInternalServerError, "internal server error")
+}
+
+func toGeneratorStateResponse(state domain.GeneratorState) generatorStateResponse {
+	changes := make([]generatorChangeResponse, len(state.Changes))
+	for i, change := range state.Changes {
+		changes[i] = generatorChangeResponse{
+			At:     change.At.UTC().Format(constants.TimeFormat),
+			Action: change.Action,
+			Detail: change.Detail,
+		}
+	}
+	return generatorStateResponse{
+		Paused:        state.Paused,
+		IntervalMS:    state.Interval.Milliseconds(),
+		MinPacketSize: state.MinPacketSize,
+		MaxPacketSize: state.MaxPacketSize,
+		Changes:       changes,
+	}
+}
@@ -0,0 +1,186 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	chi "aggregator-service/app/src/api/chi"
+	"aggregator-service/app/src/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAdminToken = "s3cret"
+
+type stubGeneratorController struct {
+	state   domain.GeneratorState
+	calls   []string
+	sizeErr error
+}
+
+func (s *stubGeneratorController) State() domain.GeneratorState {
+	return s.state
+}
+
+func (s *stubGeneratorController) Pause() {
+	s.calls = append(s.calls, "pause")
+	s.state.Paused = true
+}
+
+func (s *stubGeneratorController) Resume() {
+	s.calls = append(s.calls, "resume")
+	s.state.Paused = false
+}
+
+func (s *stubGeneratorController) SetInterval(interval time.Duration) error {
+	s.calls = append(s.calls, fmt.Sprintf("interval %s", interval))
+	s.state.Interval = interval
+	return nil
+}
+
+func (s *stubGeneratorController) SetPacketSize(min, max int) error {
+	if s.sizeErr != nil {
+		return s.sizeErr
+	}
+	s.calls = append(s.calls, fmt.Sprintf("size %d..%d", min, max))
+	s.state.MinPacketSize, s.state.MaxPacketSize = min, max
+	return nil
+}
+
+func newAdminRouter(controller domain.GeneratorController, token string) *chi.Mux {
+	router := chi.NewRouter()
+	registerRoutes(router, &handler{service: &stubAggregatorService{}, generator: controller, adminToken: token})
+	return router
+}
+
+func newAdminRequest(method, path, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	return req
+}
+
+func TestAdminGeneratorRequiresToken(t *testing.T) {
+	controller := &stubGeneratorController{}
+	router := newAdminRouter(controller, testAdminToken)
+
+	t.Log("Шаг 1: запрос без токена отклоняется")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/generator/pause", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Header().Get("WWW-Authenticate"), "Bearer")
+
+	t.Log("Шаг 2: запрос с неверным токеном отклоняется")
+	req := httptest.NewRequest(http.MethodPost, "/admin/generator/pause", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Empty(t, controller.calls)
+
+	t.Log("Шаг 3: без настроенного токена административный API выключен")
+	rr = httptest.NewRecorder()
+	newAdminRouter(controller, "").ServeHTTP(rr, newAdminRequest(http.MethodGet, "/admin/generator", ""))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
+
+func TestAdminGeneratorUnavailableWithoutController(t *testing.T) {
+	rr := httptest.NewRecorder()
+	newAdminRouter(nil, testAdminToken).ServeHTTP(rr, newAdminRequest(http.MethodGet, "/admin/generator", ""))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Contains(t, rr.Body.String(), "generator control is unavailable")
+}
+
+func TestAdminGeneratorState(t *testing.T) {
+	at := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	controller := &stubGeneratorController{state: domain.GeneratorState{
+		Paused:        true,
+		Interval:      1500 * time.Millisecond,
+		MinPacketSize: 2,
+		MaxPacketSize: 6,
+		Changes:       []domain.GeneratorChange{{At: at, Action: "pause"}},
+	}}
+
+	rr := httptest.NewRecorder()
+	newAdminRouter(controller, testAdminToken).ServeHTTP(rr, newAdminRequest(http.MethodGet, "/admin/generator", ""))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var payload generatorStateResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &payload))
+	assert.True(t, payload.Paused)
+	assert.Equal(t, int64(1500), payload.IntervalMS)
+	assert.Equal(t, 2, payload.MinPacketSize)
+	assert.Equal(t, 6, payload.MaxPacketSize)
+	require.Len(t, payload.Changes, 1)
+	assert.Equal(t, "2024-05-06T07:08:09Z", payload.Changes[0].At)
+	assert.Equal(t, "pause", payload.Changes[0].Action)
+}
+
+func TestAdminGeneratorPauseAndResume(t *testing.T) {
+	controller := &stubGeneratorController{}
+	router := newAdminRouter(controller, testAdminToken)
+
+	t.Log("Шаг 1: ставим генератор на паузу")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, newAdminRequest(http.MethodPost, "/admin/generator/pause", ""))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"paused":true`)
+
+	t.Log("Шаг 2: возобновляем генерацию")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, newAdminRequest(http.MethodPost, "/admin/generator/resume", ""))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"paused":false`)
+	assert.Equal(t, []string{"pause", "resume"}, controller.calls)
+}
+
+func TestAdminGeneratorUpdate(t *testing.T) {
+	controller := &stubGeneratorController{state: domain.GeneratorState{Interval: time.Second, MinPacketSize: 4, MaxPacketSize: 4}}
+	router := newAdminRouter(controller, testAdminToken)
+
+	t.Log("Шаг 1: меняем интервал и верхнюю границу размера пакета")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, newAdminRequest(http.MethodPatch, "/admin/generator", `{"interval_ms":250,"max_packet_size":8}`))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []string{"size 4..8", "interval 250ms"}, controller.calls)
+	assert.Contains(t, rr.Body.String(), `"interval_ms":250`)
+
+	t.Log("Шаг 2: packet_size фиксирует размер пакета")
+	controller.calls = nil
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, newAdminRequest(http.MethodPatch, "/admin/generator", `{"packet_size":3}`))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []string{"size 3..3"}, controller.calls)
+}
+
+func TestAdminGeneratorUpdateRejectsInvalidInput(t *testing.T) {
+	controller := &stubGeneratorController{state: domain.GeneratorState{Interval: time.Second, MinPacketSize: 1, MaxPacketSize: 1}}
+	router := newAdminRouter(controller, testAdminToken)
+
+	for name, body := range map[string]string{
+		"malformed":         `{"interval_ms":`,
+		"unknown field":     `{"rate":5}`,
+		"zero interval":     `{"interval_ms":0}`,
+		"mixed sizes":       `{"packet_size":3,"max_packet_size":5}`,
+		"negative interval": `{"interval_ms":-5}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, newAdminRequest(http.MethodPatch, "/admin/generator", body))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+
+	t.Log("ошибка контроллера не применяет интервал")
+	controller.sizeErr = fmt.Errorf("%w: max packet size must not be less than min", domain.ErrInvalidGeneratorSetting)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, newAdminRequest(http.MethodPatch, "/admin/generator", `{"interval_ms":10,"min_packet_size":5}`))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "max packet size must not be less than min")
+	assert.Empty(t, controller.calls)
+}
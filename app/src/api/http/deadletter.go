@@ -0,0 +1,138 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/shared/constants"
+)
+
+const (
+	queryLimit          = "limit"
+	defaultDLQListLimit = 100
+)
+
+type deadLetterResponse struct {
+	ID            string  `json:"id"`
+	PacketID      string  `json:"packet_id"`
+	SourceID      string  `json:"source_id"`
+	Value         float64 `json:"value"`
+	Timestamp     string  `json:"timestamp"`
+	Rank          int     `json:"rank,omitempty"`
+	Reason        string  `json:"reason"`
+	Attempts      int     `json:"attempts"`
+	FirstFailedAt string  `json:"first_failed_at"`
+	LastFailedAt  string  `json:"last_failed_at"`
+}
+
+type deadLetterListResponse struct {
+	Depth   int                  `json:"depth"`
+	Entries []deadLetterResponse `json:"entries"`
+}
+
+type redriveResponse struct {
+	Attempted int `json:"attempted"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Depth     int `json:"depth"`
+}
+
+// requireDeadLetters answers 503 when the storage backend has no dead-letter queue.
+func (h *handler) requireDeadLetters(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.deadLetters == nil {
+			h.writeError(w, http.StatusServiceUnavailable, domain.ErrDeadLettersDisabled.Error())
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (h *handler) handleListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	limit, ok := h.parseLimit(w, r, defaultDLQListLimit)
+	if !ok {
+		return
+	}
+
+	entries, err := h.deadLetters.DeadLetters(r.Context(), limit)
+	if err != nil {
+		h.writeDeadLetterError(w, err)
+		return
+	}
+	depth, err := h.deadLetters.DeadLetterDepth(r.Context())
+	if err != nil {
+		h.writeDeadLetterError(w, err)
+		return
+	}
+
+	payload := deadLetterListResponse{Depth: depth, Entries: make([]deadLetterResponse, len(entries))}
+	for i, entry := range entries {
+		payload.Entries[i] = toDeadLetterResponse(entry)
+	}
+	h.writeJSON(w, http.StatusOK, payload)
+}
+
+// handleRedriveDeadLetters retries up to limit entries; without limit the whole queue is retried.
+func (h *handler) handleRedriveDeadLetters(w http.ResponseWriter, r *http.Request) {
+	limit, ok := h.parseLimit(w, r, 0)
+	if !ok {
+		return
+	}
+
+	report, err := h.deadLetters.Redrive(r.Context(), limit)
+	if err != nil {
+		h.writeDeadLetterError(w, err)
+		return
+	}
+	depth, err := h.deadLetters.DeadLetterDepth(r.Context())
+	if err != nil {
+		h.writeDeadLetterError(w, err)
+		return
+	}
+
+	h.logf(r, "admin: повторная запись DLQ: попыток %d, успешно %d, с ошибкой %d", report.Attempted, report.Succeeded, report.Failed)
+	h.writeJSON(w, http.StatusOK, redriveResponse{
+		Attempted: report.Attempted,
+		Succeeded: report.Succeeded,
+		Failed:    report.Failed,
+		Depth:     depth,
+	})
+}
+
+func (h *handler) parseLimit(w http.ResponseWriter, r *http.Request, fallback int) (int, bool) {
+	raw := r.URL.Query().Get(queryLimit)
+	if raw == "" {
+		return fallback, true
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 {
+		h.writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+		return 0, false
+	}
+	return limit, true
+}
+
+func (h *handler) writeDeadLetterError(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrDeadLettersDisabled) {
+		h.writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	h.writeError(w, http.StatusInternalServerError, "internal server error")
+}
+
+func toDeadLetterResponse(entry domain.DeadLetter) deadLetterResponse {
+	return deadLetterResponse{
+		ID:            entry.ID,
+		PacketID:      entry.PacketMax.PacketID,
+		SourceID:      entry.PacketMax.SourceID,
+		Value:         entry.PacketMax.Value,
+		Timestamp:     entry.PacketMax.Timestamp.UTC().Format(constants.TimeFormat),
+		Rank:          entry.PacketMax.Rank,
+		Reason:        entry.Reason,
+		Attempts:      entry.Attempts,
+		FirstFailedAt: entry.FirstFailedAt.UTC().Format(constants.TimeFormat),
+		LastFailedAt:  entry.LastFailedAt.UTC().Format(constants.TimeFormat),
+	}
+}
@@ -0,0 +1,124 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	chi "aggregator-service/app/src/api/chi"
+	"aggregator-service/app/src/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubDeadLetterQueue struct {
+	entries       []domain.DeadLetter
+	report        domain.RedriveReport
+	err           error
+	limits        []int
+	redriveLimits []int
+}
+
+func (s *stubDeadLetterQueue) DeadLetters(ctx context.Context, limit int) ([]domain.DeadLetter, error) {
+	s.limits = append(s.limits, limit)
+	return s.entries, s.err
+}
+
+func (s *stubDeadLetterQueue) DeadLetterDepth(ctx context.Context) (int, error) {
+	return len(s.entries), s.err
+}
+
+func (s *stubDeadLetterQueue) Redrive(ctx context.Context, limit int) (domain.RedriveReport, error) {
+	s.redriveLimits = append(s.redriveLimits, limit)
+	return s.report, s.err
+}
+
+func newDeadLetterRouter(queue domain.DeadLetterQueue) *chi.Mux {
+	router := chi.NewRouter()
+	registerRoutes(router, &handler{service: &stubAggregatorService{}, deadLetters: queue, adminToken: testAdminToken})
+	return router
+}
+
+func TestAdminDeadLettersRequireTokenAndQueue(t *testing.T) {
+	t.Log("Шаг 1: без токена список DLQ недоступен")
+	rr := httptest.NewRecorder()
+	newDeadLetterRouter(&stubDeadLetterQueue{}).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/dlq", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	t.Log("Шаг 2: без очереди эндпоинты отвечают 503")
+	rr = httptest.NewRecorder()
+	newDeadLetterRouter(nil).ServeHTTP(rr, newAdminRequest(http.MethodPost, "/admin/dlq/redrive", ""))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	t.Log("Шаг 3: выключенная очередь в хранилище тоже отвечает 503")
+	rr = httptest.NewRecorder()
+	newDeadLetterRouter(&stubDeadLetterQueue{err: domain.ErrDeadLettersDisabled}).ServeHTTP(rr, newAdminRequest(http.MethodGet, "/admin/dlq", ""))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Contains(t, rr.Body.String(), "dead-letter queue is disabled")
+}
+
+func TestAdminListDeadLetters(t *testing.T) {
+	failedAt := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	queue := &stubDeadLetterQueue{entries: []domain.DeadLetter{{
+		ID:            "entry-1",
+		PacketMax:     domain.PacketMax{PacketID: "p", SourceID: "s", Value: 7.5, Timestamp: failedAt.Add(-time.Second), Rank: 1},
+		Reason:        "connection refused",
+		Attempts:      3,
+		FirstFailedAt: failedAt,
+		LastFailedAt:  failedAt.Add(time.Minute),
+	}}}
+	router := newDeadLetterRouter(queue)
+
+	t.Log("Шаг 1: запрашиваем список с лимитом")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, newAdminRequest(http.MethodGet, "/admin/dlq?limit=5", ""))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var payload deadLetterListResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &payload))
+	assert.Equal(t, 1, payload.Depth)
+	require.Len(t, payload.Entries, 1)
+	assert.Equal(t, "entry-1", payload.Entries[0].ID)
+	assert.Equal(t, "connection refused", payload.Entries[0].Reason)
+	assert.Equal(t, 3, payload.Entries[0].Attempts)
+	assert.Equal(t, "2024-05-06T07:09:09Z", payload.Entries[0].LastFailedAt)
+	assert.Equal(t, []int{5}, queue.limits)
+
+	t.Log("Шаг 2: без лимита применяется значение по умолчанию, неверный лимит отклоняется")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, newAdminRequest(http.MethodGet, "/admin/dlq", ""))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []int{5, defaultDLQListLimit}, queue.limits)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, newAdminRequest(http.MethodGet, "/admin/dlq?limit=0", ""))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestAdminRedriveDeadLetters(t *testing.T) {
+	queue := &stubDeadLetterQueue{
+		entries: []domain.DeadLetter{{ID: "left"}},
+		report:  domain.RedriveReport{Attempted: 3, Succeeded: 2, Failed: 1},
+	}
+	router := newDeadLetterRouter(queue)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, newAdminRequest(http.MethodPost, "/admin/dlq/redrive", ""))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var payload redriveResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &payload))
+	assert.Equal(t, redriveResponse{Attempted: 3, Succeeded: 2, Failed: 1, Depth: 1}, payload)
+	assert.Equal(t, []int{0}, queue.redriveLimits)
+
+	t.Log("ошибка хранилища превращается в 500")
+	queue.err = errors.New("disk full")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, newAdminRequest(http.MethodPost, "/admin/dlq/redrive?limit=10", ""))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, []int{0, 10}, queue.redriveLimits)
+}
@@ -0,0 +1,67 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"aggregator-service/app/src/domain"
+)
+
+const (
+	contentTypeNDJSON = "application/x-ndjson"
+	// exportFlushRows is how many rows are written between flushes of an export response.
+	exportFlushRows = 256
+)
+
+// handleExportMax writes every packet maximum within from..to as newline-delimited JSON. Rows are
+// encoded as they are read from the repository and the response is sent chunked, so memory does
+// not depend on the size of the range. A client that disconnects cancels the request context,
+// which closes the repository cursor. Failures after the first row cannot change the status
+// code any more; they end the stream with an errorResponse line instead.
+func (h *handler) handleExportMax(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	from, to, err := parseTimeRange(params.Get(queryFrom), params.Get(queryTo))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Exports of long ranges outlive the server-wide WriteTimeout, so lift it for this response only.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	started := false
+	rows := 0
+	start := func() {
+		w.Header().Set("Content-Type", contentTypeNDJSON)
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		started = true
+	}
+
+	err = h.service.StreamMaxInRange(r.Context(), from, to, func(result domain.AggregatorResult) error {
+		if !started {
+			start()
+		}
+		if err := encoder.Encode(toHTTPResponse(result)); err != nil {
+			return err
+		}
+		rows++
+		if flusher != nil && rows%exportFlushRows == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+
+	switch {
+	case err == nil && !started:
+		start()
+	case err != nil && !started:
+		h.respondServiceError(w, err)
+	case err != nil && r.Context().Err() == nil:
+		h.logf(r, "max export: failed after %d rows: %v", rows, err)
+		_ = encoder.Encode(errorResponse{Error: "export interrupted", Code: http.StatusInternalServerError})
+	}
+}
@@ -0,0 +1,95 @@
+package httpapi
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/shared/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportQuery(from, to time.Time) string {
+	return "/max/export?from=" + from.Format(constants.TimeFormat) + "&to=" + to.Format(constants.TimeFormat)
+}
+
+func decodeNDJSON(t *testing.T, rr *httptest.ResponseRecorder) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	scanner := bufio.NewScanner(rr.Body)
+	for scanner.Scan() {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.NoError(t, scanner.Err())
+	return lines
+}
+
+func TestHandleExportMaxWritesNDJSON(t *testing.T) {
+	t.Log("Шаг 1: сервис отдаёт больше строк, чем помещается между сбросами буфера")
+	now := time.Now().UTC().Truncate(time.Second)
+	results := make([]domain.AggregatorResult, exportFlushRows+1)
+	for i := range results {
+		results[i] = domain.AggregatorResult{PacketID: constants.GenerateUUID(), SourceID: "source", Value: float64(i), Timestamp: now}
+	}
+	service := &stubAggregatorService{maxInRangeResult: results}
+	h := &handler{service: service}
+
+	rr := httptest.NewRecorder()
+	h.handleExportMax(rr, httptest.NewRequest(http.MethodGet, exportQuery(now.Add(-time.Hour), now), nil))
+
+	t.Log("Шаг 2: каждая строка ответа — отдельный JSON-объект")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, contentTypeNDJSON, rr.Header().Get("Content-Type"))
+	assert.True(t, rr.Flushed)
+	lines := decodeNDJSON(t, rr)
+	require.Len(t, lines, len(results))
+	assert.Equal(t, results[0].PacketID, lines[0]["packet_id"])
+	assert.Equal(t, float64(exportFlushRows), lines[exportFlushRows]["value"])
+}
+
+func TestHandleExportMaxEmptyRange(t *testing.T) {
+	now := time.Now().UTC()
+	h := &handler{service: &stubAggregatorService{}}
+
+	rr := httptest.NewRecorder()
+	h.handleExportMax(rr, httptest.NewRequest(http.MethodGet, exportQuery(now.Add(-time.Hour), now), nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, contentTypeNDJSON, rr.Header().Get("Content-Type"))
+	assert.Empty(t, rr.Body.String())
+}
+
+func TestHandleExportMaxErrors(t *testing.T) {
+	now := time.Now().UTC()
+
+	t.Log("Шаг 1: некорректный диапазон отклоняется")
+	h := &handler{service: &stubAggregatorService{}}
+	rr := httptest.NewRecorder()
+	h.handleExportMax(rr, httptest.NewRequest(http.MethodGet, "/max/export?from=invalid&to=invalid", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	t.Log("Шаг 2: ошибка до первой строки возвращается статусом")
+	h = &handler{service: &stubAggregatorService{maxInRangeErr: errors.New("boom")}}
+	rr = httptest.NewRecorder()
+	h.handleExportMax(rr, httptest.NewRequest(http.MethodGet, exportQuery(now.Add(-time.Hour), now), nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	t.Log("Шаг 3: ошибка после первой строки завершает поток строкой с ошибкой")
+	result := domain.AggregatorResult{PacketID: constants.GenerateUUID(), Timestamp: now}
+	h = &handler{service: &stubAggregatorService{maxInRangeResult: []domain.AggregatorResult{result}, exportErr: errors.New("connection reset")}}
+	rr = httptest.NewRecorder()
+	h.handleExportMax(rr, httptest.NewRequest(http.MethodGet, exportQuery(now.Add(-time.Hour), now), nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	lines := decodeNDJSON(t, rr)
+	require.Len(t, lines, 2)
+	assert.Equal(t, result.PacketID, lines[0]["packet_id"])
+	assert.Equal(t, "export interrupted", lines[1]["error"])
+}
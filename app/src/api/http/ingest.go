@@ -0,0 +1,141 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/shared/constants"
+)
+
+const maxIngestBodyBytes = 4 << 20
+
+type ingestMeasurement struct {
+	SourceID  string  `json:"source_id"`
+	Value     float64 `json:"value"`
+	Timestamp string  `json:"timestamp"`
+}
+
+type ingestPacket struct {
+	ID           string              `json:"id"`
+	Measurements []ingestMeasurement `json:"measurements"`
+}
+
+type ingestResponse struct {
+	Accepted int `json:"accepted"`
+}
+
+// handleIngestPackets accepts a single packet object or an array of packets and queues them
+// for the worker pool. A full queue is reported as 429, a closed or disabled one as 503.
+func (h *handler) handleIngestPackets(w http.ResponseWriter, r *http.Request) {
+	if h.ingestor == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "packet ingestion is disabled")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIngestBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.writeError(w, http.StatusRequestEntityTooLarge, "request body is too large")
+			return
+		}
+		h.writeError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	requests, err := decodeIngestPackets(body)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	packets := make([]domain.DataPacket, len(requests))
+	now := time.Now().UTC()
+	for i, req := range requests {
+		packet, err := toDataPacket(req, now)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("packet %d: %v", i, err))
+			return
+		}
+		packets[i] = packet
+	}
+
+	accepted, err := h.ingestor.Ingest(r.Context(), packets)
+	switch {
+	case err == nil:
+		h.writeJSON(w, http.StatusAccepted, ingestResponse{Accepted: accepted})
+	case errors.Is(err, domain.ErrBackpressure):
+		w.Header().Set("Retry-After", "1")
+		h.writeError(w, http.StatusTooManyRequests, fmt.Sprintf("packet queue is full: accepted %d of %d packets", accepted, len(packets)))
+	case errors.Is(err, domain.ErrIngestClosed):
+		h.writeError(w, http.StatusServiceUnavailable, "packet ingestion is closed")
+	default:
+		h.writeError(w, http.StatusServiceUnavailable, fmt.Sprintf("packet ingestion interrupted: accepted %d of %d packets", accepted, len(packets)))
+	}
+}
+
+func decodeIngestPackets(body []byte) ([]ingestPacket, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, errors.New("request body is empty")
+	}
+
+	var packets []ingestPacket
+	if trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &packets); err != nil {
+			return nil, errors.New("invalid JSON payload")
+		}
+	} else {
+		var packet ingestPacket
+		if err := json.Unmarshal(trimmed, &packet); err != nil {
+			return nil, errors.New("invalid JSON payload")
+		}
+		packets = append(packets, packet)
+	}
+
+	if len(packets) == 0 {
+		return nil, errors.New("at least one packet is required")
+	}
+	return packets, nil
+}
+
+func toDataPacket(req ingestPacket, now time.Time) (domain.DataPacket, error) {
+	id, err := constants.ParseUUID(req.ID)
+	if err != nil {
+		return domain.DataPacket{}, errors.New("invalid id format")
+	}
+	if len(req.Measurements) == 0 {
+		return domain.DataPacket{}, errors.New("measurements must not be empty")
+	}
+
+	measurements := make([]domain.Measurement, len(req.Measurements))
+	for i, m := range req.Measurements {
+		sourceID, err := constants.ParseUUID(m.SourceID)
+		if err != nil {
+			return domain.DataPacket{}, fmt.Errorf("measurement %d: invalid source_id format", i)
+		}
+
+		timestamp := now
+		if m.Timestamp != "" {
+			timestamp, err = time.Parse(constants.TimeFormat, m.Timestamp)
+			if err != nil {
+				return domain.DataPacket{}, fmt.Errorf("measurement %d: invalid timestamp", i)
+			}
+		}
+
+		measurements[i] = domain.Measurement{
+			PacketID:  id,
+			SourceID:  sourceID,
+			Value:     m.Value,
+			Timestamp: timestamp.UTC(),
+		}
+	}
+
+	return domain.DataPacket{ID: id, Measurements: measurements}, nil
+}
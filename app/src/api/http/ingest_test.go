@@ -0,0 +1,136 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	chi "aggregator-service/app/src/api/chi"
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/shared/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubIngestor struct {
+	accepted int
+	err      error
+	packets  []domain.DataPacket
+}
+
+func (s *stubIngestor) Ingest(ctx context.Context, packets []domain.DataPacket) (int, error) {
+	s.packets = append(s.packets, packets...)
+	if s.err != nil {
+		return s.accepted, s.err
+	}
+	return len(packets), nil
+}
+
+func (s *stubIngestor) Enqueue(ctx context.Context, packet domain.DataPacket) error {
+	s.packets = append(s.packets, packet)
+	return s.err
+}
+
+func newIngestRequest(body string) *http.Request {
+	return httptest.NewRequest(http.MethodPost, "/packets", strings.NewReader(body))
+}
+
+func TestHandleIngestPacketsSinglePacket(t *testing.T) {
+	t.Log("Шаг 1: отправляем один пакет через роутер")
+	ingestor := &stubIngestor{}
+	router := chi.NewRouter()
+	registerRoutes(router, &handler{service: &stubAggregatorService{}, ingestor: ingestor})
+
+	id := constants.GenerateUUID()
+	source := constants.GenerateUUID()
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	body := `{"id":"` + strings.ToUpper(id) + `","measurements":[{"source_id":"` + source + `","value":1.5,"timestamp":"` + ts.Format(constants.TimeFormat) + `"}]}`
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, newIngestRequest(body))
+
+	t.Log("Шаг 2: проверяем ответ и переданный пакет")
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	var payload ingestResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &payload))
+	assert.Equal(t, 1, payload.Accepted)
+
+	require.Len(t, ingestor.packets, 1)
+	assert.Equal(t, id, ingestor.packets[0].ID)
+	assert.Equal(t, domain.Measurement{PacketID: id, SourceID: source, Value: 1.5, Timestamp: ts}, ingestor.packets[0].Measurements[0])
+}
+
+func TestHandleIngestPacketsBatchDefaultsTimestamp(t *testing.T) {
+	t.Log("Шаг 1: отправляем пакет-массив без временных меток")
+	ingestor := &stubIngestor{}
+	h := &handler{ingestor: ingestor}
+	body := `[{"id":"` + constants.GenerateUUID() + `","measurements":[{"source_id":"` + constants.GenerateUUID() + `","value":1}]},` +
+		`{"id":"` + constants.GenerateUUID() + `","measurements":[{"source_id":"` + constants.GenerateUUID() + `","value":2}]}]`
+
+	rr := httptest.NewRecorder()
+	h.handleIngestPackets(rr, newIngestRequest(body))
+
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	require.Len(t, ingestor.packets, 2)
+	assert.False(t, ingestor.packets[1].Measurements[0].Timestamp.IsZero())
+}
+
+func TestHandleIngestPacketsValidation(t *testing.T) {
+	cases := map[string]string{
+		"empty body":     "",
+		"invalid json":   "{",
+		"empty batch":    "[]",
+		"invalid id":     `{"id":"bad","measurements":[{"source_id":"` + constants.GenerateUUID() + `"}]}`,
+		"no measurement": `{"id":"` + constants.GenerateUUID() + `","measurements":[]}`,
+		"invalid source": `{"id":"` + constants.GenerateUUID() + `","measurements":[{"source_id":"bad"}]}`,
+		"invalid ts":     `{"id":"` + constants.GenerateUUID() + `","measurements":[{"source_id":"` + constants.GenerateUUID() + `","timestamp":"yesterday"}]}`,
+	}
+
+	for name, body := range cases {
+		t.Log("проверяем случай:", name)
+		ingestor := &stubIngestor{}
+		rr := httptest.NewRecorder()
+		(&handler{ingestor: ingestor}).handleIngestPackets(rr, newIngestRequest(body))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, name)
+		assert.Empty(t, ingestor.packets, name)
+	}
+}
+
+func TestHandleIngestPacketsBackpressure(t *testing.T) {
+	t.Log("Шаг 1: очередь переполнена после первого пакета")
+	ingestor := &stubIngestor{accepted: 1, err: domain.ErrBackpressure}
+	body := `[{"id":"` + constants.GenerateUUID() + `","measurements":[{"source_id":"` + constants.GenerateUUID() + `"}]},` +
+		`{"id":"` + constants.GenerateUUID() + `","measurements":[{"source_id":"` + constants.GenerateUUID() + `"}]}]`
+
+	rr := httptest.NewRecorder()
+	(&handler{ingestor: ingestor}).handleIngestPackets(rr, newIngestRequest(body))
+
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+	assert.Contains(t, rr.Body.String(), "accepted 1 of 2")
+}
+
+func TestHandleIngestPacketsUnavailable(t *testing.T) {
+	body := `{"id":"` + constants.GenerateUUID() + `","measurements":[{"source_id":"` + constants.GenerateUUID() + `"}]}`
+
+	t.Log("Шаг 1: приём отключён")
+	rr := httptest.NewRecorder()
+	(&handler{}).handleIngestPackets(rr, newIngestRequest(body))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	t.Log("Шаг 2: очередь закрыта при остановке сервиса")
+	rr = httptest.NewRecorder()
+	(&handler{ingestor: &stubIngestor{err: domain.ErrIngestClosed}}).handleIngestPackets(rr, newIngestRequest(body))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
+
+func TestHandleIngestPacketsRejectsLargeBody(t *testing.T) {
+	rr := httptest.NewRecorder()
+	body := `{"id":"` + strings.Repeat(" ", maxIngestBodyBytes) + `"}`
+	(&handler{ingestor: &stubIngestor{}}).handleIngestPackets(rr, newIngestRequest(body))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+}
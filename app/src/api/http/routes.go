@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	chi "aggregator-service/app/src/api/chi"
@@ -16,12 +18,22 @@ const (
 	queryPacketID = "packet_id"
 	queryFrom     = "from"
 	queryTo       = "to"
+	queryFunction = "function"
+	queryK        = "k"
+	queryCursor   = "cursor"
 )
 
 // handler contains the HTTP handlers and shared dependencies for the REST API.
 type handler struct {
-	service domain.AggregatorService
-	logger  *infra.Logger
+	service   domain.AggregatorService
+	ingestor  domain.PacketIngestor
+	watcher   domain.PacketMaxSubscriber
+	heartbeat time.Duration
+	// generator, deadLetters and adminToken back the /admin endpoints; an empty token disables them.
+	generator   domain.GeneratorController
+	deadLetters domain.DeadLetterQueue
+	adminToken  string
+	logger      *infra.Logger
 }
 
 func registerRoutes(router *chi.Mux, h *handler) {
@@ -39,6 +51,17 @@ func registerRoutes(router *chi.Mux, h *handler) {
 		_, _ = w.Write([]byte(`{"status":"ok"}`))
 	})
 	router.Get("/max", h.handleGetMax)
+	router.Get("/max/top", h.handleGetTopMax)
+	router.Get("/max/stream", h.handleMaxStream)
+	router.Get("/max/export", h.handleExportMax)
+	router.Get("/aggregate", h.handleGetAggregate)
+	router.Post("/packets", h.handleIngestPackets)
+	router.Get("/admin/generator", h.requireAdmin(h.requireGenerator(h.handleGetGenerator)))
+	router.Patch("/admin/generator", h.requireAdmin(h.requireGenerator(h.handleUpdateGenerator)))
+	router.Post("/admin/generator/pause", h.requireAdmin(h.requireGenerator(h.handlePauseGenerator)))
+	router.Post("/admin/generator/resume", h.requireAdmin(h.requireGenerator(h.handleResumeGenerator)))
+	router.Get("/admin/dlq", h.requireAdmin(h.requireDeadLetters(h.handleListDeadLetters)))
+	router.Post("/admin/dlq/redrive", h.requireAdmin(h.requireDeadLetters(h.handleRedriveDeadLetters)))
 }
 
 type maxResponse struct {
@@ -48,6 +71,32 @@ type maxResponse struct {
 	Timestamp string  `json:"timestamp"`
 }
 
+type maxPageResponse struct {
+	Results    []maxResponse `json:"results"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+type aggregatePageResponse struct {
+	Results    []aggregateResponse `json:"results"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+type topResponse struct {
+	PacketID  string  `json:"packet_id"`
+	SourceID  string  `json:"source_id"`
+	Value     float64 `json:"value"`
+	Timestamp string  `json:"timestamp"`
+	Rank      int     `json:"rank"`
+}
+
+type aggregateResponse struct {
+	PacketID  string  `json:"packet_id"`
+	Function  string  `json:"function"`
+	SourceID  string  `json:"source_id,omitempty"`
+	Value     float64 `json:"value"`
+	Timestamp string  `json:"timestamp"`
+}
+
 type errorResponse struct {
 	Error string `json:"error"`
 	Code  int    `json:"code"`
@@ -90,46 +139,186 @@ func (h *handler) handleMaxByID(w http.ResponseWriter, r *http.Request, idParam
 }
 
 func (h *handler) handleMaxByRange(w http.ResponseWriter, r *http.Request, fromParam, toParam string) {
-	if fromParam == "" || toParam == "" {
-		h.writeError(w, http.StatusBadRequest, "both from and to parameters are required")
+	from, to, err := parseTimeRange(fromParam, toParam)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	from, err := time.Parse(constants.TimeFormat, fromParam)
-	if err != nil {
-		h.writeError(w, http.StatusBadRequest, "invalid from timestamp")
+	limit, ok := h.parseLimit(w, r, 0)
+	if !ok {
 		return
 	}
 
-	to, err := time.Parse(constants.TimeFormat, toParam)
+	page := domain.PageRequest{Limit: limit, Cursor: r.URL.Query().Get(queryCursor)}
+	results, err := h.service.MaxInRange(r.Context(), from, to, page)
 	if err != nil {
-		h.writeError(w, http.StatusBadRequest, "invalid to timestamp")
+		h.respondServiceError(w, err)
 		return
 	}
 
-	if from.After(to) {
-		h.writeError(w, http.StatusBadRequest, "from must be before to")
+	payload := maxPageResponse{Results: make([]maxResponse, len(results.Results)), NextCursor: results.NextCursor}
+	for i, result := range results.Results {
+		payload.Results[i] = toHTTPResponse(result)
+	}
+
+	setNextLink(w, r, results.NextCursor)
+	h.writeJSON(w, http.StatusOK, payload)
+}
+
+func (h *handler) handleGetTopMax(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	idParam := params.Get(queryPacketID)
+	if idParam == "" {
+		h.writeError(w, http.StatusBadRequest, "packet_id parameter is required")
+		return
+	}
+
+	id, err := constants.ParseUUID(idParam)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid packet_id format")
 		return
 	}
 
-	results, err := h.service.MaxInRange(r.Context(), from, to)
+	k := 0
+	if kParam := params.Get(queryK); kParam != "" {
+		k, err = strconv.Atoi(kParam)
+		if err != nil || k <= 0 {
+			h.writeError(w, http.StatusBadRequest, "k must be a positive integer")
+			return
+		}
+	}
+
+	results, err := h.service.TopByPacketID(r.Context(), id, k)
 	if err != nil {
 		h.respondServiceError(w, err)
 		return
 	}
 
-	payload := make([]maxResponse, len(results))
+	payload := make([]topResponse, len(results))
 	for i, result := range results {
-		payload[i] = toHTTPResponse(result)
+		payload[i] = topResponse{
+			PacketID:  result.PacketID,
+			SourceID:  result.SourceID,
+			Value:     result.Value,
+			Timestamp: result.Timestamp.UTC().Format(constants.TimeFormat),
+			Rank:      result.Rank,
+		}
 	}
 
 	h.writeJSON(w, http.StatusOK, payload)
 }
 
+func (h *handler) handleGetAggregate(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	function := params.Get(queryFunction)
+	idParam := params.Get(queryPacketID)
+	fromParam := params.Get(queryFrom)
+	toParam := params.Get(queryTo)
+
+	switch {
+	case function == "":
+		h.writeError(w, http.StatusBadRequest, "function parameter is required")
+	case idParam != "" && (fromParam != "" || toParam != ""):
+		h.writeError(w, http.StatusBadRequest, "provide either packet_id or time range")
+	case idParam != "":
+		h.handleAggregateByID(w, r, function, idParam)
+	case fromParam != "" || toParam != "":
+		h.handleAggregateByRange(w, r, function, fromParam, toParam)
+	default:
+		h.writeError(w, http.StatusBadRequest, "missing required query parameters")
+	}
+}
+
+func (h *handler) handleAggregateByID(w http.ResponseWriter, r *http.Request, function, idParam string) {
+	id, err := constants.ParseUUID(idParam)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid packet_id format")
+		return
+	}
+
+	result, err := h.service.AggregateByPacketID(r.Context(), function, id)
+	if err != nil {
+		h.respondServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, toAggregateResponse(result))
+}
+
+func (h *handler) handleAggregateByRange(w http.ResponseWriter, r *http.Request, function, fromParam, toParam string) {
+	from, to, err := parseTimeRange(fromParam, toParam)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, ok := h.parseLimit(w, r, 0)
+	if !ok {
+		return
+	}
+
+	page := domain.PageRequest{Limit: limit, Cursor: r.URL.Query().Get(queryCursor)}
+	results, err := h.service.AggregateInRange(r.Context(), function, from, to, page)
+	if err != nil {
+		h.respondServiceError(w, err)
+		return
+	}
+
+	payload := aggregatePageResponse{Results: make([]aggregateResponse, len(results.Results)), NextCursor: results.NextCursor}
+	for i, result := range results.Results {
+		payload.Results[i] = toAggregateResponse(result)
+	}
+
+	setNextLink(w, r, results.NextCursor)
+	h.writeJSON(w, http.StatusOK, payload)
+}
+
+// setNextLink advertises the next page of a range query in a Link header (RFC 8288), so clients
+// that ignore next_cursor in the body can still tell that the result was cut at the page size.
+func setNextLink(w http.ResponseWriter, r *http.Request, cursor string) {
+	if cursor == "" {
+		return
+	}
+	params := r.URL.Query()
+	params.Set(queryCursor, cursor)
+	next := url.URL{Path: r.URL.Path, RawQuery: params.Encode()}
+	w.Header().Set("Link", "<"+next.String()+`>; rel="next"`)
+}
+
+// parseTimeRange parses the from and to query parameters. The error text is the message returned
+// to the client.
+func parseTimeRange(fromParam, toParam string) (time.Time, time.Time, error) {
+	if fromParam == "" || toParam == "" {
+		return time.Time{}, time.Time{}, errors.New("both from and to parameters are required")
+	}
+
+	from, err := time.Parse(constants.TimeFormat, fromParam)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid from timestamp")
+	}
+
+	to, err := time.Parse(constants.TimeFormat, toParam)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid to timestamp")
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+	return from, to, nil
+}
+
 func (h *handler) respondServiceError(w http.ResponseWriter, err error) {
 	switch {
 	case errors.Is(err, domain.ErrNotFound):
 		h.writeError(w, http.StatusNotFound, "measurement not found")
+	case errors.Is(err, domain.ErrUnknownAggregation):
+		h.writeError(w, http.StatusBadRequest, "unknown aggregation function")
+	case errors.Is(err, domain.ErrInvalidCursor):
+		h.writeError(w, http.StatusBadRequest, "invalid cursor")
 	default:
 		h.writeError(w, http.StatusInternalServerError, "internal server error")
 	}
@@ -153,3 +342,13 @@ func toHTTPResponse(result domain.AggregatorResult) maxResponse {
 		Timestamp: result.Timestamp.UTC().Format(constants.TimeFormat),
 	}
 }
+
+func toAggregateResponse(result domain.AggregatorResult) aggregateResponse {
+	return aggregateResponse{
+		PacketID:  result.PacketID,
+		Function:  result.Function,
+		SourceID:  result.SourceID,
+		Value:     result.Value,
+		Timestamp: result.Timestamp.UTC().Format(constants.TimeFormat),
+	}
+}
@@ -3,9 +3,11 @@ package httpapi
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -20,11 +22,22 @@ type stubAggregatorService struct {
 	maxByIDResult    domain.AggregatorResult
 	maxByIDErr       error
 	maxInRangeResult []domain.AggregatorResult
+	maxInRangeNext   string
 	maxInRangeErr    error
-
-	lastID   string
-	lastFrom time.Time
-	lastTo   time.Time
+	exportErr        error
+	aggregateResult  domain.AggregatorResult
+	aggregateResults []domain.AggregatorResult
+	aggregateNext    string
+	aggregateErr     error
+	topResults       []domain.AggregatorResult
+	topErr           error
+
+	lastK        int
+	lastID       string
+	lastFunction string
+	lastFrom     time.Time
+	lastTo       time.Time
+	lastPage     domain.PageRequest
 }
 
 func (s *stubAggregatorService) MaxByPacketID(ctx context.Context, packetID string) (domain.AggregatorResult, error) {
@@ -32,10 +45,45 @@ func (s *stubAggregatorService) MaxByPacketID(ctx context.Context, packetID stri
 	return s.maxByIDResult, s.maxByIDErr
 }
 
-func (s *stubAggregatorService) MaxInRange(ctx context.Context, from, to time.Time) ([]domain.AggregatorResult, error) {
+func (s *stubAggregatorService) MaxInRange(ctx context.Context, from, to time.Time, page domain.PageRequest) (domain.ResultPage, error) {
 	s.lastFrom = from
 	s.lastTo = to
-	return s.maxInRangeResult, s.maxInRangeErr
+	s.lastPage = page
+	return domain.ResultPage{Results: s.maxInRangeResult, NextCursor: s.maxInRangeNext}, s.maxInRangeErr
+}
+
+func (s *stubAggregatorService) StreamMaxInRange(ctx context.Context, from, to time.Time, emit func(domain.AggregatorResult) error) error {
+	s.lastFrom = from
+	s.lastTo = to
+	if s.maxInRangeErr != nil {
+		return s.maxInRangeErr
+	}
+	for _, result := range s.maxInRangeResult {
+		if err := emit(result); err != nil {
+			return err
+		}
+	}
+	return s.exportErr
+}
+
+func (s *stubAggregatorService) AggregateByPacketID(ctx context.Context, function, packetID string) (domain.AggregatorResult, error) {
+	s.lastFunction = function
+	s.lastID = packetID
+	return s.aggregateResult, s.aggregateErr
+}
+
+func (s *stubAggregatorService) AggregateInRange(ctx context.Context, function string, from, to time.Time, page domain.PageRequest) (domain.ResultPage, error) {
+	s.lastFunction = function
+	s.lastFrom = from
+	s.lastTo = to
+	s.lastPage = page
+	return domain.ResultPage{Results: s.aggregateResults, NextCursor: s.aggregateNext}, s.aggregateErr
+}
+
+func (s *stubAggregatorService) TopByPacketID(ctx context.Context, packetID string, k int) ([]domain.AggregatorResult, error) {
+	s.lastID = packetID
+	s.lastK = k
+	return s.topResults, s.topErr
 }
 
 func TestRegisterRoutesRegistersHealthEndpoints(t *testing.T) {
@@ -157,6 +205,48 @@ func TestHandleMaxByRangeSuccess(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rr.Code)
 	assert.True(t, service.lastFrom.Equal(from))
 	assert.True(t, service.lastTo.Equal(to))
+	assert.Equal(t, domain.PageRequest{}, service.lastPage)
+
+	var body maxPageResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+	assert.Equal(t, []maxResponse{toHTTPResponse(result)}, body.Results)
+	assert.Empty(t, body.NextCursor)
+	assert.Empty(t, rr.Header().Get("Link"))
+}
+
+func TestHandleMaxByRangePagination(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	result := domain.AggregatorResult{PacketID: constants.GenerateUUID(), SourceID: constants.GenerateUUID(), Value: 5, Timestamp: now}
+	service := &stubAggregatorService{maxInRangeResult: []domain.AggregatorResult{result}, maxInRangeNext: "next"}
+	h := &handler{service: service}
+	query := "/max?from=" + now.Add(-time.Hour).Format(constants.TimeFormat) + "&to=" + now.Format(constants.TimeFormat)
+
+	t.Log("Шаг 1: лимит и курсор передаются сервису, курсор следующей страницы попадает в ответ")
+	rr := httptest.NewRecorder()
+	h.handleGetMax(rr, httptest.NewRequest(http.MethodGet, query+"&limit=20&cursor=current", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, domain.PageRequest{Limit: 20, Cursor: "current"}, service.lastPage)
+	var body maxPageResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+	assert.Equal(t, "next", body.NextCursor)
+
+	t.Log("Шаг 2: обрезанный ответ без limit сообщает о следующей странице в заголовке Link")
+	rr = httptest.NewRecorder()
+	h.handleGetMax(rr, httptest.NewRequest(http.MethodGet, query, nil))
+	link := rr.Header().Get("Link")
+	assert.Contains(t, link, "/max?cursor=next&from=")
+	assert.True(t, strings.HasSuffix(link, `>; rel="next"`), link)
+
+	t.Log("Шаг 3: некорректный лимит отклоняется")
+	rr = httptest.NewRecorder()
+	h.handleGetMax(rr, httptest.NewRequest(http.MethodGet, query+"&limit=0", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	t.Log("Шаг 4: испорченный курсор возвращает 400")
+	service.maxInRangeErr = fmt.Errorf("repository: %w", domain.ErrInvalidCursor)
+	rr = httptest.NewRecorder()
+	h.handleGetMax(rr, httptest.NewRequest(http.MethodGet, query+"&cursor=broken", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
 }
 
 func TestHandleMaxByRangeServiceError(t *testing.T) {
@@ -216,3 +306,143 @@ func TestServerRouterAndServeHTTP(t *testing.T) {
 	server.ServeHTTP(rr, req)
 	assert.Equal(t, http.StatusOK, rr.Code)
 }
+
+func TestHandleGetAggregateRequiresFunction(t *testing.T) {
+	t.Log("Шаг 1: запрашиваем агрегат без имени функции")
+	h := &handler{service: &stubAggregatorService{}}
+
+	req := httptest.NewRequest(http.MethodGet, "/aggregate?packet_id="+constants.GenerateUUID(), nil)
+	rr := httptest.NewRecorder()
+
+	h.handleGetAggregate(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestHandleAggregateByIDSuccess(t *testing.T) {
+	t.Log("Шаг 1: готовим ответ сервиса для функции mean")
+	id := constants.GenerateUUID()
+	now := time.Now().UTC()
+	service := &stubAggregatorService{aggregateResult: domain.AggregatorResult{PacketID: id, Function: "mean", Value: 2.5, Timestamp: now}}
+	router := chi.NewRouter()
+	registerRoutes(router, &handler{service: service})
+
+	req := httptest.NewRequest(http.MethodGet, "/aggregate?function=mean&packet_id="+id, nil)
+	rr := httptest.NewRecorder()
+
+	t.Log("Шаг 2: выполняем запрос и проверяем тело ответа")
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "mean", service.lastFunction)
+	assert.Equal(t, id, service.lastID)
+
+	var payload aggregateResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &payload))
+	assert.Equal(t, "mean", payload.Function)
+	assert.Equal(t, 2.5, payload.Value)
+	assert.Empty(t, payload.SourceID)
+}
+
+func TestHandleAggregateByRangeSuccess(t *testing.T) {
+	t.Log("Шаг 1: готовим диапазон и ответ сервиса для функции sum")
+	now := time.Now().UTC().Truncate(time.Second)
+	from := now.Add(-time.Hour)
+	result := domain.AggregatorResult{PacketID: constants.GenerateUUID(), Function: "sum", Value: 10, Timestamp: now}
+	service := &stubAggregatorService{aggregateResults: []domain.AggregatorResult{result}}
+	h := &handler{service: service}
+
+	query := "/aggregate?function=sum&from=" + from.Format(constants.TimeFormat) + "&to=" + now.Format(constants.TimeFormat)
+	req := httptest.NewRequest(http.MethodGet, query, nil)
+	rr := httptest.NewRecorder()
+
+	t.Log("Шаг 2: выполняем запрос и проверяем переданные параметры")
+	h.handleGetAggregate(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "sum", service.lastFunction)
+	assert.True(t, service.lastFrom.Equal(from))
+	assert.True(t, service.lastTo.Equal(now))
+	assert.Equal(t, domain.PageRequest{}, service.lastPage)
+
+	var body aggregatePageResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+	assert.Equal(t, []aggregateResponse{toAggregateResponse(result)}, body.Results)
+	assert.Empty(t, body.NextCursor)
+
+	t.Log("Шаг 3: диапазон агрегатов тоже отдаётся постранично")
+	service.aggregateNext = "next"
+	rr = httptest.NewRecorder()
+	h.handleGetAggregate(rr, httptest.NewRequest(http.MethodGet, query+"&limit=5&cursor=current", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, domain.PageRequest{Limit: 5, Cursor: "current"}, service.lastPage)
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+	assert.Equal(t, "next", body.NextCursor)
+	assert.Contains(t, rr.Header().Get("Link"), "cursor=next")
+
+	rr = httptest.NewRecorder()
+	h.handleGetAggregate(rr, httptest.NewRequest(http.MethodGet, query+"&limit=-1", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestHandleAggregateUnknownFunction(t *testing.T) {
+	t.Log("Шаг 1: сервис сообщает о неизвестной функции")
+	service := &stubAggregatorService{aggregateErr: domain.ErrUnknownAggregation}
+	h := &handler{service: service}
+
+	req := httptest.NewRequest(http.MethodGet, "/aggregate?function=median&packet_id="+constants.GenerateUUID(), nil)
+	rr := httptest.NewRecorder()
+
+	t.Log("Шаг 2: ожидаем 400")
+	h.handleGetAggregate(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestHandleGetTopMaxValidation(t *testing.T) {
+	t.Log("Шаг 1: проверяем обязательность packet_id")
+	h := &handler{service: &stubAggregatorService{}}
+
+	rr := httptest.NewRecorder()
+	h.handleGetTopMax(rr, httptest.NewRequest(http.MethodGet, "/max/top", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	t.Log("Шаг 2: проверяем отказ при неположительном k")
+	rr = httptest.NewRecorder()
+	h.handleGetTopMax(rr, httptest.NewRequest(http.MethodGet, "/max/top?k=0&packet_id="+constants.GenerateUUID(), nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestHandleGetTopMaxSuccess(t *testing.T) {
+	t.Log("Шаг 1: готовим два ранжированных результата")
+	id := constants.GenerateUUID()
+	now := time.Now().UTC()
+	service := &stubAggregatorService{topResults: []domain.AggregatorResult{
+		{PacketID: id, SourceID: "s1", Value: 9, Timestamp: now, Rank: 1},
+		{PacketID: id, SourceID: "s2", Value: 7, Timestamp: now, Rank: 2},
+	}}
+	router := chi.NewRouter()
+	registerRoutes(router, &handler{service: service})
+
+	t.Log("Шаг 2: выполняем запрос и проверяем порядок рангов")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/max/top?k=2&packet_id="+id, nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 2, service.lastK)
+
+	var payload []topResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &payload))
+	assert.Len(t, payload, 2)
+	assert.Equal(t, "s2", payload[1].SourceID)
+	assert.Equal(t, 2, payload[1].Rank)
+}
+
+func TestHandleGetTopMaxNotFound(t *testing.T) {
+	t.Log("Шаг 1: сервис не находит пакет")
+	service := &stubAggregatorService{topErr: domain.ErrNotFound}
+	h := &handler{service: service, logger: infra.NewLogger(io.Discard, "test")}
+
+	rr := httptest.NewRecorder()
+	h.handleGetTopMax(rr, httptest.NewRequest(http.MethodGet, "/max/top?packet_id="+constants.GenerateUUID(), nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, 0, service.lastK)
+}
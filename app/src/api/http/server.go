@@ -11,6 +11,7 @@ import (
 // Server exposes the HTTP transport for the aggregator application.
 type Server struct {
 	handler http.Handler
+	api     *handler
 }
 
 // NewServer constructs an HTTP server that forwards requests to the application service.
@@ -28,7 +29,35 @@ func NewServer(service domain.AggregatorService, logger *infra.Logger) *Server {
 		return r.URL.Path
 	}))
 
-	return &Server{handler: router}
+	return &Server{handler: router, api: handler}
+}
+
+// WithWatcher enables GET /max/stream backed by subscriber.
+func (s *Server) WithWatcher(subscriber domain.PacketMaxSubscriber) *Server {
+	s.api.watcher = subscriber
+	return s
+}
+
+// WithIngestor enables POST /packets and queues accepted packets through ingestor.
+func (s *Server) WithIngestor(ingestor domain.PacketIngestor) *Server {
+	s.api.ingestor = ingestor
+	return s
+}
+
+// WithGeneratorAdmin enables the /admin/generator endpoints. Requests must carry
+// "Authorization: Bearer <token>"; an empty token keeps the endpoints disabled.
+func (s *Server) WithGeneratorAdmin(controller domain.GeneratorController, token string) *Server {
+	s.api.generator = controller
+	s.api.adminToken = token
+	return s
+}
+
+// WithDeadLetterAdmin enables GET /admin/dlq and POST /admin/dlq/redrive, guarded by the same
+// bearer token as the generator endpoints.
+func (s *Server) WithDeadLetterAdmin(queue domain.DeadLetterQueue, token string) *Server {
+	s.api.deadLetters = queue
+	s.api.adminToken = token
+	return s
 }
 
 // Router returns the configured HTTP handler for reuse in tests or external HTTP servers.
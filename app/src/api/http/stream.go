@@ -0,0 +1,230 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/shared/constants"
+)
+
+const (
+	defaultHeartbeatInterval = 15 * time.Second
+	headerLastEventID        = "Last-Event-ID"
+	queryMinValue            = "min_value"
+	querySince               = "since"
+)
+
+// handleMaxStream serves newly persisted packet maxima as Server-Sent Events. Every event id
+// encodes the measurement timestamp and packet id, so a reconnecting client that sends
+// Last-Event-ID is first served the maxima stored after that event.
+func (h *handler) handleMaxStream(w http.ResponseWriter, r *http.Request) {
+	if h.watcher == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "maxima stream is disabled")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	filter, cursor, err := parseStreamParams(r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The server-wide WriteTimeout would cut the stream off, so lift it for this response only.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	ctx := r.Context()
+	sub := h.watcher.Subscribe(filter)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	replayed, err := h.replayStream(w, r, filter, cursor)
+	if err != nil {
+		h.logf(r, "max stream: replay failed: %v", err)
+		writeSSEError(w, "failed to replay stored maxima")
+		flusher.Flush()
+		return
+	}
+	flusher.Flush()
+
+	heartbeat := h.heartbeat
+	if heartbeat <= 0 {
+		heartbeat = defaultHeartbeatInterval
+	}
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case packetMax, ok := <-sub.Updates():
+			if !ok {
+				if errors.Is(sub.Err(), domain.ErrSlowConsumer) {
+					writeSSEError(w, "subscriber evicted: too slow to consume updates")
+					flusher.Flush()
+				}
+				return
+			}
+			if _, seen := replayed[packetMax.PacketID]; seen {
+				delete(replayed, packetMax.PacketID)
+				continue
+			}
+			if err := writeSSEMax(w, packetMax); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// streamCursor identifies the last event a client has seen.
+type streamCursor struct {
+	timestamp time.Time
+	packetID  string
+}
+
+func parseStreamParams(r *http.Request) (domain.MaxFilter, streamCursor, error) {
+	params := r.URL.Query()
+	var filter domain.MaxFilter
+	var cursor streamCursor
+
+	if raw := params.Get(queryMinValue); raw != "" {
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return filter, cursor, errors.New("invalid min_value")
+		}
+		filter.MinValue = value
+		filter.HasMinValue = true
+	}
+
+	if raw := params.Get(querySince); raw != "" {
+		since, err := time.Parse(constants.TimeFormat, raw)
+		if err != nil {
+			return filter, cursor, errors.New("invalid since timestamp")
+		}
+		cursor.timestamp = since.UTC()
+	}
+
+	if raw := r.Header.Get(headerLastEventID); raw != "" {
+		parsed, err := parseEventID(raw)
+		if err != nil {
+			return filter, cursor, errors.New("invalid Last-Event-ID")
+		}
+		cursor = parsed
+	}
+
+	filter.Since = cursor.timestamp
+	return filter, cursor, nil
+}
+
+// replayStream writes stored maxima after cursor, one page at a time, and returns the packet ids
+// it sent.
+func (h *handler) replayStream(w http.ResponseWriter, r *http.Request, filter domain.MaxFilter, cursor streamCursor) (map[string]struct{}, error) {
+	replayed := make(map[string]struct{})
+	if cursor.timestamp.IsZero() {
+		return replayed, nil
+	}
+
+	until := time.Now().UTC()
+	var page domain.PageRequest
+	for {
+		results, err := h.service.MaxInRange(r.Context(), cursor.timestamp, until, page)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return replayed, nil
+			}
+			return nil, err
+		}
+
+		for _, result := range results.Results {
+			packetMax := domain.PacketMax{
+				PacketID:  result.PacketID,
+				SourceID:  result.SourceID,
+				Value:     result.Value,
+				Timestamp: result.Timestamp,
+			}
+			if packetMax.PacketID == cursor.packetID || !filter.Match(packetMax) {
+				continue
+			}
+			if err := writeSSEMax(w, packetMax); err != nil {
+				return nil, err
+			}
+			replayed[packetMax.PacketID] = struct{}{}
+		}
+		if results.NextCursor == "" {
+			return replayed, nil
+		}
+		page.Cursor = results.NextCursor
+	}
+}
+
+func writeSSEMax(w http.ResponseWriter, packetMax domain.PacketMax) error {
+	payload, err := json.Marshal(toHTTPResponse(domain.AggregatorResult{
+		PacketID:  packetMax.PacketID,
+		SourceID:  packetMax.SourceID,
+		Value:     packetMax.Value,
+		Timestamp: packetMax.Timestamp,
+	}))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: max\ndata: %s\n\n", formatEventID(packetMax), payload)
+	return err
+}
+
+func writeSSEError(w http.ResponseWriter, message string) {
+	payload, _ := json.Marshal(errorResponse{Error: message})
+	_, _ = fmt.Fprintf(w, "event: error\ndata: %s\n\n", payload)
+}
+
+func formatEventID(packetMax domain.PacketMax) string {
+	return packetMax.Timestamp.UTC().Format(constants.TimeFormat) + "/" + packetMax.PacketID
+}
+
+func parseEventID(raw string) (streamCursor, error) {
+	tsPart, idPart, found := strings.Cut(raw, "/")
+	if !found {
+		return streamCursor{}, errors.New("missing packet id")
+	}
+
+	ts, err := time.Parse(constants.TimeFormat, tsPart)
+	if err != nil {
+		return streamCursor{}, err
+	}
+
+	id, err := constants.ParseUUID(idPart)
+	if err != nil {
+		return streamCursor{}, err
+	}
+
+	return streamCursor{timestamp: ts.UTC(), packetID: id}, nil
+}
+
+func (h *handler) logf(r *http.Request, format string, v ...any) {
+	if h.logger != nil {
+		h.logger.Printf(r.Context(), format, v...)
+	}
+}
@@ -0,0 +1,192 @@
+package httpapi
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"aggregator-service/app/src/core"
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/infra"
+	"aggregator-service/app/src/shared/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startStreamServer(t *testing.T, service domain.AggregatorService, hub *core.MaxHub, writeTimeout time.Duration) *httptest.Server {
+	t.Helper()
+	server := NewServer(service, infra.NewLogger(io.Discard, "test")).WithWatcher(hub)
+	server.api.heartbeat = 20 * time.Millisecond
+
+	ts := httptest.NewUnstartedServer(server)
+	ts.Config.WriteTimeout = writeTimeout
+	ts.Start()
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func openStream(t *testing.T, ctx context.Context, url string, lastEventID string) *bufio.Reader {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set(headerLastEventID, lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	return bufio.NewReader(resp.Body)
+}
+
+// readEvent returns the next event block, skipping heartbeat comments.
+func readEvent(t *testing.T, reader *bufio.Reader) string {
+	t.Helper()
+	var block strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		if line == "\n" {
+			if block.Len() > 0 {
+				return block.String()
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		block.WriteString(line)
+	}
+}
+
+func TestHandleMaxStreamDisabled(t *testing.T) {
+	t.Log("Шаг 1: без хаба поток недоступен")
+	rr := httptest.NewRecorder()
+	(&handler{}).handleMaxStream(rr, httptest.NewRequest(http.MethodGet, "/max/stream", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
+
+func TestHandleMaxStreamValidation(t *testing.T) {
+	h := &handler{watcher: core.NewMaxHub(1, nil)}
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/max/stream?min_value=abc", nil),
+		httptest.NewRequest(http.MethodGet, "/max/stream?since=yesterday", nil),
+	}
+	badID := httptest.NewRequest(http.MethodGet, "/max/stream", nil)
+	badID.Header.Set(headerLastEventID, "garbage")
+	requests = append(requests, badID)
+
+	for _, req := range requests {
+		t.Log("проверяем запрос:", req.URL.String(), req.Header.Get(headerLastEventID))
+		rr := httptest.NewRecorder()
+		h.handleMaxStream(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestHandleMaxStreamOutlivesWriteTimeout(t *testing.T) {
+	t.Log("Шаг 1: поднимаем сервер с коротким WriteTimeout и подписываемся")
+	hub := core.NewMaxHub(4, nil)
+	ts := startStreamServer(t, &stubAggregatorService{}, hub, 50*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	reader := openStream(t, ctx, ts.URL+"/max/stream?min_value=10", "")
+	require.Eventually(t, func() bool { return hub.Subscribers() == 1 }, time.Second, time.Millisecond)
+
+	t.Log("Шаг 2: ждём дольше WriteTimeout и получаем heartbeat")
+	time.Sleep(150 * time.Millisecond)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, ": heartbeat\n", line)
+
+	t.Log("Шаг 3: публикуем максимумы ниже и выше порога")
+	id := constants.GenerateUUID()
+	now := time.Now().UTC()
+	hub.Publish(domain.PacketMax{PacketID: constants.GenerateUUID(), SourceID: "s0", Value: 5, Timestamp: now})
+	hub.Publish(domain.PacketMax{PacketID: id, SourceID: "s1", Value: 42, Timestamp: now})
+
+	event := readEvent(t, reader)
+	assert.Contains(t, event, "id: "+now.Format(constants.TimeFormat)+"/"+id+"\n")
+	assert.Contains(t, event, "event: max\n")
+	assert.Contains(t, event, `"value":42`)
+}
+
+func TestHandleMaxStreamResumesFromLastEventID(t *testing.T) {
+	t.Log("Шаг 1: в базе есть последний увиденный и новый максимум")
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	seenID := constants.GenerateUUID()
+	newID := constants.GenerateUUID()
+	service := &stubAggregatorService{maxInRangeResult: []domain.AggregatorResult{
+		{PacketID: seenID, SourceID: "s1", Value: 1, Timestamp: now},
+		{PacketID: newID, SourceID: "s2", Value: 2, Timestamp: now.Add(time.Second)},
+	}}
+	hub := core.NewMaxHub(4, nil)
+	ts := startStreamServer(t, service, hub, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	lastEventID := formatEventID(domain.PacketMax{PacketID: seenID, Timestamp: now})
+	reader := openStream(t, ctx, ts.URL+"/max/stream", lastEventID)
+
+	t.Log("Шаг 2: досылается только новый максимум")
+	event := readEvent(t, reader)
+	assert.Contains(t, event, newID)
+	assert.True(t, service.lastFrom.Equal(now))
+
+	t.Log("Шаг 3: живое событие того же пакета не дублируется")
+	hub.Publish(domain.PacketMax{PacketID: newID, Value: 2, Timestamp: now.Add(time.Second)})
+	freshID := constants.GenerateUUID()
+	hub.Publish(domain.PacketMax{PacketID: freshID, Value: 3, Timestamp: now.Add(2 * time.Second)})
+	assert.Contains(t, readEvent(t, reader), freshID)
+
+	t.Log("Шаг 4: закрытие хаба завершает поток")
+	hub.Close()
+	_, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+}
+
+func TestHandleMaxStreamReportsEviction(t *testing.T) {
+	t.Log("Шаг 1: подписчик вытеснен до начала чтения")
+	hub := core.NewMaxHub(1, nil)
+	sub := hub.Subscribe(domain.MaxFilter{})
+	hub.Publish(domain.PacketMax{PacketID: "p1"})
+	hub.Publish(domain.PacketMax{PacketID: "p2"})
+	<-sub.Updates()
+
+	h := &handler{service: &stubAggregatorService{}, watcher: fixedWatcher{sub: sub}}
+	rr := httptest.NewRecorder()
+	h.handleMaxStream(rr, httptest.NewRequest(http.MethodGet, "/max/stream", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "event: error\n")
+	assert.Contains(t, rr.Body.String(), "too slow")
+}
+
+type fixedWatcher struct {
+	sub domain.MaxSubscription
+}
+
+func (w fixedWatcher) Subscribe(domain.MaxFilter) domain.MaxSubscription {
+	return w.sub
+}
+
+func TestEventIDRoundTrip(t *testing.T) {
+	now := time.Now().UTC()
+	id := constants.GenerateUUID()
+
+	cursor, err := parseEventID(formatEventID(domain.PacketMax{PacketID: id, Timestamp: now}))
+	require.NoError(t, err)
+	assert.True(t, cursor.timestamp.Equal(now))
+	assert.Equal(t, id, cursor.packetID)
+
+	_, err = parseEventID(now.Format(constants.TimeFormat) + "/bad")
+	assert.Error(t, err)
+}
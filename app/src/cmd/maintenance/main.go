@@ -0,0 +1,191 @@
+package main
+
+import (
+	_ "aggregator-service/app/src/infra/utils/autoload"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"aggregator-service/app/src/core"
+	"aggregator-service/app/src/database"
+	"aggregator-service/app/src/infra"
+)
+
+const usage = `usage: maintenance <command> [flags]
+
+commands:
+  purge        delete packet maxima and aggregates older than the retention window
+  partitions   create upcoming packet_max partitions and expire old ones
+
+exit codes: 0 success, 1 failure, 2 usage error
+`
+
+const (
+	exitOK     = 0
+	exitFailed = 1
+	exitUsage  = 2
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, newEnvironment(), os.Args[1:])
+	stop()
+	os.Exit(code)
+}
+
+// ----------------------------
+// Вспомогательные функции
+// ----------------------------
+
+// environment — зависимости команд. main собирает настоящие, тесты подставляют заглушку runner'а
+// и пропускают ожидание базы.
+type environment struct {
+	cfg       infra.Config
+	logger    *infra.Logger
+	stderr    io.Writer
+	newRunner func() database.CommandRunner
+	waitForDB func(ctx context.Context, cfg infra.Config, logger *infra.Logger) error
+}
+
+// newEnvironment загружает конфигурацию и логгер.
+func newEnvironment() environment {
+	return environment{
+		cfg:       infra.LoadConfig(),
+		logger:    infra.NewLogger(os.Stdout, "maintenance"),
+		stderr:    os.Stderr,
+		newRunner: func() database.CommandRunner { return database.NewSQLRunner() },
+		waitForDB: checkDatabaseConnection,
+	}
+}
+
+// run выполняет команду и возвращает код выхода: 0 — успех, 1 — сбой, 2 — ошибка в аргументах.
+func run(ctx context.Context, env environment, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(env.stderr, usage)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "purge":
+		return runPurge(ctx, env, args[1:])
+	case "partitions":
+		return runPartitions(ctx, env, args[1:])
+	default:
+		fmt.Fprintf(env.stderr, "unknown command %q\n%s", args[0], usage)
+		return exitUsage
+	}
+}
+
+// runPurge однократно удаляет данные старше окна хранения. Значения по умолчанию берутся из
+// RETENTION_DAYS, RETENTION_CHUNK_SIZE и RETENTION_DRY_RUN.
+func runPurge(ctx context.Context, env environment, args []string) int {
+	flags := newFlagSet("purge", env)
+	days := flags.Int("days", env.cfg.RetentionDays, "retention window in days")
+	chunkSize := flags.Int("chunk", env.cfg.RetentionChunkSize, "maximum rows deleted per statement")
+	dryRun := flags.Bool("dry-run", env.cfg.RetentionDryRun, "only count the rows that would be deleted")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	if *days <= 0 {
+		env.logger.Printf(ctx, "purge: retention window is not set, pass -days or RETENTION_DAYS")
+		return exitUsage
+	}
+
+	return withRunner(ctx, env, func(runner database.CommandRunner, dsn string) error {
+		job := core.NewRetentionJob(database.NewPurger(runner, dsn, *chunkSize), core.RetentionConfig{
+			MaxAge: time.Duration(*days) * 24 * time.Hour,
+			DryRun: *dryRun,
+		}, env.logger)
+		if _, err := job.RunOnce(ctx); err != nil {
+			return fmt.Errorf("purge: %w", err)
+		}
+		return nil
+	})
+}
+
+// runPartitions выполняет один проход обслуживания разделов packet_max с настройками PARTITION_*;
+// устаревшие разделы определяются по RETENTION_DAYS.
+func runPartitions(ctx context.Context, env environment, args []string) int {
+	flags := newFlagSet("partitions", env)
+	dryRun := flags.Bool("dry-run", env.cfg.RetentionDryRun, "only log the partitions that would expire")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	partitions, err := database.LoadPartitionConfig(env.cfg)
+	if err != nil {
+		env.logger.Printf(ctx, "partitions: %v", err)
+		return exitFailed
+	}
+	partitions.DryRun = *dryRun
+
+	return withRunner(ctx, env, func(runner database.CommandRunner, dsn string) error {
+		report, err := database.NewPartitionMaintainer(runner, dsn, partitions, env.logger).Maintain(ctx)
+		if err != nil {
+			return fmt.Errorf("partitions: %w", err)
+		}
+		env.logger.Printf(ctx, "partitions: created %d, expired %d, %d in total", len(report.Created), len(report.Expired), report.Partitions)
+		return nil
+	})
+}
+
+func newFlagSet(name string, env environment) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(env.stderr)
+	return flags
+}
+
+// parseFlags разбирает флаги команды; -h печатает справку и завершает команду успешно.
+func parseFlags(flags *flag.FlagSet, args []string) (int, bool) {
+	err := flags.Parse(args)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK, false
+	case err != nil:
+		return exitUsage, false
+	case flags.NArg() > 0:
+		fmt.Fprintf(flags.Output(), "%s: unexpected arguments %q\n", flags.Name(), flags.Args())
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+// withRunner дожидается базы, создаёт runner и переводит ошибку команды в код выхода.
+func withRunner(ctx context.Context, env environment, fn func(database.CommandRunner, string) error) int {
+	if err := env.waitForDB(ctx, env.cfg, env.logger); err != nil {
+		env.logger.Printf(ctx, "database connectivity check failed: %v", err)
+		return exitFailed
+	}
+
+	dsn, err := database.BuildDatabaseDSN(env.cfg)
+	if err != nil {
+		env.logger.Printf(ctx, "failed to build database DSN: %v", err)
+		return exitFailed
+	}
+
+	runner := env.newRunner()
+	defer runner.Close()
+
+	if err := fn(runner, dsn); err != nil {
+		env.logger.Printf(ctx, "%v", err)
+		return exitFailed
+	}
+	return exitOK
+}
+
+// checkDatabaseConnection выполняет проверку соединения с БД.
+func checkDatabaseConnection(ctx context.Context, cfg infra.Config, logger *infra.Logger) error {
+	if !database.ShouldCheckDatabase(cfg) {
+		return nil
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return database.WaitForDatabase(waitCtx, cfg, logger)
+}
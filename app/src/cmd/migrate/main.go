@@ -3,64 +3,414 @@ package main
 import (
 	_ "aggregator-service/app/src/infra/utils/autoload"
 	"context"
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"aggregator-service/app/src/database"
 	"aggregator-service/app/src/infra"
 )
 
-func main() {
-	migrationsDir := flag.String("dir", database.ResolveMigrationsDir(), "directory with SQL migration files")
-	flag.Parse()
+const usage = `usage: migrate [flags] [command] [args]
 
-	cfg, logger := initEnvironment()
+commands:
+  up [N]          apply the next N pending migrations (all when N is omitted)
+  down [N]        roll back the last N applied migrations (1 when N is omitted)
+  status          list applied and pending migrations with their checksums
+  create <name>   write empty timestamped up/down files into the migrations directory
+  verify          check applied files and the live schema against schema_migrations
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
+Without a command every pending migration is applied, or the schema is moved to -target.
+
+flags (accepted before or after the command):
+  -dir DIR        directory with SQL migration files
+  -dry-run        print the SQL of up/down or the files of create instead of running them
+  -target V       without a command: migrate up or down to schema version V
+
+exit codes: 0 success, 1 failure, 2 usage error, 3 verification failed (edited or missing
+files, out-of-order migrations, schema drift)
+`
+
+const (
+	exitOK     = 0
+	exitFailed = 1
+	exitUsage  = 2
+	exitVerify = 3
+)
 
-	checkDatabaseConnection(ctx, cfg, logger)
-	runMigrations(ctx, cfg, logger, *migrationsDir)
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, newEnvironment(), os.Args[1:])
+	stop()
+	os.Exit(code)
 }
 
 // ----------------------------
 // Вспомогательные функции
 // ----------------------------
 
-// initEnvironment загружает конфигурацию и логгер.
-func initEnvironment() (infra.Config, *infra.Logger) {
-	cfg := infra.LoadConfig()
-	logger := infra.NewLogger(os.Stdout, "migrate")
-	return cfg, logger
+// options — флаги, общие для всех команд.
+type options struct {
+	dir    string
+	dryRun bool
+	target int64
+}
+
+// environment — зависимости команд. main собирает настоящие, тесты подставляют заглушку runner'а,
+// буферы вместо stdout/stderr, фиксированные часы и пропускают ожидание базы.
+type environment struct {
+	cfg       infra.Config
+	logger    *infra.Logger
+	stdout    io.Writer
+	stderr    io.Writer
+	newRunner func() database.CommandRunner
+	waitForDB func(ctx context.Context, cfg infra.Config, logger *infra.Logger) error
+	// now задаёт версию файлов, которые пишет create.
+	now func() time.Time
+}
+
+// newEnvironment загружает конфигурацию и логгер.
+func newEnvironment() environment {
+	return environment{
+		cfg:       infra.LoadConfig(),
+		logger:    infra.NewLogger(os.Stderr, "migrate"),
+		stdout:    os.Stdout,
+		stderr:    os.Stderr,
+		newRunner: func() database.CommandRunner { return database.NewSQLRunner() },
+		waitForDB: checkDatabaseConnection,
+		now:       time.Now,
+	}
+}
+
+// run разбирает аргументы, выполняет команду и возвращает код выхода. Результат команды пишется в
+// stdout, журнал — в stderr, чтобы вывод status и -dry-run можно было разбирать скриптами.
+func run(ctx context.Context, env environment, args []string) int {
+	var opts options
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&opts.dir, "dir", database.ResolveMigrationsDir(), "directory with SQL migration files")
+	flags.BoolVar(&opts.dryRun, "dry-run", false, "print the SQL instead of running it")
+	flags.Int64Var(&opts.target, "target", -1, "schema version to migrate to without a command")
+
+	positional, err := parseInterspersed(flags, args)
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprint(env.stdout, usage)
+		return exitOK
+	}
+	if err != nil {
+		return usageError(env.stderr, err.Error())
+	}
+
+	command := "migrate"
+	if len(positional) > 0 {
+		command, positional = positional[0], positional[1:]
+	}
+
+	cfg, logger, stdout := env.cfg, env.logger, env.stdout
+
+	switch command {
+	case "create":
+		if len(positional) != 1 {
+			return usageError(env.stderr, "create expects exactly one migration name")
+		}
+		return runCreate(ctx, logger, opts, positional[0], env.now(), stdout)
+	case "up", "down":
+		steps, err := parseSteps(positional, command == "down")
+		if err != nil {
+			return usageError(env.stderr, fmt.Sprintf("%s: %v", command, err))
+		}
+		return withMigrator(ctx, env, opts, func(migrator *database.Migrator, runner database.CommandRunner, dsn string) error {
+			return runSteps(ctx, cfg, logger, migrator, runner, dsn, command == "down", steps, opts.dryRun, stdout)
+		})
+	case "status":
+		if len(positional) != 0 {
+			return usageError(env.stderr, "status takes no arguments")
+		}
+		return withMigrator(ctx, env, opts, func(migrator *database.Migrator, _ database.CommandRunner, _ string) error {
+			return printStatus(ctx, migrator, stdout)
+		})
+	case "verify":
+		if len(positional) != 0 {
+			return usageError(env.stderr, "verify takes no arguments")
+		}
+		return withMigrator(ctx, env, opts, func(migrator *database.Migrator, _ database.CommandRunner, _ string) error {
+			return runVerify(ctx, migrator, stdout)
+		})
+	case "migrate":
+		return withMigrator(ctx, env, opts, func(migrator *database.Migrator, runner database.CommandRunner, dsn string) error {
+			return runTarget(ctx, cfg, logger, migrator, runner, dsn, opts, stdout)
+		})
+	default:
+		return usageError(env.stderr, fmt.Sprintf("unknown command %q", command))
+	}
+}
+
+// parseInterspersed разбирает флаги, стоящие в любом месте командной строки, и возвращает
+// позиционные аргументы: `migrate up 2 -dry-run` равносильно `migrate -dry-run up 2`.
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// parseSteps читает необязательное число шагов для up/down. Для down по умолчанию один шаг,
+// для up — все ожидающие миграции (0).
+func parseSteps(args []string, down bool) (int, error) {
+	switch len(args) {
+	case 0:
+		if down {
+			return 1, nil
+		}
+		return 0, nil
+	case 1:
+		steps, err := strconv.Atoi(args[0])
+		if err != nil || steps <= 0 {
+			return 0, fmt.Errorf("number of steps must be a positive integer, got %q", args[0])
+		}
+		return steps, nil
+	default:
+		return 0, errors.New("expects at most one number of steps")
+	}
+}
+
+func usageError(w io.Writer, message string) int {
+	fmt.Fprintf(w, "migrate: %s\n\n%s", message, usage)
+	return exitUsage
+}
+
+// withMigrator проверяет соединение с БД, создаёт runner и Migrator и переводит ошибку команды
+// в код выхода.
+func withMigrator(ctx context.Context, env environment, opts options, fn func(*database.Migrator, database.CommandRunner, string) error) int {
+	cfg, logger := env.cfg, env.logger
+	if err := env.waitForDB(ctx, cfg, logger); err != nil {
+		logger.Printf(ctx, "database connectivity check failed: %v", err)
+		return exitFailed
+	}
+
+	dsn, err := database.BuildDatabaseDSN(cfg)
+	if err != nil {
+		logger.Printf(ctx, "failed to build database DSN: %v", err)
+		return exitFailed
+	}
+
+	runner := env.newRunner()
+	defer runner.Close()
+
+	migrator, err := database.NewMigrator(runner, dsn, opts.dir, logger)
+	if err != nil {
+		logger.Printf(ctx, "migrate: %v", err)
+		return exitFailed
+	}
+
+	if err := fn(migrator, runner, dsn); err != nil {
+		logger.Printf(ctx, "migrate: %v", err)
+		return exitCode(err)
+	}
+	return exitOK
+}
+
+// exitCode отделяет ошибки проверки схемы от прочих сбоев.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, database.ErrMigrationChecksum),
+		errors.Is(err, database.ErrMigrationMissing),
+		errors.Is(err, database.ErrMigrationOrder),
+		errors.Is(err, database.ErrSchemaDrift):
+		return exitVerify
+	default:
+		return exitFailed
+	}
 }
 
 // checkDatabaseConnection выполняет проверку соединения с БД.
-func checkDatabaseConnection(ctx context.Context, cfg infra.Config, logger *infra.Logger) {
+func checkDatabaseConnection(ctx context.Context, cfg infra.Config, logger *infra.Logger) error {
 	if !database.ShouldCheckDatabase(cfg) {
-		return
+		return nil
 	}
 	waitCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
+	return database.WaitForDatabase(waitCtx, cfg, logger)
+}
 
-	if err := database.WaitForDatabase(waitCtx, cfg, logger); err != nil {
-		logger.Fatalf(ctx, "database connectivity check failed: %v", err)
+// runSteps применяет или откатывает заданное число миграций. Число шагов переводится в целевую
+// версию уже под advisory-блокировкой: реплика, применявшая миграции до нас, могла сдвинуть схему.
+func runSteps(ctx context.Context, cfg infra.Config, logger *infra.Logger, migrator *database.Migrator, runner database.CommandRunner, dsn string, down bool, steps int, dryRun bool, stdout io.Writer) error {
+	return migrateTo(ctx, cfg, logger, migrator, runner, dsn, dryRun, stdout, func(ctx context.Context) (int64, bool, error) {
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return 0, false, err
+		}
+		if down {
+			return database.DownTarget(statuses, steps), true, nil
+		}
+		return database.UpTarget(statuses, steps), false, nil
+	})
+}
+
+// runTarget — поведение без команды: все новые миграции или переход к версии -target, вверх или
+// вниз в зависимости от текущей версии.
+func runTarget(ctx context.Context, cfg infra.Config, logger *infra.Logger, migrator *database.Migrator, runner database.CommandRunner, dsn string, opts options, stdout io.Writer) error {
+	return migrateTo(ctx, cfg, logger, migrator, runner, dsn, opts.dryRun, stdout, func(ctx context.Context) (int64, bool, error) {
+		if opts.target < 0 {
+			return database.LatestVersion, false, nil
+		}
+		current, err := database.SchemaVersion(ctx, runner, dsn)
+		if err != nil {
+			return 0, false, err
+		}
+		return opts.target, opts.target < current, nil
+	})
+}
+
+// migrateTo выполняет переход к версии, которую выбирает plan, под блокировкой миграций, а с
+// -dry-run только печатает SQL, который был бы выполнен, ничего не меняя в базе.
+func migrateTo(ctx context.Context, cfg infra.Config, logger *infra.Logger, migrator *database.Migrator, runner database.CommandRunner, dsn string, dryRun bool, stdout io.Writer, plan func(context.Context) (int64, bool, error)) error {
+	if dryRun {
+		target, down, err := plan(ctx)
+		if err != nil {
+			return err
+		}
+		var steps []database.Step
+		if down {
+			steps, err = migrator.PlanDown(ctx, target)
+		} else {
+			steps, err = migrator.PlanUp(ctx, target)
+		}
+		printSteps(stdout, steps)
+		return err
+	}
+
+	lockTimeout := time.Duration(cfg.MigrationLockTimeoutMS) * time.Millisecond
+	err := database.WithMigrationLock(ctx, runner, dsn, lockTimeout, logger, func(ctx context.Context) error {
+		target, down, err := plan(ctx)
+		if err != nil {
+			return err
+		}
+		if down {
+			_, err = migrator.Down(ctx, target)
+		} else {
+			_, err = migrator.Up(ctx, target)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if version, err := migrator.Version(ctx); err == nil {
+		logger.Printf(ctx, "schema version %d", version)
 	}
+	return nil
 }
 
-// runMigrations строит DSN, создаёт runner и применяет миграции.
-func runMigrations(ctx context.Context, cfg infra.Config, logger *infra.Logger, migrationsDir string) {
-	dsn, err := database.BuildDatabaseDSN(cfg)
+// printSteps печатает SQL шагов так, чтобы вывод можно было передать в psql.
+func printSteps(w io.Writer, steps []database.Step) {
+	if len(steps) == 0 {
+		fmt.Fprintln(w, "-- nothing to do")
+		return
+	}
+	for _, step := range steps {
+		direction := "up"
+		if step.Down {
+			direction = "down"
+		}
+		fmt.Fprintf(w, "-- %s (%s)\n%s\n\n", step.Migration, direction, step.SQL)
+	}
+}
+
+// printStatus печатает таблицу миграций: версия, имя, состояние, контрольные суммы файла и
+// записи в schema_migrations и время применения.
+func printStatus(ctx context.Context, migrator *database.Migrator, stdout io.Writer) error {
+	statuses, err := migrator.Status(ctx)
 	if err != nil {
-		logger.Fatalf(ctx, "failed to build database DSN: %v", err)
+		return err
 	}
 
-	runner := database.NewSQLRunner()
-	defer runner.Close()
+	table := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "VERSION\tNAME\tSTATE\tCHECKSUM\tAPPLIED CHECKSUM\tAPPLIED AT")
+	var current int64
+	pending := 0
+	for _, status := range statuses {
+		appliedChecksum, appliedAt := "-", "-"
+		if status.Applied != nil {
+			appliedChecksum = shortChecksum(status.Applied.Checksum)
+			appliedAt = status.Applied.AppliedAt.UTC().Format(time.RFC3339)
+			current = status.Version
+		} else {
+			pending++
+		}
+		fmt.Fprintf(table, "%04d\t%s\t%s\t%s\t%s\t%s\n",
+			status.Version, status.Name, status.State(), shortChecksum(status.Checksum), appliedChecksum, appliedAt)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "\nschema version %d, %d pending\n", current, pending)
+	return nil
+}
 
-	if err := database.ApplyMigrations(ctx, runner, dsn, migrationsDir, logger); err != nil {
-		logger.Fatalf(ctx, "migrate: %v", err)
+func shortChecksum(checksum string) string {
+	if checksum == "" {
+		return "-"
+	}
+	return checksum[:min(len(checksum), 12)]
+}
+
+// runVerify сверяет применённые файлы и живую схему с schema_migrations и печатает расхождения.
+func runVerify(ctx context.Context, migrator *database.Migrator, stdout io.Writer) error {
+	drift, err := migrator.Verify(ctx)
+	for _, line := range drift.Missing {
+		fmt.Fprintf(stdout, "- %s\n", line)
+	}
+	for _, line := range drift.Unexpected {
+		fmt.Fprintf(stdout, "+ %s\n", line)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case drift.Version == 0:
+		fmt.Fprintln(stdout, "no migrations applied")
+	case drift.Checked:
+		fmt.Fprintf(stdout, "schema matches version %d\n", drift.Version)
+	default:
+		fmt.Fprintf(stdout, "applied files match; version %d has no schema snapshot, drift not checked\n", drift.Version)
+	}
+	return nil
+}
+
+// runCreate создаёт пустую пару up/down-файлов с версией-меткой времени. С -dry-run печатает
+// имена файлов, не создавая их.
+func runCreate(ctx context.Context, logger *infra.Logger, opts options, name string, now time.Time, stdout io.Writer) int {
+	create := database.CreateMigration
+	if opts.dryRun {
+		create = database.ScaffoldMigration
+	}
+
+	files, err := create(opts.dir, name, now)
+	if err != nil {
+		logger.Printf(ctx, "migrate: %v", err)
+		return exitFailed
+	}
+	for _, file := range files {
+		fmt.Fprintln(stdout, file.Path)
 	}
+	return exitOK
 }
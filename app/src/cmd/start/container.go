@@ -1,25 +1,39 @@
 package main
 
 import (
+	"aggregator-service/app/src/core"
+	dbpostgres "aggregator-service/app/src/database"
 	"aggregator-service/app/src/domain"
 	"aggregator-service/app/src/infra"
 )
 
 type application struct {
-	Config     infra.Config
-	Logger     *infra.Logger
-	Service    domain.AggregatorService
-	Generator  domain.PacketGenerator
-	WorkerPool domain.WorkerPool
+	Config      infra.Config
+	Logger      *infra.Logger
+	Service     domain.AggregatorService
+	Generator   domain.PacketGenerator
+	Control     domain.GeneratorController
+	Ingestor    *core.Ingestor
+	MaxHub      *core.MaxHub
+	WorkerPool  domain.WorkerPool
+	DeadLetters domain.DeadLetterQueue
+	Retention   *core.RetentionJob
+	Partitions  *dbpostgres.PartitionMaintainer
 }
 
-func newApplication(cfg infra.Config, logger *infra.Logger, service domain.AggregatorService, generator domain.PacketGenerator, workerPool domain.WorkerPool) *application {
+func newApplication(cfg infra.Config, logger *infra.Logger, service domain.AggregatorService, generator domain.PacketGenerator, control domain.GeneratorController, ingestor *core.Ingestor, hub *core.MaxHub, workerPool domain.WorkerPool, deadLetters domain.DeadLetterQueue, retention *core.RetentionJob, partitions *dbpostgres.PartitionMaintainer) *application {
 	return &application{
-		Config:     cfg,
-		Logger:     logger,
-		Service:    service,
-		Generator:  generator,
-		WorkerPool: workerPool,
+		Config:      cfg,
+		Logger:      logger,
+		Service:     service,
+		Generator:   generator,
+		Control:     control,
+		Ingestor:    ingestor,
+		MaxHub:      hub,
+		WorkerPool:  workerPool,
+		DeadLetters: deadLetters,
+		Retention:   retention,
+		Partitions:  partitions,
 	}
 }
 
@@ -39,26 +39,61 @@ func main() {
 
 	service := app.Service
 	generator := app.Generator
+	ingestor := app.Ingestor
 	workerPool := app.WorkerPool
 
-	bufferSize := cfg.PacketBufferSize
-	if bufferSize <= 0 {
-		bufferSize = 100
+	var workers sync.WaitGroup
+	if generator != nil {
+		bufferSize := cfg.PacketBufferSize
+		if bufferSize <= 0 {
+			bufferSize = 100
+		}
+		generated := make(chan domain.DataPacket, bufferSize)
+
+		workers.Add(2)
+		go func() {
+			defer workers.Done()
+			generator.Run(ctx, generated)
+		}()
+		go func() {
+			defer workers.Done()
+			ingestor.Pipe(ctx, generated)
+		}()
+	} else {
+		logger.Println(ctx, "generator disabled, accepting packets only via POST /packets")
 	}
-	packets := make(chan domain.DataPacket, bufferSize)
 
-	var workers sync.WaitGroup
-	workers.Add(2)
-	go func() {
-		defer workers.Done()
-		generator.Run(ctx, packets)
-	}()
+	workers.Add(1)
 	go func() {
 		defer workers.Done()
-		workerPool.Run(ctx, packets)
+		workerPool.Run(ctx, ingestor.Packets())
 	}()
 
-	httpServer := newHTTPServer(cfg.HTTPPort, service, logger)
+	if app.Retention != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			app.Retention.Run(ctx)
+		}()
+	} else if cfg.RetentionDays > 0 {
+		logger.Println(ctx, "RETENTION_DAYS is set but the storage backend does not support purging")
+	}
+
+	// The first maintenance pass already ran while the repository was set up.
+	if app.Partitions != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			app.Partitions.Run(ctx)
+		}()
+	}
+
+	httpHandler := httpapi.NewServer(service, logger).
+		WithIngestor(ingestor).
+		WithWatcher(app.MaxHub).
+		WithGeneratorAdmin(app.Control, cfg.AdminToken).
+		WithDeadLetterAdmin(app.DeadLetters, cfg.AdminToken)
+	httpServer := newHTTPServer(cfg.HTTPPort, httpHandler)
 
 	httpListener, err := net.Listen("tcp", httpServer.Addr)
 	if err != nil {
@@ -67,7 +102,11 @@ func main() {
 		logger.Fatalf(ctx, "failed to listen on HTTP port %s: %v", cfg.HTTPPort, err)
 	}
 
-	grpcServer := grpcapi.NewServer(service, logger)
+	grpcServer := grpcapi.NewServer(service, logger,
+		grpcapi.WithIngestor(ingestor),
+		grpcapi.WithWatcher(app.MaxHub),
+		grpcapi.WithGeneratorAdmin(app.Control, cfg.AdminToken),
+	)
 	grpcAddr := fmt.Sprintf(":%s", cfg.GRPCPort)
 	grpcListener, err := net.Listen("tcp", grpcAddr)
 	if err != nil {
@@ -81,6 +120,7 @@ func main() {
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
+		app.MaxHub.Close()
 		if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logger.Printf(ctx, "HTTP server shutdown error: %v", err)
 		}
@@ -123,8 +163,9 @@ func main() {
 	}
 
 	stop()
-	workers.Wait()
 	serverGroup.Wait()
+	ingestor.Close()
+	workers.Wait()
 
 	if serveErr != nil {
 		logger.Printf(ctx, "server error: %v", serveErr)
@@ -133,10 +174,12 @@ func main() {
 	logger.Println(ctx, "server stopped")
 }
 
-func newHTTPServer(port string, service domain.AggregatorService, logger *infra.Logger) *http.Server {
+// newHTTPServer keeps WriteTimeout for regular requests; GET /max/stream lifts the deadline for
+// its own response.
+func newHTTPServer(port string, handler http.Handler) *http.Server {
 	return &http.Server{
 		Addr:              fmt.Sprintf(":%s", port),
-		Handler:           httpapi.NewServer(service, logger),
+		Handler:           handler,
 		ReadHeaderTimeout: 5 * time.Second,
 		ReadTimeout:       10 * time.Second,
 		WriteTimeout:      10 * time.Second,
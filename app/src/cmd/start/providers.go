@@ -2,11 +2,15 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"math/rand"
+	"strings"
 	"time"
 
 	"aggregator-service/app/src/core"
 	dbpostgres "aggregator-service/app/src/database"
+	"aggregator-service/app/src/database/memory"
 	"aggregator-service/app/src/domain"
 	"aggregator-service/app/src/infra"
 )
@@ -23,26 +27,163 @@ func provideLogger(out io.Writer, serviceName string) *infra.Logger {
 	return infra.NewLogger(out, serviceName)
 }
 
-func provideGeneratorConfig(cfg infra.Config) core.GeneratorConfig {
-	return core.GeneratorConfig{
-		Interval:   time.Duration(cfg.GeneratorIntervalMillis) * time.Millisecond,
-		PacketSize: cfg.MeasurementsPerPacket,
+func provideGeneratorConfig(cfg infra.Config) (core.GeneratorConfig, error) {
+	distribution := core.DistributionConfig{
+		Name:        cfg.GeneratorDistribution,
+		Min:         cfg.GeneratorMin,
+		Max:         cfg.GeneratorMax,
+		Mean:        cfg.GeneratorMean,
+		StdDev:      cfg.GeneratorStdDev,
+		Amplitude:   cfg.GeneratorAmplitude,
+		Period:      cfg.GeneratorPeriod,
+		Noise:       cfg.GeneratorNoise,
+		Step:        cfg.GeneratorStep,
+		SpikeEvery:  cfg.GeneratorSpikeEvery,
+		SpikeHeight: cfg.GeneratorSpikeHeight,
 	}
+	if err := distribution.Validate(); err != nil {
+		return core.GeneratorConfig{}, err
+	}
+
+	genCfg := core.GeneratorConfig{
+		Interval:        time.Duration(cfg.GeneratorIntervalMillis) * time.Millisecond,
+		PacketSize:      cfg.MeasurementsPerPacket,
+		MinPacketSize:   cfg.GeneratorMinPacketSize,
+		MaxPacketSize:   cfg.GeneratorMaxPacketSize,
+		Sources:         cfg.GeneratorSources,
+		SourceCount:     cfg.GeneratorSourceCount,
+		TimestampJitter: time.Duration(cfg.GeneratorJitterMillis) * time.Millisecond,
+		Distribution:    distribution,
+	}
+	if cfg.GeneratorSeed != 0 {
+		genCfg.RandSource = rand.NewSource(cfg.GeneratorSeed)
+	}
+	return genCfg, nil
+}
+
+// provideGenerator returns nil when the generator is disabled so the service runs ingest-only.
+// GENERATOR_MODE=replay swaps the synthetic generator for a recording played from REPLAY_FILE.
+func provideGenerator(cfg infra.Config, genCfg core.GeneratorConfig, logger *infra.Logger) (domain.PacketGenerator, error) {
+	if !cfg.GeneratorEnabled {
+		return nil, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(cfg.GeneratorMode)) {
+	case "", "synthetic":
+		return core.NewGenerator(genCfg, logger), nil
+	case "replay":
+		replay, err := core.NewReplayGenerator(core.ReplayConfig{
+			Path:             cfg.ReplayFile,
+			Format:           cfg.ReplayFormat,
+			Speed:            cfg.ReplaySpeed,
+			Loop:             cfg.ReplayLoop,
+			RebaseTimestamps: cfg.ReplayRebaseTimestamps,
+		}, logger)
+		if err != nil {
+			return nil, err
+		}
+		return replay, nil
+	default:
+		return nil, fmt.Errorf("unknown generator mode %q", cfg.GeneratorMode)
+	}
+}
+
+// provideGeneratorController exposes the generator to the admin API when it supports runtime
+// control; replayed recordings and a disabled generator yield nil.
+func provideGeneratorController(generator domain.PacketGenerator) domain.GeneratorController {
+	controller, ok := generator.(domain.GeneratorController)
+	if !ok {
+		return nil
+	}
+	return controller
+}
+
+// provideDeadLetterQueue exposes the repository dead-letter queue to the admin API when the
+// storage backend keeps one.
+func provideDeadLetterQueue(repo domain.Repository) domain.DeadLetterQueue {
+	queue, ok := repo.(domain.DeadLetterQueue)
+	if !ok {
+		return nil
+	}
+	return queue
+}
+
+// provideRetentionJob enforces RETENTION_DAYS in the background. It is nil when retention is
+// disabled or the storage backend cannot purge.
+func provideRetentionJob(cfg infra.Config, repo domain.Repository, logger *infra.Logger) *core.RetentionJob {
+	if cfg.RetentionDays <= 0 {
+		return nil
+	}
+	purger, ok := repo.(domain.RetentionPurger)
+	if !ok {
+		return nil
+	}
+	return core.NewRetentionJob(purger, core.RetentionConfig{
+		MaxAge:   time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+		Interval: time.Duration(cfg.RetentionIntervalMS) * time.Millisecond,
+		DryRun:   cfg.RetentionDryRun,
+	}, logger)
+}
+
+// providePartitionMaintainer keeps packet_max partitions ahead of the clock in the background.
+// It is nil when the storage backend is not the partitioned Postgres table.
+func providePartitionMaintainer(cfg infra.Config, repo domain.Repository) (*dbpostgres.PartitionMaintainer, error) {
+	postgres, ok := repo.(*dbpostgres.Repository)
+	if !ok {
+		return nil, nil
+	}
+	partitions, err := dbpostgres.LoadPartitionConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return postgres.PartitionMaintainer(partitions), nil
+}
+
+func provideIngestor(cfg infra.Config, logger *infra.Logger) *core.Ingestor {
+	return core.NewIngestor(cfg.PacketBufferSize, logger)
 }
 
-func provideGenerator(cfg core.GeneratorConfig, logger *infra.Logger) domain.PacketGenerator {
-	return core.NewGenerator(cfg, logger)
+func provideAggregationRegistry() *core.AggregationRegistry {
+	return core.DefaultAggregationRegistry()
 }
 
-func provideWorkerPool(cfg infra.Config, repo domain.PacketMaxRepository, logger *infra.Logger) domain.WorkerPool {
-	return core.NewWorkerPool(cfg.WorkerCount, repo, logger)
+func provideAggregations(cfg infra.Config, registry *core.AggregationRegistry) ([]core.AggregationFunc, error) {
+	return registry.Resolve(cfg.Aggregations)
 }
 
-func provideAggregatorService(repo domain.PacketMaxRepository) domain.AggregatorService {
-	return core.NewAggregator(repo)
+func provideMaxHub(cfg infra.Config, logger *infra.Logger) *core.MaxHub {
+	return core.NewMaxHub(cfg.WatchBufferSize, logger)
+}
+
+func provideWorkerPool(cfg infra.Config, repo domain.Repository, aggregations []core.AggregationFunc, logger *infra.Logger) domain.WorkerPool {
+	return core.NewWorkerPool(cfg.WorkerCount, repo, logger).
+		WithTopK(cfg.TopK).
+		WithAggregations(repo, aggregations...)
+}
+
+func provideAggregatorService(cfg infra.Config, repo domain.Repository, registry *core.AggregationRegistry) domain.AggregatorService {
+	return core.NewAggregator(repo).WithAggregates(repo, registry).WithMaxPageSize(cfg.MaxPageSize)
+}
+
+// provideRepository selects the storage backend from STORAGE: Postgres by default, or an
+// in-memory repository for local development that needs no database and keeps nothing across
+// restarts.
+func provideRepository(ctx context.Context, cfg infra.Config, hub *core.MaxHub, logger *infra.Logger) (domain.Repository, func(), error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Storage)) {
+	case "", "postgres":
+		return providePostgresRepository(ctx, cfg, hub, logger)
+	case "memory":
+		if logger != nil {
+			logger.Printf(ctx, "storage: in-memory repository (max rows %d), data is lost on restart", cfg.MemoryMaxRows)
+		}
+		repo := memory.New(memory.Config{MaxRows: cfg.MemoryMaxRows, Publisher: hub})
+		return repo, func() { _ = repo.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage %q", cfg.Storage)
+	}
 }
 
-func provideRepository(ctx context.Context, cfg infra.Config, logger *infra.Logger) (domain.PacketMaxRepository, func(), error) {
+func providePostgresRepository(ctx context.Context, cfg infra.Config, hub *core.MaxHub, logger *infra.Logger) (domain.Repository, func(), error) {
 	if dbpostgres.ShouldCheckDatabase(cfg) {
 		if err := dbpostgres.WaitForDatabase(ctx, cfg, logger); err != nil {
 			if logger != nil {
@@ -57,5 +198,5 @@ func provideRepository(ctx context.Context, cfg infra.Config, logger *infra.Logg
 		logger.Println(ctx, "database connectivity check skipped (no DSN or host/port configured)")
 	}
 
-	return dbpostgres.SetupRepository(ctx, cfg, logger)
+	return dbpostgres.SetupRepository(ctx, cfg, hub, logger)
 }
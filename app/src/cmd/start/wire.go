@@ -21,9 +21,17 @@ func initApplication(ctx context.Context, out io.Writer) (*application, func(),
 		provideLogger,
 		provideGeneratorConfig,
 		provideGenerator,
+		provideGeneratorController,
+		provideIngestor,
+		provideMaxHub,
+		provideAggregationRegistry,
+		provideAggregations,
 		provideWorkerPool,
 		provideAggregatorService,
 		provideRepository,
+		provideDeadLetterQueue,
+		provideRetentionJob,
+		providePartitionMaintainer,
 		newApplication,
 		assembleApplication,
 	)
@@ -4,22 +4,44 @@ import (
 	"context"
 	"io"
 
+	"aggregator-service/app/src/core"
 	"aggregator-service/app/src/domain"
 	"aggregator-service/app/src/infra"
 )
 
 func initApplication(ctx context.Context, out io.Writer) (*application, func(), error) {
 	cfg, logger := setupBase(out)
-	repo, cleanup, err := setupRepository(ctx, cfg, logger)
+	hub := provideMaxHub(cfg, logger)
+	repo, cleanup, err := setupRepository(ctx, cfg, hub, logger)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	gen := setupGenerator(cfg, logger)
-	pool := setupWorkerPool(cfg, repo, logger)
-	svc := provideAggregatorService(repo)
+	registry := provideAggregationRegistry()
+	aggregations, err := provideAggregations(cfg, registry)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	gen, err := setupGenerator(cfg, logger)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	control := provideGeneratorController(gen)
+	ingestor := provideIngestor(cfg, logger)
+	pool := setupWorkerPool(cfg, repo, aggregations, logger)
+	svc := provideAggregatorService(cfg, repo, registry)
+	deadLetters := provideDeadLetterQueue(repo)
+	retention := provideRetentionJob(cfg, repo, logger)
+	partitions, err := providePartitionMaintainer(cfg, repo)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
 
-	app := newApplication(cfg, logger, svc, gen, pool)
+	app := newApplication(cfg, logger, svc, gen, control, ingestor, hub, pool, deadLetters, retention, partitions)
 	return assembleApplication(app, cleanup)
 }
 
@@ -30,15 +52,18 @@ func setupBase(out io.Writer) (infra.Config, *infra.Logger) {
 	return cfg, log
 }
 
-func setupRepository(ctx context.Context, cfg infra.Config, logger *infra.Logger) (domain.PacketMaxRepository, func(), error) {
-	return provideRepository(ctx, cfg, logger)
+func setupRepository(ctx context.Context, cfg infra.Config, hub *core.MaxHub, logger *infra.Logger) (domain.Repository, func(), error) {
+	return provideRepository(ctx, cfg, hub, logger)
 }
 
-func setupGenerator(cfg infra.Config, logger *infra.Logger) domain.PacketGenerator {
-	genCfg := provideGeneratorConfig(cfg)
-	return provideGenerator(genCfg, logger)
+func setupGenerator(cfg infra.Config, logger *infra.Logger) (domain.PacketGenerator, error) {
+	genCfg, err := provideGeneratorConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return provideGenerator(cfg, genCfg, logger)
 }
 
-func setupWorkerPool(cfg infra.Config, repo domain.PacketMaxRepository, logger *infra.Logger) domain.WorkerPool {
-	return provideWorkerPool(cfg, repo, logger)
+func setupWorkerPool(cfg infra.Config, repo domain.Repository, aggregations []core.AggregationFunc, logger *infra.Logger) domain.WorkerPool {
+	return provideWorkerPool(cfg, repo, aggregations, logger)
 }
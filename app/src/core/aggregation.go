@@ -0,0 +1,208 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"aggregator-service/app/src/domain"
+)
+
+const (
+	AggregationMax   = "max"
+	AggregationMin   = "min"
+	AggregationMean  = "mean"
+	AggregationSum   = "sum"
+	AggregationCount = "count"
+	AggregationLast  = "last"
+)
+
+// AggregationFunc reduces the measurements of a single packet to one representative measurement.
+// Functions that do not select an existing measurement (mean, sum, count) return a measurement
+// without a source and with the latest timestamp of the packet.
+type AggregationFunc interface {
+	Name() string
+	Aggregate(measurements []domain.Measurement) (domain.Measurement, bool)
+}
+
+type aggregationFunc struct {
+	name string
+	fn   func([]domain.Measurement) (domain.Measurement, bool)
+}
+
+// NewAggregationFunc wraps a plain function into an AggregationFunc registered under name.
+func NewAggregationFunc(name string, fn func([]domain.Measurement) (domain.Measurement, bool)) AggregationFunc {
+	return aggregationFunc{name: name, fn: fn}
+}
+
+func (f aggregationFunc) Name() string {
+	return f.name
+}
+
+func (f aggregationFunc) Aggregate(measurements []domain.Measurement) (domain.Measurement, bool) {
+	if len(measurements) == 0 {
+		return domain.Measurement{}, false
+	}
+	return f.fn(measurements)
+}
+
+// AggregationRegistry resolves aggregation functions by name.
+type AggregationRegistry struct {
+	mu    sync.RWMutex
+	funcs map[string]AggregationFunc
+}
+
+func NewAggregationRegistry(funcs ...AggregationFunc) (*AggregationRegistry, error) {
+	registry := &AggregationRegistry{funcs: make(map[string]AggregationFunc, len(funcs))}
+	for _, fn := range funcs {
+		if err := registry.Register(fn); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// DefaultAggregationRegistry returns a registry populated with every built-in aggregation function.
+func DefaultAggregationRegistry() *AggregationRegistry {
+	registry, err := NewAggregationRegistry(BuiltinAggregations()...)
+	if err != nil {
+		panic(err)
+	}
+	return registry
+}
+
+// BuiltinAggregations lists the aggregation functions shipped with the service.
+func BuiltinAggregations() []AggregationFunc {
+	return []AggregationFunc{
+		NewAggregationFunc(AggregationMax, aggregateMax),
+		NewAggregationFunc(AggregationMin, aggregateMin),
+		NewAggregationFunc(AggregationMean, aggregateMean),
+		NewAggregationFunc(AggregationSum, aggregateSum),
+		NewAggregationFunc(AggregationCount, aggregateCount),
+		NewAggregationFunc(AggregationLast, aggregateLast),
+	}
+}
+
+func (r *AggregationRegistry) Register(fn AggregationFunc) error {
+	if fn == nil {
+		return fmt.Errorf("aggregation registry: function is nil")
+	}
+	name := normalizeAggregationName(fn.Name())
+	if name == "" {
+		return fmt.Errorf("aggregation registry: function name is required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.funcs[name]; exists {
+		return fmt.Errorf("aggregation registry: duplicate function %q", name)
+	}
+	r.funcs[name] = fn
+	return nil
+}
+
+func (r *AggregationRegistry) Lookup(name string) (AggregationFunc, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fn, ok := r.funcs[normalizeAggregationName(name)]
+	return fn, ok
+}
+
+// Resolve maps the configured names onto registered functions, skipping duplicates.
+func (r *AggregationRegistry) Resolve(names []string) ([]AggregationFunc, error) {
+	seen := make(map[string]struct{}, len(names))
+	funcs := make([]AggregationFunc, 0, len(names))
+	for _, name := range names {
+		name = normalizeAggregationName(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		fn, ok := r.Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", domain.ErrUnknownAggregation, name)
+		}
+		seen[name] = struct{}{}
+		funcs = append(funcs, fn)
+	}
+	return funcs, nil
+}
+
+func (r *AggregationRegistry) Names() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.funcs))
+	for name := range r.funcs {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+
+	sort.Strings(names)
+	return names
+}
+
+func normalizeAggregationName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func aggregateMax(measurements []domain.Measurement) (domain.Measurement, bool) {
+	result := measurements[0]
+	for _, m := range measurements[1:] {
+		if outranks(m, result) {
+			result = m
+		}
+	}
+	return result, true
+}
+
+func aggregateMin(measurements []domain.Measurement) (domain.Measurement, bool) {
+	result := measurements[0]
+	for _, m := range measurements[1:] {
+		if m.Value < result.Value || (m.Value == result.Value && m.Timestamp.After(result.Timestamp)) {
+			result = m
+		}
+	}
+	return result, true
+}
+
+func aggregateLast(measurements []domain.Measurement) (domain.Measurement, bool) {
+	result := measurements[0]
+	for _, m := range measurements[1:] {
+		if !m.Timestamp.Before(result.Timestamp) {
+			result = m
+		}
+	}
+	return result, true
+}
+
+func aggregateSum(measurements []domain.Measurement) (domain.Measurement, bool) {
+	result := summaryMeasurement(measurements)
+	for _, m := range measurements {
+		result.Value += m.Value
+	}
+	return result, true
+}
+
+func aggregateMean(measurements []domain.Measurement) (domain.Measurement, bool) {
+	result, _ := aggregateSum(measurements)
+	result.Value /= float64(len(measurements))
+	return result, true
+}
+
+func aggregateCount(measurements []domain.Measurement) (domain.Measurement, bool) {
+	result := summaryMeasurement(measurements)
+	result.Value = float64(len(measurements))
+	return result, true
+}
+
+func summaryMeasurement(measurements []domain.Measurement) domain.Measurement {
+	latest := measurements[0].Timestamp
+	for _, m := range measurements[1:] {
+		if m.Timestamp.After(latest) {
+			latest = m.Timestamp
+		}
+	}
+	return domain.Measurement{PacketID: measurements[0].PacketID, Timestamp: latest}
+}
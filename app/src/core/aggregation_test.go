@@ -0,0 +1,94 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"aggregator-service/app/src/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleMeasurements(now time.Time) []domain.Measurement {
+	return []domain.Measurement{
+		{PacketID: "packet", SourceID: "s1", Value: 4, Timestamp: now.Add(-2 * time.Second)},
+		{PacketID: "packet", SourceID: "s2", Value: 1, Timestamp: now},
+		{PacketID: "packet", SourceID: "s3", Value: 7, Timestamp: now.Add(-time.Second)},
+	}
+}
+
+func TestBuiltinAggregations(t *testing.T) {
+	now := time.Now().UTC()
+	measurements := sampleMeasurements(now)
+	registry := DefaultAggregationRegistry()
+
+	tests := []struct {
+		name     string
+		value    float64
+		sourceID string
+	}{
+		{AggregationMax, 7, "s3"},
+		{AggregationMin, 1, "s2"},
+		{AggregationSum, 12, ""},
+		{AggregationMean, 4, ""},
+		{AggregationCount, 3, ""},
+		{AggregationLast, 1, "s2"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fn, ok := registry.Lookup(tc.name)
+			require.True(t, ok)
+
+			result, found := fn.Aggregate(measurements)
+			require.True(t, found)
+			assert.Equal(t, tc.value, result.Value)
+			assert.Equal(t, tc.sourceID, result.SourceID)
+			assert.Equal(t, "packet", result.PacketID)
+		})
+	}
+}
+
+func TestSummaryAggregationsUseLatestTimestamp(t *testing.T) {
+	now := time.Now().UTC()
+	fn, ok := DefaultAggregationRegistry().Lookup(AggregationMean)
+	require.True(t, ok)
+
+	result, _ := fn.Aggregate(sampleMeasurements(now))
+	assert.True(t, result.Timestamp.Equal(now))
+}
+
+func TestAggregationsIgnoreEmptyInput(t *testing.T) {
+	for _, fn := range BuiltinAggregations() {
+		_, found := fn.Aggregate(nil)
+		assert.False(t, found, fn.Name())
+	}
+}
+
+func TestAggregationRegistryRejectsDuplicates(t *testing.T) {
+	fn := NewAggregationFunc("custom", aggregateMax)
+	registry, err := NewAggregationRegistry(fn)
+	require.NoError(t, err)
+
+	assert.Error(t, registry.Register(NewAggregationFunc(" Custom ", aggregateMin)))
+	assert.Error(t, registry.Register(NewAggregationFunc("", aggregateMin)))
+	assert.Error(t, registry.Register(nil))
+}
+
+func TestAggregationRegistryResolve(t *testing.T) {
+	registry := DefaultAggregationRegistry()
+
+	funcs, err := registry.Resolve([]string{"MAX", " mean", "", "max"})
+	require.NoError(t, err)
+	require.Len(t, funcs, 2)
+	assert.Equal(t, AggregationMax, funcs[0].Name())
+	assert.Equal(t, AggregationMean, funcs[1].Name())
+
+	_, err = registry.Resolve([]string{"median"})
+	assert.ErrorIs(t, err, domain.ErrUnknownAggregation)
+}
+
+func TestAggregationRegistryNames(t *testing.T) {
+	assert.Equal(t, []string{"count", "last", "max", "mean", "min", "sum"}, DefaultAggregationRegistry().Names())
+}
@@ -2,17 +2,44 @@ package core
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"aggregator-service/app/src/domain"
 )
 
+// DefaultMaxPageSize caps range query pages when WithMaxPageSize is not used.
+const DefaultMaxPageSize = 1000
+
 type Aggregator struct {
-	repo domain.PacketMaxReader
+	repo        domain.PacketMaxReader
+	maxPageSize int
+
+	aggregates domain.PacketAggregateReader
+	registry   *AggregationRegistry
 }
 
 func NewAggregator(repo domain.PacketMaxReader) *Aggregator {
-	return &Aggregator{repo: repo}
+	return &Aggregator{repo: repo, maxPageSize: DefaultMaxPageSize, registry: DefaultAggregationRegistry()}
+}
+
+// WithMaxPageSize caps the number of results returned by one range query page. Larger limits are
+// lowered to it; a non-positive size keeps the current cap.
+func (a *Aggregator) WithMaxPageSize(size int) *Aggregator {
+	if size > 0 {
+		a.maxPageSize = size
+	}
+	return a
+}
+
+// WithAggregates enables queries for aggregation functions other than max. Function names are
+// validated against registry; a nil registry keeps the built-in one.
+func (a *Aggregator) WithAggregates(reader domain.PacketAggregateReader, registry *AggregationRegistry) *Aggregator {
+	a.aggregates = reader
+	if registry != nil {
+		a.registry = registry
+	}
+	return a
 }
 
 func (a *Aggregator) MaxByPacketID(ctx context.Context, packetID string) (domain.AggregatorResult, error) {
@@ -23,8 +50,44 @@ func (a *Aggregator) MaxByPacketID(ctx context.Context, packetID string) (domain
 	return toResult(packetMax), nil
 }
 
-func (a *Aggregator) MaxInRange(ctx context.Context, from, to time.Time) ([]domain.AggregatorResult, error) {
-	packetMaxes, err := a.repo.PacketMaxInRange(ctx, from, to)
+// MaxInRange returns one page of packet maxima recorded within the range. page.Limit is clamped to
+// the configured maximum page size.
+func (a *Aggregator) MaxInRange(ctx context.Context, from, to time.Time, page domain.PageRequest) (domain.ResultPage, error) {
+	page.Limit = a.pageLimit(page.Limit)
+	packetMaxes, err := a.repo.PacketMaxInRange(ctx, from, to, page)
+	if err != nil {
+		return domain.ResultPage{}, err
+	}
+
+	results := make([]domain.AggregatorResult, len(packetMaxes.PacketMaxes))
+	for i, p := range packetMaxes.PacketMaxes {
+		results[i] = toResult(p)
+	}
+	return domain.ResultPage{Results: results, NextCursor: packetMaxes.NextCursor}, nil
+}
+
+// StreamMaxInRange passes the packet maxima recorded within the range to emit one at a time, so
+// memory does not depend on the size of the range. The repository cursor is closed on return,
+// including when emit fails because the client went away.
+func (a *Aggregator) StreamMaxInRange(ctx context.Context, from, to time.Time, emit func(domain.AggregatorResult) error) error {
+	it, err := a.repo.IteratePacketMaxInRange(ctx, from, to)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	for it.Next() {
+		if err := emit(toResult(it.PacketMax())); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// TopByPacketID returns up to k highest measurements stored for the packet ordered by rank.
+// A non-positive k returns every stored rank.
+func (a *Aggregator) TopByPacketID(ctx context.Context, packetID string, k int) ([]domain.AggregatorResult, error) {
+	packetMaxes, err := a.repo.TopPacketMaxByID(ctx, packetID, k)
 	if err != nil {
 		return nil, err
 	}
@@ -36,12 +99,100 @@ func (a *Aggregator) MaxInRange(ctx context.Context, from, to time.Time) ([]doma
 	return results, nil
 }
 
+func (a *Aggregator) AggregateByPacketID(ctx context.Context, function, packetID string) (domain.AggregatorResult, error) {
+	name, err := a.resolveFunction(function)
+	if err != nil {
+		return domain.AggregatorResult{}, err
+	}
+
+	if name == AggregationMax {
+		result, err := a.MaxByPacketID(ctx, packetID)
+		if err != nil {
+			return domain.AggregatorResult{}, err
+		}
+		result.Function = AggregationMax
+		return result, nil
+	}
+
+	if a.aggregates == nil {
+		return domain.AggregatorResult{}, domain.ErrNotFound
+	}
+
+	aggregate, err := a.aggregates.AggregateByID(ctx, name, packetID)
+	if err != nil {
+		return domain.AggregatorResult{}, err
+	}
+	return aggregateToResult(aggregate), nil
+}
+
+// AggregateInRange returns one page of the results of function recorded within the range. Like
+// MaxInRange, page.Limit is clamped to the configured maximum page size.
+func (a *Aggregator) AggregateInRange(ctx context.Context, function string, from, to time.Time, page domain.PageRequest) (domain.ResultPage, error) {
+	name, err := a.resolveFunction(function)
+	if err != nil {
+		return domain.ResultPage{}, err
+	}
+
+	if name == AggregationMax {
+		results, err := a.MaxInRange(ctx, from, to, page)
+		if err != nil {
+			return domain.ResultPage{}, err
+		}
+		for i := range results.Results {
+			results.Results[i].Function = AggregationMax
+		}
+		return results, nil
+	}
+
+	if a.aggregates == nil {
+		return domain.ResultPage{}, domain.ErrNotFound
+	}
+
+	page.Limit = a.pageLimit(page.Limit)
+	aggregates, err := a.aggregates.AggregatesInRange(ctx, name, from, to, page)
+	if err != nil {
+		return domain.ResultPage{}, err
+	}
+
+	results := make([]domain.AggregatorResult, len(aggregates.Aggregates))
+	for i, aggregate := range aggregates.Aggregates {
+		results[i] = aggregateToResult(aggregate)
+	}
+	return domain.ResultPage{Results: results, NextCursor: aggregates.NextCursor}, nil
+}
+
+func (a *Aggregator) pageLimit(limit int) int {
+	if limit <= 0 || limit > a.maxPageSize {
+		return a.maxPageSize
+	}
+	return limit
+}
+
+func (a *Aggregator) resolveFunction(function string) (string, error) {
+	fn, ok := a.registry.Lookup(function)
+	if !ok {
+		return "", fmt.Errorf("%w: %q", domain.ErrUnknownAggregation, function)
+	}
+	return fn.Name(), nil
+}
+
 func toResult(p domain.PacketMax) domain.AggregatorResult {
 	return domain.AggregatorResult{
 		PacketID:  p.PacketID,
 		SourceID:  p.SourceID,
 		Value:     p.Value,
 		Timestamp: p.Timestamp,
+		Rank:      p.Rank,
+	}
+}
+
+func aggregateToResult(p domain.PacketAggregate) domain.AggregatorResult {
+	return domain.AggregatorResult{
+		PacketID:  p.PacketID,
+		Function:  p.Function,
+		SourceID:  p.SourceID,
+		Value:     p.Value,
+		Timestamp: p.Timestamp,
 	}
 }
 
@@ -16,14 +16,91 @@ type stubPacketMaxReader struct {
 	byIDErr      error
 	rangeResults []domain.PacketMax
 	rangeErr     error
+	// rangePages, when set, answers PacketMaxInRange by cursor instead of rangeResults.
+	rangePages map[string]domain.PacketMaxPage
+	topResults []domain.PacketMax
+	topErr     error
+	iterator   *sliceIterator
+	iterateErr error
+
+	lastK     int
+	lastPages []domain.PageRequest
 }
 
 func (s *stubPacketMaxReader) PacketMaxByID(ctx context.Context, packetID string) (domain.PacketMax, error) {
 	return s.byIDResult, s.byIDErr
 }
 
-func (s *stubPacketMaxReader) PacketMaxInRange(ctx context.Context, from, to time.Time) ([]domain.PacketMax, error) {
-	return s.rangeResults, s.rangeErr
+func (s *stubPacketMaxReader) PacketMaxInRange(ctx context.Context, from, to time.Time, page domain.PageRequest) (domain.PacketMaxPage, error) {
+	s.lastPages = append(s.lastPages, page)
+	if s.rangePages != nil {
+		return s.rangePages[page.Cursor], s.rangeErr
+	}
+	return domain.PacketMaxPage{PacketMaxes: s.rangeResults}, s.rangeErr
+}
+
+func (s *stubPacketMaxReader) IteratePacketMaxInRange(ctx context.Context, from, to time.Time) (domain.PacketMaxIterator, error) {
+	if s.iterateErr != nil {
+		return nil, s.iterateErr
+	}
+	return s.iterator, nil
+}
+
+// sliceIterator serves packetMaxes and then err, and records whether it was closed.
+type sliceIterator struct {
+	packetMaxes []domain.PacketMax
+	err         error
+	next        int
+	closed      bool
+}
+
+func (it *sliceIterator) Next() bool {
+	if it.next >= len(it.packetMaxes) {
+		return false
+	}
+	it.next++
+	return true
+}
+
+func (it *sliceIterator) PacketMax() domain.PacketMax { return it.packetMaxes[it.next-1] }
+
+func (it *sliceIterator) Err() error {
+	if it.next < len(it.packetMaxes) {
+		return nil
+	}
+	return it.err
+}
+
+func (it *sliceIterator) Close() error {
+	it.closed = true
+	return nil
+}
+
+func (s *stubPacketMaxReader) TopPacketMaxByID(ctx context.Context, packetID string, k int) ([]domain.PacketMax, error) {
+	s.lastK = k
+	return s.topResults, s.topErr
+}
+
+type stubPacketAggregateReader struct {
+	byIDResult   domain.PacketAggregate
+	byIDErr      error
+	rangeResults []domain.PacketAggregate
+	rangeCursor  string
+	rangeErr     error
+
+	lastFunction string
+	lastPage     domain.PageRequest
+}
+
+func (s *stubPacketAggregateReader) AggregateByID(ctx context.Context, function, packetID string) (domain.PacketAggregate, error) {
+	s.lastFunction = function
+	return s.byIDResult, s.byIDErr
+}
+
+func (s *stubPacketAggregateReader) AggregatesInRange(ctx context.Context, function string, from, to time.Time, page domain.PageRequest) (domain.PacketAggregatePage, error) {
+	s.lastFunction = function
+	s.lastPage = page
+	return domain.PacketAggregatePage{Aggregates: s.rangeResults, NextCursor: s.rangeCursor}, s.rangeErr
 }
 
 func newTestAggregator(repo *stubPacketMaxReader) *Aggregator {
@@ -68,20 +145,98 @@ func TestAggregatorMaxInRangeSuccess(t *testing.T) {
 	packet := newPacket("packet", 7, now)
 	agg := newTestAggregator(&stubPacketMaxReader{rangeResults: []domain.PacketMax{packet}})
 
-	results, err := agg.MaxInRange(context.Background(), now.Add(-time.Hour), now)
+	results, err := agg.MaxInRange(context.Background(), now.Add(-time.Hour), now, domain.PageRequest{})
 
 	assert.NoError(t, err)
-	assert.Equal(t, []domain.AggregatorResult{toResult(packet)}, results)
+	assert.Equal(t, []domain.AggregatorResult{toResult(packet)}, results.Results)
+	assert.Empty(t, results.NextCursor)
 }
 
 func TestAggregatorMaxInRangeError(t *testing.T) {
 	expected := errors.New("boom")
 	agg := newTestAggregator(&stubPacketMaxReader{rangeErr: expected})
 
-	results, err := agg.MaxInRange(context.Background(), time.Now(), time.Now())
+	results, err := agg.MaxInRange(context.Background(), time.Now(), time.Now(), domain.PageRequest{})
+
+	assert.ErrorIs(t, err, expected)
+	assert.Equal(t, domain.ResultPage{}, results)
+}
+
+func TestAggregatorMaxInRangeClampsPageSize(t *testing.T) {
+	repo := &stubPacketMaxReader{}
+	agg := newTestAggregator(repo).WithMaxPageSize(50)
+
+	t.Log("Шаг 1: без лимита и с лимитом больше максимума запрашивается максимальная страница")
+	_, err := agg.MaxInRange(context.Background(), time.Now(), time.Now(), domain.PageRequest{})
+	assert.NoError(t, err)
+	_, err = agg.MaxInRange(context.Background(), time.Now(), time.Now(), domain.PageRequest{Limit: 500, Cursor: "next"})
+	assert.NoError(t, err)
+
+	t.Log("Шаг 2: лимит в пределах максимума передаётся как есть")
+	_, err = agg.MaxInRange(context.Background(), time.Now(), time.Now(), domain.PageRequest{Limit: 10})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []domain.PageRequest{{Limit: 50}, {Limit: 50, Cursor: "next"}, {Limit: 10}}, repo.lastPages)
+}
+
+func TestAggregatorMaxInRangeReturnsNextCursor(t *testing.T) {
+	now := time.Now().UTC()
+	packet := newPacket("packet", 7, now)
+	agg := newTestAggregator(&stubPacketMaxReader{rangePages: map[string]domain.PacketMaxPage{
+		"": {PacketMaxes: []domain.PacketMax{packet}, NextCursor: "next"},
+	}})
+
+	results, err := agg.MaxInRange(context.Background(), now.Add(-time.Hour), now, domain.PageRequest{Limit: 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ResultPage{Results: []domain.AggregatorResult{toResult(packet)}, NextCursor: "next"}, results)
+}
+
+func TestAggregatorStreamMaxInRange(t *testing.T) {
+	now := time.Now().UTC()
+	packets := []domain.PacketMax{newPacket("first", 1, now), newPacket("second", 2, now)}
+
+	t.Log("Шаг 1: все строки передаются по порядку, курсор закрывается")
+	it := &sliceIterator{packetMaxes: packets}
+	agg := newTestAggregator(&stubPacketMaxReader{iterator: it})
+	var emitted []domain.AggregatorResult
+	err := agg.StreamMaxInRange(context.Background(), now.Add(-time.Hour), now, func(result domain.AggregatorResult) error {
+		emitted = append(emitted, result)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.AggregatorResult{toResult(packets[0]), toResult(packets[1])}, emitted)
+	assert.True(t, it.closed)
+
+	t.Log("Шаг 2: ошибка получателя останавливает чтение и тоже закрывает курсор")
+	it = &sliceIterator{packetMaxes: packets}
+	agg = newTestAggregator(&stubPacketMaxReader{iterator: it})
+	gone := errors.New("client went away")
+	calls := 0
+	err = agg.StreamMaxInRange(context.Background(), now.Add(-time.Hour), now, func(domain.AggregatorResult) error {
+		calls++
+		return gone
+	})
+	assert.ErrorIs(t, err, gone)
+	assert.Equal(t, 1, calls)
+	assert.True(t, it.closed)
+
+	t.Log("Шаг 3: ошибка чтения строк возвращается вызывающему")
+	readErr := errors.New("connection reset")
+	it = &sliceIterator{packetMaxes: packets[:1], err: readErr}
+	agg = newTestAggregator(&stubPacketMaxReader{iterator: it})
+	err = agg.StreamMaxInRange(context.Background(), now.Add(-time.Hour), now, func(domain.AggregatorResult) error { return nil })
+	assert.ErrorIs(t, err, readErr)
+	assert.True(t, it.closed)
+}
+
+func TestAggregatorStreamMaxInRangeQueryError(t *testing.T) {
+	expected := errors.New("boom")
+	agg := newTestAggregator(&stubPacketMaxReader{iterateErr: expected})
+
+	err := agg.StreamMaxInRange(context.Background(), time.Now(), time.Now(), func(domain.AggregatorResult) error { return nil })
 
 	assert.ErrorIs(t, err, expected)
-	assert.Nil(t, results)
 }
 
 func TestToResult(t *testing.T) {
@@ -97,3 +252,102 @@ func TestToResult(t *testing.T) {
 		Timestamp: now,
 	}, result)
 }
+
+func TestAggregatorAggregateByPacketIDRejectsUnknownFunction(t *testing.T) {
+	agg := newTestAggregator(&stubPacketMaxReader{}).WithAggregates(&stubPacketAggregateReader{}, nil)
+
+	_, err := agg.AggregateByPacketID(context.Background(), "median", "packet")
+
+	assert.ErrorIs(t, err, domain.ErrUnknownAggregation)
+}
+
+func TestAggregatorAggregateByPacketIDUsesMaxReader(t *testing.T) {
+	now := time.Now().UTC()
+	packet := newPacket("packet", 3, now)
+	aggregates := &stubPacketAggregateReader{}
+	agg := newTestAggregator(&stubPacketMaxReader{byIDResult: packet}).WithAggregates(aggregates, nil)
+
+	result, err := agg.AggregateByPacketID(context.Background(), "MAX", "packet")
+
+	assert.NoError(t, err)
+	assert.Equal(t, AggregationMax, result.Function)
+	assert.Equal(t, 3.0, result.Value)
+	assert.Empty(t, aggregates.lastFunction)
+}
+
+func TestAggregatorAggregateByPacketIDUsesAggregateReader(t *testing.T) {
+	now := time.Now().UTC()
+	aggregate := domain.PacketAggregate{PacketID: "packet", Function: AggregationMean, Value: 1.5, Timestamp: now}
+	aggregates := &stubPacketAggregateReader{byIDResult: aggregate}
+	agg := newTestAggregator(&stubPacketMaxReader{}).WithAggregates(aggregates, nil)
+
+	result, err := agg.AggregateByPacketID(context.Background(), AggregationMean, "packet")
+
+	assert.NoError(t, err)
+	assert.Equal(t, AggregationMean, aggregates.lastFunction)
+	assert.Equal(t, aggregateToResult(aggregate), result)
+}
+
+func TestAggregatorAggregateWithoutReaderIsNotFound(t *testing.T) {
+	agg := newTestAggregator(&stubPacketMaxReader{})
+
+	_, err := agg.AggregateByPacketID(context.Background(), AggregationMin, "packet")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = agg.AggregateInRange(context.Background(), AggregationMin, time.Now(), time.Now(), domain.PageRequest{})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestAggregatorAggregateInRangeReturnsOnePage(t *testing.T) {
+	now := time.Now().UTC()
+	first := newPacket("first", 1, now)
+	second := newPacket("second", 2, now)
+	repo := &stubPacketMaxReader{rangePages: map[string]domain.PacketMaxPage{
+		"":     {PacketMaxes: []domain.PacketMax{first}, NextCursor: "next"},
+		"next": {PacketMaxes: []domain.PacketMax{second}},
+	}}
+	aggregates := &stubPacketAggregateReader{rangeResults: []domain.PacketAggregate{{PacketID: "packet", Function: AggregationSum}}, rangeCursor: "more"}
+	agg := newTestAggregator(repo).WithMaxPageSize(50).WithAggregates(aggregates, nil)
+
+	t.Log("Шаг 1: max отдаёт одну страницу и курсор на следующую")
+	page, err := agg.AggregateInRange(context.Background(), AggregationMax, now.Add(-time.Hour), now, domain.PageRequest{})
+	assert.NoError(t, err)
+	if assert.Len(t, page.Results, 1) {
+		assert.Equal(t, "first", page.Results[0].PacketID)
+		assert.Equal(t, AggregationMax, page.Results[0].Function)
+	}
+	assert.Equal(t, "next", page.NextCursor)
+	assert.Equal(t, []domain.PageRequest{{Limit: 50}}, repo.lastPages)
+
+	t.Log("Шаг 2: остальные функции ограничены тем же размером страницы")
+	page, err = agg.AggregateInRange(context.Background(), AggregationSum, now.Add(-time.Hour), now, domain.PageRequest{Limit: 500, Cursor: "c"})
+	assert.NoError(t, err)
+	assert.Len(t, page.Results, 1)
+	assert.Equal(t, "more", page.NextCursor)
+	assert.Equal(t, domain.PageRequest{Limit: 50, Cursor: "c"}, aggregates.lastPage)
+}
+
+func TestAggregatorTopByPacketID(t *testing.T) {
+	now := time.Now().UTC()
+	first := newPacket("packet", 9, now)
+	first.Rank = 1
+	second := newPacket("packet", 7, now)
+	second.Rank = 2
+	repo := &stubPacketMaxReader{topResults: []domain.PacketMax{first, second}}
+	agg := newTestAggregator(repo)
+
+	results, err := agg.TopByPacketID(context.Background(), "packet", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, repo.lastK)
+	assert.Len(t, results, 2)
+	assert.Equal(t, 2, results[1].Rank)
+	assert.Equal(t, 7.0, results[1].Value)
+}
+
+func TestAggregatorTopByPacketIDError(t *testing.T) {
+	repo := &stubPacketMaxReader{topErr: domain.ErrNotFound}
+	agg := newTestAggregator(repo)
+
+	_, err := agg.TopByPacketID(context.Background(), "packet", 3)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
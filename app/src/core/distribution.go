@@ -0,0 +1,174 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+const (
+	DistributionUniform    = "uniform"
+	DistributionNormal     = "normal"
+	DistributionLogNormal  = "lognormal"
+	DistributionSine       = "sine"
+	DistributionRandomWalk = "randomwalk"
+	DistributionSpikes     = "spikes"
+)
+
+// DistributionConfig selects the shape of generated measurement values. Only the parameters of
+// the selected distribution are used:
+//   - uniform: values in [Min, Max);
+//   - normal: Mean and StdDev;
+//   - lognormal: exp of a normal value with Mean and StdDev;
+//   - sine: Mean + Amplitude*sin(2π*packet/Period) plus normal noise with StdDev Noise;
+//   - randomwalk: starts at Mean and moves by normal steps with StdDev Step, kept in [Min, Max] when Max > Min;
+//   - spikes: normal values around Mean with StdDev, raised by SpikeHeight on every SpikeEvery-th packet.
+type DistributionConfig struct {
+	Name        string
+	Min         float64
+	Max         float64
+	Mean        float64
+	StdDev      float64
+	Amplitude   float64
+	Period      int
+	Noise       float64
+	Step        float64
+	SpikeEvery  int
+	SpikeHeight float64
+}
+
+// ValueDistribution produces the value of a single measurement. packet is the zero-based sequence
+// number of the packet being generated, which periodic distributions use as their clock.
+type ValueDistribution interface {
+	Sample(rnd *rand.Rand, packet int) float64
+}
+
+// DefaultDistributionConfig reproduces the original generator behaviour: uniform values in [0, 1000).
+func DefaultDistributionConfig() DistributionConfig {
+	return DistributionConfig{Name: DistributionUniform, Min: 0, Max: 1000}
+}
+
+func (c DistributionConfig) Validate() error {
+	switch normalizeDistributionName(c.Name) {
+	case "", DistributionUniform:
+		if c.Max <= c.Min {
+			return fmt.Errorf("distribution %s: max must be greater than min", DistributionUniform)
+		}
+	case DistributionNormal, DistributionLogNormal:
+		if c.StdDev < 0 {
+			return fmt.Errorf("distribution %s: stddev must not be negative", c.Name)
+		}
+	case DistributionSine:
+		if c.Period <= 0 {
+			return fmt.Errorf("distribution %s: period must be positive", DistributionSine)
+		}
+		if c.Noise < 0 {
+			return fmt.Errorf("distribution %s: noise must not be negative", DistributionSine)
+		}
+	case DistributionRandomWalk:
+		if c.Step < 0 {
+			return fmt.Errorf("distribution %s: step must not be negative", DistributionRandomWalk)
+		}
+	case DistributionSpikes:
+		if c.SpikeEvery <= 0 {
+			return fmt.Errorf("distribution %s: spike interval must be positive", DistributionSpikes)
+		}
+		if c.StdDev < 0 {
+			return fmt.Errorf("distribution %s: stddev must not be negative", DistributionSpikes)
+		}
+	default:
+		return fmt.Errorf("unknown distribution %q", c.Name)
+	}
+	return nil
+}
+
+// NewValueDistribution builds the distribution described by cfg. An empty name selects uniform.
+func NewValueDistribution(cfg DistributionConfig) (ValueDistribution, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch normalizeDistributionName(cfg.Name) {
+	case DistributionNormal:
+		return normalDistribution{mean: cfg.Mean, stdDev: cfg.StdDev}, nil
+	case DistributionLogNormal:
+		return logNormalDistribution{mu: cfg.Mean, sigma: cfg.StdDev}, nil
+	case DistributionSine:
+		return sineDistribution{mean: cfg.Mean, amplitude: cfg.Amplitude, period: cfg.Period, noise: cfg.Noise}, nil
+	case DistributionRandomWalk:
+		return &randomWalkDistribution{value: cfg.Mean, step: cfg.Step, min: cfg.Min, max: cfg.Max}, nil
+	case DistributionSpikes:
+		return spikesDistribution{mean: cfg.Mean, stdDev: cfg.StdDev, every: cfg.SpikeEvery, height: cfg.SpikeHeight}, nil
+	default:
+		return uniformDistribution{min: cfg.Min, max: cfg.Max}, nil
+	}
+}
+
+func normalizeDistributionName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+type uniformDistribution struct {
+	min, max float64
+}
+
+func (d uniformDistribution) Sample(rnd *rand.Rand, _ int) float64 {
+	return d.min + rnd.Float64()*(d.max-d.min)
+}
+
+type normalDistribution struct {
+	mean, stdDev float64
+}
+
+func (d normalDistribution) Sample(rnd *rand.Rand, _ int) float64 {
+	return d.mean + rnd.NormFloat64()*d.stdDev
+}
+
+type logNormalDistribution struct {
+	mu, sigma float64
+}
+
+func (d logNormalDistribution) Sample(rnd *rand.Rand, _ int) float64 {
+	return math.Exp(d.mu + rnd.NormFloat64()*d.sigma)
+}
+
+type sineDistribution struct {
+	mean, amplitude float64
+	period          int
+	noise           float64
+}
+
+func (d sineDistribution) Sample(rnd *rand.Rand, packet int) float64 {
+	phase := 2 * math.Pi * float64(packet%d.period) / float64(d.period)
+	return d.mean + d.amplitude*math.Sin(phase) + rnd.NormFloat64()*d.noise
+}
+
+// randomWalkDistribution keeps its position between samples, so it must not be shared between
+// generators.
+type randomWalkDistribution struct {
+	value, step float64
+	min, max    float64
+}
+
+func (d *randomWalkDistribution) Sample(rnd *rand.Rand, _ int) float64 {
+	d.value += rnd.NormFloat64() * d.step
+	if d.max > d.min {
+		d.value = math.Max(d.min, math.Min(d.max, d.value))
+	}
+	return d.value
+}
+
+type spikesDistribution struct {
+	mean, stdDev float64
+	every        int
+	height       float64
+}
+
+func (d spikesDistribution) Sample(rnd *rand.Rand, packet int) float64 {
+	value := d.mean + rnd.NormFloat64()*d.stdDev
+	if (packet+1)%d.every == 0 {
+		value += d.height
+	}
+	return value
+}
@@ -0,0 +1,207 @@
+package core
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const distributionSamples = 20000
+
+func sampleDistribution(t *testing.T, cfg DistributionConfig, n int) []float64 {
+	t.Helper()
+	dist, err := NewValueDistribution(cfg)
+	require.NoError(t, err)
+
+	rnd := rand.New(rand.NewSource(42))
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = dist.Sample(rnd, i)
+	}
+	return values
+}
+
+func meanAndStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)))
+}
+
+func TestUniformDistributionShape(t *testing.T) {
+	t.Log("Шаг 1: генерируем значения равномерного распределения в [10, 20)")
+	values := sampleDistribution(t, DistributionConfig{Name: DistributionUniform, Min: 10, Max: 20}, distributionSamples)
+
+	t.Log("Шаг 2: проверяем границы, среднее и разброс (σ = ширина/√12)")
+	for _, v := range values {
+		assert.GreaterOrEqual(t, v, 10.0)
+		assert.Less(t, v, 20.0)
+	}
+	mean, stdDev := meanAndStdDev(values)
+	assert.InDelta(t, 15.0, mean, 0.1)
+	assert.InDelta(t, 10/math.Sqrt(12), stdDev, 0.05)
+}
+
+func TestNormalDistributionShape(t *testing.T) {
+	t.Log("Шаг 1: генерируем нормальное распределение со средним 100 и σ 15")
+	values := sampleDistribution(t, DistributionConfig{Name: DistributionNormal, Mean: 100, StdDev: 15}, distributionSamples)
+
+	t.Log("Шаг 2: проверяем среднее, σ и правило трёх сигм")
+	mean, stdDev := meanAndStdDev(values)
+	assert.InDelta(t, 100.0, mean, 0.5)
+	assert.InDelta(t, 15.0, stdDev, 0.5)
+
+	within := 0
+	for _, v := range values {
+		if math.Abs(v-100) <= 15 {
+			within++
+		}
+	}
+	assert.InDelta(t, 0.6827, float64(within)/float64(len(values)), 0.02)
+}
+
+func TestLogNormalDistributionShape(t *testing.T) {
+	t.Log("Шаг 1: генерируем логнормальное распределение с μ=2 и σ=0.5")
+	values := sampleDistribution(t, DistributionConfig{Name: DistributionLogNormal, Mean: 2, StdDev: 0.5}, distributionSamples)
+
+	t.Log("Шаг 2: значения положительны, медиана ≈ e^μ, распределение скошено вправо")
+	for _, v := range values {
+		assert.Greater(t, v, 0.0)
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+	assert.InDelta(t, math.Exp(2), median, 0.15)
+
+	mean, _ := meanAndStdDev(values)
+	assert.Greater(t, mean, median)
+	assert.InDelta(t, math.Exp(2+0.5*0.5/2), mean, 0.2)
+}
+
+func TestSineDistributionFollowsWave(t *testing.T) {
+	t.Log("Шаг 1: синусоида без шума повторяет форму волны")
+	cfg := DistributionConfig{Name: DistributionSine, Mean: 50, Amplitude: 10, Period: 4}
+	values := sampleDistribution(t, cfg, 8)
+	expected := []float64{50, 60, 50, 40, 50, 60, 50, 40}
+	for i := range expected {
+		assert.InDelta(t, expected[i], values[i], 1e-9)
+	}
+
+	t.Log("Шаг 2: шум добавляет нормальное отклонение вокруг волны")
+	cfg.Noise = 2
+	noisy := sampleDistribution(t, cfg, distributionSamples)
+	residuals := make([]float64, len(noisy))
+	for i, v := range noisy {
+		residuals[i] = v - expected[i%len(expected)]
+	}
+	mean, stdDev := meanAndStdDev(residuals)
+	assert.InDelta(t, 0.0, mean, 0.1)
+	assert.InDelta(t, 2.0, stdDev, 0.1)
+}
+
+func TestRandomWalkDistributionSteps(t *testing.T) {
+	t.Log("Шаг 1: случайное блуждание без границ меняется шагами с σ = Step")
+	values := sampleDistribution(t, DistributionConfig{Name: DistributionRandomWalk, Mean: 0, Step: 3}, distributionSamples)
+	steps := make([]float64, len(values)-1)
+	for i := 1; i < len(values); i++ {
+		steps[i-1] = values[i] - values[i-1]
+	}
+	mean, stdDev := meanAndStdDev(steps)
+	assert.InDelta(t, 0.0, mean, 0.1)
+	assert.InDelta(t, 3.0, stdDev, 0.1)
+
+	t.Log("Шаг 2: с заданными границами значения не выходят за [Min, Max]")
+	bounded := sampleDistribution(t, DistributionConfig{Name: DistributionRandomWalk, Mean: 5, Step: 4, Min: 0, Max: 10}, distributionSamples)
+	for _, v := range bounded {
+		assert.GreaterOrEqual(t, v, 0.0)
+		assert.LessOrEqual(t, v, 10.0)
+	}
+}
+
+func TestSpikesDistributionRaisesEveryNthPacket(t *testing.T) {
+	t.Log("Шаг 1: генерируем фон σ=1 вокруг 10 со всплеском +100 каждый 5-й пакет")
+	values := sampleDistribution(t, DistributionConfig{
+		Name: DistributionSpikes, Mean: 10, StdDev: 1, SpikeEvery: 5, SpikeHeight: 100,
+	}, 1000)
+
+	t.Log("Шаг 2: всплески приходятся ровно на каждый 5-й пакет")
+	for i, v := range values {
+		if (i+1)%5 == 0 {
+			assert.Greater(t, v, 100.0, "packet %d", i)
+		} else {
+			assert.Less(t, v, 20.0, "packet %d", i)
+		}
+	}
+}
+
+func TestDistributionConfigValidate(t *testing.T) {
+	invalid := map[string]DistributionConfig{
+		"unknown":          {Name: "poisson"},
+		"uniform range":    {Name: DistributionUniform, Min: 5, Max: 5},
+		"normal stddev":    {Name: DistributionNormal, StdDev: -1},
+		"sine period":      {Name: DistributionSine, Period: 0},
+		"sine noise":       {Name: DistributionSine, Period: 10, Noise: -1},
+		"randomwalk step":  {Name: DistributionRandomWalk, Step: -1},
+		"spikes interval":  {Name: DistributionSpikes, SpikeEvery: 0},
+		"spikes deviation": {Name: DistributionSpikes, SpikeEvery: 3, StdDev: -1},
+	}
+	for name, cfg := range invalid {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, cfg.Validate())
+			_, err := NewValueDistribution(cfg)
+			assert.Error(t, err)
+		})
+	}
+
+	t.Log("имя распределения не зависит от регистра и пробелов")
+	assert.NoError(t, DistributionConfig{Name: " Normal ", StdDev: 1}.Validate())
+}
+
+func TestGeneratorSameSeedProducesSameValues(t *testing.T) {
+	t.Log("Шаг 1: создаём два генератора с одинаковым зерном")
+	cfg := func() GeneratorConfig {
+		return GeneratorConfig{
+			Interval:     time.Millisecond,
+			PacketSize:   4,
+			RandSource:   rand.NewSource(7),
+			Distribution: DistributionConfig{Name: DistributionRandomWalk, Mean: 100, Step: 5},
+		}
+	}
+	first := newTestGenerator(cfg())
+	second := newTestGenerator(cfg())
+
+	t.Log("Шаг 2: значения измерений совпадают пакет за пакетом")
+	for i := 0; i < 10; i++ {
+		a, b := first.generatePacket(), second.generatePacket()
+		for j := range a.Measurements {
+			assert.Equal(t, a.Measurements[j].Value, b.Measurements[j].Value)
+		}
+	}
+}
+
+func TestNewGeneratorFallsBackOnInvalidDistribution(t *testing.T) {
+	logger := &stubLogger{}
+	gen := NewGenerator(GeneratorConfig{Distribution: DistributionConfig{Name: "poisson"}}, logger)
+
+	assert.Equal(t, DefaultDistributionConfig(), gen.cfg.Distribution)
+	assert.Len(t, logger.messages(), 1)
+	assert.Contains(t, logger.messages()[0], "равномерное распределение")
+
+	packet := gen.generatePacket()
+	for _, m := range packet.Measurements {
+		assert.GreaterOrEqual(t, m.Value, 0.0)
+		assert.Less(t, m.Value, 1000.0)
+	}
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"math/rand"
+	"sync"
 	"time"
 
 	"aggregator-service/app/src/domain"
@@ -11,15 +12,41 @@ import (
 )
 
 type GeneratorConfig struct {
-	Interval   time.Duration
+	Interval time.Duration
+	// PacketSize is the number of measurements per packet unless MaxPacketSize is set.
 	PacketSize int
+	// MinPacketSize and MaxPacketSize make the packet size vary uniformly in [Min, Max]; a zero
+	// Max keeps every packet at PacketSize.
+	MinPacketSize int
+	MaxPacketSize int
+	// Sources names the measurement sources; when empty SourceCount sources are generated, and a
+	// zero SourceCount sizes the pool to the largest packet.
+	Sources     []string
+	SourceCount int
+	// TimestampJitter spreads measurement timestamps over [now-TimestampJitter, now]; zero stamps
+	// every measurement of a packet with the same time.
+	TimestampJitter time.Duration
+	// RandSource drives every random choice of the generator; a fixed seed makes runs reproducible.
 	RandSource rand.Source
+	// Distribution shapes the measurement values; the zero value means DefaultDistributionConfig.
+	Distribution DistributionConfig
 }
 
 type Generator struct {
 	cfg    GeneratorConfig
 	logger Logger
 	rnd    *rand.Rand
+	dist   ValueDistribution
+	pool   *SourcePool
+	seq    int
+
+	// mu guards the runtime-controlled fields: cfg.Interval, cfg.MinPacketSize,
+	// cfg.MaxPacketSize, paused and changes.
+	mu      sync.Mutex
+	paused  bool
+	changes []domain.GeneratorChange
+	// control wakes Run after a schedule change.
+	control chan struct{}
 }
 
 func NewGenerator(cfg GeneratorConfig, logger Logger) *Generator {
@@ -29,6 +56,22 @@ func NewGenerator(cfg GeneratorConfig, logger Logger) *Generator {
 	if cfg.PacketSize <= 0 {
 		cfg.PacketSize = 1
 	}
+	if cfg.MaxPacketSize > 0 {
+		if cfg.MinPacketSize <= 0 {
+			cfg.MinPacketSize = 1
+		}
+		if cfg.MinPacketSize > cfg.MaxPacketSize {
+			cfg.MinPacketSize = cfg.MaxPacketSize
+		}
+	} else {
+		cfg.MinPacketSize, cfg.MaxPacketSize = cfg.PacketSize, cfg.PacketSize
+	}
+	if cfg.SourceCount <= 0 {
+		cfg.SourceCount = cfg.MaxPacketSize
+	}
+	if cfg.TimestampJitter < 0 {
+		cfg.TimestampJitter = 0
+	}
 
 	source := cfg.RandSource
 	if source == nil {
@@ -36,27 +79,61 @@ func NewGenerator(cfg GeneratorConfig, logger Logger) *Generator {
 	}
 	cfg.RandSource = source
 
+	if cfg.Distribution == (DistributionConfig{}) {
+		cfg.Distribution = DefaultDistributionConfig()
+	}
+	dist, err := NewValueDistribution(cfg.Distribution)
+	if err != nil {
+		if logger != nil {
+			logger.Printf(context.Background(), "generator: %v, используется равномерное распределение", err)
+		}
+		cfg.Distribution = DefaultDistributionConfig()
+		dist, _ = NewValueDistribution(cfg.Distribution)
+	}
+
 	return &Generator{
-		cfg:    cfg,
-		logger: logger,
-		rnd:    rand.New(source),
+		cfg:     cfg,
+		logger:  logger,
+		rnd:     rand.New(source),
+		dist:    dist,
+		pool:    NewSourcePool(cfg.Sources, cfg.SourceCount),
+		control: make(chan struct{}, 1),
 	}
 }
 
 func (g *Generator) Run(ctx context.Context, out chan<- domain.DataPacket) {
 	defer close(out)
 
-	ticker := time.NewTicker(g.cfg.Interval)
+	state := g.State()
+	g.log(ctx, "generator: пул источников: %d, размер пакета %d..%d", g.pool.Len(), state.MinPacketSize, state.MaxPacketSize)
+	infra.SetGeneratorState(state.Paused, state.Interval, state.MinPacketSize, state.MaxPacketSize)
+
+	ticker := time.NewTicker(state.Interval)
 	defer ticker.Stop()
+	if state.Paused {
+		ticker.Stop()
+	}
 
 	for {
 		select {
 		case <-ctx.Done():
 			g.log(ctx, "generator: остановлен (context cancelled): %v", ctx.Err())
 			return
+		case <-g.control:
+			interval, paused := g.schedule()
+			if paused {
+				ticker.Stop()
+			} else {
+				ticker.Reset(interval)
+			}
+			continue
 		case <-ticker.C:
 		}
 
+		if _, paused := g.schedule(); paused {
+			continue
+		}
+
 		packet := g.generatePacket()
 		g.log(ctx, "generator: создан пакет id=%s", packet.ID)
 		infra.IncGeneratorPackets()
@@ -71,20 +148,46 @@ func (g *Generator) generatePacket() domain.DataPacket {
 	packetID := constants.GenerateUUID()
 	now := time.Now().UTC()
 
-	measurements := make([]domain.Measurement, g.cfg.PacketSize)
-	for i := range measurements {
-		value := float64(g.rnd.Int63n(1000))
+	sources := g.pool.Pick(g.rnd, g.packetSize())
+	measurements := make([]domain.Measurement, len(sources))
+	for i, source := range sources {
+		value := g.dist.Sample(g.rnd, g.seq)
 		measurements[i] = domain.Measurement{
 			PacketID:  packetID,
-			SourceID:  constants.GenerateUUID(),
+			SourceID:  source.ID,
 			Value:     value,
-			Timestamp: now,
+			Timestamp: now.Add(-g.jitter()),
 		}
 	}
 
+	g.seq++
+
 	return domain.DataPacket{ID: packetID, Measurements: measurements}
 }
 
+func (g *Generator) packetSize() int {
+	g.mu.Lock()
+	minSize, maxSize := g.cfg.MinPacketSize, g.cfg.MaxPacketSize
+	g.mu.Unlock()
+
+	if maxSize == minSize {
+		return maxSize
+	}
+	return minSize + g.rnd.Intn(maxSize-minSize+1)
+}
+
+func (g *Generator) jitter() time.Duration {
+	if g.cfg.TimestampJitter <= 0 {
+		return 0
+	}
+	return time.Duration(g.rnd.Int63n(int64(g.cfg.TimestampJitter) + 1))
+}
+
+// Sources returns the pool the generator draws measurements from.
+func (g *Generator) Sources() []Source {
+	return g.pool.Sources()
+}
+
 func (g *Generator) sendPacket(ctx context.Context, out chan<- domain.DataPacket, packet domain.DataPacket) bool {
 	select {
 	case <-ctx.Done():
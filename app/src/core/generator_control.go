@@ -0,0 +1,127 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/infra"
+)
+
+const (
+	// maxGeneratorChanges bounds the change history kept for State.
+	maxGeneratorChanges = 20
+	// minGeneratorInterval keeps a runtime interval change from turning the generator into a
+	// busy loop.
+	minGeneratorInterval = time.Millisecond
+)
+
+// State returns the current schedule and the most recent runtime changes.
+func (g *Generator) State() domain.GeneratorState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return domain.GeneratorState{
+		Paused:        g.paused,
+		Interval:      g.cfg.Interval,
+		MinPacketSize: g.cfg.MinPacketSize,
+		MaxPacketSize: g.cfg.MaxPacketSize,
+		Changes:       append([]domain.GeneratorChange(nil), g.changes...),
+	}
+}
+
+// Pause stops packet generation until Resume. Pausing a paused generator is a no-op.
+func (g *Generator) Pause() {
+	g.update(func() (string, string, bool) {
+		if g.paused {
+			return "", "", false
+		}
+		g.paused = true
+		return "pause", "", true
+	})
+}
+
+func (g *Generator) Resume() {
+	g.update(func() (string, string, bool) {
+		if !g.paused {
+			return "", "", false
+		}
+		g.paused = false
+		return "resume", "", true
+	})
+}
+
+// SetInterval changes the time between packets; the next packet follows one new interval later.
+func (g *Generator) SetInterval(interval time.Duration) error {
+	if interval < minGeneratorInterval {
+		return fmt.Errorf("%w: interval must be at least %s", domain.ErrInvalidGeneratorSetting, minGeneratorInterval)
+	}
+	g.update(func() (string, string, bool) {
+		if g.cfg.Interval == interval {
+			return "", "", false
+		}
+		detail := fmt.Sprintf("%s -> %s", g.cfg.Interval, interval)
+		g.cfg.Interval = interval
+		return "interval", detail, true
+	})
+	return nil
+}
+
+// SetPacketSize makes packets carry between min and max measurements; min == max fixes the size.
+func (g *Generator) SetPacketSize(min, max int) error {
+	if min <= 0 {
+		return fmt.Errorf("%w: packet size must be positive", domain.ErrInvalidGeneratorSetting)
+	}
+	if max < min {
+		return fmt.Errorf("%w: max packet size must not be less than min", domain.ErrInvalidGeneratorSetting)
+	}
+	g.update(func() (string, string, bool) {
+		if g.cfg.MinPacketSize == min && g.cfg.MaxPacketSize == max {
+			return "", "", false
+		}
+		detail := fmt.Sprintf("%d..%d -> %d..%d", g.cfg.MinPacketSize, g.cfg.MaxPacketSize, min, max)
+		g.cfg.MinPacketSize, g.cfg.MaxPacketSize = min, max
+		return "packet_size", detail, true
+	})
+	return nil
+}
+
+// update applies change under the lock and, when it reports a change, records it, refreshes the
+// metrics and wakes Run.
+func (g *Generator) update(change func() (action, detail string, changed bool)) {
+	g.mu.Lock()
+	action, detail, changed := change()
+	if !changed {
+		g.mu.Unlock()
+		return
+	}
+
+	at := time.Now().UTC()
+	g.changes = append(g.changes, domain.GeneratorChange{At: at, Action: action, Detail: detail})
+	if len(g.changes) > maxGeneratorChanges {
+		g.changes = append([]domain.GeneratorChange(nil), g.changes[len(g.changes)-maxGeneratorChanges:]...)
+	}
+	paused, interval, minSize, maxSize := g.paused, g.cfg.Interval, g.cfg.MinPacketSize, g.cfg.MaxPacketSize
+	g.mu.Unlock()
+
+	infra.RecordGeneratorChange(action, at)
+	infra.SetGeneratorState(paused, interval, minSize, maxSize)
+	if detail != "" {
+		action += " " + detail
+	}
+	g.log(context.Background(), "generator: изменение %s", action)
+
+	select {
+	case g.control <- struct{}{}:
+	default:
+	}
+}
+
+func (g *Generator) schedule() (time.Duration, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.cfg.Interval, g.paused
+}
+
+var _ domain.GeneratorController = (*Generator)(nil)
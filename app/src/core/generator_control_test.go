@@ -0,0 +1,163 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/infra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runTestGenerator(t *testing.T, gen *Generator) (<-chan domain.DataPacket, func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan domain.DataPacket, 100)
+	done := make(chan struct{})
+	go func() {
+		gen.Run(ctx, out)
+		close(done)
+	}()
+	return out, func() {
+		cancel()
+		<-done
+	}
+}
+
+func drain(packets <-chan domain.DataPacket) int {
+	count := 0
+	for {
+		select {
+		case <-packets:
+			count++
+		default:
+			return count
+		}
+	}
+}
+
+func TestGeneratorPauseAndResume(t *testing.T) {
+	t.Log("Шаг 1: запускаем генератор с интервалом 5 мс")
+	gen := newTestGenerator(GeneratorConfig{Interval: 5 * time.Millisecond})
+	packets, stop := runTestGenerator(t, gen)
+	defer stop()
+
+	select {
+	case <-packets:
+	case <-time.After(time.Second):
+		t.Fatal("генератор не выдал пакет")
+	}
+
+	t.Log("Шаг 2: после паузы пакеты перестают поступать")
+	gen.Pause()
+	assert.True(t, gen.State().Paused)
+	time.Sleep(20 * time.Millisecond)
+	drain(packets)
+	time.Sleep(50 * time.Millisecond)
+	assert.Zero(t, drain(packets))
+	assert.Equal(t, 1.0, infra.GeneratorPaused.Value())
+
+	t.Log("Шаг 3: после возобновления генерация продолжается")
+	gen.Resume()
+	select {
+	case <-packets:
+	case <-time.After(time.Second):
+		t.Fatal("генератор не возобновил работу")
+	}
+	assert.False(t, gen.State().Paused)
+	assert.Equal(t, 0.0, infra.GeneratorPaused.Value())
+}
+
+func TestGeneratorSetIntervalReschedulesTicker(t *testing.T) {
+	t.Log("Шаг 1: генератор с интервалом в час не выдаёт пакетов")
+	gen := newTestGenerator(GeneratorConfig{Interval: time.Hour})
+	packets, stop := runTestGenerator(t, gen)
+	defer stop()
+
+	t.Log("Шаг 2: уменьшаем интервал без перезапуска")
+	require.NoError(t, gen.SetInterval(5*time.Millisecond))
+	select {
+	case <-packets:
+	case <-time.After(time.Second):
+		t.Fatal("новый интервал не применён")
+	}
+	assert.Equal(t, 5*time.Millisecond, gen.State().Interval)
+	assert.Equal(t, 0.005, infra.GeneratorIntervalSeconds.Value())
+
+	t.Log("Шаг 3: некорректный интервал отклоняется")
+	err := gen.SetInterval(0)
+	assert.ErrorIs(t, err, domain.ErrInvalidGeneratorSetting)
+	assert.Equal(t, 5*time.Millisecond, gen.State().Interval)
+}
+
+func TestGeneratorStartsPausedAfterPauseBeforeRun(t *testing.T) {
+	gen := newTestGenerator(GeneratorConfig{Interval: 5 * time.Millisecond})
+	gen.Pause()
+
+	packets, stop := runTestGenerator(t, gen)
+	defer stop()
+
+	time.Sleep(40 * time.Millisecond)
+	assert.Zero(t, drain(packets))
+}
+
+func TestGeneratorSetPacketSize(t *testing.T) {
+	gen := newTestGenerator(GeneratorConfig{PacketSize: 2})
+
+	t.Log("Шаг 1: фиксированный размер пакета меняется на лету")
+	require.NoError(t, gen.SetPacketSize(5, 5))
+	assert.Len(t, gen.generatePacket().Measurements, 5)
+
+	t.Log("Шаг 2: диапазон размеров")
+	require.NoError(t, gen.SetPacketSize(1, 3))
+	for i := 0; i < 50; i++ {
+		size := len(gen.generatePacket().Measurements)
+		assert.GreaterOrEqual(t, size, 1)
+		assert.LessOrEqual(t, size, 3)
+	}
+	assert.Equal(t, 3.0, infra.GeneratorMaxPacketSize.Value())
+
+	t.Log("Шаг 3: некорректные размеры отклоняются")
+	assert.ErrorIs(t, gen.SetPacketSize(0, 3), domain.ErrInvalidGeneratorSetting)
+	assert.ErrorIs(t, gen.SetPacketSize(4, 3), domain.ErrInvalidGeneratorSetting)
+	state := gen.State()
+	assert.Equal(t, 1, state.MinPacketSize)
+	assert.Equal(t, 3, state.MaxPacketSize)
+}
+
+func TestGeneratorRecordsChangeHistory(t *testing.T) {
+	logger := &stubLogger{}
+	gen := NewGenerator(GeneratorConfig{Interval: time.Second, PacketSize: 2}, logger)
+	pauses := infra.GeneratorPausesTotal.Value()
+
+	t.Log("Шаг 1: повторные вызовы без изменения состояния не попадают в историю")
+	gen.Resume()
+	gen.Pause()
+	gen.Pause()
+	require.NoError(t, gen.SetInterval(time.Second))
+	require.NoError(t, gen.SetInterval(2*time.Second))
+	require.NoError(t, gen.SetPacketSize(2, 4))
+
+	changes := gen.State().Changes
+	require.Len(t, changes, 3)
+	assert.Equal(t, "pause", changes[0].Action)
+	assert.Equal(t, "interval", changes[1].Action)
+	assert.Equal(t, "1s -> 2s", changes[1].Detail)
+	assert.Equal(t, "packet_size", changes[2].Action)
+	assert.Equal(t, "2..2 -> 2..4", changes[2].Detail)
+	assert.False(t, changes[0].At.IsZero())
+	assert.Equal(t, pauses+1, infra.GeneratorPausesTotal.Value())
+	assert.Contains(t, logger.messages(), "generator: изменение interval 1s -> 2s")
+
+	t.Log("Шаг 2: история ограничена последними изменениями")
+	for i := 0; i < maxGeneratorChanges; i++ {
+		gen.Resume()
+		gen.Pause()
+	}
+	changes = gen.State().Changes
+	assert.Len(t, changes, maxGeneratorChanges)
+	assert.Equal(t, "pause", changes[len(changes)-1].Action)
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type stubLogger struct {
@@ -103,6 +104,76 @@ func TestGeneratorRunProducesPackets(t *testing.T) {
 	}
 }
 
+func TestGeneratorDrawsFromStableSourcePool(t *testing.T) {
+	t.Log("Шаг 1: генератор с пулом из трёх именованных источников")
+	gen := newTestGenerator(GeneratorConfig{PacketSize: 2, Sources: []string{"boiler", "pump", "fan"}, RandSource: rand.NewSource(3)})
+	allowed := map[string]struct{}{SourceID("boiler"): {}, SourceID("pump"): {}, SourceID("fan"): {}}
+
+	t.Log("Шаг 2: все измерения приходят от источников пула, без повторов внутри пакета")
+	for i := 0; i < 50; i++ {
+		packet := gen.generatePacket()
+		require.Len(t, packet.Measurements, 2)
+		assert.NotEqual(t, packet.Measurements[0].SourceID, packet.Measurements[1].SourceID)
+		for _, m := range packet.Measurements {
+			assert.Contains(t, allowed, m.SourceID)
+		}
+	}
+	assert.Len(t, gen.Sources(), 3)
+}
+
+func TestGeneratorDefaultPoolMatchesPacketSize(t *testing.T) {
+	gen := newTestGenerator(GeneratorConfig{PacketSize: 4, MinPacketSize: 2, MaxPacketSize: 6})
+	assert.Len(t, gen.Sources(), 6)
+
+	gen = newTestGenerator(GeneratorConfig{PacketSize: 4})
+	assert.Len(t, gen.Sources(), 4)
+}
+
+func TestGeneratorVariesPacketSize(t *testing.T) {
+	t.Log("Шаг 1: размер пакета выбирается в диапазоне [2, 5]")
+	gen := newTestGenerator(GeneratorConfig{PacketSize: 10, MinPacketSize: 2, MaxPacketSize: 5, RandSource: rand.NewSource(9)})
+
+	sizes := make(map[int]int)
+	for i := 0; i < 400; i++ {
+		sizes[len(gen.generatePacket().Measurements)]++
+	}
+
+	t.Log("Шаг 2: встречаются все размеры диапазона и никакие другие")
+	assert.Len(t, sizes, 4)
+	for size := 2; size <= 5; size++ {
+		assert.Greater(t, sizes[size], 50, "size %d", size)
+	}
+
+	t.Log("Шаг 3: минимум больше максимума приводится к максимуму")
+	gen = newTestGenerator(GeneratorConfig{MinPacketSize: 7, MaxPacketSize: 3})
+	assert.Len(t, gen.generatePacket().Measurements, 3)
+}
+
+func TestGeneratorJittersMeasurementTimestamps(t *testing.T) {
+	t.Log("Шаг 1: метки времени измерений распределены в окне джиттера")
+	jitter := 500 * time.Millisecond
+	gen := newTestGenerator(GeneratorConfig{PacketSize: 64, TimestampJitter: jitter, RandSource: rand.NewSource(5)})
+
+	before := time.Now().UTC()
+	packet := gen.generatePacket()
+	after := time.Now().UTC()
+
+	distinct := make(map[time.Time]struct{})
+	for _, m := range packet.Measurements {
+		assert.False(t, m.Timestamp.Before(before.Add(-jitter)))
+		assert.False(t, m.Timestamp.After(after))
+		distinct[m.Timestamp] = struct{}{}
+	}
+	assert.Greater(t, len(distinct), 32)
+
+	t.Log("Шаг 2: без джиттера все измерения пакета получают одну метку")
+	gen = newTestGenerator(GeneratorConfig{PacketSize: 8})
+	packet = gen.generatePacket()
+	for _, m := range packet.Measurements {
+		assert.Equal(t, packet.Measurements[0].Timestamp, m.Timestamp)
+	}
+}
+
 func TestGeneratorLog(t *testing.T) {
 	logger := &stubLogger{}
 	gen := NewGenerator(GeneratorConfig{}, logger)
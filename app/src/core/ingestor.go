@@ -0,0 +1,138 @@
+package core
+
+import (
+	"context"
+	"sync"
+
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/infra"
+)
+
+// Ingestor owns the packet channel consumed by the WorkerPool. External producers submit
+// packets through Ingest, which never blocks, while the generator is attached with Pipe.
+type Ingestor struct {
+	mu      sync.RWMutex
+	packets chan domain.DataPacket
+	closed  bool
+	logger  Logger
+}
+
+func NewIngestor(bufferSize int, logger Logger) *Ingestor {
+	if bufferSize <= 0 {
+		bufferSize = 100
+	}
+	return &Ingestor{packets: make(chan domain.DataPacket, bufferSize), logger: logger}
+}
+
+// Packets returns the channel the WorkerPool should consume. It is closed by Close.
+func (i *Ingestor) Packets() <-chan domain.DataPacket {
+	return i.packets
+}
+
+// Ingest queues packets in order and stops at the first one that does not fit into the buffer,
+// returning domain.ErrBackpressure together with the number of packets already queued.
+func (i *Ingestor) Ingest(ctx context.Context, packets []domain.DataPacket) (int, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if i.closed {
+		return 0, domain.ErrIngestClosed
+	}
+
+	for n, packet := range packets {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		select {
+		case i.packets <- packet:
+			infra.IncIngestedPackets()
+		default:
+			infra.IncIngestRejected()
+			i.log(ctx, "ingestor: очередь заполнена, принято %d из %d пакетов", n, len(packets))
+			return n, domain.ErrBackpressure
+		}
+	}
+	return len(packets), nil
+}
+
+// Enqueue waits until the packet fits into the buffer. It returns ctx.Err() when ctx ends first
+// and domain.ErrIngestClosed once the ingestor is closed.
+func (i *Ingestor) Enqueue(ctx context.Context, packet domain.DataPacket) error {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if i.closed {
+		return domain.ErrIngestClosed
+	}
+
+	select {
+	case i.packets <- packet:
+		infra.IncIngestedPackets()
+		return nil
+	default:
+	}
+
+	select {
+	case <-ctx.Done():
+		infra.IncIngestRejected()
+		return ctx.Err()
+	case i.packets <- packet:
+		infra.IncIngestedPackets()
+		return nil
+	}
+}
+
+// Pipe forwards packets from in until it is closed or ctx is cancelled. Unlike Ingest it waits
+// for free space, so the generator keeps its blocking semantics.
+func (i *Ingestor) Pipe(ctx context.Context, in <-chan domain.DataPacket) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case packet, ok := <-in:
+			if !ok {
+				return
+			}
+			if !i.send(ctx, packet) {
+				return
+			}
+		}
+	}
+}
+
+func (i *Ingestor) send(ctx context.Context, packet domain.DataPacket) bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if i.closed {
+		return false
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case i.packets <- packet:
+		return true
+	}
+}
+
+// Close rejects further packets and closes the channel returned by Packets. Pending Pipe calls
+// must be released by cancelling their context first.
+func (i *Ingestor) Close() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.closed {
+		return
+	}
+	i.closed = true
+	close(i.packets)
+}
+
+func (i *Ingestor) log(ctx context.Context, format string, v ...any) {
+	if i.logger != nil {
+		i.logger.Printf(ctx, format, v...)
+	}
+}
+
+var _ domain.PacketIngestor = (*Ingestor)(nil)
@@ -0,0 +1,122 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"aggregator-service/app/src/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ingestPackets(ids ...string) []domain.DataPacket {
+	packets := make([]domain.DataPacket, len(ids))
+	for i, id := range ids {
+		packets[i] = domain.DataPacket{ID: id, Measurements: []domain.Measurement{{PacketID: id, SourceID: "s1", Value: 1}}}
+	}
+	return packets
+}
+
+func TestNewIngestorAppliesDefaultBuffer(t *testing.T) {
+	ingestor := NewIngestor(0, &stubLogger{})
+	assert.Equal(t, 100, cap(ingestor.packets))
+}
+
+func TestIngestorIngestQueuesPackets(t *testing.T) {
+	ingestor := NewIngestor(2, &stubLogger{})
+
+	accepted, err := ingestor.Ingest(context.Background(), ingestPackets("p1", "p2"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, accepted)
+	assert.Equal(t, "p1", (<-ingestor.Packets()).ID)
+	assert.Equal(t, "p2", (<-ingestor.Packets()).ID)
+}
+
+func TestIngestorIngestReportsBackpressure(t *testing.T) {
+	logger := &stubLogger{}
+	ingestor := NewIngestor(1, logger)
+
+	accepted, err := ingestor.Ingest(context.Background(), ingestPackets("p1", "p2", "p3"))
+	assert.ErrorIs(t, err, domain.ErrBackpressure)
+	assert.Equal(t, 1, accepted)
+	assert.Len(t, ingestor.Packets(), 1)
+	assert.Contains(t, logger.messages()[0], "принято 1 из 3")
+}
+
+func TestIngestorIngestAfterClose(t *testing.T) {
+	ingestor := NewIngestor(1, &stubLogger{})
+	ingestor.Close()
+	ingestor.Close()
+
+	_, err := ingestor.Ingest(context.Background(), ingestPackets("p1"))
+	assert.ErrorIs(t, err, domain.ErrIngestClosed)
+
+	_, ok := <-ingestor.Packets()
+	assert.False(t, ok)
+}
+
+func TestIngestorIngestRespectsContext(t *testing.T) {
+	ingestor := NewIngestor(1, &stubLogger{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	accepted, err := ingestor.Ingest(ctx, ingestPackets("p1"))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, accepted)
+}
+
+func TestIngestorPipeForwardsUntilInputClosed(t *testing.T) {
+	ingestor := NewIngestor(2, &stubLogger{})
+	in := make(chan domain.DataPacket, 2)
+	for _, packet := range ingestPackets("p1", "p2") {
+		in <- packet
+	}
+	close(in)
+
+	ingestor.Pipe(context.Background(), in)
+
+	assert.Equal(t, "p1", (<-ingestor.Packets()).ID)
+	assert.Equal(t, "p2", (<-ingestor.Packets()).ID)
+}
+
+func TestIngestorPipeStopsOnContextCancel(t *testing.T) {
+	ingestor := NewIngestor(1, &stubLogger{})
+	in := make(chan domain.DataPacket, 2)
+	for _, packet := range ingestPackets("p1", "p2") {
+		in <- packet
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		ingestor.Pipe(ctx, in)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return len(ingestor.Packets()) == 1 }, time.Second, 5*time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("pipe did not stop after cancel")
+	}
+}
+
+func TestIngestorEnqueueWaitsForSpace(t *testing.T) {
+	ingestor := NewIngestor(1, &stubLogger{})
+	require.NoError(t, ingestor.Enqueue(context.Background(), ingestPackets("p1")[0]))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, ingestor.Enqueue(ctx, ingestPackets("p2")[0]), context.DeadlineExceeded)
+
+	go func() { <-ingestor.Packets() }()
+	assert.NoError(t, ingestor.Enqueue(context.Background(), ingestPackets("p3")[0]))
+	assert.Equal(t, "p3", (<-ingestor.Packets()).ID)
+
+	ingestor.Close()
+	assert.ErrorIs(t, ingestor.Enqueue(context.Background(), ingestPackets("p4")[0]), domain.ErrIngestClosed)
+}
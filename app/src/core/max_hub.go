@@ -0,0 +1,124 @@
+package core
+
+import (
+	"context"
+	"sync"
+
+	"aggregator-service/app/src/domain"
+)
+
+// MaxHub fans persisted packet maxima out to in-process subscribers. Every subscriber owns a
+// bounded buffer; a subscriber whose buffer is full when a new maximum arrives is evicted so a
+// slow consumer never stalls the publisher.
+type MaxHub struct {
+	mu         sync.Mutex
+	subs       map[*maxSubscription]struct{}
+	closed     bool
+	bufferSize int
+	logger     Logger
+}
+
+func NewMaxHub(bufferSize int, logger Logger) *MaxHub {
+	if bufferSize <= 0 {
+		bufferSize = 64
+	}
+	return &MaxHub{subs: make(map[*maxSubscription]struct{}), bufferSize: bufferSize, logger: logger}
+}
+
+func (h *MaxHub) Subscribe(filter domain.MaxFilter) domain.MaxSubscription {
+	sub := &maxSubscription{hub: h, filter: filter, updates: make(chan domain.PacketMax, h.bufferSize)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(sub.updates)
+		return sub
+	}
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+// Close ends every subscription and makes later ones start closed, so long-lived streams finish
+// during shutdown.
+func (h *MaxHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subs {
+		h.removeLocked(sub, nil)
+	}
+}
+
+// Publish delivers packetMax to every matching subscriber without blocking.
+func (h *MaxHub) Publish(packetMax domain.PacketMax) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		if !sub.filter.Match(packetMax) {
+			continue
+		}
+		select {
+		case sub.updates <- packetMax:
+		default:
+			h.removeLocked(sub, domain.ErrSlowConsumer)
+			h.log("max hub: подписчик отключён, буфер %d заполнен", h.bufferSize)
+		}
+	}
+}
+
+// Subscribers returns the number of active subscriptions.
+func (h *MaxHub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+func (h *MaxHub) remove(sub *maxSubscription, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(sub, err)
+}
+
+func (h *MaxHub) removeLocked(sub *maxSubscription, err error) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	sub.err = err
+	close(sub.updates)
+}
+
+func (h *MaxHub) log(format string, v ...any) {
+	if h.logger != nil {
+		h.logger.Printf(context.Background(), format, v...)
+	}
+}
+
+type maxSubscription struct {
+	hub     *MaxHub
+	filter  domain.MaxFilter
+	updates chan domain.PacketMax
+	err     error
+}
+
+func (s *maxSubscription) Updates() <-chan domain.PacketMax {
+	return s.updates
+}
+
+// Err reports domain.ErrSlowConsumer once the subscription has been evicted.
+func (s *maxSubscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.err
+}
+
+func (s *maxSubscription) Close() {
+	s.hub.remove(s, nil)
+}
+
+var (
+	_ domain.PacketMaxPublisher  = (*MaxHub)(nil)
+	_ domain.PacketMaxSubscriber = (*MaxHub)(nil)
+)
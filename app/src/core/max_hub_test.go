@@ -0,0 +1,71 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"aggregator-service/app/src/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxHubDeliversMatchingMaxima(t *testing.T) {
+	hub := NewMaxHub(4, &stubLogger{})
+	now := time.Now().UTC()
+	all := hub.Subscribe(domain.MaxFilter{})
+	high := hub.Subscribe(domain.MaxFilter{MinValue: 10, HasMinValue: true})
+	recent := hub.Subscribe(domain.MaxFilter{Since: now})
+	defer all.Close()
+	defer high.Close()
+	defer recent.Close()
+
+	hub.Publish(domain.PacketMax{PacketID: "p1", Value: 5, Timestamp: now.Add(-time.Minute)})
+	hub.Publish(domain.PacketMax{PacketID: "p2", Value: 20, Timestamp: now})
+
+	assert.Len(t, all.Updates(), 2)
+	require.Len(t, high.Updates(), 1)
+	assert.Equal(t, "p2", (<-high.Updates()).PacketID)
+	require.Len(t, recent.Updates(), 1)
+	assert.Equal(t, "p2", (<-recent.Updates()).PacketID)
+}
+
+func TestMaxHubEvictsSlowConsumer(t *testing.T) {
+	logger := &stubLogger{}
+	hub := NewMaxHub(1, logger)
+	slow := hub.Subscribe(domain.MaxFilter{})
+	fast := hub.Subscribe(domain.MaxFilter{})
+
+	hub.Publish(domain.PacketMax{PacketID: "p1"})
+	<-fast.Updates()
+	hub.Publish(domain.PacketMax{PacketID: "p2"})
+
+	assert.Equal(t, "p1", (<-slow.Updates()).PacketID)
+	_, ok := <-slow.Updates()
+	assert.False(t, ok)
+	assert.ErrorIs(t, slow.Err(), domain.ErrSlowConsumer)
+	assert.Equal(t, 1, hub.Subscribers())
+	assert.Contains(t, logger.messages()[0], "подписчик отключён")
+
+	assert.Equal(t, "p2", (<-fast.Updates()).PacketID)
+	assert.NoError(t, fast.Err())
+}
+
+func TestMaxHubCloseEndsSubscriptions(t *testing.T) {
+	hub := NewMaxHub(0, nil)
+	sub := hub.Subscribe(domain.MaxFilter{})
+	sub.Close()
+	sub.Close()
+	assert.Zero(t, hub.Subscribers())
+
+	active := hub.Subscribe(domain.MaxFilter{})
+	hub.Close()
+	_, ok := <-active.Updates()
+	assert.False(t, ok)
+	assert.NoError(t, active.Err())
+
+	late := hub.Subscribe(domain.MaxFilter{})
+	_, ok = <-late.Updates()
+	assert.False(t, ok)
+	assert.NotPanics(t, func() { hub.Publish(domain.PacketMax{PacketID: "p1"}) })
+}
@@ -0,0 +1,384 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/infra"
+	"aggregator-service/app/src/shared/constants"
+)
+
+const (
+	ReplayFormatJSONL = "jsonl"
+	ReplayFormatCSV   = "csv"
+)
+
+// ReplayConfig describes a recording to replay. JSONL files hold one packet per line in the
+// POST /packets format; CSV files hold one measurement per row as
+// packet_id,source_id,value,timestamp with the rows of a packet kept together and an optional
+// header line.
+type ReplayConfig struct {
+	Path string
+	// Format is ReplayFormatJSONL or ReplayFormatCSV; empty means detect it from the file extension.
+	Format string
+	// Speed scales the recorded gaps between packets: 2 replays twice as fast. Zero means 1.
+	Speed float64
+	// Loop restarts the recording at EOF instead of stopping. Packets of later passes get new ids
+	// so they are stored next to, not over, the previous pass.
+	Loop bool
+	// RebaseTimestamps shifts measurement timestamps to the moment a packet is sent, keeping the
+	// offsets between measurements of the packet.
+	RebaseTimestamps bool
+}
+
+// ReplayGenerator is a domain.PacketGenerator that sends recorded packets with their original
+// timing instead of synthesizing new ones.
+type ReplayGenerator struct {
+	cfg    ReplayConfig
+	logger Logger
+	now    func() time.Time
+}
+
+func NewReplayGenerator(cfg ReplayConfig, logger Logger) (*ReplayGenerator, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("replay: file path is required")
+	}
+	if cfg.Speed < 0 {
+		return nil, errors.New("replay: speed must not be negative")
+	}
+	if cfg.Speed == 0 {
+		cfg.Speed = 1
+	}
+
+	format, err := replayFormat(cfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Format = format
+
+	if _, err := os.Stat(cfg.Path); err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+
+	return &ReplayGenerator{cfg: cfg, logger: logger, now: func() time.Time { return time.Now().UTC() }}, nil
+}
+
+func replayFormat(cfg ReplayConfig) (string, error) {
+	format := strings.ToLower(strings.TrimSpace(cfg.Format))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(cfg.Path)), ".")
+		if format == "json" || format == "ndjson" {
+			format = ReplayFormatJSONL
+		}
+	}
+	switch format {
+	case ReplayFormatJSONL, ReplayFormatCSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("replay: unsupported format %q", format)
+	}
+}
+
+func (g *ReplayGenerator) Run(ctx context.Context, out chan<- domain.DataPacket) {
+	defer close(out)
+
+	for pass := 0; ; pass++ {
+		sent, ok := g.replayFile(ctx, out, pass)
+		if !ok {
+			return
+		}
+		if !g.cfg.Loop {
+			g.log(ctx, "replay: файл %s воспроизведён, отправлено пакетов: %d", g.cfg.Path, sent)
+			return
+		}
+		if sent == 0 {
+			g.log(ctx, "replay: в файле %s нет пакетов, воспроизведение остановлено", g.cfg.Path)
+			return
+		}
+	}
+}
+
+// replayFile sends every packet of one pass and reports whether replaying may continue.
+func (g *ReplayGenerator) replayFile(ctx context.Context, out chan<- domain.DataPacket, pass int) (int, bool) {
+	file, err := os.Open(g.cfg.Path)
+	if err != nil {
+		g.log(ctx, "replay: не удалось открыть файл %s: %v", g.cfg.Path, err)
+		return 0, false
+	}
+	defer file.Close()
+
+	reader := newPacketReader(g.cfg.Format, file)
+	var prev time.Time
+	sent := 0
+
+	for {
+		packet, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return sent, true
+		}
+		if err != nil {
+			var recErr *replayRecordError
+			if errors.As(err, &recErr) {
+				g.log(ctx, "replay: пропущена запись: %v", err)
+				continue
+			}
+			g.log(ctx, "replay: ошибка чтения файла %s: %v", g.cfg.Path, err)
+			return sent, false
+		}
+
+		recorded := packetTime(packet)
+		if !prev.IsZero() && recorded.After(prev) {
+			if !g.wait(ctx, time.Duration(float64(recorded.Sub(prev))/g.cfg.Speed)) {
+				return sent, false
+			}
+		}
+		prev = recorded
+
+		g.prepare(&packet, recorded, pass)
+		g.log(ctx, "generator: воспроизведён пакет id=%s", packet.ID)
+		infra.IncGeneratorPackets()
+
+		select {
+		case <-ctx.Done():
+			g.log(ctx, "replay: остановлен (context cancelled): %v", ctx.Err())
+			return sent, false
+		case out <- packet:
+			sent++
+		}
+	}
+}
+
+func (g *ReplayGenerator) prepare(packet *domain.DataPacket, recorded time.Time, pass int) {
+	if pass > 0 {
+		packet.ID = constants.GenerateUUID()
+	}
+	now := g.now()
+	for i := range packet.Measurements {
+		m := &packet.Measurements[i]
+		m.PacketID = packet.ID
+		if g.cfg.RebaseTimestamps {
+			m.Timestamp = now.Add(m.Timestamp.Sub(recorded))
+		}
+	}
+}
+
+func (g *ReplayGenerator) wait(ctx context.Context, delay time.Duration) bool {
+	if delay <= 0 {
+		return true
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		g.log(ctx, "replay: остановлен (context cancelled): %v", ctx.Err())
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (g *ReplayGenerator) log(ctx context.Context, format string, v ...any) {
+	if g.logger != nil {
+		g.logger.Printf(ctx, format, v...)
+	}
+}
+
+// packetTime is the earliest measurement timestamp, used as the moment the packet was recorded.
+func packetTime(packet domain.DataPacket) time.Time {
+	var earliest time.Time
+	for _, m := range packet.Measurements {
+		if earliest.IsZero() || m.Timestamp.Before(earliest) {
+			earliest = m.Timestamp
+		}
+	}
+	return earliest
+}
+
+// replayRecordError marks a malformed record that is skipped without aborting the replay.
+type replayRecordError struct {
+	line int
+	err  error
+}
+
+func (e *replayRecordError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.line, e.err)
+}
+
+func (e *replayRecordError) Unwrap() error {
+	return e.err
+}
+
+type packetReader interface {
+	Next() (domain.DataPacket, error)
+}
+
+func newPacketReader(format string, r io.Reader) packetReader {
+	if format == ReplayFormatCSV {
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		return &csvPacketReader{reader: reader}
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	return &jsonlPacketReader{scanner: scanner}
+}
+
+type replayMeasurement struct {
+	SourceID  string  `json:"source_id"`
+	Value     float64 `json:"value"`
+	Timestamp string  `json:"timestamp"`
+}
+
+type replayPacket struct {
+	ID           string              `json:"id"`
+	Measurements []replayMeasurement `json:"measurements"`
+}
+
+type jsonlPacketReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *jsonlPacketReader) Next() (domain.DataPacket, error) {
+	for r.scanner.Scan() {
+		r.line++
+		text := strings.TrimSpace(r.scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var record replayPacket
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			return domain.DataPacket{}, &replayRecordError{line: r.line, err: err}
+		}
+		packet, err := parseReplayPacket(record)
+		if err != nil {
+			return domain.DataPacket{}, &replayRecordError{line: r.line, err: err}
+		}
+		return packet, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return domain.DataPacket{}, err
+	}
+	return domain.DataPacket{}, io.EOF
+}
+
+type csvPacketReader struct {
+	reader  *csv.Reader
+	pending []string
+	line    int
+	started bool
+}
+
+func (r *csvPacketReader) Next() (domain.DataPacket, error) {
+	var record replayPacket
+	first := 0
+
+	for {
+		row := r.pending
+		r.pending = nil
+		if row == nil {
+			var err error
+			row, err = r.reader.Read()
+			if errors.Is(err, io.EOF) {
+				if record.ID == "" {
+					return domain.DataPacket{}, io.EOF
+				}
+				break
+			}
+			if err != nil {
+				var parseErr *csv.ParseError
+				if errors.As(err, &parseErr) {
+					return domain.DataPacket{}, &replayRecordError{line: parseErr.Line, err: parseErr.Err}
+				}
+				return domain.DataPacket{}, err
+			}
+			r.line++
+		}
+
+		if !r.started {
+			r.started = true
+			if len(row) > 0 && strings.EqualFold(strings.TrimSpace(row[0]), "packet_id") {
+				continue
+			}
+		}
+
+		if len(row) != 4 {
+			if record.ID != "" {
+				r.pending = row
+				break
+			}
+			return domain.DataPacket{}, &replayRecordError{line: r.line, err: fmt.Errorf("expected 4 fields, got %d", len(row))}
+		}
+
+		if record.ID != "" && row[0] != record.ID {
+			r.pending = row
+			break
+		}
+		if record.ID == "" {
+			record.ID = row[0]
+			first = r.line
+		}
+
+		value, err := strconv.ParseFloat(strings.TrimSpace(row[2]), 64)
+		if err != nil {
+			return domain.DataPacket{}, &replayRecordError{line: r.line, err: errors.New("invalid value")}
+		}
+		record.Measurements = append(record.Measurements, replayMeasurement{
+			SourceID:  row[1],
+			Value:     value,
+			Timestamp: strings.TrimSpace(row[3]),
+		})
+	}
+
+	packet, err := parseReplayPacket(record)
+	if err != nil {
+		return domain.DataPacket{}, &replayRecordError{line: first, err: err}
+	}
+	return packet, nil
+}
+
+func parseReplayPacket(record replayPacket) (domain.DataPacket, error) {
+	id, err := constants.ParseUUID(record.ID)
+	if err != nil {
+		return domain.DataPacket{}, errors.New("invalid id format")
+	}
+	if len(record.Measurements) == 0 {
+		return domain.DataPacket{}, errors.New("measurements must not be empty")
+	}
+
+	measurements := make([]domain.Measurement, len(record.Measurements))
+	for i, m := range record.Measurements {
+		sourceID, err := constants.ParseUUID(m.SourceID)
+		if err != nil {
+			return domain.DataPacket{}, fmt.Errorf("measurement %d: invalid source_id format", i)
+		}
+		timestamp, err := time.Parse(constants.TimeFormat, m.Timestamp)
+		if err != nil {
+			return domain.DataPacket{}, fmt.Errorf("measurement %d: invalid timestamp", i)
+		}
+		measurements[i] = domain.Measurement{
+			PacketID:  id,
+			SourceID:  sourceID,
+			Value:     m.Value,
+			Timestamp: timestamp.UTC(),
+		}
+	}
+
+	return domain.DataPacket{ID: id, Measurements: measurements}, nil
+}
+
+var _ domain.PacketGenerator = (*ReplayGenerator)(nil)
@@ -0,0 +1,225 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"aggregator-service/app/src/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	replayPacketA = "11111111-1111-4111-8111-111111111111"
+	replayPacketB = "22222222-2222-4222-8222-222222222222"
+	replaySourceA = "aaaaaaaa-aaaa-4aaa-8aaa-aaaaaaaaaaaa"
+	replaySourceB = "bbbbbbbb-bbbb-4bbb-8bbb-bbbbbbbbbbbb"
+)
+
+func writeReplayFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func collectReplay(t *testing.T, gen *ReplayGenerator, ctx context.Context, limit int) []domain.DataPacket {
+	t.Helper()
+	out := make(chan domain.DataPacket)
+	go gen.Run(ctx, out)
+
+	var packets []domain.DataPacket
+	for packet := range out {
+		packets = append(packets, packet)
+		if limit > 0 && len(packets) == limit {
+			break
+		}
+	}
+	return packets
+}
+
+func replayJSONL(gap time.Duration) string {
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	second := base.Add(gap)
+	return strings.Join([]string{
+		`{"id":"` + replayPacketA + `","measurements":[{"source_id":"` + replaySourceA + `","value":1.5,"timestamp":"` + base.Format(time.RFC3339Nano) + `"},{"source_id":"` + replaySourceB + `","value":2,"timestamp":"` + base.Add(time.Millisecond).Format(time.RFC3339Nano) + `"}]}`,
+		``,
+		`{"id":"` + replayPacketB + `","measurements":[{"source_id":"` + replaySourceA + `","value":7,"timestamp":"` + second.Format(time.RFC3339Nano) + `"}]}`,
+	}, "\n")
+}
+
+func TestNewReplayGeneratorValidatesConfig(t *testing.T) {
+	path := writeReplayFile(t, "packets.jsonl", "")
+
+	_, err := NewReplayGenerator(ReplayConfig{}, nil)
+	assert.Error(t, err)
+
+	_, err = NewReplayGenerator(ReplayConfig{Path: path, Speed: -1}, nil)
+	assert.Error(t, err)
+
+	_, err = NewReplayGenerator(ReplayConfig{Path: filepath.Join(t.TempDir(), "missing.jsonl")}, nil)
+	assert.Error(t, err)
+
+	_, err = NewReplayGenerator(ReplayConfig{Path: writeReplayFile(t, "packets.txt", "")}, nil)
+	assert.Error(t, err)
+
+	gen, err := NewReplayGenerator(ReplayConfig{Path: writeReplayFile(t, "packets.txt", ""), Format: "CSV"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ReplayFormatCSV, gen.cfg.Format)
+	assert.Equal(t, 1.0, gen.cfg.Speed)
+}
+
+func TestReplayGeneratorReplaysJSONLAndStopsAtEOF(t *testing.T) {
+	t.Log("Шаг 1: воспроизводим JSONL-запись из двух пакетов")
+	logger := &stubLogger{}
+	gen, err := NewReplayGenerator(ReplayConfig{Path: writeReplayFile(t, "packets.jsonl", replayJSONL(time.Second)), Speed: 1000}, logger)
+	require.NoError(t, err)
+
+	packets := collectReplay(t, gen, context.Background(), 0)
+
+	t.Log("Шаг 2: пакеты, значения и исходные метки времени сохранены")
+	require.Len(t, packets, 2)
+	assert.Equal(t, replayPacketA, packets[0].ID)
+	require.Len(t, packets[0].Measurements, 2)
+	assert.Equal(t, replaySourceB, packets[0].Measurements[1].SourceID)
+	assert.Equal(t, 2.0, packets[0].Measurements[1].Value)
+	assert.Equal(t, replayPacketA, packets[0].Measurements[1].PacketID)
+	assert.Equal(t, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), packets[0].Measurements[0].Timestamp)
+	assert.Equal(t, replayPacketB, packets[1].ID)
+
+	t.Log("Шаг 3: по достижении конца файла генератор закрывает канал и пишет итог")
+	assert.Contains(t, strings.Join(logger.messages(), "\n"), "отправлено пакетов: 2")
+}
+
+func TestReplayGeneratorHonoursTimingAndSpeed(t *testing.T) {
+	path := writeReplayFile(t, "packets.jsonl", replayJSONL(400*time.Millisecond))
+
+	t.Log("Шаг 1: при скорости 1 выдерживается записанный интервал")
+	gen, err := NewReplayGenerator(ReplayConfig{Path: path}, nil)
+	require.NoError(t, err)
+	start := time.Now()
+	collectReplay(t, gen, context.Background(), 0)
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+
+	t.Log("Шаг 2: при скорости 4 интервал сокращается в четыре раза")
+	gen, err = NewReplayGenerator(ReplayConfig{Path: path, Speed: 4}, nil)
+	require.NoError(t, err)
+	start = time.Now()
+	collectReplay(t, gen, context.Background(), 0)
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, elapsed, 100*time.Millisecond)
+	assert.Less(t, elapsed, 350*time.Millisecond)
+}
+
+func TestReplayGeneratorRebasesTimestamps(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	gen, err := NewReplayGenerator(ReplayConfig{
+		Path:             writeReplayFile(t, "packets.jsonl", replayJSONL(time.Millisecond)),
+		RebaseTimestamps: true,
+	}, nil)
+	require.NoError(t, err)
+	gen.now = func() time.Time { return now }
+
+	packets := collectReplay(t, gen, context.Background(), 0)
+
+	require.Len(t, packets, 2)
+	assert.Equal(t, now, packets[0].Measurements[0].Timestamp)
+	assert.Equal(t, now.Add(time.Millisecond), packets[0].Measurements[1].Timestamp)
+	assert.Equal(t, now, packets[1].Measurements[0].Timestamp)
+}
+
+func TestReplayGeneratorLoopsWithFreshIDs(t *testing.T) {
+	t.Log("Шаг 1: включаем зацикливание и читаем больше пакетов, чем в файле")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	gen, err := NewReplayGenerator(ReplayConfig{
+		Path:  writeReplayFile(t, "packets.jsonl", replayJSONL(time.Millisecond)),
+		Speed: 1000,
+		Loop:  true,
+	}, nil)
+	require.NoError(t, err)
+
+	packets := collectReplay(t, gen, ctx, 5)
+
+	t.Log("Шаг 2: повторные проходы получают новые идентификаторы пакетов")
+	require.Len(t, packets, 5)
+	assert.Equal(t, replayPacketA, packets[0].ID)
+	assert.NotEqual(t, replayPacketA, packets[2].ID)
+	assert.Equal(t, packets[2].ID, packets[2].Measurements[0].PacketID)
+	assert.Equal(t, packets[0].Measurements[0].Value, packets[2].Measurements[0].Value)
+	assert.NotEqual(t, packets[2].ID, packets[4].ID)
+}
+
+func TestReplayGeneratorReadsCSV(t *testing.T) {
+	content := strings.Join([]string{
+		"packet_id,source_id,value,timestamp",
+		replayPacketA + "," + replaySourceA + ",1.5,2024-03-01T12:00:00Z",
+		replayPacketA + "," + replaySourceB + ",3,2024-03-01T12:00:00.5Z",
+		"broken,row",
+		replayPacketB + "," + replaySourceA + ",not-a-number,2024-03-01T12:00:01Z",
+		replayPacketB + "," + replaySourceB + ",4,2024-03-01T12:00:01Z",
+	}, "\n")
+	logger := &stubLogger{}
+	gen, err := NewReplayGenerator(ReplayConfig{Path: writeReplayFile(t, "packets.csv", content), Speed: 1000}, logger)
+	require.NoError(t, err)
+
+	packets := collectReplay(t, gen, context.Background(), 0)
+
+	t.Log("строки одного пакета объединяются, некорректные строки пропускаются с записью в лог")
+	require.Len(t, packets, 2)
+	assert.Equal(t, replayPacketA, packets[0].ID)
+	require.Len(t, packets[0].Measurements, 2)
+	assert.Equal(t, 3.0, packets[0].Measurements[1].Value)
+	assert.Equal(t, replayPacketB, packets[1].ID)
+	require.Len(t, packets[1].Measurements, 1)
+	assert.Equal(t, 4.0, packets[1].Measurements[0].Value)
+
+	skipped := 0
+	for _, msg := range logger.messages() {
+		if strings.Contains(msg, "пропущена запись") {
+			skipped++
+		}
+	}
+	assert.Equal(t, 2, skipped)
+}
+
+func TestReplayGeneratorSkipsInvalidJSONLines(t *testing.T) {
+	content := "not json\n" + `{"id":"bad","measurements":[]}` + "\n" + replayJSONL(time.Millisecond)
+	logger := &stubLogger{}
+	gen, err := NewReplayGenerator(ReplayConfig{Path: writeReplayFile(t, "packets.jsonl", content), Speed: 1000}, logger)
+	require.NoError(t, err)
+
+	packets := collectReplay(t, gen, context.Background(), 0)
+
+	assert.Len(t, packets, 2)
+	assert.Contains(t, strings.Join(logger.messages(), "\n"), "line 2: invalid id format")
+}
+
+func TestReplayGeneratorStopsOnContextCancel(t *testing.T) {
+	gen, err := NewReplayGenerator(ReplayConfig{Path: writeReplayFile(t, "packets.jsonl", replayJSONL(time.Hour))}, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan domain.DataPacket, 2)
+	done := make(chan struct{})
+	go func() {
+		gen.Run(ctx, out)
+		close(done)
+	}()
+
+	<-out
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("генератор не остановился после отмены контекста")
+	}
+	_, open := <-out
+	assert.False(t, open)
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/infra"
+)
+
+// DefaultRetentionInterval is how often RetentionJob.Run purges when no interval is configured.
+const DefaultRetentionInterval = time.Hour
+
+type RetentionConfig struct {
+	// MaxAge is how long measurements are kept; rows older than now-MaxAge are purged.
+	MaxAge time.Duration
+	// Interval is the time between background runs.
+	Interval time.Duration
+	// DryRun only counts the expired rows and leaves them in place.
+	DryRun bool
+}
+
+// RetentionJob enforces the retention window over a RetentionPurger, either periodically from Run
+// or once from RunOnce.
+type RetentionJob struct {
+	purger domain.RetentionPurger
+	cfg    RetentionConfig
+	logger Logger
+	now    func() time.Time
+}
+
+func NewRetentionJob(purger domain.RetentionPurger, cfg RetentionConfig, logger Logger) *RetentionJob {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultRetentionInterval
+	}
+	return &RetentionJob{purger: purger, cfg: cfg, logger: logger, now: time.Now}
+}
+
+// RunOnce purges everything older than the retention window and records the outcome in metrics.
+func (j *RetentionJob) RunOnce(ctx context.Context) (domain.PurgeReport, error) {
+	if j.cfg.MaxAge <= 0 {
+		return domain.PurgeReport{}, errors.New("retention: max age must be positive")
+	}
+
+	cutoff := j.now().Add(-j.cfg.MaxAge)
+	started := time.Now()
+	report, err := j.purger.PurgeBefore(ctx, cutoff, j.cfg.DryRun)
+	if err != nil {
+		if j.cfg.DryRun {
+			infra.RecordRetentionFailure(0)
+		} else {
+			infra.RecordRetentionFailure(report.Rows)
+		}
+		j.log(ctx, "retention: очистка до %s прервана после удаления %d строк: %v", cutoff.UTC().Format(time.RFC3339), report.Rows, err)
+		return report, err
+	}
+
+	infra.RecordRetentionRun(report.Rows, report.DryRun, j.now())
+	if report.DryRun {
+		j.log(ctx, "retention: пробный запуск, к удалению до %s: %d строк", report.Cutoff.Format(time.RFC3339), report.Rows)
+	} else {
+		j.log(ctx, "retention: удалено %d строк до %s за %d запросов (%s)", report.Rows, report.Cutoff.Format(time.RFC3339), report.Chunks, time.Since(started).Round(time.Millisecond))
+	}
+	return report, nil
+}
+
+// Run purges once at start and then every Interval until ctx is cancelled. Failed runs are
+// logged and retried on the next tick.
+func (j *RetentionJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := j.RunOnce(ctx); err != nil && ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *RetentionJob) log(ctx context.Context, format string, v ...any) {
+	if j.logger != nil {
+		j.logger.Printf(ctx, format, v...)
+	}
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/infra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubPurger struct {
+	mu      sync.Mutex
+	cutoffs []time.Time
+	dryRuns []bool
+	report  domain.PurgeReport
+	err     error
+}
+
+func (p *stubPurger) PurgeBefore(_ context.Context, cutoff time.Time, dryRun bool) (domain.PurgeReport, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cutoffs = append(p.cutoffs, cutoff)
+	p.dryRuns = append(p.dryRuns, dryRun)
+	report := p.report
+	report.Cutoff = cutoff
+	report.DryRun = dryRun
+	return report, p.err
+}
+
+func (p *stubPurger) calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.cutoffs)
+}
+
+func TestRetentionJobRunOncePurgesExpiredRows(t *testing.T) {
+	purger := &stubPurger{report: domain.PurgeReport{Rows: 42, Chunks: 3}}
+	logger := &stubLogger{}
+	job := NewRetentionJob(purger, RetentionConfig{MaxAge: 48 * time.Hour}, logger)
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	job.now = func() time.Time { return now }
+	purged := infra.RetentionPurgedRowsTotal.Value()
+	runs := infra.RetentionRunsTotal.Value()
+
+	t.Log("Шаг 1: граница отстоит от текущего времени на окно хранения")
+	report, err := job.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-48*time.Hour), purger.cutoffs[0])
+	assert.False(t, purger.dryRuns[0])
+	assert.Equal(t, int64(42), report.Rows)
+
+	t.Log("Шаг 2: удалённые строки попадают в метрики и журнал")
+	assert.Equal(t, purged+42, infra.RetentionPurgedRowsTotal.Value())
+	assert.Equal(t, runs+1, infra.RetentionRunsTotal.Value())
+	assert.Equal(t, float64(now.Unix()), infra.RetentionLastRunTimestamp.Value())
+	require.Len(t, logger.messages(), 1)
+	assert.Contains(t, logger.messages()[0], "удалено 42 строк")
+}
+
+func TestRetentionJobDryRunOnlyReports(t *testing.T) {
+	purger := &stubPurger{report: domain.PurgeReport{Rows: 7}}
+	job := NewRetentionJob(purger, RetentionConfig{MaxAge: time.Hour, DryRun: true}, nil)
+	purged := infra.RetentionPurgedRowsTotal.Value()
+
+	report, err := job.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.True(t, purger.dryRuns[0])
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 7.0, infra.RetentionDryRunRows.Value())
+	assert.Equal(t, purged, infra.RetentionPurgedRowsTotal.Value())
+}
+
+func TestRetentionJobCountsFailures(t *testing.T) {
+	purger := &stubPurger{report: domain.PurgeReport{Rows: 5}, err: errors.New("lock timeout")}
+	job := NewRetentionJob(purger, RetentionConfig{MaxAge: time.Hour}, nil)
+	failures := infra.RetentionFailuresTotal.Value()
+	purged := infra.RetentionPurgedRowsTotal.Value()
+
+	_, err := job.RunOnce(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, failures+1, infra.RetentionFailuresTotal.Value())
+	assert.Equal(t, purged+5, infra.RetentionPurgedRowsTotal.Value())
+}
+
+func TestRetentionJobRequiresWindow(t *testing.T) {
+	purger := &stubPurger{}
+	_, err := NewRetentionJob(purger, RetentionConfig{}, nil).RunOnce(context.Background())
+	require.Error(t, err)
+	assert.Zero(t, purger.calls())
+}
+
+func TestRetentionJobRunRepeatsUntilCancelled(t *testing.T) {
+	purger := &stubPurger{}
+	job := NewRetentionJob(purger, RetentionConfig{MaxAge: time.Hour, Interval: time.Millisecond}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	t.Log("Шаг 1: первая очистка выполняется сразу, следующие по таймеру")
+	go func() {
+		job.Run(ctx)
+		close(done)
+	}()
+	require.Eventually(t, func() bool { return purger.calls() >= 3 }, time.Second, time.Millisecond)
+
+	t.Log("Шаг 2: отмена контекста останавливает задачу")
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("retention job did not stop after cancellation")
+	}
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// sourceNamespace seeds the name-based source IDs, so a source keeps its ID across restarts.
+var sourceNamespace = [16]byte{0x6f, 0x2c, 0x41, 0x8e, 0x93, 0x5b, 0x4d, 0x10, 0xa2, 0x77, 0x0e, 0x3f, 0xc4, 0x58, 0xd1, 0x26}
+
+// Source is a named measurement source with an ID derived from its name.
+type Source struct {
+	Name string
+	ID   string
+}
+
+// SourcePool is the fixed set of sources the generator draws measurements from.
+type SourcePool struct {
+	sources []Source
+}
+
+// NewSourcePool builds a pool from names; with no names it creates count sources named
+// sensor-001, sensor-002, and so on. Blank and duplicate names are dropped.
+func NewSourcePool(names []string, count int) *SourcePool {
+	if len(names) == 0 {
+		if count <= 0 {
+			count = 1
+		}
+		names = make([]string, count)
+		for i := range names {
+			names[i] = fmt.Sprintf("sensor-%03d", i+1)
+		}
+	}
+
+	seen := make(map[string]struct{}, len(names))
+	sources := make([]Source, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		sources = append(sources, Source{Name: name, ID: SourceID(name)})
+	}
+	if len(sources) == 0 {
+		sources = append(sources, Source{Name: "sensor-001", ID: SourceID("sensor-001")})
+	}
+	return &SourcePool{sources: sources}
+}
+
+// SourceID returns the name-based (version 5) UUID of a source name.
+func SourceID(name string) string {
+	h := sha1.New()
+	h.Write(sourceNamespace[:])
+	h.Write([]byte(name))
+	b := h.Sum(nil)
+
+	b[6] = (b[6] & 0x0f) | 0x50
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+func (p *SourcePool) Sources() []Source {
+	return append([]Source(nil), p.sources...)
+}
+
+func (p *SourcePool) Len() int {
+	return len(p.sources)
+}
+
+// Pick returns n sources. They are distinct while n does not exceed the pool size; larger
+// packets cycle through the pool again.
+func (p *SourcePool) Pick(rnd *rand.Rand, n int) []Source {
+	picked := make([]Source, 0, n)
+	for len(picked) < n {
+		order := rnd.Perm(len(p.sources))
+		for _, idx := range order {
+			if len(picked) == n {
+				break
+			}
+			picked = append(picked, p.sources[idx])
+		}
+	}
+	return picked
+}
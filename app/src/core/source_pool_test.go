@@ -0,0 +1,72 @@
+package core
+
+import (
+	"math/rand"
+	"testing"
+
+	"aggregator-service/app/src/shared/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceIDIsStableAndValid(t *testing.T) {
+	t.Log("Шаг 1: идентификатор источника зависит только от имени")
+	id := SourceID("boiler")
+	assert.Equal(t, id, SourceID("boiler"))
+	assert.NotEqual(t, id, SourceID("pump"))
+
+	t.Log("Шаг 2: идентификатор — корректный UUID версии 5")
+	parsed, err := constants.ParseUUID(id)
+	require.NoError(t, err)
+	assert.Equal(t, id, parsed)
+	assert.Equal(t, byte('5'), id[14])
+}
+
+func TestNewSourcePoolFromNames(t *testing.T) {
+	pool := NewSourcePool([]string{"boiler", " pump ", "", "boiler", "fan"}, 10)
+
+	sources := pool.Sources()
+	require.Len(t, sources, 3)
+	assert.Equal(t, "boiler", sources[0].Name)
+	assert.Equal(t, "pump", sources[1].Name)
+	assert.Equal(t, SourceID("pump"), sources[1].ID)
+	assert.Equal(t, "fan", sources[2].Name)
+}
+
+func TestNewSourcePoolFromCount(t *testing.T) {
+	pool := NewSourcePool(nil, 3)
+
+	sources := pool.Sources()
+	require.Len(t, sources, 3)
+	assert.Equal(t, "sensor-001", sources[0].Name)
+	assert.Equal(t, "sensor-003", sources[2].Name)
+
+	assert.Equal(t, 1, NewSourcePool(nil, 0).Len())
+}
+
+func TestSourcePoolPick(t *testing.T) {
+	pool := NewSourcePool(nil, 5)
+	rnd := rand.New(rand.NewSource(1))
+
+	t.Log("Шаг 1: пока размер пакета не превышает пул, источники не повторяются")
+	picked := pool.Pick(rnd, 5)
+	seen := make(map[string]struct{})
+	for _, source := range picked {
+		seen[source.ID] = struct{}{}
+	}
+	assert.Len(t, seen, 5)
+
+	t.Log("Шаг 2: больший пакет проходит по пулу повторно")
+	picked = pool.Pick(rnd, 12)
+	counts := make(map[string]int)
+	for _, source := range picked {
+		counts[source.ID]++
+	}
+	assert.Len(t, picked, 12)
+	assert.Len(t, counts, 5)
+	for _, count := range counts {
+		assert.GreaterOrEqual(t, count, 2)
+		assert.LessOrEqual(t, count, 3)
+	}
+}
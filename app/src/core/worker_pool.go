@@ -4,20 +4,50 @@ import (
 	"aggregator-service/app/src/domain"
 	"aggregator-service/app/src/infra"
 	"context"
+	"sort"
 	"sync"
 )
 
 type WorkerPool struct {
 	repo        domain.PacketMaxWriter
 	workerCount int
+	topK        int
 	logger      Logger
+
+	aggregates   domain.PacketAggregateWriter
+	aggregations []AggregationFunc
 }
 
 func NewWorkerPool(workerCount int, repo domain.PacketMaxWriter, logger Logger) *WorkerPool {
 	if workerCount < 0 {
 		workerCount = 0
 	}
-	return &WorkerPool{repo: repo, workerCount: workerCount, logger: logger}
+	return &WorkerPool{repo: repo, workerCount: workerCount, topK: 1, logger: logger}
+}
+
+// WithTopK makes the pool keep the k highest measurements of every packet, one per source,
+// instead of only the maximum. Values below 1 are treated as 1.
+func (p *WorkerPool) WithTopK(k int) *WorkerPool {
+	if k < 1 {
+		k = 1
+	}
+	p.topK = k
+	return p
+}
+
+// WithAggregations makes the pool compute the given functions for every packet and persist
+// their results through writer. The maximum is always stored via the PacketMaxWriter, so a
+// configured max function is ignored here.
+func (p *WorkerPool) WithAggregations(writer domain.PacketAggregateWriter, funcs ...AggregationFunc) *WorkerPool {
+	p.aggregates = writer
+	p.aggregations = p.aggregations[:0]
+	for _, fn := range funcs {
+		if fn == nil || fn.Name() == AggregationMax {
+			continue
+		}
+		p.aggregations = append(p.aggregations, fn)
+	}
+	return p
 }
 
 func (p *WorkerPool) Run(ctx context.Context, packets <-chan domain.DataPacket) {
@@ -60,12 +90,57 @@ func (p *WorkerPool) processPacket(ctx context.Context, packet domain.DataPacket
 		return
 	}
 
-	maxMeasurement, found := p.findMaxMeasurement(ctx, packet)
+	ranked, found := p.rankMeasurements(ctx, packet)
 	if !found {
 		return
 	}
 
-	p.storePacketMax(ctx, packet, maxMeasurement)
+	for i, m := range ranked {
+		p.storePacketMax(ctx, packet, m, i+1)
+	}
+	p.storeAggregates(ctx, packet)
+}
+
+// rankMeasurements returns up to topK measurements ordered by value, preferring later timestamps
+// on ties. Only the best measurement of every source is kept.
+func (p *WorkerPool) rankMeasurements(ctx context.Context, packet domain.DataPacket) ([]domain.Measurement, bool) {
+	if p.topK <= 1 {
+		maxMeasurement, found := p.findMaxMeasurement(ctx, packet)
+		if !found {
+			return nil, false
+		}
+		return []domain.Measurement{maxMeasurement}, true
+	}
+
+	bestBySource := make(map[string]int, len(packet.Measurements))
+	ranked := make([]domain.Measurement, 0, len(packet.Measurements))
+	for _, m := range packet.Measurements {
+		if ctx.Err() != nil {
+			p.log(ctx, "worker: aborting packet %s due to context: %v", packet.ID, ctx.Err())
+			return nil, false
+		}
+		if idx, ok := bestBySource[m.SourceID]; ok {
+			if outranks(m, ranked[idx]) {
+				ranked[idx] = m
+			}
+			continue
+		}
+		bestBySource[m.SourceID] = len(ranked)
+		ranked = append(ranked, m)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return outranks(ranked[i], ranked[j])
+	})
+
+	if len(ranked) > p.topK {
+		ranked = ranked[:p.topK]
+	}
+	return ranked, len(ranked) > 0
+}
+
+func outranks(a, b domain.Measurement) bool {
+	return a.Value > b.Value || (a.Value == b.Value && a.Timestamp.After(b.Timestamp))
 }
 
 func (p *WorkerPool) findMaxMeasurement(ctx context.Context, packet domain.DataPacket) (domain.Measurement, bool) {
@@ -78,8 +153,7 @@ func (p *WorkerPool) findMaxMeasurement(ctx context.Context, packet domain.DataP
 			p.log(ctx, "worker: aborting packet %s due to context: %v", packet.ID, ctx.Err())
 			return domain.Measurement{}, false
 		}
-		if !found || m.Value > maxMeasurement.Value ||
-			(m.Value == maxMeasurement.Value && m.Timestamp.After(maxMeasurement.Timestamp)) {
+		if !found || outranks(m, maxMeasurement) {
 			maxMeasurement = m
 			found = true
 		}
@@ -87,19 +161,48 @@ func (p *WorkerPool) findMaxMeasurement(ctx context.Context, packet domain.DataP
 	return maxMeasurement, found
 }
 
-func (p *WorkerPool) storePacketMax(ctx context.Context, packet domain.DataPacket, m domain.Measurement) {
+func (p *WorkerPool) storePacketMax(ctx context.Context, packet domain.DataPacket, m domain.Measurement, rank int) {
 	packetMax := domain.PacketMax{
 		PacketID:  m.PacketID,
 		SourceID:  m.SourceID,
 		Value:     m.Value,
 		Timestamp: m.Timestamp,
+		Rank:      rank,
 	}
 
 	if err := p.repo.Add(ctx, packetMax); err != nil {
-		p.log(ctx, "worker: failed to store packet=%s source=%s: %v", packet.ID, packetMax.SourceID, err)
+		p.log(ctx, "worker: failed to store packet=%s source=%s rank=%d: %v", packet.ID, packetMax.SourceID, rank, err)
+		return
+	}
+	p.log(ctx, "worker: stored packet=%s source=%s rank=%d", packet.ID, packetMax.SourceID, rank)
+}
+
+func (p *WorkerPool) storeAggregates(ctx context.Context, packet domain.DataPacket) {
+	if p.aggregates == nil {
 		return
 	}
-	p.log(ctx, "worker: stored packet=%s source=%s", packet.ID, packetMax.SourceID)
+
+	for _, fn := range p.aggregations {
+		if ctx.Err() != nil {
+			return
+		}
+
+		m, ok := fn.Aggregate(packet.Measurements)
+		if !ok {
+			continue
+		}
+
+		aggregate := domain.PacketAggregate{
+			PacketID:  packet.ID,
+			Function:  fn.Name(),
+			SourceID:  m.SourceID,
+			Value:     m.Value,
+			Timestamp: m.Timestamp,
+		}
+		if err := p.aggregates.AddAggregate(ctx, aggregate); err != nil {
+			p.log(ctx, "worker: failed to store %s aggregate packet=%s: %v", aggregate.Function, packet.ID, err)
+		}
+	}
 }
 
 func (p *WorkerPool) drainUntilClosed(ctx context.Context, packets <-chan domain.DataPacket) {
@@ -34,6 +34,28 @@ func (r *recordingRepo) calls() []domain.PacketMax {
 	return append([]domain.PacketMax(nil), r.records...)
 }
 
+type recordingAggregateRepo struct {
+	mu         sync.Mutex
+	aggregates []domain.PacketAggregate
+	err        error
+}
+
+func (r *recordingAggregateRepo) AddAggregate(_ context.Context, aggregate domain.PacketAggregate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.aggregates = append(r.aggregates, aggregate)
+	return nil
+}
+
+func (r *recordingAggregateRepo) calls() []domain.PacketAggregate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]domain.PacketAggregate(nil), r.aggregates...)
+}
+
 func newTestRepo() *recordingRepo {
 	return &recordingRepo{}
 }
@@ -123,6 +145,72 @@ func TestProcessPacketChoosesMaxMeasurement(t *testing.T) {
 	assert.Equal(t, packet.ID, calls[0].PacketID)
 }
 
+func TestProcessPacketStoresTopKMeasurements(t *testing.T) {
+	repo := newTestRepo()
+	pool := newTestPool(1, repo, &stubLogger{}).WithTopK(3)
+
+	now := time.Now().UTC()
+	packet := domain.DataPacket{ID: "packet", Measurements: []domain.Measurement{
+		{PacketID: "packet", SourceID: "s1", Value: 1, Timestamp: now},
+		{PacketID: "packet", SourceID: "s2", Value: 7, Timestamp: now},
+		{PacketID: "packet", SourceID: "s1", Value: 9, Timestamp: now},
+		{PacketID: "packet", SourceID: "s3", Value: 4, Timestamp: now},
+		{PacketID: "packet", SourceID: "s4", Value: 2, Timestamp: now},
+	}}
+
+	pool.processPacket(context.Background(), packet)
+
+	calls := repo.calls()
+	require.Len(t, calls, 3)
+	assert.Equal(t, domain.PacketMax{PacketID: "packet", SourceID: "s1", Value: 9, Timestamp: now, Rank: 1}, calls[0])
+	assert.Equal(t, "s2", calls[1].SourceID)
+	assert.Equal(t, 2, calls[1].Rank)
+	assert.Equal(t, "s3", calls[2].SourceID)
+	assert.Equal(t, 3, calls[2].Rank)
+}
+
+func TestWithTopKIgnoresNonPositive(t *testing.T) {
+	pool := newTestPool(1, newTestRepo(), &stubLogger{}).WithTopK(0)
+	assert.Equal(t, 1, pool.topK)
+}
+
+func TestProcessPacketStoresConfiguredAggregations(t *testing.T) {
+	repo := newTestRepo()
+	aggregates := &recordingAggregateRepo{}
+	funcs, err := DefaultAggregationRegistry().Resolve([]string{"max", "min", "count"})
+	require.NoError(t, err)
+	pool := newTestPool(1, repo, &stubLogger{}).WithAggregations(aggregates, funcs...)
+
+	now := time.Now().UTC()
+	packet := domain.DataPacket{ID: "packet", Measurements: []domain.Measurement{
+		{PacketID: "packet", SourceID: "s1", Value: 3, Timestamp: now},
+		{PacketID: "packet", SourceID: "s2", Value: 9, Timestamp: now},
+	}}
+
+	pool.processPacket(context.Background(), packet)
+
+	require.Len(t, repo.calls(), 1)
+	stored := aggregates.calls()
+	require.Len(t, stored, 2)
+	assert.Equal(t, domain.PacketAggregate{PacketID: "packet", Function: "min", SourceID: "s1", Value: 3, Timestamp: now}, stored[0])
+	assert.Equal(t, "count", stored[1].Function)
+	assert.Equal(t, 2.0, stored[1].Value)
+}
+
+func TestProcessPacketLogsOnAggregateError(t *testing.T) {
+	aggregates := &recordingAggregateRepo{err: errors.New("failure")}
+	logger := &stubLogger{}
+	funcs, err := DefaultAggregationRegistry().Resolve([]string{"sum"})
+	require.NoError(t, err)
+	pool := newTestPool(1, newTestRepo(), logger).WithAggregations(aggregates, funcs...)
+
+	packet := domain.DataPacket{ID: "packet", Measurements: []domain.Measurement{{PacketID: "packet", SourceID: "s1", Value: 1}}}
+	pool.processPacket(context.Background(), packet)
+
+	assert.Empty(t, aggregates.calls())
+	assert.Contains(t, logger.messages()[len(logger.messages())-1], "sum aggregate")
+}
+
 func TestProcessPacketSkipsEmptyMeasurements(t *testing.T) {
 	repo := newTestRepo()
 	pool := newTestPool(1, repo, &stubLogger{})
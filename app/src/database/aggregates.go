@@ -0,0 +1,177 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/shared/constants"
+)
+
+const (
+	upsertAggregateSQL = `
+INSERT INTO public.packet_aggregate (packet_id, function_name, source_id, value, ts)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (packet_id, function_name) DO UPDATE
+SET source_id = EXCLUDED.source_id,
+    value     = EXCLUDED.value,
+    ts        = EXCLUDED.ts
+`
+	selectAggregateByIDSQL = `
+SELECT packet_id::text, function_name, COALESCE(source_id::text, ''), value, ts
+FROM public.packet_aggregate
+WHERE packet_id = $1::uuid AND function_name = $2
+LIMIT 1
+`
+	selectAggregatesInRangeSQL = `
+SELECT packet_id::text, function_name, COALESCE(source_id::text, ''), value, ts
+FROM public.packet_aggregate
+WHERE function_name = $1 AND ts BETWEEN $2 AND $3
+ORDER BY ts ASC, packet_id ASC
+LIMIT $4
+`
+	selectAggregatesInRangeAfterSQL = `
+SELECT packet_id::text, function_name, COALESCE(source_id::text, ''), value, ts
+FROM public.packet_aggregate
+WHERE function_name = $1 AND ts BETWEEN $2 AND $3 AND (ts, packet_id) > ($4, $5::uuid)
+ORDER BY ts ASC, packet_id ASC
+LIMIT $6
+`
+)
+
+// AddAggregate upserts the result of an aggregation function for a packet. Unlike Add it writes
+// synchronously, since aggregates are only produced once per packet.
+func (r *Repository) AddAggregate(ctx context.Context, aggregate domain.PacketAggregate) error {
+	if err := validatePacketAggregate(aggregate); err != nil {
+		return err
+	}
+
+	var sourceID any
+	if aggregate.SourceID != "" {
+		sourceID = aggregate.SourceID
+	}
+
+	_, err := r.runner.Exec(ctx, r.dsn, r.password, upsertAggregateSQL,
+		aggregate.PacketID, aggregate.Function, sourceID, aggregate.Value, aggregate.Timestamp.UTC())
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Printf(ctx, "postgres repository: upsert aggregate failed packet=%s function=%s: %v", aggregate.PacketID, aggregate.Function, err)
+		}
+		return fmt.Errorf("postgres repository: upsert aggregate: %w", err)
+	}
+	return nil
+}
+
+// AggregateByID returns the stored result of function for the provided packet identifier.
+func (r *Repository) AggregateByID(ctx context.Context, function, packetID string) (domain.PacketAggregate, error) {
+	if _, err := constants.ParseUUID(packetID); err != nil {
+		return domain.PacketAggregate{}, fmt.Errorf("postgres repository: invalid packet id: %w", err)
+	}
+
+	rows, err := r.query(ctx, selectAggregateByIDSQL, packetID, function)
+	if err != nil {
+		return domain.PacketAggregate{}, fmt.Errorf("postgres repository: aggregate by id: %w", err)
+	}
+
+	aggregates, err := scanPacketAggregates(rows)
+	if err != nil {
+		return domain.PacketAggregate{}, fmt.Errorf("postgres repository: aggregate by id: %w", err)
+	}
+	if len(aggregates) == 0 {
+		return domain.PacketAggregate{}, domain.ErrNotFound
+	}
+
+	return aggregates[0], nil
+}
+
+// AggregatesInRange returns one page of the results of function recorded within the provided time
+// range, ordered by timestamp and packet id, using the same keyset cursor as PacketMaxInRange.
+func (r *Repository) AggregatesInRange(ctx context.Context, function string, from, to time.Time, page domain.PageRequest) (domain.PacketAggregatePage, error) {
+	if page.Limit <= 0 {
+		return domain.PacketAggregatePage{}, errors.New("postgres repository: page limit must be positive")
+	}
+
+	var (
+		rows Rows
+		err  error
+	)
+	if page.Cursor == "" {
+		rows, err = r.query(ctx, selectAggregatesInRangeSQL, function, from.UTC(), to.UTC(), page.Limit+1)
+	} else {
+		cursor, decodeErr := domain.DecodePageCursor(page.Cursor)
+		if decodeErr != nil {
+			return domain.PacketAggregatePage{}, fmt.Errorf("postgres repository: aggregates in range: %w", decodeErr)
+		}
+		if _, uuidErr := constants.ParseUUID(cursor.PacketID); uuidErr != nil {
+			return domain.PacketAggregatePage{}, fmt.Errorf("postgres repository: aggregates in range: %w: %v", domain.ErrInvalidCursor, uuidErr)
+		}
+		rows, err = r.query(ctx, selectAggregatesInRangeAfterSQL, function, from.UTC(), to.UTC(), cursor.Timestamp, cursor.PacketID, page.Limit+1)
+	}
+	if err != nil {
+		return domain.PacketAggregatePage{}, fmt.Errorf("postgres repository: aggregates in range: %w", err)
+	}
+
+	aggregates, err := scanPacketAggregates(rows)
+	if err != nil {
+		return domain.PacketAggregatePage{}, fmt.Errorf("postgres repository: aggregates in range: %w", err)
+	}
+	if len(aggregates) == 0 && page.Cursor == "" {
+		return domain.PacketAggregatePage{}, domain.ErrNotFound
+	}
+
+	return paginateAggregates(aggregates, page.Limit), nil
+}
+
+// paginateAggregates is paginate for aggregate rows.
+func paginateAggregates(aggregates []domain.PacketAggregate, limit int) domain.PacketAggregatePage {
+	if len(aggregates) <= limit {
+		return domain.PacketAggregatePage{Aggregates: aggregates}
+	}
+
+	aggregates = aggregates[:limit]
+	last := aggregates[limit-1]
+	cursor := domain.PageCursor{Timestamp: last.Timestamp, PacketID: last.PacketID}
+	return domain.PacketAggregatePage{Aggregates: aggregates, NextCursor: cursor.Encode()}
+}
+
+func validatePacketAggregate(aggregate domain.PacketAggregate) error {
+	if aggregate.PacketID == "" {
+		return errors.New("postgres repository: packet id is required")
+	}
+	if _, err := constants.ParseUUID(aggregate.PacketID); err != nil {
+		return fmt.Errorf("postgres repository: invalid packet id: %w", err)
+	}
+	if strings.TrimSpace(aggregate.Function) == "" {
+		return errors.New("postgres repository: aggregation function is required")
+	}
+	if aggregate.SourceID != "" {
+		if _, err := constants.ParseUUID(aggregate.SourceID); err != nil {
+			return fmt.Errorf("postgres repository: invalid source id: %w", err)
+		}
+	}
+	return nil
+}
+
+// scanPacketAggregates reads packet_id, function_name, source_id, value and ts and closes rows.
+func scanPacketAggregates(rows Rows) ([]domain.PacketAggregate, error) {
+	defer rows.Close()
+
+	var results []domain.PacketAggregate
+	for rows.Next() {
+		var aggregate domain.PacketAggregate
+		if err := rows.Scan(&aggregate.PacketID, &aggregate.Function, &aggregate.SourceID, &aggregate.Value, &aggregate.Timestamp); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		aggregate.Timestamp = aggregate.Timestamp.UTC()
+		results = append(results, aggregate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read rows: %w", err)
+	}
+	return results, nil
+}
+
+var _ domain.Repository = (*Repository)(nil)
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/shared/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddAggregateUpsertsWithNullableSource(t *testing.T) {
+	runner := &fakeRunner{}
+	repo := newTestRepository(t, runner)
+	defer repo.Close()
+
+	aggregate := domain.PacketAggregate{PacketID: constants.GenerateUUID(), Function: "mean", Value: 2, Timestamp: time.Now()}
+	require.NoError(t, repo.AddAggregate(context.Background(), aggregate))
+
+	call := runner.lastCall()
+	assert.Equal(t, upsertAggregateSQL, call.statement)
+	require.Len(t, call.args, 5)
+	assert.Equal(t, aggregate.PacketID, call.args[0])
+	assert.Equal(t, "mean", call.args[1])
+	assert.Nil(t, call.args[2])
+}
+
+func TestAddAggregateValidates(t *testing.T) {
+	repo := newTestRepository(t, &fakeRunner{})
+	defer repo.Close()
+
+	assert.Error(t, repo.AddAggregate(context.Background(), domain.PacketAggregate{}))
+	assert.Error(t, repo.AddAggregate(context.Background(), domain.PacketAggregate{PacketID: constants.GenerateUUID()}))
+	assert.Error(t, repo.AddAggregate(context.Background(), domain.PacketAggregate{PacketID: constants.GenerateUUID(), Function: "min", SourceID: "bad"}))
+}
+
+func TestAddAggregateReturnsRunnerError(t *testing.T) {
+	runner := &fakeRunner{responses: []execResponse{{err: errors.New("boom")}}}
+	repo := newTestRepository(t, runner)
+	defer repo.Close()
+
+	err := repo.AddAggregate(context.Background(), domain.PacketAggregate{PacketID: constants.GenerateUUID(), Function: "sum"})
+	assert.Error(t, err)
+}
+
+func TestAggregateByIDSuccess(t *testing.T) {
+	timestamp := time.Now().UTC()
+	runner := &fakeRunner{responses: []execResponse{{rows: [][]any{{"packet", "min", "source", 0.5, timestamp}}}}}
+	repo := newTestRepository(t, runner)
+	defer repo.Close()
+
+	result, err := repo.AggregateByID(context.Background(), "min", constants.GenerateUUID())
+	require.NoError(t, err)
+	assert.Equal(t, "min", result.Function)
+	assert.Equal(t, "source", result.SourceID)
+	assert.Equal(t, 0.5, result.Value)
+	assert.Equal(t, "min", runner.lastCall().args[1])
+}
+
+func TestAggregateByIDNotFound(t *testing.T) {
+	runner := &fakeRunner{}
+	repo := newTestRepository(t, runner)
+	defer repo.Close()
+
+	_, err := repo.AggregateByID(context.Background(), "min", constants.GenerateUUID())
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestAggregatesInRange(t *testing.T) {
+	timestamp := time.Now().UTC()
+	a, b := constants.GenerateUUID(), constants.GenerateUUID()
+	runner := &fakeRunner{responses: []execResponse{{rows: [][]any{
+		{a, "count", "", 3.0, timestamp},
+		{b, "count", "", 4.0, timestamp},
+	}}}}
+	repo := newTestRepository(t, runner)
+	defer repo.Close()
+
+	results, err := repo.AggregatesInRange(context.Background(), "count", time.Now().Add(-time.Hour), time.Now(), domain.PageRequest{Limit: 5})
+	require.NoError(t, err)
+	assert.Len(t, results.Aggregates, 2)
+	assert.Empty(t, results.Aggregates[0].SourceID)
+	assert.Equal(t, 4.0, results.Aggregates[1].Value)
+	assert.Empty(t, results.NextCursor)
+	assert.Equal(t, 6, runner.lastCall().args[3], "one look-ahead row past the limit")
+}
+
+func TestAggregatesInRangePaginates(t *testing.T) {
+	timestamp := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	a, b := constants.GenerateUUID(), constants.GenerateUUID()
+	runner := &fakeRunner{}
+	runner.setResponses(
+		execResponse{rows: [][]any{{a, "sum", "", 1.0, timestamp}, {b, "sum", "", 2.0, timestamp}}},
+		execResponse{rows: [][]any{{b, "sum", "", 2.0, timestamp}}},
+	)
+	repo := newTestRepository(t, runner)
+	defer repo.Close()
+
+	t.Log("Шаг 1: лишняя строка превращается в курсор на следующую страницу")
+	first, err := repo.AggregatesInRange(context.Background(), "sum", timestamp, timestamp, domain.PageRequest{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, first.Aggregates, 1)
+	require.NotEmpty(t, first.NextCursor)
+	assert.Equal(t, selectAggregatesInRangeSQL, runner.calls[0].statement)
+
+	t.Log("Шаг 2: курсор передаётся в запрос как (ts, packet_id)")
+	second, err := repo.AggregatesInRange(context.Background(), "sum", timestamp, timestamp, domain.PageRequest{Limit: 1, Cursor: first.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, b, second.Aggregates[0].PacketID)
+	assert.Empty(t, second.NextCursor)
+	assert.Equal(t, selectAggregatesInRangeAfterSQL, runner.calls[1].statement)
+	assert.Equal(t, []any{"sum", timestamp, timestamp, timestamp, a, 2}, runner.calls[1].args)
+
+	t.Log("Шаг 3: испорченный курсор и пустая страница без курсора")
+	_, err = repo.AggregatesInRange(context.Background(), "sum", timestamp, timestamp, domain.PageRequest{Limit: 1, Cursor: "???"})
+	assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+	_, err = repo.AggregatesInRange(context.Background(), "sum", timestamp, timestamp, domain.PageRequest{Limit: 1})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestScanPacketAggregatesRejectsShortRows(t *testing.T) {
+	_, err := scanPacketAggregates(&fakeRows{rows: [][]any{{"a", "b", "c"}}})
+	assert.Error(t, err)
+}
@@ -97,8 +97,27 @@ func WaitForDatabase(ctx context.Context, cfg infra.Config, logger *infra.Logger
 	return fmt.Errorf("database not reachable at %s", address)
 }
 
+// prepareSchema applies pending migrations under the migration lock. With
+// MIGRATION_EXPECT_VERSION set the service leaves migrations to cmd/migrate and refuses to start
+// until the schema has reached that version.
+func prepareSchema(ctx context.Context, cfg infra.Config, runner CommandRunner, dsn string, logger *infra.Logger) error {
+	if cfg.MigrationExpectVersion > 0 {
+		if err := ExpectSchemaVersion(ctx, runner, dsn, int64(cfg.MigrationExpectVersion)); err != nil {
+			return err
+		}
+		if logger != nil {
+			logger.Printf(ctx, "schema has reached expected version %d, migrations are left to cmd/migrate", cfg.MigrationExpectVersion)
+		}
+		return nil
+	}
+
+	lockTimeout := time.Duration(cfg.MigrationLockTimeoutMS) * time.Millisecond
+	return ApplyMigrations(ctx, runner, dsn, ResolveMigrationsDir(), lockTimeout, logger)
+}
+
 // SetupRepository initialises the Postgres-backed repository and cleanup routine.
-func SetupRepository(ctx context.Context, cfg infra.Config, logger *infra.Logger) (domain.PacketMaxRepository, func(), error) {
+// Persisted packet maxima are forwarded to publisher when it is not nil.
+func SetupRepository(ctx context.Context, cfg infra.Config, publisher domain.PacketMaxPublisher, logger *infra.Logger) (domain.Repository, func(), error) {
 	dsn, err := BuildDatabaseDSN(cfg)
 	if err != nil {
 		return nil, nil, err
@@ -117,23 +136,74 @@ func SetupRepository(ctx context.Context, cfg infra.Config, logger *infra.Logger
 		}
 	}
 
-	migrationsDir := ResolveMigrationsDir()
 	runner := NewSQLRunner()
 	defer runner.Close()
-	if err := ApplyMigrations(ctx, runner, dsn, migrationsDir, logger); err != nil {
+	if err := prepareSchema(ctx, cfg, runner, dsn, logger); err != nil {
 		return nil, nil, err
 	}
 
-	repo, err := New(ctx, Config{
-		DSN:          dsn,
-		Logger:       logger,
-		BatchSize:    cfg.DatabaseBatchSize,
-		BatchTimeout: time.Duration(cfg.DatabaseBatchTimeoutMS) * time.Millisecond,
-		BufferSize:   cfg.DatabaseBatchBufferSize,
-	})
+	partitions, err := LoadPartitionConfig(cfg)
 	if err != nil {
 		return nil, nil, err
 	}
+	// Partitions for today must exist before the repository replays or writes anything, otherwise
+	// the first rows land in the default partition and block creating the partition later. A
+	// failed pass is logged by the maintainer and retried in the background.
+	_, _ = NewPartitionMaintainer(runner, dsn, partitions, logger).Maintain(ctx)
+
+	overflow, err := ParseOverflowPolicy(cfg.DatabaseBufferPolicy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	walSync, err := ParseWALSyncPolicy(cfg.DatabaseWALFsync)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var deadLetters *FileDeadLetterStore
+	repoCfg := Config{
+		DSN:             dsn,
+		Logger:          logger,
+		BatchSize:       cfg.DatabaseBatchSize,
+		BatchTimeout:    time.Duration(cfg.DatabaseBatchTimeoutMS) * time.Millisecond,
+		BufferSize:      cfg.DatabaseBatchBufferSize,
+		Overflow:        overflow,
+		OverflowTimeout: time.Duration(cfg.DatabaseBufferTimeoutMS) * time.Millisecond,
+		SpillFile:       cfg.DatabaseSpillFile,
+		Publisher:       publisher,
+		Retry: RetryPolicy{
+			MaxAttempts: cfg.DatabaseRetryAttempts,
+			BaseDelay:   time.Duration(cfg.DatabaseRetryBaseMS) * time.Millisecond,
+			MaxDelay:    time.Duration(cfg.DatabaseRetryMaxMS) * time.Millisecond,
+			Jitter:      cfg.DatabaseRetryJitter,
+		},
+		WAL: WALConfig{
+			Dir:          cfg.DatabaseWALDir,
+			SegmentSize:  int64(cfg.DatabaseWALSegmentBytes),
+			Sync:         walSync,
+			SyncInterval: time.Duration(cfg.DatabaseWALFsyncMS) * time.Millisecond,
+		},
+		PurgeChunkSize: cfg.RetentionChunkSize,
+	}
+	if cfg.DLQEnabled {
+		deadLetters, err = OpenFileDeadLetterStore(cfg.DLQFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		repoCfg.DeadLetters = deadLetters
+		if depth, _ := deadLetters.Depth(ctx); depth > 0 && logger != nil {
+			logger.Printf(ctx, "dead-letter queue %s holds %d entries", cfg.DLQFile, depth)
+		}
+	}
+
+	repo, err := New(ctx, repoCfg)
+	if err != nil {
+		if deadLetters != nil {
+			_ = deadLetters.Close()
+		}
+		return nil, nil, err
+	}
 
 	cleanup := func() {
 		if err := repo.Close(); err != nil {
@@ -141,11 +211,34 @@ func SetupRepository(ctx context.Context, cfg infra.Config, logger *infra.Logger
 				logger.Printf(ctx, "failed to close repository: %v", err)
 			}
 		}
+		// The repository flushes its last batch on Close, so the store is closed after it.
+		if deadLetters != nil {
+			if err := deadLetters.Close(); err != nil && logger != nil {
+				logger.Printf(ctx, "failed to close dead-letter store: %v", err)
+			}
+		}
 	}
 
 	return repo, cleanup, nil
 }
 
+// LoadPartitionConfig derives the partition maintainer settings from the service configuration.
+// Expired partitions follow RETENTION_DAYS and RETENTION_DRY_RUN.
+func LoadPartitionConfig(cfg infra.Config) (PartitionConfig, error) {
+	interval, err := ParsePartitionInterval(cfg.PartitionInterval)
+	if err != nil {
+		return PartitionConfig{}, err
+	}
+	return PartitionConfig{
+		Interval:      interval,
+		Premake:       cfg.PartitionPremake,
+		Retention:     time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+		Detach:        cfg.PartitionDetach,
+		DryRun:        cfg.RetentionDryRun,
+		CheckInterval: time.Duration(cfg.PartitionCheckMS) * time.Millisecond,
+	}, nil
+}
+
 // BuildDatabaseDSN constructs a DSN from discrete configuration values when not provided explicitly.
 func BuildDatabaseDSN(cfg infra.Config) (string, error) {
 	if cfg.DatabaseDSN != "" {
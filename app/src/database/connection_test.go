@@ -10,6 +10,7 @@ import (
 	"aggregator-service/app/src/infra"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // вспомогательные функции
@@ -120,6 +121,25 @@ func TestBuildDatabaseDSNDefaultsPort(t *testing.T) {
 // SetupRepository
 
 func TestSetupRepositoryPropagatesErrors(t *testing.T) {
-	_, _, err := SetupRepository(context.Background(), infra.Config{}, nil)
+	_, _, err := SetupRepository(context.Background(), infra.Config{}, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestLoadPartitionConfig(t *testing.T) {
+	cfg, err := LoadPartitionConfig(infra.Config{
+		PartitionInterval: "daily",
+		PartitionPremake:  5,
+		PartitionCheckMS:  60000,
+		RetentionDays:     2,
+		RetentionDryRun:   true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, PartitionDaily, cfg.Interval)
+	assert.Equal(t, 5, cfg.Premake)
+	assert.Equal(t, time.Minute, cfg.CheckInterval)
+	assert.Equal(t, 48*time.Hour, cfg.Retention)
+	assert.True(t, cfg.DryRun)
+
+	_, err = LoadPartitionConfig(infra.Config{PartitionInterval: "hourly"})
 	assert.Error(t, err)
 }
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"aggregator-service/app/src/domain"
+	metrics "aggregator-service/app/src/infra"
+	"aggregator-service/app/src/shared/constants"
+)
+
+// deadLetter routes a packet maximum whose write failed to the dead-letter store. Without a store
+// the row is lost, as before, and only the log line from processBatch remains.
+func (r *Repository) deadLetter(ctx context.Context, packetMax domain.PacketMax, cause error) {
+	if r.deadLetters == nil {
+		return
+	}
+
+	now := time.Now().UTC()
+	entry := domain.DeadLetter{
+		ID:            constants.GenerateUUID(),
+		PacketMax:     packetMax,
+		Reason:        cause.Error(),
+		Attempts:      1,
+		FirstFailedAt: now,
+		LastFailedAt:  now,
+	}
+	if err := r.deadLetters.Put(ctx, entry); err != nil {
+		if r.logger != nil {
+			r.logger.Printf(ctx, "postgres repository: dead-letter put failed packet=%s source=%s: %v", packetMax.PacketID, packetMax.SourceID, err)
+		}
+		return
+	}
+	metrics.IncDLQEntries()
+}
+
+// DeadLetters returns up to limit failed writes, oldest first.
+func (r *Repository) DeadLetters(ctx context.Context, limit int) ([]domain.DeadLetter, error) {
+	if r.deadLetters == nil {
+		return nil, domain.ErrDeadLettersDisabled
+	}
+	return r.deadLetters.List(ctx, limit)
+}
+
+func (r *Repository) DeadLetterDepth(ctx context.Context) (int, error) {
+	if r.deadLetters == nil {
+		return 0, domain.ErrDeadLettersDisabled
+	}
+	return r.deadLetters.Depth(ctx)
+}
+
+// Redrive writes up to limit dead letters again, oldest first. Written entries leave the queue and
+// are published like any other write; entries that fail again stay with a bumped attempt count.
+func (r *Repository) Redrive(ctx context.Context, limit int) (domain.RedriveReport, error) {
+	var report domain.RedriveReport
+	if r.deadLetters == nil {
+		return report, domain.ErrDeadLettersDisabled
+	}
+
+	entries, err := r.deadLetters.List(ctx, limit)
+	if err != nil {
+		return report, fmt.Errorf("postgres repository: list dead letters: %w", err)
+	}
+
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		report.Attempted++
+
+		if writeErr := r.writePacketMax(ctx, entry.PacketMax); writeErr != nil {
+			report.Failed++
+			entry.Attempts++
+			entry.Reason = writeErr.Error()
+			entry.LastFailedAt = time.Now().UTC()
+			if err := r.deadLetters.Put(ctx, entry); err != nil {
+				return report, fmt.Errorf("postgres repository: update dead letter %s: %w", entry.ID, err)
+			}
+			continue
+		}
+
+		if err := r.deadLetters.Delete(ctx, entry.ID); err != nil {
+			return report, fmt.Errorf("postgres repository: delete dead letter %s: %w", entry.ID, err)
+		}
+		report.Succeeded++
+		metrics.IncDLQRedriven()
+		r.publish(entry.PacketMax)
+	}
+
+	if r.logger != nil {
+		r.logger.Printf(ctx, "postgres repository: dead-letter redrive attempted=%d succeeded=%d failed=%d", report.Attempted, report.Succeeded, report.Failed)
+	}
+	return report, nil
+}
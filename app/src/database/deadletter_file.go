@@ -0,0 +1,286 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/infra"
+)
+
+const (
+	deadLetterOpPut    = "put"
+	deadLetterOpDelete = "delete"
+)
+
+// deadLetterRecord is one line of the dead-letter log. A put carries the whole entry, a delete
+// only its id.
+type deadLetterRecord struct {
+	Op            string    `json:"op"`
+	ID            string    `json:"id"`
+	PacketID      string    `json:"packet_id,omitempty"`
+	SourceID      string    `json:"source_id,omitempty"`
+	Value         float64   `json:"value,omitempty"`
+	Timestamp     time.Time `json:"timestamp,omitempty"`
+	Rank          int       `json:"rank,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	Attempts      int       `json:"attempts,omitempty"`
+	FirstFailedAt time.Time `json:"first_failed_at,omitempty"`
+	LastFailedAt  time.Time `json:"last_failed_at,omitempty"`
+}
+
+// FileDeadLetterStore keeps dead letters in a local append-only JSONL log, so failed writes
+// survive both a database outage and a restart. The log is replayed on open and compacted
+// once the queue drains.
+type FileDeadLetterStore struct {
+	path string
+
+	mu      sync.Mutex
+	file    *os.File
+	entries map[string]domain.DeadLetter
+	records int
+}
+
+// OpenFileDeadLetterStore opens or creates the log at path and loads the entries it holds.
+func OpenFileDeadLetterStore(path string) (*FileDeadLetterStore, error) {
+	if path == "" {
+		return nil, errors.New("dead-letter store: file path is required")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("dead-letter store: create directory: %w", err)
+	}
+
+	store := &FileDeadLetterStore{path: path, entries: make(map[string]domain.DeadLetter)}
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("dead-letter store: open: %w", err)
+	}
+	store.file = file
+	if err := store.repairTail(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	infra.SetDLQDepth(len(store.entries))
+	return store, nil
+}
+
+func (s *FileDeadLetterStore) load() error {
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("dead-letter store: open: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var record deadLetterRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			// A torn last line is what a crash mid-append leaves behind; the entries before it
+			// are intact.
+			continue
+		}
+		s.apply(record)
+		s.records++
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("dead-letter store: read: %w", err)
+	}
+	return nil
+}
+
+// repairTail terminates a torn last line, which load has skipped, so that the next record does not
+// get glued to it and lost on the following load.
+func (s *FileDeadLetterStore) repairTail() error {
+	info, err := s.file.Stat()
+	if err != nil {
+		return fmt.Errorf("dead-letter store: stat: %w", err)
+	}
+	if info.Size() == 0 {
+		return nil
+	}
+
+	last := make([]byte, 1)
+	if _, err := s.file.ReadAt(last, info.Size()-1); err != nil {
+		return fmt.Errorf("dead-letter store: read: %w", err)
+	}
+	if last[0] == '\n' {
+		return nil
+	}
+	if _, err := s.file.Write([]byte{'\n'}); err != nil {
+		return fmt.Errorf("dead-letter store: repair: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("dead-letter store: sync: %w", err)
+	}
+	return nil
+}
+
+func (s *FileDeadLetterStore) apply(record deadLetterRecord) {
+	switch record.Op {
+	case deadLetterOpPut:
+		s.entries[record.ID] = record.deadLetter()
+	case deadLetterOpDelete:
+		delete(s.entries, record.ID)
+	}
+}
+
+// Put appends the entry to the log, replacing an earlier entry with the same id.
+func (s *FileDeadLetterStore) Put(ctx context.Context, entry domain.DeadLetter) error {
+	if entry.ID == "" {
+		return errors.New("dead-letter store: entry id is required")
+	}
+	return s.append(ctx, newDeadLetterRecord(entry))
+}
+
+// Delete removes the entry; deleting an unknown id is not an error.
+func (s *FileDeadLetterStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	_, ok := s.entries[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return s.append(ctx, deadLetterRecord{Op: deadLetterOpDelete, ID: id})
+}
+
+func (s *FileDeadLetterStore) append(ctx context.Context, record deadLetterRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("dead-letter store: encode: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return errors.New("dead-letter store: store closed")
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("dead-letter store: append: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("dead-letter store: sync: %w", err)
+	}
+	s.apply(record)
+	s.records++
+
+	if len(s.entries) == 0 {
+		if err := s.compact(); err != nil {
+			return err
+		}
+	}
+	infra.SetDLQDepth(len(s.entries))
+	return nil
+}
+
+// compact truncates the log once every entry has been deleted, so it does not grow forever.
+func (s *FileDeadLetterStore) compact() error {
+	if err := s.file.Truncate(0); err != nil {
+		return fmt.Errorf("dead-letter store: compact: %w", err)
+	}
+	s.records = 0
+	return nil
+}
+
+// List returns up to limit entries, oldest failure first.
+func (s *FileDeadLetterStore) List(ctx context.Context, limit int) ([]domain.DeadLetter, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	entries := make([]domain.DeadLetter, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].FirstFailedAt.Equal(entries[j].FirstFailedAt) {
+			return entries[i].FirstFailedAt.Before(entries[j].FirstFailedAt)
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+func (s *FileDeadLetterStore) Depth(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries), nil
+}
+
+func (s *FileDeadLetterStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func newDeadLetterRecord(entry domain.DeadLetter) deadLetterRecord {
+	return deadLetterRecord{
+		Op:            deadLetterOpPut,
+		ID:            entry.ID,
+		PacketID:      entry.PacketMax.PacketID,
+		SourceID:      entry.PacketMax.SourceID,
+		Value:         entry.PacketMax.Value,
+		Timestamp:     entry.PacketMax.Timestamp.UTC(),
+		Rank:          entry.PacketMax.Rank,
+		Reason:        entry.Reason,
+		Attempts:      entry.Attempts,
+		FirstFailedAt: entry.FirstFailedAt.UTC(),
+		LastFailedAt:  entry.LastFailedAt.UTC(),
+	}
+}
+
+func (r deadLetterRecord) deadLetter() domain.DeadLetter {
+	return domain.DeadLetter{
+		ID: r.ID,
+		PacketMax: domain.PacketMax{
+			PacketID:  r.PacketID,
+			SourceID:  r.SourceID,
+			Value:     r.Value,
+			Timestamp: r.Timestamp,
+			Rank:      r.Rank,
+		},
+		Reason:        r.Reason,
+		Attempts:      r.Attempts,
+		FirstFailedAt: r.FirstFailedAt,
+		LastFailedAt:  r.LastFailedAt,
+	}
+}
+
+var _ domain.DeadLetterStore = (*FileDeadLetterStore)(nil)
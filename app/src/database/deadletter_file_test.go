@@ -0,0 +1,169 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/shared/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDeadLetter(id string, failedAt time.Time) domain.DeadLetter {
+	return domain.DeadLetter{
+		ID: id,
+		PacketMax: domain.PacketMax{
+			PacketID:  constants.GenerateUUID(),
+			SourceID:  constants.GenerateUUID(),
+			Value:     42.5,
+			Timestamp: failedAt.Add(-time.Second),
+			Rank:      1,
+		},
+		Reason:        "connection refused",
+		Attempts:      1,
+		FirstFailedAt: failedAt,
+		LastFailedAt:  failedAt,
+	}
+}
+
+func TestOpenFileDeadLetterStoreValidatesPath(t *testing.T) {
+	_, err := OpenFileDeadLetterStore("")
+	assert.Error(t, err)
+}
+
+func TestFileDeadLetterStorePutListDelete(t *testing.T) {
+	ctx := context.Background()
+	store, err := OpenFileDeadLetterStore(filepath.Join(t.TempDir(), "dlq", "packet_max.jsonl"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Log("Шаг 1: добавляем записи в порядке, отличном от времени ошибки")
+	require.NoError(t, store.Put(ctx, newDeadLetter("b", base.Add(time.Minute))))
+	require.NoError(t, store.Put(ctx, newDeadLetter("a", base)))
+	assert.Error(t, store.Put(ctx, domain.DeadLetter{}))
+
+	t.Log("Шаг 2: список упорядочен по времени первой ошибки и учитывает лимит")
+	entries, err := store.List(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "a", entries[0].ID)
+	entries, err = store.List(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	t.Log("Шаг 3: повторный Put заменяет запись, Delete удаляет её")
+	updated := entries[0]
+	updated.Attempts = 2
+	require.NoError(t, store.Put(ctx, updated))
+	require.NoError(t, store.Delete(ctx, "b"))
+	require.NoError(t, store.Delete(ctx, "missing"))
+
+	entries, err = store.List(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 2, entries[0].Attempts)
+	depth, err := store.Depth(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, depth)
+}
+
+func TestFileDeadLetterStoreSurvivesReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "dlq.jsonl")
+	entry := newDeadLetter("a", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+
+	t.Log("Шаг 1: записываем две записи и удаляем одну")
+	store, err := OpenFileDeadLetterStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(ctx, entry))
+	require.NoError(t, store.Put(ctx, newDeadLetter("b", entry.FirstFailedAt)))
+	require.NoError(t, store.Delete(ctx, "b"))
+	require.NoError(t, store.Close())
+
+	t.Log("Шаг 2: обрезанная последняя строка не мешает чтению журнала")
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"op":"put","id":"c","pack`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	t.Log("Шаг 3: после повторного открытия состояние восстановлено из журнала")
+	store, err = OpenFileDeadLetterStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	entries, err := store.List(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, entry.PacketMax, entries[0].PacketMax)
+	assert.Equal(t, entry.Reason, entries[0].Reason)
+	assert.Equal(t, entry.FirstFailedAt, entries[0].FirstFailedAt)
+}
+
+func TestFileDeadLetterStoreAppendsAfterTornLine(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "dlq.jsonl")
+	failedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Log("Шаг 1: журнал с двумя записями обрывается посреди третьей")
+	store, err := OpenFileDeadLetterStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(ctx, newDeadLetter("a", failedAt)))
+	require.NoError(t, store.Put(ctx, newDeadLetter("b", failedAt)))
+	require.NoError(t, store.Close())
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"op":"put","id":"torn","pack`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	t.Log("Шаг 2: после открытия дописываем новую запись и удаление")
+	store, err = OpenFileDeadLetterStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(ctx, newDeadLetter("c", failedAt.Add(time.Minute))))
+	require.NoError(t, store.Delete(ctx, "a"))
+	require.NoError(t, store.Close())
+
+	t.Log("Шаг 3: при следующем открытии не потеряны ни запись, ни удаление")
+	store, err = OpenFileDeadLetterStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+	entries, err := store.List(ctx, 0)
+	require.NoError(t, err)
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+	assert.Equal(t, []string{"b", "c"}, ids)
+}
+
+func TestFileDeadLetterStoreCompactsWhenDrained(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "dlq.jsonl")
+	store, err := OpenFileDeadLetterStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.Put(ctx, newDeadLetter("a", time.Now())))
+	require.NoError(t, store.Delete(ctx, "a"))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+
+	t.Log("после сжатия журнал продолжает принимать записи")
+	require.NoError(t, store.Put(ctx, newDeadLetter("b", time.Now())))
+	require.NoError(t, store.Close())
+	store, err = OpenFileDeadLetterStore(path)
+	require.NoError(t, err)
+	depth, err := store.Depth(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, depth)
+}
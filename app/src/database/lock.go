@@ -0,0 +1,228 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"aggregator-service/app/src/infra"
+)
+
+// DefaultMigrationLockTimeout is how long a replica waits for another one to finish migrating.
+const DefaultMigrationLockTimeout = time.Minute
+
+// migrationLockKey is the advisory lock key taken around migrations ("migr" in ASCII). It fits
+// into 32 bits, so pg_locks reports it as objid with a zero classid.
+const migrationLockKey int64 = 0x6d696772
+
+const (
+	setApplicationNameSQL = `SELECT set_config('application_name', $1, false)`
+	tryAdvisoryLockSQL    = `SELECT pg_try_advisory_lock($1)`
+	selectLockHolderSQL   = `
+SELECT a.pid, coalesce(a.application_name, ''), coalesce(host(a.client_addr), ''), a.backend_start
+FROM pg_locks l
+JOIN pg_stat_activity a ON a.pid = l.pid
+WHERE l.locktype = 'advisory' AND l.granted AND l.classid = 0 AND l.objid::bigint = $1 AND l.objsubid = 1
+LIMIT 1
+`
+	schemaMigrationsExistSQL = `SELECT to_regclass('public.schema_migrations') IS NOT NULL`
+	selectSchemaVersionSQL   = `SELECT coalesce(max(version), 0) FROM public.schema_migrations`
+)
+
+// migrationLockPoll is how often a waiting replica retries the lock.
+var migrationLockPoll = 500 * time.Millisecond
+
+var (
+	// ErrMigrationLockTimeout reports that another session held the migration lock for too long.
+	ErrMigrationLockTimeout = errors.New("timed out waiting for the migration lock")
+	// ErrSchemaVersion reports a schema older than the version the service expects.
+	ErrSchemaVersion = errors.New("schema is behind the expected version")
+)
+
+// LockHolder describes the database session holding an advisory lock.
+type LockHolder struct {
+	PID         int64
+	Application string
+	ClientAddr  string
+	Since       time.Time
+}
+
+func (h LockHolder) String() string {
+	if h.PID == 0 {
+		return "unknown session"
+	}
+	addr := h.ClientAddr
+	if addr == "" {
+		addr = "local"
+	}
+	return fmt.Sprintf("pid=%d application=%q client=%s connected=%s",
+		h.PID, h.Application, addr, h.Since.UTC().Format(time.RFC3339))
+}
+
+// WithMigrationLock runs fn while holding the migration advisory lock, so replicas that start
+// together apply migrations one after another instead of racing on the same DDL. The lock is
+// session-level and lives on a connection set aside for the call; closing that connection
+// releases it even if fn panics or the process dies. While waiting, the holder is logged with its
+// pid, application_name and address; after timeout the call gives up with
+// ErrMigrationLockTimeout. Runners that cannot set a connection aside run fn without the lock.
+func WithMigrationLock(ctx context.Context, runner CommandRunner, dsn string, timeout time.Duration, logger *infra.Logger, fn func(context.Context) error) error {
+	sessions, ok := runner.(SessionRunner)
+	if !ok {
+		lockLogf(ctx, logger, "migration lock: runner %T cannot hold a session, migrating without the lock", runner)
+		return fn(ctx)
+	}
+	if timeout <= 0 {
+		timeout = DefaultMigrationLockTimeout
+	}
+
+	session, err := sessions.Session(ctx, dsn, "")
+	if err != nil {
+		return fmt.Errorf("migration lock: %w", err)
+	}
+	defer session.Close()
+
+	owner := lockOwnerName()
+	if _, err := session.Exec(ctx, setApplicationNameSQL, owner); err != nil {
+		return fmt.Errorf("migration lock: set application_name: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	var last LockHolder
+	for {
+		acquired, err := queryBool(ctx, session, tryAdvisoryLockSQL, migrationLockKey)
+		if err != nil {
+			return fmt.Errorf("migration lock: %w", err)
+		}
+		if acquired {
+			break
+		}
+
+		holder, err := migrationLockHolder(ctx, session)
+		if err != nil {
+			lockLogf(ctx, logger, "migration lock: look up holder: %v", err)
+		}
+		if holder != last {
+			lockLogf(ctx, logger, "migration lock is held by %s, waiting up to %s", holder, timeout)
+			last = holder
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("%w after %s, held by %s", ErrMigrationLockTimeout, timeout, holder)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(migrationLockPoll):
+		}
+	}
+
+	lockLogf(ctx, logger, "migration lock acquired by %s", owner)
+	defer lockLogf(ctx, logger, "migration lock released by %s", owner)
+	return fn(ctx)
+}
+
+// SchemaVersion returns the newest version recorded in schema_migrations without creating the
+// table; a database that was never migrated reports zero.
+func SchemaVersion(ctx context.Context, runner CommandRunner, dsn string) (int64, error) {
+	exists, err := queryBool(ctx, runnerQuerier{runner: runner, dsn: dsn}, schemaMigrationsExistSQL)
+	if err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	rows, err := runner.Query(ctx, dsn, "", selectSchemaVersionSQL)
+	if err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	defer rows.Close()
+
+	var version int64
+	if rows.Next() {
+		if err := rows.Scan(&version); err != nil {
+			return 0, fmt.Errorf("read schema version: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// ExpectSchemaVersion fails with ErrSchemaVersion unless the schema has reached want. It is used
+// by replicas that leave migrations to a separate cmd/migrate run.
+func ExpectSchemaVersion(ctx context.Context, runner CommandRunner, dsn string, want int64) error {
+	version, err := SchemaVersion(ctx, runner, dsn)
+	if err != nil {
+		return err
+	}
+	if version < want {
+		return fmt.Errorf("%w: database is at %d, service expects %d; run cmd/migrate first", ErrSchemaVersion, version, want)
+	}
+	return nil
+}
+
+func migrationLockHolder(ctx context.Context, session Session) (LockHolder, error) {
+	rows, err := session.Query(ctx, selectLockHolderSQL, migrationLockKey)
+	if err != nil {
+		return LockHolder{}, err
+	}
+	defer rows.Close()
+
+	var holder LockHolder
+	if rows.Next() {
+		if err := rows.Scan(&holder.PID, &holder.Application, &holder.ClientAddr, &holder.Since); err != nil {
+			return LockHolder{}, err
+		}
+	}
+	return holder, rows.Err()
+}
+
+// querier is the query half shared by Session and a CommandRunner bound to one DSN.
+type querier interface {
+	Query(ctx context.Context, statement string, args ...any) (Rows, error)
+}
+
+type runnerQuerier struct {
+	runner CommandRunner
+	dsn    string
+}
+
+func (q runnerQuerier) Query(ctx context.Context, statement string, args ...any) (Rows, error) {
+	return q.runner.Query(ctx, q.dsn, "", statement, args...)
+}
+
+// queryBool reads a single boolean column; no rows reads as false.
+func queryBool(ctx context.Context, q querier, statement string, args ...any) (bool, error) {
+	rows, err := q.Query(ctx, statement, args...)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	var value bool
+	if rows.Next() {
+		if err := rows.Scan(&value); err != nil {
+			return false, err
+		}
+	}
+	return value, rows.Err()
+}
+
+// lockOwnerName identifies this process in pg_stat_activity while it holds or waits for the lock.
+func lockOwnerName() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("aggregator-migrate %s:%d", host, os.Getpid())
+}
+
+func lockLogf(ctx context.Context, logger *infra.Logger, format string, v ...any) {
+	if logger != nil {
+		logger.Printf(ctx, format, v...)
+	}
+}
@@ -0,0 +1,185 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"aggregator-service/app/src/infra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sessionRunner hands out sessions whose statements go through the embedded fakeRunner, so one
+// response queue covers both the lock session and the migrator.
+type sessionRunner struct {
+	*fakeRunner
+	opened int
+	closed int
+}
+
+func (r *sessionRunner) Session(ctx context.Context, dsn, password string) (Session, error) {
+	r.opened++
+	return fakeSession{runner: r, dsn: dsn}, nil
+}
+
+type fakeSession struct {
+	runner *sessionRunner
+	dsn    string
+}
+
+func (s fakeSession) Exec(ctx context.Context, statement string, args ...any) (sql.Result, error) {
+	return s.runner.fakeRunner.Exec(ctx, s.dsn, "", statement, args...)
+}
+
+func (s fakeSession) Query(ctx context.Context, statement string, args ...any) (Rows, error) {
+	return s.runner.fakeRunner.Query(ctx, s.dsn, "", statement, args...)
+}
+
+func (s fakeSession) Close() error {
+	s.runner.closed++
+	return nil
+}
+
+func withLockPoll(t *testing.T, poll time.Duration) {
+	t.Helper()
+	previous := migrationLockPoll
+	migrationLockPoll = poll
+	t.Cleanup(func() { migrationLockPoll = previous })
+}
+
+func TestWithMigrationLockRunsWhileHoldingLock(t *testing.T) {
+	runner := &sessionRunner{fakeRunner: &fakeRunner{}}
+	runner.setResponses(
+		execResponse{},
+		execResponse{rows: [][]any{{true}}},
+	)
+
+	called := false
+	err := WithMigrationLock(context.Background(), runner, testPurgeDSN, time.Second, infra.NewLogger(io.Discard, "test"), func(context.Context) error {
+		called = true
+		assert.Equal(t, 0, runner.closed, "lock session must stay open while fn runs")
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.True(t, called)
+	assert.Equal(t, 1, runner.opened)
+	assert.Equal(t, 1, runner.closed)
+	require.Equal(t, 2, runner.callCount())
+	assert.Equal(t, setApplicationNameSQL, runner.calls[0].statement)
+	assert.Equal(t, tryAdvisoryLockSQL, runner.calls[1].statement)
+	assert.Equal(t, []any{migrationLockKey}, runner.calls[1].args)
+}
+
+func TestWithMigrationLockWaitsAndLogsHolder(t *testing.T) {
+	withLockPoll(t, time.Millisecond)
+	runner := &sessionRunner{fakeRunner: &fakeRunner{}}
+	since := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	runner.setResponses(
+		execResponse{},
+		execResponse{rows: [][]any{{false}}},
+		execResponse{rows: [][]any{{int64(42), "aggregator-migrate host-a:7", "10.0.0.5", since}}},
+		execResponse{rows: [][]any{{true}}},
+	)
+	var logs bytes.Buffer
+
+	called := false
+	err := WithMigrationLock(context.Background(), runner, testPurgeDSN, time.Second, infra.NewLogger(&logs, "test"), func(context.Context) error {
+		called = true
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.True(t, called)
+	assert.Contains(t, logs.String(), "migration lock is held by pid=42")
+	assert.Contains(t, logs.String(), "aggregator-migrate host-a:7")
+	assert.Contains(t, logs.String(), "client=10.0.0.5")
+	assert.Contains(t, logs.String(), "migration lock acquired")
+	assert.Contains(t, logs.String(), "migration lock released")
+}
+
+func TestWithMigrationLockTimesOut(t *testing.T) {
+	withLockPoll(t, time.Millisecond)
+	runner := &sessionRunner{fakeRunner: &fakeRunner{}}
+
+	err := WithMigrationLock(context.Background(), runner, testPurgeDSN, 5*time.Millisecond, nil, func(context.Context) error {
+		t.Fatal("fn must not run without the lock")
+		return nil
+	})
+
+	require.ErrorIs(t, err, ErrMigrationLockTimeout)
+	assert.Equal(t, 1, runner.closed)
+}
+
+func TestWithMigrationLockReturnsFnError(t *testing.T) {
+	runner := &sessionRunner{fakeRunner: &fakeRunner{}}
+	runner.setResponses(execResponse{}, execResponse{rows: [][]any{{true}}})
+	boom := errors.New("boom")
+
+	err := WithMigrationLock(context.Background(), runner, testPurgeDSN, time.Second, nil, func(context.Context) error {
+		return boom
+	})
+
+	require.ErrorIs(t, err, boom)
+	assert.Equal(t, 1, runner.closed)
+}
+
+func TestWithMigrationLockWithoutSessions(t *testing.T) {
+	runner := &fakeRunner{}
+
+	called := false
+	err := WithMigrationLock(context.Background(), runner, testPurgeDSN, time.Second, nil, func(context.Context) error {
+		called = true
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.True(t, called)
+	assert.Equal(t, 0, runner.callCount())
+}
+
+func TestApplyMigrationsTakesLock(t *testing.T) {
+	runner := &sessionRunner{fakeRunner: &fakeRunner{}}
+	runner.setResponses(
+		execResponse{},
+		execResponse{rows: [][]any{{true}}},
+	)
+	dir := writeMigrationFiles(t, map[string]string{"0001_init.up.sql": "CREATE TABLE a (id int);"})
+
+	require.NoError(t, ApplyMigrations(context.Background(), runner, testPurgeDSN, dir, time.Second, nil))
+
+	t.Log("Миграции применяются только после захвата блокировки")
+	require.Equal(t, 5, runner.callCount())
+	assert.Equal(t, tryAdvisoryLockSQL, runner.calls[1].statement)
+	assert.Equal(t, createSchemaMigrationsSQL, runner.calls[2].statement)
+	assert.Contains(t, runner.calls[4].statement, "INSERT INTO public.schema_migrations")
+	assert.Equal(t, 1, runner.closed)
+}
+
+func TestExpectSchemaVersion(t *testing.T) {
+	ctx := context.Background()
+
+	t.Log("Шаг 1: схема достигла ожидаемой версии")
+	runner := &fakeRunner{}
+	runner.setResponses(execResponse{rows: [][]any{{true}}}, execResponse{rows: [][]any{{int64(5)}}})
+	require.NoError(t, ExpectSchemaVersion(ctx, runner, testPurgeDSN, 5))
+
+	t.Log("Шаг 2: схема отстаёт")
+	runner = &fakeRunner{}
+	runner.setResponses(execResponse{rows: [][]any{{true}}}, execResponse{rows: [][]any{{int64(3)}}})
+	err := ExpectSchemaVersion(ctx, runner, testPurgeDSN, 5)
+	require.ErrorIs(t, err, ErrSchemaVersion)
+	assert.Contains(t, err.Error(), "database is at 3, service expects 5")
+
+	t.Log("Шаг 3: таблицы schema_migrations ещё нет — версия 0, таблица не создаётся")
+	runner = &fakeRunner{}
+	runner.setResponses(execResponse{rows: [][]any{{false}}})
+	require.ErrorIs(t, ExpectSchemaVersion(ctx, runner, testPurgeDSN, 1), ErrSchemaVersion)
+	assert.Equal(t, 1, runner.callCount())
+}
@@ -0,0 +1,541 @@
+// Package memory keeps packet maxima and aggregates in process memory. It implements the same
+// storage contracts as the Postgres repository for local development and tests; everything is
+// lost when the process exits.
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/shared/constants"
+)
+
+// Config controls an in-memory repository.
+type Config struct {
+	// MaxRows bounds the stored packet maxima and, separately, the stored aggregates. Once a bound
+	// is reached the rows with the oldest timestamps are evicted first. Zero means unbounded.
+	MaxRows int
+	// Publisher, when set, receives every packet maximum (rank 1) after it has been stored.
+	Publisher domain.PacketMaxPublisher
+}
+
+// rowKey orders packet maxima by timestamp; like packet_max under its upsert, a row is unique per
+// packet and source. Timestamps are kept in the microseconds Postgres stores.
+type rowKey struct {
+	ts       int64
+	packetID string
+	sourceID string
+}
+
+func lessRowKey(a, b rowKey) bool {
+	if a.ts != b.ts {
+		return a.ts < b.ts
+	}
+	if a.packetID != b.packetID {
+		return a.packetID < b.packetID
+	}
+	return a.sourceID < b.sourceID
+}
+
+// aggregateKey orders aggregates by timestamp; an aggregate is unique per packet and function.
+type aggregateKey struct {
+	ts       int64
+	packetID string
+	function string
+}
+
+func lessAggregateKey(a, b aggregateKey) bool {
+	if a.ts != b.ts {
+		return a.ts < b.ts
+	}
+	if a.packetID != b.packetID {
+		return a.packetID < b.packetID
+	}
+	return a.function < b.function
+}
+
+// Repository is a thread-safe in-memory domain.Repository.
+type Repository struct {
+	mu        sync.RWMutex
+	maxRows   int
+	publisher domain.PacketMaxPublisher
+	closed    bool
+
+	rows     map[rowKey]domain.PacketMax
+	byPacket map[string]map[rowKey]struct{}
+	order    orderedKeys[rowKey]
+	// rowTS finds the stored timestamp of a packet and source, whose row an upsert moves.
+	rowTS map[[2]string]int64
+
+	aggregates     map[aggregateKey]domain.PacketAggregate
+	aggregateOrder orderedKeys[aggregateKey]
+	// aggregateTS finds the stored timestamp of a packet's aggregate, which an upsert replaces.
+	aggregateTS map[[2]string]int64
+}
+
+// New creates an empty repository.
+func New(cfg Config) *Repository {
+	maxRows := cfg.MaxRows
+	if maxRows < 0 {
+		maxRows = 0
+	}
+	return &Repository{
+		maxRows:        maxRows,
+		publisher:      cfg.Publisher,
+		rows:           make(map[rowKey]domain.PacketMax),
+		byPacket:       make(map[string]map[rowKey]struct{}),
+		order:          orderedKeys[rowKey]{less: lessRowKey},
+		rowTS:          make(map[[2]string]int64),
+		aggregates:     make(map[aggregateKey]domain.PacketAggregate),
+		aggregateOrder: orderedKeys[aggregateKey]{less: lessAggregateKey},
+		aggregateTS:    make(map[[2]string]int64),
+	}
+}
+
+// Close marks the repository closed; later writes fail. Stored rows stay readable.
+func (r *Repository) Close() error {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+	return nil
+}
+
+// Len returns the number of stored packet maxima of every rank.
+func (r *Repository) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.rows)
+}
+
+// Add stores a packet maximum with the semantics of the Postgres upsert: a packet and source keep
+// one row, which takes the greater value, the better rank and the timestamp of the latest write.
+func (r *Repository) Add(ctx context.Context, packetMax domain.PacketMax) error {
+	if err := validatePacketMax(packetMax); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rank := packetRank(packetMax)
+	ts := storedTime(packetMax.Timestamp)
+	id := [2]string{canonicalID(packetMax.PacketID), canonicalID(packetMax.SourceID)}
+	key := rowKey{ts: ts.UnixMicro(), packetID: id[0], sourceID: id[1]}
+	row := domain.PacketMax{PacketID: key.packetID, SourceID: key.sourceID, Value: packetMax.Value, Timestamp: ts, Rank: rank}
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return errors.New("memory repository: repository closed")
+	}
+	if storedTS, ok := r.rowTS[id]; ok {
+		old := rowKey{ts: storedTS, packetID: key.packetID, sourceID: key.sourceID}
+		stored := r.rows[old]
+		row.Value = math.Max(row.Value, stored.Value)
+		row.Rank = min(row.Rank, stored.Rank)
+		r.removeRow(old)
+	}
+	r.rows[key] = row
+	r.rowTS[id] = key.ts
+	keys, ok := r.byPacket[key.packetID]
+	if !ok {
+		keys = make(map[rowKey]struct{})
+		r.byPacket[key.packetID] = keys
+	}
+	keys[key] = struct{}{}
+	r.order.insert(key)
+	r.evictRows()
+	r.mu.Unlock()
+
+	if r.publisher != nil && rank == 1 {
+		r.publisher.Publish(packetMax)
+	}
+	return nil
+}
+
+// removeRow forgets a stored packet maximum. The caller holds r.mu.
+func (r *Repository) removeRow(key rowKey) {
+	delete(r.rows, key)
+	delete(r.rowTS, [2]string{key.packetID, key.sourceID})
+	r.order.remove(key)
+	keys := r.byPacket[key.packetID]
+	delete(keys, key)
+	if len(keys) == 0 {
+		delete(r.byPacket, key.packetID)
+	}
+}
+
+// evictRows drops the oldest packet maxima beyond maxRows. The caller holds r.mu.
+func (r *Repository) evictRows() {
+	for r.maxRows > 0 && len(r.order.keys) > r.maxRows {
+		r.removeRow(r.order.keys[0])
+	}
+}
+
+// PacketMaxByID returns the stored maximum for the provided packet identifier.
+func (r *Repository) PacketMaxByID(ctx context.Context, packetID string) (domain.PacketMax, error) {
+	if _, err := constants.ParseUUID(packetID); err != nil {
+		return domain.PacketMax{}, fmt.Errorf("memory repository: invalid packet id: %w", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var (
+		best  domain.PacketMax
+		found bool
+	)
+	for key := range r.byPacket[canonicalID(packetID)] {
+		row := r.rows[key]
+		if row.Rank != 1 {
+			continue
+		}
+		if !found || row.Value > best.Value || (row.Value == best.Value && row.Timestamp.After(best.Timestamp)) {
+			best, found = row, true
+		}
+	}
+	if !found {
+		return domain.PacketMax{}, domain.ErrNotFound
+	}
+	return withoutRank(best), nil
+}
+
+// PacketMaxInRange returns one page of the maxima recorded within [from, to], ordered by
+// timestamp and packet id.
+func (r *Repository) PacketMaxInRange(ctx context.Context, from, to time.Time, page domain.PageRequest) (domain.PacketMaxPage, error) {
+	if page.Limit <= 0 {
+		return domain.PacketMaxPage{}, errors.New("memory repository: page limit must be positive")
+	}
+
+	after, err := decodeCursor(page.Cursor)
+	if err != nil {
+		return domain.PacketMaxPage{}, fmt.Errorf("memory repository: packet max in range: %w", err)
+	}
+
+	packetMaxes := r.scan(from, to, after, page.Limit+1)
+	if len(packetMaxes) == 0 && page.Cursor == "" {
+		return domain.PacketMaxPage{}, domain.ErrNotFound
+	}
+	if len(packetMaxes) <= page.Limit {
+		return domain.PacketMaxPage{PacketMaxes: packetMaxes}, nil
+	}
+
+	packetMaxes = packetMaxes[:page.Limit]
+	last := packetMaxes[page.Limit-1]
+	cursor := domain.PageCursor{Timestamp: last.Timestamp, PacketID: last.PacketID}
+	return domain.PacketMaxPage{PacketMaxes: packetMaxes, NextCursor: cursor.Encode()}, nil
+}
+
+// IteratePacketMaxInRange walks the maxima recorded within [from, to] in the order of
+// PacketMaxInRange. The iterator reads a copy taken when it is created, so concurrent writes do
+// not affect it.
+func (r *Repository) IteratePacketMaxInRange(ctx context.Context, from, to time.Time) (domain.PacketMaxIterator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &packetMaxIterator{ctx: ctx, rows: r.scan(from, to, nil, 0)}, nil
+}
+
+// scan returns the rank 1 maxima within [from, to] positioned after the cursor, up to limit rows
+// when limit is positive.
+func (r *Repository) scan(from, to time.Time, after *keysetCursor, limit int) []domain.PacketMax {
+	fromMicro := from.Round(time.Microsecond).UnixMicro()
+	toMicro := to.Round(time.Microsecond).UnixMicro()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := r.order.keys
+	start := sort.Search(len(keys), func(i int) bool { return keys[i].ts >= fromMicro })
+	var results []domain.PacketMax
+	for _, key := range keys[start:] {
+		if key.ts > toMicro || (limit > 0 && len(results) == limit) {
+			break
+		}
+		if after.covers(key.ts, key.packetID) {
+			continue
+		}
+		if row := r.rows[key]; row.Rank == 1 {
+			results = append(results, withoutRank(row))
+		}
+	}
+	return results
+}
+
+// TopPacketMaxByID returns up to k ranked maxima stored for the packet, best first.
+// A non-positive k returns every stored rank.
+func (r *Repository) TopPacketMaxByID(ctx context.Context, packetID string, k int) ([]domain.PacketMax, error) {
+	if _, err := constants.ParseUUID(packetID); err != nil {
+		return nil, fmt.Errorf("memory repository: invalid packet id: %w", err)
+	}
+
+	packetID = canonicalID(packetID)
+	r.mu.RLock()
+	results := make([]domain.PacketMax, 0, len(r.byPacket[packetID]))
+	for key := range r.byPacket[packetID] {
+		results = append(results, r.rows[key])
+	}
+	r.mu.RUnlock()
+
+	if len(results) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank < results[j].Rank
+		}
+		return results[i].Value > results[j].Value
+	})
+	if k > 0 && len(results) > k {
+		results = results[:k]
+	}
+	return results, nil
+}
+
+// AddAggregate upserts the result of an aggregation function for a packet.
+func (r *Repository) AddAggregate(ctx context.Context, aggregate domain.PacketAggregate) error {
+	if err := validatePacketAggregate(aggregate); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	aggregate.PacketID = canonicalID(aggregate.PacketID)
+	aggregate.SourceID = canonicalID(aggregate.SourceID)
+	aggregate.Timestamp = storedTime(aggregate.Timestamp)
+	id := [2]string{aggregate.PacketID, aggregate.Function}
+	key := aggregateKey{ts: aggregate.Timestamp.UnixMicro(), packetID: aggregate.PacketID, function: aggregate.Function}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return errors.New("memory repository: repository closed")
+	}
+
+	if ts, ok := r.aggregateTS[id]; ok {
+		old := aggregateKey{ts: ts, packetID: aggregate.PacketID, function: aggregate.Function}
+		delete(r.aggregates, old)
+		r.aggregateOrder.remove(old)
+	}
+	r.aggregates[key] = aggregate
+	r.aggregateTS[id] = key.ts
+	r.aggregateOrder.insert(key)
+
+	for r.maxRows > 0 && len(r.aggregateOrder.keys) > r.maxRows {
+		oldest := r.aggregateOrder.keys[0]
+		r.aggregateOrder.keys = r.aggregateOrder.keys[1:]
+		delete(r.aggregates, oldest)
+		delete(r.aggregateTS, [2]string{oldest.packetID, oldest.function})
+	}
+	return nil
+}
+
+// AggregateByID returns the stored result of function for the provided packet identifier.
+func (r *Repository) AggregateByID(ctx context.Context, function, packetID string) (domain.PacketAggregate, error) {
+	if _, err := constants.ParseUUID(packetID); err != nil {
+		return domain.PacketAggregate{}, fmt.Errorf("memory repository: invalid packet id: %w", err)
+	}
+
+	packetID = canonicalID(packetID)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ts, ok := r.aggregateTS[[2]string{packetID, function}]
+	if !ok {
+		return domain.PacketAggregate{}, domain.ErrNotFound
+	}
+	return r.aggregates[aggregateKey{ts: ts, packetID: packetID, function: function}], nil
+}
+
+// AggregatesInRange returns one page of the results of function recorded within [from, to],
+// ordered by timestamp and packet id.
+func (r *Repository) AggregatesInRange(ctx context.Context, function string, from, to time.Time, page domain.PageRequest) (domain.PacketAggregatePage, error) {
+	if page.Limit <= 0 {
+		return domain.PacketAggregatePage{}, errors.New("memory repository: page limit must be positive")
+	}
+	after, err := decodeCursor(page.Cursor)
+	if err != nil {
+		return domain.PacketAggregatePage{}, fmt.Errorf("memory repository: aggregates in range: %w", err)
+	}
+
+	fromMicro := from.Round(time.Microsecond).UnixMicro()
+	toMicro := to.Round(time.Microsecond).UnixMicro()
+
+	r.mu.RLock()
+	keys := r.aggregateOrder.keys
+	start := sort.Search(len(keys), func(i int) bool { return keys[i].ts >= fromMicro })
+	var results []domain.PacketAggregate
+	for _, key := range keys[start:] {
+		if key.ts > toMicro || len(results) > page.Limit {
+			break
+		}
+		if key.function != function || after.covers(key.ts, key.packetID) {
+			continue
+		}
+		results = append(results, r.aggregates[key])
+	}
+	r.mu.RUnlock()
+
+	if len(results) == 0 && page.Cursor == "" {
+		return domain.PacketAggregatePage{}, domain.ErrNotFound
+	}
+	if len(results) <= page.Limit {
+		return domain.PacketAggregatePage{Aggregates: results}, nil
+	}
+
+	results = results[:page.Limit]
+	last := results[page.Limit-1]
+	cursor := domain.PageCursor{Timestamp: last.Timestamp, PacketID: last.PacketID}
+	return domain.PacketAggregatePage{Aggregates: results, NextCursor: cursor.Encode()}, nil
+}
+
+// keysetCursor is a decoded page cursor in the units rows are keyed by.
+type keysetCursor struct {
+	ts       int64
+	packetID string
+}
+
+// decodeCursor parses a page cursor; an empty token starts at the beginning of the range.
+func decodeCursor(token string) (*keysetCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	cursor, err := domain.DecodePageCursor(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := constants.ParseUUID(cursor.PacketID); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidCursor, err)
+	}
+	return &keysetCursor{ts: cursor.Timestamp.Round(time.Microsecond).UnixMicro(), packetID: canonicalID(cursor.PacketID)}, nil
+}
+
+// covers reports whether a row at (ts, packetID) was already returned on an earlier page.
+func (c *keysetCursor) covers(ts int64, packetID string) bool {
+	if c == nil {
+		return false
+	}
+	return ts < c.ts || (ts == c.ts && packetID <= c.packetID)
+}
+
+// orderedKeys keeps keys sorted by less for range scans and oldest-first eviction.
+type orderedKeys[K comparable] struct {
+	keys []K
+	less func(a, b K) bool
+}
+
+func (o *orderedKeys[K]) insert(key K) {
+	i := sort.Search(len(o.keys), func(i int) bool { return !o.less(o.keys[i], key) })
+	o.keys = append(o.keys, key)
+	copy(o.keys[i+1:], o.keys[i:])
+	o.keys[i] = key
+}
+
+func (o *orderedKeys[K]) remove(key K) {
+	i := sort.Search(len(o.keys), func(i int) bool { return !o.less(o.keys[i], key) })
+	if i < len(o.keys) && o.keys[i] == key {
+		o.keys = append(o.keys[:i], o.keys[i+1:]...)
+	}
+}
+
+// packetMaxIterator walks a copy of the rows taken when the iteration started.
+type packetMaxIterator struct {
+	ctx     context.Context
+	rows    []domain.PacketMax
+	next    int
+	current domain.PacketMax
+	err     error
+}
+
+func (it *packetMaxIterator) Next() bool {
+	if it.err != nil || it.next >= len(it.rows) {
+		return false
+	}
+	if it.err = it.ctx.Err(); it.err != nil {
+		return false
+	}
+	it.current = it.rows[it.next]
+	it.next++
+	return true
+}
+
+func (it *packetMaxIterator) PacketMax() domain.PacketMax { return it.current }
+
+func (it *packetMaxIterator) Err() error { return it.err }
+
+func (it *packetMaxIterator) Close() error {
+	it.rows = nil
+	return nil
+}
+
+// storedTime rounds t to the microseconds Postgres keeps and reports it in UTC, as reads from
+// Postgres do.
+func storedTime(t time.Time) time.Time {
+	return t.Round(time.Microsecond).UTC()
+}
+
+// canonicalID lowercases a UUID the way Postgres prints the uuid type, so lookups and keyset
+// order do not depend on the case the caller used.
+func canonicalID(id string) string {
+	return strings.ToLower(id)
+}
+
+// withoutRank mirrors the Postgres reads of rank 1 maxima, which do not select the rank column.
+func withoutRank(packetMax domain.PacketMax) domain.PacketMax {
+	packetMax.Rank = 0
+	return packetMax
+}
+
+func packetRank(packetMax domain.PacketMax) int {
+	if packetMax.Rank <= 0 {
+		return 1
+	}
+	return packetMax.Rank
+}
+
+func validatePacketMax(packetMax domain.PacketMax) error {
+	if packetMax.PacketID == "" {
+		return errors.New("memory repository: packet id is required")
+	}
+	if _, err := constants.ParseUUID(packetMax.PacketID); err != nil {
+		return fmt.Errorf("memory repository: invalid packet id: %w", err)
+	}
+	if packetMax.SourceID == "" {
+		return errors.New("memory repository: source id is required")
+	}
+	if packetMax.Rank < 0 {
+		return errors.New("memory repository: rank must not be negative")
+	}
+	if _, err := constants.ParseUUID(packetMax.SourceID); err != nil {
+		return fmt.Errorf("memory repository: invalid source id: %w", err)
+	}
+	return nil
+}
+
+func validatePacketAggregate(aggregate domain.PacketAggregate) error {
+	if aggregate.PacketID == "" {
+		return errors.New("memory repository: packet id is required")
+	}
+	if _, err := constants.ParseUUID(aggregate.PacketID); err != nil {
+		return fmt.Errorf("memory repository: invalid packet id: %w", err)
+	}
+	if strings.TrimSpace(aggregate.Function) == "" {
+		return errors.New("memory repository: aggregation function is required")
+	}
+	if aggregate.SourceID != "" {
+		if _, err := constants.ParseUUID(aggregate.SourceID); err != nil {
+			return fmt.Errorf("memory repository: invalid source id: %w", err)
+		}
+	}
+	return nil
+}
+
+var _ domain.Repository = (*Repository)(nil)
@@ -0,0 +1,275 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/shared/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingPublisher struct {
+	mu        sync.Mutex
+	published []domain.PacketMax
+}
+
+func (p *recordingPublisher) Publish(packetMax domain.PacketMax) {
+	p.mu.Lock()
+	p.published = append(p.published, packetMax)
+	p.mu.Unlock()
+}
+
+var base = time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func newPacketMax(packetID string, value float64, ts time.Time, rank int) domain.PacketMax {
+	return domain.PacketMax{PacketID: packetID, SourceID: constants.GenerateUUID(), Value: value, Timestamp: ts, Rank: rank}
+}
+
+func TestAddMergesLikePostgresUpsert(t *testing.T) {
+	ctx := context.Background()
+	publisher := &recordingPublisher{}
+	repo := New(Config{Publisher: publisher})
+
+	first := newPacketMax(constants.GenerateUUID(), 5, base, 2)
+	again := first
+	again.Value = 3
+	again.Timestamp = base.Add(time.Hour)
+	again.Rank = 1
+	higher := first
+	higher.Value = 9
+	higher.Timestamp = base.Add(time.Minute)
+
+	t.Log("Шаг 1: строки одного packet и source сливаются в одну при любом времени")
+	require.NoError(t, repo.Add(ctx, first))
+	require.NoError(t, repo.Add(ctx, again))
+	require.NoError(t, repo.Add(ctx, higher))
+	assert.Equal(t, 1, repo.Len())
+
+	top, err := repo.TopPacketMaxByID(ctx, first.PacketID, 0)
+	require.NoError(t, err)
+	require.Len(t, top, 1)
+	assert.Equal(t, 9.0, top[0].Value, "keeps the greater value")
+	assert.Equal(t, 1, top[0].Rank, "keeps the better rank")
+	assert.Equal(t, higher.Timestamp, top[0].Timestamp, "moves to the timestamp of the latest write")
+
+	page, err := repo.PacketMaxInRange(ctx, base, base.Add(2*time.Hour), domain.PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.PacketMaxes, 1, "the row is listed once, at its new timestamp")
+	assert.Equal(t, higher.Timestamp, page.PacketMaxes[0].Timestamp)
+
+	t.Log("Шаг 2: подписчикам уходят только максимумы пакета (rank 1)")
+	require.Len(t, publisher.published, 1)
+	assert.Equal(t, again.Value, publisher.published[0].Value)
+
+	t.Log("Шаг 3: некорректные строки отклоняются")
+	assert.Error(t, repo.Add(ctx, domain.PacketMax{PacketID: "nope", SourceID: constants.GenerateUUID()}))
+	assert.Error(t, repo.Add(ctx, domain.PacketMax{PacketID: constants.GenerateUUID()}))
+}
+
+func TestPacketMaxByIDAndTop(t *testing.T) {
+	ctx := context.Background()
+	repo := New(Config{})
+	packetID := constants.GenerateUUID()
+
+	_, err := repo.PacketMaxByID(ctx, packetID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repo.PacketMaxByID(ctx, "invalid")
+	assert.Error(t, err)
+
+	require.NoError(t, repo.Add(ctx, newPacketMax(packetID, 7, base, 0)))
+	require.NoError(t, repo.Add(ctx, newPacketMax(packetID, 5, base, 2)))
+	require.NoError(t, repo.Add(ctx, newPacketMax(packetID, 3, base, 3)))
+
+	packetMax, err := repo.PacketMaxByID(ctx, strings.ToUpper(packetID))
+	require.NoError(t, err)
+	assert.Equal(t, 7.0, packetMax.Value)
+	assert.Equal(t, 0, packetMax.Rank)
+
+	top, err := repo.TopPacketMaxByID(ctx, packetID, 2)
+	require.NoError(t, err)
+	require.Len(t, top, 2)
+	assert.Equal(t, []int{1, 2}, []int{top[0].Rank, top[1].Rank})
+}
+
+func TestPacketMaxInRangePaginates(t *testing.T) {
+	ctx := context.Background()
+	repo := New(Config{})
+
+	var want []string
+	for i := 0; i < 5; i++ {
+		packetID := constants.GenerateUUID()
+		require.NoError(t, repo.Add(ctx, newPacketMax(packetID, float64(i), base.Add(time.Duration(4-i)*time.Second), 1)))
+		want = append([]string{packetID}, want...)
+		require.NoError(t, repo.Add(ctx, newPacketMax(packetID, 0, base.Add(time.Duration(4-i)*time.Second), 2)))
+	}
+	require.NoError(t, repo.Add(ctx, newPacketMax(constants.GenerateUUID(), 1, base.Add(time.Hour), 1)))
+
+	t.Log("Шаг 1: страницы идут по времени, без строк вне диапазона и с рангом больше 1")
+	var got []string
+	page := domain.PageRequest{Limit: 2}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 5)
+		result, err := repo.PacketMaxInRange(ctx, base, base.Add(4*time.Second), page)
+		require.NoError(t, err)
+		for _, packetMax := range result.PacketMaxes {
+			got = append(got, packetMax.PacketID)
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		page.Cursor = result.NextCursor
+	}
+	assert.Equal(t, want, got)
+
+	t.Log("Шаг 2: итератор проходит тот же диапазон целиком")
+	it, err := repo.IteratePacketMaxInRange(ctx, base, base.Add(4*time.Second))
+	require.NoError(t, err)
+	var iterated []string
+	for it.Next() {
+		iterated = append(iterated, it.PacketMax().PacketID)
+	}
+	require.NoError(t, it.Err())
+	require.NoError(t, it.Close())
+	assert.Equal(t, want, iterated)
+
+	t.Log("Шаг 3: пустой диапазон и испорченный курсор")
+	_, err = repo.PacketMaxInRange(ctx, base.Add(-time.Hour), base.Add(-time.Minute), domain.PageRequest{Limit: 1})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repo.PacketMaxInRange(ctx, base, base, domain.PageRequest{Limit: 1, Cursor: "???"})
+	assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+}
+
+func TestIteratorStopsWhenContextEnds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	repo := New(Config{})
+	require.NoError(t, repo.Add(ctx, newPacketMax(constants.GenerateUUID(), 1, base, 1)))
+	require.NoError(t, repo.Add(ctx, newPacketMax(constants.GenerateUUID(), 2, base.Add(time.Second), 1)))
+
+	it, err := repo.IteratePacketMaxInRange(ctx, base, base.Add(time.Minute))
+	require.NoError(t, err)
+	require.True(t, it.Next())
+	cancel()
+	assert.False(t, it.Next())
+	assert.ErrorIs(t, it.Err(), context.Canceled)
+}
+
+func TestMaxRowsEvictsOldest(t *testing.T) {
+	ctx := context.Background()
+	repo := New(Config{MaxRows: 3})
+
+	ids := make([]string, 5)
+	for i := range ids {
+		ids[i] = constants.GenerateUUID()
+		require.NoError(t, repo.Add(ctx, newPacketMax(ids[i], 1, base.Add(time.Duration(i)*time.Second), 1)))
+	}
+
+	assert.Equal(t, 3, repo.Len())
+	_, err := repo.PacketMaxByID(ctx, ids[1])
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repo.PacketMaxByID(ctx, ids[2])
+	assert.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, repo.AddAggregate(ctx, domain.PacketAggregate{
+			PacketID: ids[i], Function: "sum", Value: float64(i), Timestamp: base.Add(time.Duration(i) * time.Second),
+		}))
+	}
+	aggregates, err := repo.AggregatesInRange(ctx, "sum", base, base.Add(time.Minute), domain.PageRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, aggregates.Aggregates, 3)
+	assert.Equal(t, ids[2], aggregates.Aggregates[0].PacketID)
+}
+
+func TestAggregatesUpsertPerFunction(t *testing.T) {
+	ctx := context.Background()
+	repo := New(Config{})
+	packetID := constants.GenerateUUID()
+
+	require.NoError(t, repo.AddAggregate(ctx, domain.PacketAggregate{PacketID: packetID, Function: "sum", Value: 1, Timestamp: base}))
+	require.NoError(t, repo.AddAggregate(ctx, domain.PacketAggregate{PacketID: packetID, Function: "mean", Value: 2, Timestamp: base}))
+	require.NoError(t, repo.AddAggregate(ctx, domain.PacketAggregate{PacketID: packetID, Function: "sum", Value: 3, Timestamp: base.Add(time.Minute)}))
+	assert.Error(t, repo.AddAggregate(ctx, domain.PacketAggregate{PacketID: packetID}))
+
+	sum, err := repo.AggregateByID(ctx, "sum", packetID)
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, sum.Value)
+	assert.Equal(t, base.Add(time.Minute), sum.Timestamp)
+
+	_, err = repo.AggregatesInRange(ctx, "sum", base, base, domain.PageRequest{Limit: 10})
+	assert.ErrorIs(t, err, domain.ErrNotFound, "the upsert moved the sum out of the first range")
+	sums, err := repo.AggregatesInRange(ctx, "sum", base, base.Add(time.Hour), domain.PageRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, sums.Aggregates, 1)
+	_, err = repo.AggregateByID(ctx, "max", packetID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestAggregatesInRangePaginates(t *testing.T) {
+	ctx := context.Background()
+	repo := New(Config{})
+
+	var want []string
+	for i := 0; i < 5; i++ {
+		packetID := constants.GenerateUUID()
+		require.NoError(t, repo.AddAggregate(ctx, domain.PacketAggregate{PacketID: packetID, Function: "sum", Value: 1, Timestamp: base.Add(time.Duration(i) * time.Second)}))
+		require.NoError(t, repo.AddAggregate(ctx, domain.PacketAggregate{PacketID: packetID, Function: "mean", Value: 1, Timestamp: base}))
+		want = append(want, packetID)
+	}
+
+	var got []string
+	page := domain.PageRequest{Limit: 2}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 5)
+		result, err := repo.AggregatesInRange(ctx, "sum", base, base.Add(time.Minute), page)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(result.Aggregates), 2)
+		for _, aggregate := range result.Aggregates {
+			got = append(got, aggregate.PacketID)
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		page.Cursor = result.NextCursor
+	}
+	assert.Equal(t, want, got)
+
+	_, err := repo.AggregatesInRange(ctx, "sum", base, base, domain.PageRequest{Limit: 1, Cursor: "???"})
+	assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+}
+
+func TestConcurrentAdds(t *testing.T) {
+	ctx := context.Background()
+	repo := New(Config{MaxRows: 500})
+	packetID := constants.GenerateUUID()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				ts := base.Add(time.Duration(w*100+i) * time.Millisecond)
+				_ = repo.Add(ctx, newPacketMax(packetID, float64(i), ts, 1))
+				_, _ = repo.PacketMaxInRange(ctx, base, base.Add(time.Hour), domain.PageRequest{Limit: 10})
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 500, repo.Len())
+	top, err := repo.TopPacketMaxByID(ctx, packetID, 0)
+	require.NoError(t, err)
+	assert.Len(t, top, 500)
+}
+
+func TestClosedRepositoryRejectsWrites(t *testing.T) {
+	repo := New(Config{})
+	require.NoError(t, repo.Close())
+	assert.Error(t, repo.Add(context.Background(), newPacketMax(constants.GenerateUUID(), 1, base, 1)))
+}
@@ -3,7 +3,8 @@ package database
 import (
 	"context"
 	"database/sql"
-	"encoding/csv"
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -12,44 +13,151 @@ import (
 	_ "github.com/lib/pq"
 )
 
+// maxPreparedStatements bounds the statement cache of SQLRunner; queries beyond it run unprepared.
+const maxPreparedStatements = 64
+
+// SQLRunner runs statements on a pool per DSN. Queries are prepared once per pool and the
+// resulting *sql.Stmt is kept, so every connection of the pool prepares a query the first time
+// it runs it and reuses it after that.
 type SQLRunner struct {
-	mu  sync.Mutex
-	dbs map[string]*sql.DB
+	mu    sync.Mutex
+	dbs   map[string]*sql.DB
+	stmts map[statementKey]*sql.Stmt
+}
+
+type statementKey struct {
+	dsn       string
+	statement string
 }
 
 func newSQLRunner() CommandRunner {
-	return &SQLRunner{dbs: make(map[string]*sql.DB)}
+	return &SQLRunner{dbs: make(map[string]*sql.DB), stmts: make(map[statementKey]*sql.Stmt)}
 }
 
 func NewSQLRunner() CommandRunner {
 	return newSQLRunner()
 }
 
-func (r *SQLRunner) Exec(ctx context.Context, dsn, _ string, statement string, args ...any) (string, error) {
-	if err := ctx.Err(); err != nil {
-		return "", err
+func (r *SQLRunner) Exec(ctx context.Context, dsn, _ string, statement string, args ...any) (sql.Result, error) {
+	db, err := r.pool(ctx, dsn, statement)
+	if err != nil {
+		return nil, err
+	}
+	return db.ExecContext(ctx, statement, args...)
+}
+
+func (r *SQLRunner) Query(ctx context.Context, dsn, _ string, statement string, args ...any) (Rows, error) {
+	db, err := r.pool(ctx, dsn, statement)
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := r.prepared(ctx, db, dsn, statement)
+	if err != nil {
+		return nil, err
+	}
+	if stmt == nil {
+		return db.QueryContext(ctx, statement, args...)
+	}
+	return stmt.QueryContext(ctx, args...)
+}
+
+// prepared returns the cached statement for the query, preparing it on first use. It returns nil
+// once the cache is full.
+func (r *SQLRunner) prepared(ctx context.Context, db *sql.DB, dsn, statement string) (*sql.Stmt, error) {
+	key := statementKey{dsn: dsn, statement: statement}
+	r.mu.Lock()
+	stmt, ok := r.stmts[key]
+	full := len(r.stmts) >= maxPreparedStatements
+	r.mu.Unlock()
+	if ok {
+		return stmt, nil
+	}
+	if full {
+		return nil, nil
 	}
 
-	query := strings.TrimSpace(statement)
-	if query == "" {
-		return "", nil
+	stmt, err := db.PrepareContext(ctx, statement)
+	if err != nil {
+		return nil, fmt.Errorf("sql runner: prepare: %w", err)
 	}
 
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.stmts[key]; ok {
+		_ = stmt.Close()
+		return existing, nil
+	}
+	r.stmts[key] = stmt
+	return stmt, nil
+}
+
+// Session is one database connection set aside for the caller, for state that lives as long as
+// the connection does, such as session-level advisory locks.
+type Session interface {
+	Exec(ctx context.Context, statement string, args ...any) (sql.Result, error)
+	Query(ctx context.Context, statement string, args ...any) (Rows, error)
+	Close() error
+}
+
+// SessionRunner is implemented by runners that can set a connection aside as a Session.
+type SessionRunner interface {
+	Session(ctx context.Context, dsn, password string) (Session, error)
+}
+
+// Session takes a connection out of the pool for dsn until the session is closed.
+func (r *SQLRunner) Session(ctx context.Context, dsn, _ string) (Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	db, err := r.dbFor(ctx, dsn)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("sql runner: session: %w", err)
+	}
+	return sqlSession{conn: conn}, nil
+}
 
-	if isSelectStatement(query) {
-		return runSelectAsCSV(ctx, db, query, args...)
+type sqlSession struct {
+	conn *sql.Conn
+}
+
+func (s sqlSession) Exec(ctx context.Context, statement string, args ...any) (sql.Result, error) {
+	return s.conn.ExecContext(ctx, statement, args...)
+}
+
+func (s sqlSession) Query(ctx context.Context, statement string, args ...any) (Rows, error) {
+	return s.conn.QueryContext(ctx, statement, args...)
+}
+
+// Close discards the connection instead of returning it to the pool. That ends the database
+// session, and with it every advisory lock the session still holds.
+func (s sqlSession) Close() error {
+	_ = s.conn.Raw(func(any) error { return driver.ErrBadConn })
+	return nil
+}
+
+// pool checks the context and the statement and returns the pool for dsn.
+func (r *SQLRunner) pool(ctx context.Context, dsn, statement string) (*sql.DB, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	return runCommand(ctx, db, query, args...)
+	if strings.TrimSpace(statement) == "" {
+		return nil, errors.New("sql runner: empty statement")
+	}
+	return r.dbFor(ctx, dsn)
 }
 
 func (r *SQLRunner) Close() error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	for key, stmt := range r.stmts {
+		_ = stmt.Close()
+		delete(r.stmts, key)
+	}
 	for dsn, db := range r.dbs {
 		_ = db.Close()
 		delete(r.dbs, dsn)
@@ -113,114 +221,7 @@ func contextForPool(ctx context.Context) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(context.Background(), 5*time.Second)
 }
 
-func isSelectStatement(statement string) bool {
-	s := strings.ToUpper(statement)
-	return strings.HasPrefix(s, "SELECT") || strings.HasPrefix(s, "WITH")
-}
-
-func runSelectAsCSV(ctx context.Context, db *sql.DB, query string, args ...any) (string, error) {
-	rows, err := db.QueryContext(ctx, query, args...)
-	if err != nil {
-		return "", err
-	}
-	defer rows.Close()
-
-	return writeCSV(rows)
-}
-
-func runCommand(ctx context.Context, db *sql.DB, query string, args ...any) (string, error) {
-	result, err := db.ExecContext(ctx, query, args...)
-	if err != nil {
-		return "", err
-	}
-	return commandTag(query, result)
-}
-
-func writeCSV(rows *sql.Rows) (string, error) {
-	columns, err := rows.Columns()
-	if err != nil {
-		return "", err
-	}
-
-	var b strings.Builder
-	writer := csv.NewWriter(&b)
-	wrote := false
-
-	for rows.Next() {
-		record, err := scanRow(rows, len(columns))
-		if err != nil {
-			return "", err
-		}
-		if err := writer.Write(record); err != nil {
-			return "", err
-		}
-		wrote = true
-	}
-
-	writer.Flush()
-	if err := writer.Error(); err != nil {
-		return "", err
-	}
-	if err := rows.Err(); err != nil {
-		return "", err
-	}
-	if !wrote {
-		return "", nil
-	}
-
-	return b.String(), nil
-}
-
-func scanRow(rows *sql.Rows, n int) ([]string, error) {
-	values := make([]any, n)
-	ptrs := make([]any, n)
-	for i := range values {
-		ptrs[i] = &values[i]
-	}
-
-	if err := rows.Scan(ptrs...); err != nil {
-		return nil, err
-	}
-
-	record := make([]string, n)
-	for i, v := range values {
-		record[i] = formatValue(v)
-	}
-	return record, nil
-}
-
-func formatValue(value any) string {
-	switch v := value.(type) {
-	case nil:
-		return ""
-	case string:
-		return v
-	case []byte:
-		return string(v)
-	case time.Time:
-		return v.UTC().Format(time.RFC3339Nano)
-	case fmt.Stringer:
-		return v.String()
-	default:
-		return fmt.Sprint(v)
-	}
-}
-
-func commandTag(statement string, result sql.Result) (string, error) {
-	affected, err := result.RowsAffected()
-	if err != nil {
-		return "", err
-	}
-
-	verb := strings.ToUpper(strings.Fields(statement)[0])
-	switch verb {
-	case "INSERT":
-		return fmt.Sprintf("INSERT 0 %d", affected), nil
-	case "UPDATE", "DELETE":
-		return fmt.Sprintf("%s %d", verb, affected), nil
-	default:
-		return fmt.Sprintf("%s %d", verb, affected), nil
-	}
-}
-
-var _ CommandRunner = (*SQLRunner)(nil)
+var (
+	_ CommandRunner = (*SQLRunner)(nil)
+	_ SessionRunner = (*SQLRunner)(nil)
+)
@@ -2,18 +2,109 @@ package database
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"aggregator-service/app/src/infra"
 )
 
 const defaultMigrationsDir = "app/resources/db/migrations"
 
+// LatestVersion as a target applies every pending migration.
+const LatestVersion int64 = math.MaxInt64
+
+const (
+	createSchemaMigrationsSQL = `
+CREATE TABLE IF NOT EXISTS public.schema_migrations (
+  version    BIGINT PRIMARY KEY,
+  name       TEXT NOT NULL,
+  checksum   TEXT NOT NULL,
+  applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+ALTER TABLE public.schema_migrations ADD COLUMN IF NOT EXISTS schema_snapshot TEXT`
+	selectSchemaMigrationsSQL = `
+SELECT version, name, checksum, applied_at
+FROM public.schema_migrations
+ORDER BY version ASC
+`
+	// selectSchemaSnapshotSQL goes through to_jsonb so that it also reads tables created before
+	// the schema_snapshot column existed, where it reports an empty snapshot.
+	selectSchemaSnapshotSQL = `
+SELECT version, coalesce(to_jsonb(s) ->> 'schema_snapshot', '')
+FROM public.schema_migrations s
+ORDER BY version DESC
+LIMIT 1
+`
+)
+
+// schemaSnapshotSQL renders the public schema as sorted lines, one per relation, column, index
+// and constraint. It is recorded after every applied migration and compared with the live schema
+// by Verify. Partitions are left out: the partition maintainer creates and drops them at runtime,
+// and detached ones keep their packet_max_pYYYYMM names.
+var schemaSnapshotSQL = `(
+WITH rels AS (
+  SELECT c.oid, c.relname, c.relkind
+  FROM pg_catalog.pg_class c
+  JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+  WHERE n.nspname = 'public'
+    AND c.relkind IN ('r', 'p', 'v', 'm', 'S')
+    AND NOT c.relispartition
+    AND c.relname <> 'schema_migrations'
+    AND c.relname !~ '^` + partitionPrefix + `[0-9]+$'
+), objects AS (
+  SELECT format('relation %s %s', r.relname, r.relkind) AS line
+  FROM rels r
+  UNION ALL
+  SELECT format('column %s.%s %s%s%s', r.relname, a.attname, pg_catalog.format_type(a.atttypid, a.atttypmod),
+    CASE WHEN a.attnotnull THEN ' not null' ELSE '' END,
+    coalesce(' default ' || pg_catalog.pg_get_expr(d.adbin, d.adrelid), ''))
+  FROM rels r
+  JOIN pg_catalog.pg_attribute a ON a.attrelid = r.oid AND a.attnum > 0 AND NOT a.attisdropped
+  LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+  WHERE r.relkind <> 'S'
+  UNION ALL
+  SELECT format('index %s', pg_catalog.pg_get_indexdef(i.indexrelid))
+  FROM rels r
+  JOIN pg_catalog.pg_index i ON i.indrelid = r.oid
+  UNION ALL
+  SELECT format('constraint %s.%s %s', r.relname, k.conname, pg_catalog.pg_get_constraintdef(k.oid))
+  FROM rels r
+  JOIN pg_catalog.pg_constraint k ON k.conrelid = r.oid
+)
+SELECT coalesce(string_agg(line, E'\n' ORDER BY line), '') FROM objects
+)`
+
+// migrationNamePattern is what `migrate create` accepts as a migration name.
+var migrationNamePattern = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`)
+
+// migrationFilePattern matches NNNN_name.sql, NNNN_name.up.sql and NNNN_name.down.sql. A file
+// without a direction is an up migration that cannot be rolled back.
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([A-Za-z0-9_\-]+?)(\.up|\.down)?\.sql$`)
+
+var (
+	// ErrMigrationChecksum reports an applied migration whose file was edited afterwards.
+	ErrMigrationChecksum = errors.New("migration file changed after it was applied")
+	// ErrMigrationOrder reports a pending migration older than the newest applied one.
+	ErrMigrationOrder = errors.New("migration is older than the applied schema version")
+	// ErrIrreversibleMigration reports a rollback through a migration without a down file.
+	ErrIrreversibleMigration = errors.New("migration has no down file")
+	// ErrMigrationMissing reports an applied migration whose file is gone from the directory.
+	ErrMigrationMissing = errors.New("applied migration is missing from the migrations directory")
+	// ErrSchemaDrift reports a live schema that differs from the one recorded with the newest
+	// applied migration.
+	ErrSchemaDrift = errors.New("schema has drifted from the applied migrations")
+)
+
 func ResolveMigrationsDir() string {
 	if dir := strings.TrimSpace(os.Getenv("MIGRATIONS_DIR")); dir != "" {
 		return dir
@@ -21,70 +112,620 @@ func ResolveMigrationsDir() string {
 	return defaultMigrationsDir
 }
 
-func ApplyMigrations(ctx context.Context, runner CommandRunner, dsn, dir string, logger *infra.Logger) error {
+// Migration is one schema version read from the migrations directory.
+type Migration struct {
+	Version int64
+	Name    string
+	// Up and Down hold the SQL of the paired files; Down is empty when there is no down file.
+	Up   string
+	Down string
+	// Checksum is the SHA-256 of the up file, recorded when the migration is applied.
+	Checksum string
+}
+
+// Reversible reports whether the migration can be rolled back.
+func (m Migration) Reversible() bool {
+	return strings.TrimSpace(m.Down) != ""
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// AppliedMigration is a row of schema_migrations.
+type AppliedMigration struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// MigrationStatus pairs a migration on disk with its schema_migrations row. Checksum is empty when
+// the file is missing and Applied is nil while the migration is pending.
+type MigrationStatus struct {
+	Version  int64
+	Name     string
+	Checksum string
+	Applied  *AppliedMigration
+}
+
+// State is one of pending, applied, modified (the file changed after it was applied) and missing.
+func (s MigrationStatus) State() string {
+	switch {
+	case s.Applied == nil:
+		return "pending"
+	case s.Checksum == "":
+		return "missing"
+	case s.Checksum != s.Applied.Checksum:
+		return "modified"
+	default:
+		return "applied"
+	}
+}
+
+// Step is one statement of a run: a migration file together with its schema_migrations
+// bookkeeping.
+type Step struct {
+	Migration Migration
+	Down      bool
+	SQL       string
+}
+
+// SchemaDrift lists the differences between the live schema and the snapshot recorded with the
+// newest applied migration. Checked is false when that migration was applied without a snapshot.
+type SchemaDrift struct {
+	Version    int64
+	Checked    bool
+	Missing    []string
+	Unexpected []string
+}
+
+// Drifted reports whether the live schema differs from the recorded one.
+func (d SchemaDrift) Drifted() bool {
+	return len(d.Missing) > 0 || len(d.Unexpected) > 0
+}
+
+// LoadMigrations reads the migrations in dir ordered by version.
+func LoadMigrations(dir string) ([]Migration, error) {
 	if strings.TrimSpace(dir) == "" {
-		return errors.New("migrations directory is not specified")
+		return nil, errors.New("migrations directory is not specified")
 	}
 
 	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return fmt.Errorf("read migrations directory %q: %w", dir, err)
+		return nil, fmt.Errorf("read migrations directory %q: %w", dir, err)
 	}
 
-	migrationFiles := make([]os.DirEntry, 0, len(entries))
+	byVersion := make(map[int64]*Migration)
 	for _, entry := range entries {
-		if entry.IsDir() {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
 			continue
 		}
-		if !strings.HasSuffix(entry.Name(), ".sql") {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %q: name must look like 0001_name.up.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %q: invalid version", entry.Name())
+		}
+
+		contents, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read migration %q: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %q: version %d is already used by %q", entry.Name(), version, migration.Name)
+		}
+
+		if match[3] == ".down" {
+			if migration.Down != "" {
+				return nil, fmt.Errorf("migration %q: duplicate down file for version %d", entry.Name(), version)
+			}
+			migration.Down = string(contents)
 			continue
 		}
-		migrationFiles = append(migrationFiles, entry)
+		if migration.Checksum != "" {
+			return nil, fmt.Errorf("migration %q: duplicate up file for version %d", entry.Name(), version)
+		}
+		sum := sha256.Sum256(contents)
+		migration.Up = string(contents)
+		migration.Checksum = hex.EncodeToString(sum[:])
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Checksum == "" {
+			return nil, fmt.Errorf("migration %s: down file without an up file", migration)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrator applies and rolls back the migrations of one directory and records them in
+// schema_migrations. Every file is sent together with its bookkeeping statement as a single
+// simple-query message without bind parameters, which Postgres runs as one implicit transaction:
+// a failing migration leaves neither schema changes nor a schema_migrations row behind. Files
+// therefore must not contain their own BEGIN/COMMIT or statements that refuse to run inside a
+// transaction, such as CREATE INDEX CONCURRENTLY.
+type Migrator struct {
+	runner     CommandRunner
+	dsn        string
+	migrations []Migration
+	logger     *infra.Logger
+}
+
+// NewMigrator loads the migrations in dir.
+func NewMigrator(runner CommandRunner, dsn, dir string, logger *infra.Logger) (*Migrator, error) {
+	migrations, err := LoadMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{runner: runner, dsn: dsn, migrations: migrations, logger: logger}, nil
+}
+
+// Migrations returns the migrations found on disk ordered by version.
+func (m *Migrator) Migrations() []Migration {
+	return append([]Migration(nil), m.migrations...)
+}
+
+// Applied returns the rows of schema_migrations, creating the table on first use.
+func (m *Migrator) Applied(ctx context.Context) ([]AppliedMigration, error) {
+	if _, err := m.runner.Exec(ctx, m.dsn, "", createSchemaMigrationsSQL); err != nil {
+		return nil, fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return m.selectApplied(ctx)
+}
+
+// readApplied is Applied for read-only callers: a database without schema_migrations has no
+// applied migrations and is left untouched.
+func (m *Migrator) readApplied(ctx context.Context) ([]AppliedMigration, error) {
+	exists, err := queryBool(ctx, runnerQuerier{runner: m.runner, dsn: m.dsn}, schemaMigrationsExistSQL)
+	if err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+	return m.selectApplied(ctx)
+}
+
+func (m *Migrator) selectApplied(ctx context.Context) ([]AppliedMigration, error) {
+	rows, err := m.runner.Query(ctx, m.dsn, "", selectSchemaMigrationsSQL)
+	if err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var applied []AppliedMigration
+	for rows.Next() {
+		var row AppliedMigration
+		if err := rows.Scan(&row.Version, &row.Name, &row.Checksum, &row.AppliedAt); err != nil {
+			return nil, fmt.Errorf("read schema_migrations: %w", err)
+		}
+		applied = append(applied, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// Version returns the newest applied version, or zero on an empty database.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(applied) == 0 {
+		return 0, nil
+	}
+	return applied[len(applied)-1].Version, nil
+}
+
+// Status lists every migration found on disk or in schema_migrations ordered by version. It only
+// reads the database.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.readApplied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*MigrationStatus, len(m.migrations)+len(applied))
+	for _, migration := range m.migrations {
+		byVersion[migration.Version] = &MigrationStatus{Version: migration.Version, Name: migration.Name, Checksum: migration.Checksum}
+	}
+	for i := range applied {
+		status, ok := byVersion[applied[i].Version]
+		if !ok {
+			status = &MigrationStatus{Version: applied[i].Version, Name: applied[i].Name}
+			byVersion[applied[i].Version] = status
+		}
+		status.Applied = &applied[i]
 	}
 
-	sort.Slice(migrationFiles, func(i, j int) bool {
-		return migrationFiles[i].Name() < migrationFiles[j].Name()
+	statuses := make([]MigrationStatus, 0, len(byVersion))
+	for _, status := range byVersion {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
 	})
+	return statuses, nil
+}
+
+// UpTarget returns the version reached by applying the next steps pending migrations of statuses;
+// a non-positive steps applies all of them.
+func UpTarget(statuses []MigrationStatus, steps int) int64 {
+	if steps <= 0 {
+		return LatestVersion
+	}
+	for _, status := range statuses {
+		if status.Applied != nil {
+			continue
+		}
+		if steps--; steps == 0 {
+			return status.Version
+		}
+	}
+	return LatestVersion
+}
+
+// DownTarget returns the version left after rolling back the newest steps applied migrations of
+// statuses.
+func DownTarget(statuses []MigrationStatus, steps int) int64 {
+	var applied []int64
+	for _, status := range statuses {
+		if status.Applied != nil {
+			applied = append(applied, status.Version)
+		}
+	}
+	if steps >= len(applied) {
+		return 0
+	}
+	return applied[len(applied)-steps-1]
+}
+
+// Up applies the pending migrations up to and including target, oldest first. It refuses to run
+// when an applied migration was edited or a pending one is older than the applied schema.
+func (m *Migrator) Up(ctx context.Context, target int64) ([]Migration, error) {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	steps, err := m.planUp(applied, target)
+	if err != nil {
+		return nil, err
+	}
+
+	done, err := m.run(ctx, steps)
+	if err == nil && len(done) == 0 {
+		m.log(ctx, "schema is up to date")
+	}
+	return done, err
+}
+
+// Down rolls back the applied migrations newer than target, newest first. Every migration to roll
+// back must have a down file; the run stops before the first one that does not.
+func (m *Migrator) Down(ctx context.Context, target int64) ([]Migration, error) {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	steps, planErr := m.planDown(applied, target)
+	if steps == nil && planErr != nil {
+		return nil, planErr
+	}
+
+	done, err := m.run(ctx, steps)
+	if err != nil {
+		return done, err
+	}
+	return done, planErr
+}
+
+// PlanUp returns the statements Up would execute for target without executing them or creating
+// schema_migrations.
+func (m *Migrator) PlanUp(ctx context.Context, target int64) ([]Step, error) {
+	applied, err := m.readApplied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.planUp(applied, target)
+}
+
+// PlanDown returns the statements Down would execute for target without executing them. Like
+// Down, it stops before the first migration without a down file and reports it alongside the
+// steps before it.
+func (m *Migrator) PlanDown(ctx context.Context, target int64) ([]Step, error) {
+	applied, err := m.readApplied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.planDown(applied, target)
+}
+
+// Verify checks that every applied migration is still on disk unchanged and that the live schema
+// matches the snapshot recorded with the newest applied migration. Drift is returned together
+// with an error wrapping ErrSchemaDrift.
+func (m *Migrator) Verify(ctx context.Context) (SchemaDrift, error) {
+	applied, err := m.readApplied(ctx)
+	if err != nil {
+		return SchemaDrift{}, err
+	}
+	if err := m.verify(applied); err != nil {
+		return SchemaDrift{}, err
+	}
+	if len(applied) == 0 {
+		return SchemaDrift{}, nil
+	}
+
+	var drift SchemaDrift
+	var recorded string
+	if err := m.queryRow(ctx, selectSchemaSnapshotSQL, &drift.Version, &recorded); err != nil {
+		return SchemaDrift{}, fmt.Errorf("read schema snapshot: %w", err)
+	}
+	if recorded == "" {
+		m.log(ctx, "no schema snapshot recorded for version %d, skipping the drift check", drift.Version)
+		return drift, nil
+	}
+
+	var live string
+	if err := m.queryRow(ctx, "SELECT "+schemaSnapshotSQL, &live); err != nil {
+		return SchemaDrift{}, fmt.Errorf("read live schema: %w", err)
+	}
+	drift.Checked = true
+	drift.Missing, drift.Unexpected = diffLines(recorded, live)
+	if drift.Drifted() {
+		return drift, fmt.Errorf("%w since version %d: %d objects missing, %d unexpected",
+			ErrSchemaDrift, drift.Version, len(drift.Missing), len(drift.Unexpected))
+	}
+	return drift, nil
+}
+
+func (m *Migrator) planUp(applied []AppliedMigration, target int64) ([]Step, error) {
+	pending, err := m.pending(applied)
+	if err != nil {
+		return nil, err
+	}
 
-	if len(migrationFiles) == 0 {
-		if logger != nil {
-			logger.Printf(ctx, "no migrations found in %s", dir)
+	var steps []Step
+	for _, migration := range pending {
+		if migration.Version > target {
+			break
 		}
-		return nil
+		statement := migration.Up + "\n;\n" + fmt.Sprintf(
+			"INSERT INTO public.schema_migrations (version, name, checksum) VALUES (%d, %s, %s);\n"+
+				"UPDATE public.schema_migrations SET schema_snapshot = %s WHERE version = %d;",
+			migration.Version, quoteLiteral(migration.Name), quoteLiteral(migration.Checksum),
+			schemaSnapshotSQL, migration.Version)
+		steps = append(steps, Step{Migration: migration, SQL: statement})
+	}
+	return steps, nil
+}
+
+func (m *Migrator) planDown(applied []AppliedMigration, target int64) ([]Step, error) {
+	if err := m.verify(applied); err != nil {
+		return nil, err
 	}
 
-	for _, file := range migrationFiles {
-		path := filepath.Join(dir, file.Name())
+	byVersion := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		byVersion[migration.Version] = migration
+	}
 
-		contents, readErr := os.ReadFile(path)
-		if readErr != nil {
-			return fmt.Errorf("read migration %q: %w", file.Name(), readErr)
+	var steps []Step
+	for i := len(applied) - 1; i >= 0 && applied[i].Version > target; i-- {
+		migration := byVersion[applied[i].Version]
+		if !migration.Reversible() {
+			return steps, fmt.Errorf("roll back migration %s: %w", migration, ErrIrreversibleMigration)
 		}
+		statement := migration.Down + "\n;\n" + fmt.Sprintf(
+			"DELETE FROM public.schema_migrations WHERE version = %d;", migration.Version)
+		steps = append(steps, Step{Migration: migration, Down: true, SQL: statement})
+	}
+	return steps, nil
+}
 
-		statements := strings.TrimSpace(string(contents))
-		if statements == "" {
-			if logger != nil {
-				logger.Printf(ctx, "skipping empty migration %s", file.Name())
+// run executes steps in order and stops at the first failure.
+func (m *Migrator) run(ctx context.Context, steps []Step) ([]Migration, error) {
+	var done []Migration
+	for _, step := range steps {
+		action, result := "applying", "applied"
+		if step.Down {
+			action, result = "rolling back", "rolled back"
+		}
+		m.log(ctx, "%s migration %s", action, step.Migration)
+		if _, err := m.runner.Exec(ctx, m.dsn, "", step.SQL); err != nil {
+			if step.Down {
+				return done, fmt.Errorf("roll back migration %s: %w", step.Migration, err)
 			}
+			return done, fmt.Errorf("apply migration %s: %w", step.Migration, err)
+		}
+		m.log(ctx, "migration %s %s", step.Migration, result)
+		done = append(done, step.Migration)
+	}
+	return done, nil
+}
+
+// queryRow scans the first row of statement into dest.
+func (m *Migrator) queryRow(ctx context.Context, statement string, dest ...any) error {
+	rows, err := m.runner.Query(ctx, m.dsn, "", statement)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return errors.New("no rows")
+	}
+	return rows.Scan(dest...)
+}
+
+// verify checks that every applied migration still exists on disk unchanged.
+func (m *Migrator) verify(applied []AppliedMigration) error {
+	byVersion := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		byVersion[migration.Version] = migration
+	}
+	for _, row := range applied {
+		migration, ok := byVersion[row.Version]
+		if !ok {
+			return fmt.Errorf("migration %04d_%s: %w", row.Version, row.Name, ErrMigrationMissing)
+		}
+		if migration.Checksum != row.Checksum {
+			return fmt.Errorf("migration %s: %w (recorded %s, file %s)", migration, ErrMigrationChecksum, row.Checksum, migration.Checksum)
+		}
+	}
+	return nil
+}
+
+// pending returns the migrations not recorded in applied after verifying the applied ones.
+func (m *Migrator) pending(applied []AppliedMigration) ([]Migration, error) {
+	if err := m.verify(applied); err != nil {
+		return nil, err
+	}
+
+	var current int64
+	done := make(map[int64]struct{}, len(applied))
+	for _, row := range applied {
+		done[row.Version] = struct{}{}
+		current = max(current, row.Version)
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := done[migration.Version]; ok {
 			continue
 		}
+		if migration.Version < current {
+			return nil, fmt.Errorf("migration %s: %w %d", migration, ErrMigrationOrder, current)
+		}
+		pending = append(pending, migration)
+	}
+	return pending, nil
+}
+
+func (m *Migrator) log(ctx context.Context, format string, v ...any) {
+	if m.logger != nil {
+		m.logger.Printf(ctx, format, v...)
+	}
+}
+
+// quoteLiteral renders s as a Postgres string literal for the bookkeeping statements, which
+// cannot use bind parameters inside a multi-statement message.
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
 
-		if logger != nil {
-			logger.Printf(ctx, "applying migration %s", file.Name())
+// diffLines returns the lines of want missing from got and the lines of got missing from want.
+func diffLines(want, got string) (missing, unexpected []string) {
+	wantSet := lineSet(want)
+	gotSet := lineSet(got)
+	for line := range wantSet {
+		if _, ok := gotSet[line]; !ok {
+			missing = append(missing, line)
 		}
+	}
+	for line := range gotSet {
+		if _, ok := wantSet[line]; !ok {
+			unexpected = append(unexpected, line)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(unexpected)
+	return missing, unexpected
+}
 
-		if _, execErr := runner.Exec(ctx, dsn, "", statements); execErr != nil {
-			return fmt.Errorf("apply migration %q: %w", file.Name(), execErr)
+func lineSet(s string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			set[line] = struct{}{}
 		}
+	}
+	return set
+}
+
+// MigrationFile is a file written by CreateMigration.
+type MigrationFile struct {
+	Path     string
+	Contents string
+}
+
+// ScaffoldMigration returns an empty up/down pair for name in dir. The version is the UTC
+// timestamp now as YYYYMMDDHHMMSS, so files created on different branches rarely collide and
+// still sort after the numbered migrations.
+func ScaffoldMigration(dir, name string, now time.Time) ([]MigrationFile, error) {
+	if !migrationNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("migration name %q: use letters, digits, '_' and '-'", name)
+	}
+	version := now.UTC().Format("20060102150405")
+	files := make([]MigrationFile, 0, 2)
+	for _, direction := range []string{"up", "down"} {
+		file := fmt.Sprintf("%s_%s.%s.sql", version, name, direction)
+		files = append(files, MigrationFile{
+			Path:     filepath.Join(dir, file),
+			Contents: "-- " + file + "\n\n",
+		})
+	}
+	return files, nil
+}
 
-		if logger != nil {
-			logger.Printf(ctx, "migration %s applied", file.Name())
+// CreateMigration writes the files of ScaffoldMigration, refusing to overwrite existing ones.
+func CreateMigration(dir, name string, now time.Time) ([]MigrationFile, error) {
+	files, err := ScaffoldMigration(dir, name, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create migrations directory %q: %w", dir, err)
+	}
+	for _, file := range files {
+		f, err := os.OpenFile(file.Path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("create migration: %w", err)
+		}
+		_, err = f.WriteString(file.Contents)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("create migration %q: %w", file.Path, err)
 		}
 	}
+	return files, nil
+}
 
+// ApplyMigrations brings the schema in dir up to the latest version while holding the migration
+// lock, waiting up to lockTimeout for another replica to finish first. Databases migrated before
+// schema_migrations existed re-run every file once; the files are idempotent for that reason.
+func ApplyMigrations(ctx context.Context, runner CommandRunner, dsn, dir string, lockTimeout time.Duration, logger *infra.Logger) error {
+	migrator, err := NewMigrator(runner, dsn, dir, logger)
+	if err != nil {
+		return err
+	}
+	err = WithMigrationLock(ctx, runner, dsn, lockTimeout, logger, func(ctx context.Context) error {
+		_, err := migrator.Up(ctx, LatestVersion)
+		return err
+	})
+	if err != nil {
+		return err
+	}
 	if logger != nil {
 		logger.Println(ctx, "migrations applied successfully")
 	}
-
 	return nil
 }
@@ -0,0 +1,335 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"aggregator-service/app/src/infra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeMigrationFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, contents := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644))
+	}
+	return dir
+}
+
+func checksumOf(contents string) string {
+	sum := sha256.Sum256([]byte(contents))
+	return hex.EncodeToString(sum[:])
+}
+
+func appliedRows(migrations ...Migration) execResponse {
+	rows := make([][]any, len(migrations))
+	for i, migration := range migrations {
+		rows[i] = []any{migration.Version, migration.Name, migration.Checksum, time.Now()}
+	}
+	return execResponse{rows: rows}
+}
+
+var testMigrationFiles = map[string]string{
+	"0001_init.up.sql":      "CREATE TABLE a (id int);",
+	"0001_init.down.sql":    "DROP TABLE a;",
+	"0002_legacy.sql":       "CREATE TABLE b (id int);",
+	"0003_index.up.sql":     "CREATE INDEX a_idx ON a (id);",
+	"0003_index.down.sql":   "DROP INDEX a_idx;",
+	"README.md":             "not a migration",
+	"0004_columns.up.sql":   "ALTER TABLE a ADD COLUMN name text;",
+	"0004_columns.down.sql": "ALTER TABLE a DROP COLUMN name;",
+}
+
+func newTestMigrator(t *testing.T, runner *fakeRunner) *Migrator {
+	t.Helper()
+	migrator, err := NewMigrator(runner, testPurgeDSN, writeMigrationFiles(t, testMigrationFiles), infra.NewLogger(io.Discard, "test"))
+	require.NoError(t, err)
+	return migrator
+}
+
+func TestLoadMigrationsPairsFiles(t *testing.T) {
+	migrations, err := LoadMigrations(writeMigrationFiles(t, testMigrationFiles))
+	require.NoError(t, err)
+
+	require.Len(t, migrations, 4)
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "init", migrations[0].Name)
+	assert.Equal(t, "DROP TABLE a;", migrations[0].Down)
+	assert.Equal(t, checksumOf("CREATE TABLE a (id int);"), migrations[0].Checksum)
+	assert.Equal(t, "legacy", migrations[1].Name)
+	assert.False(t, migrations[1].Reversible())
+	assert.Equal(t, "0003_index", migrations[2].String())
+}
+
+func TestLoadMigrationsRejectsInvalidFiles(t *testing.T) {
+	for name, files := range map[string]map[string]string{
+		"без номера":           {"init.sql": "SELECT 1;"},
+		"down без up":          {"0001_init.down.sql": "SELECT 1;"},
+		"номер занят":          {"0001_a.up.sql": "SELECT 1;", "0001_b.up.sql": "SELECT 1;"},
+		"два up одной версии":  {"0001_a.sql": "SELECT 1;", "0001_a.up.sql": "SELECT 1;"},
+		"недопустимые символы": {"0001_drop table.sql": "SELECT 1;"},
+	} {
+		_, err := LoadMigrations(writeMigrationFiles(t, files))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestLoadMigrationsReadsRepositoryMigrations(t *testing.T) {
+	migrations, err := LoadMigrations(filepath.Join("..", "..", "resources", "db", "migrations"))
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	assert.Equal(t, int64(1), migrations[0].Version)
+	for i, migration := range migrations {
+		if i > 0 {
+			assert.Greater(t, migration.Version, migrations[i-1].Version)
+		}
+		assert.True(t, migration.Reversible(), migration.String())
+	}
+}
+
+func TestMigratorUpAppliesPendingMigrations(t *testing.T) {
+	runner := &fakeRunner{}
+	migrator := newTestMigrator(t, runner)
+	migrations := migrator.Migrations()
+	runner.setResponses(execResponse{}, appliedRows(migrations[0]))
+
+	t.Log("Шаг 1: первая миграция уже применена, применяются вторая и третья")
+	done, err := migrator.Up(context.Background(), 3)
+	require.NoError(t, err)
+	require.Len(t, done, 2)
+	assert.Equal(t, int64(2), done[0].Version)
+	assert.Equal(t, int64(3), done[1].Version)
+
+	t.Log("Шаг 2: файл и запись в schema_migrations уходят одним сообщением")
+	require.Equal(t, 4, runner.callCount())
+	statement := runner.calls[2].statement
+	assert.True(t, strings.HasPrefix(statement, "CREATE TABLE b (id int);"))
+	assert.Contains(t, statement, "INSERT INTO public.schema_migrations (version, name, checksum) VALUES (2, 'legacy', '"+migrations[1].Checksum+"');")
+	assert.Contains(t, statement, "UPDATE public.schema_migrations SET schema_snapshot = "+schemaSnapshotSQL+" WHERE version = 2;")
+	assert.Empty(t, runner.calls[2].args)
+}
+
+func TestMigratorUpRefusesEditedMigration(t *testing.T) {
+	runner := &fakeRunner{}
+	migrator := newTestMigrator(t, runner)
+	edited := migrator.Migrations()[0]
+	edited.Checksum = checksumOf("CREATE TABLE a (id bigint);")
+	runner.setResponses(execResponse{}, appliedRows(edited))
+
+	_, err := migrator.Up(context.Background(), LatestVersion)
+	assert.ErrorIs(t, err, ErrMigrationChecksum)
+	assert.Equal(t, 2, runner.callCount())
+}
+
+func TestMigratorUpRefusesOutOfOrderMigration(t *testing.T) {
+	runner := &fakeRunner{}
+	migrator := newTestMigrator(t, runner)
+	migrations := migrator.Migrations()
+	runner.setResponses(execResponse{}, appliedRows(migrations[0], migrations[2]))
+
+	_, err := migrator.Up(context.Background(), LatestVersion)
+	assert.ErrorIs(t, err, ErrMigrationOrder)
+	assert.Contains(t, err.Error(), "0002_legacy")
+}
+
+func TestMigratorDownRollsBackToTarget(t *testing.T) {
+	runner := &fakeRunner{}
+	migrator := newTestMigrator(t, runner)
+	migrations := migrator.Migrations()
+	runner.setResponses(execResponse{}, appliedRows(migrations...))
+
+	t.Log("Шаг 1: откат до второй версии снимает четвёртую и третью, начиная с новой")
+	done, err := migrator.Down(context.Background(), 2)
+	require.NoError(t, err)
+	require.Len(t, done, 2)
+	assert.Equal(t, int64(4), done[0].Version)
+	assert.Equal(t, int64(3), done[1].Version)
+	assert.Equal(t, "DROP INDEX a_idx;\n;\nDELETE FROM public.schema_migrations WHERE version = 3;", runner.lastCall().statement)
+
+	t.Log("Шаг 2: миграцию без down-файла откатить нельзя")
+	runner = &fakeRunner{}
+	migrator.runner = runner
+	runner.setResponses(execResponse{}, appliedRows(migrations[:2]...))
+	done, err = migrator.Down(context.Background(), 0)
+	assert.ErrorIs(t, err, ErrIrreversibleMigration)
+	assert.Empty(t, done)
+	assert.Equal(t, 2, runner.callCount())
+}
+
+func TestMigratorVersion(t *testing.T) {
+	runner := &fakeRunner{}
+	migrator := newTestMigrator(t, runner)
+	runner.setResponses(execResponse{}, appliedRows(migrator.Migrations()[:2]...))
+
+	version, err := migrator.Version(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), version)
+}
+
+func TestMigratorStatusAndStepTargets(t *testing.T) {
+	runner := &fakeRunner{}
+	migrator := newTestMigrator(t, runner)
+	migrations := migrator.Migrations()
+	edited := migrations[1]
+	edited.Checksum = checksumOf("CREATE TABLE b (id bigint);")
+	runner.setResponses(
+		execResponse{rows: [][]any{{true}}},
+		appliedRows(migrations[0], edited, Migration{Version: 3, Name: "index", Checksum: migrations[2].Checksum}),
+	)
+
+	t.Log("Шаг 1: status только читает schema_migrations и отмечает изменённые файлы")
+	statuses, err := migrator.Status(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 4)
+	assert.Equal(t, "applied", statuses[0].State())
+	assert.Equal(t, "modified", statuses[1].State())
+	assert.Equal(t, "applied", statuses[2].State())
+	assert.Equal(t, "pending", statuses[3].State())
+	assert.Equal(t, schemaMigrationsExistSQL, runner.calls[0].statement)
+
+	t.Log("Шаг 2: шаги up и down переводятся в целевые версии")
+	assert.Equal(t, int64(4), UpTarget(statuses, 1))
+	assert.Equal(t, LatestVersion, UpTarget(statuses, 0))
+	assert.Equal(t, LatestVersion, UpTarget(statuses, 5))
+	assert.Equal(t, int64(2), DownTarget(statuses, 1))
+	assert.Equal(t, int64(1), DownTarget(statuses, 2))
+	assert.Equal(t, int64(0), DownTarget(statuses, 3))
+	assert.Equal(t, int64(0), DownTarget(statuses, 10))
+
+	t.Log("Шаг 3: файл удалён после применения")
+	missing := MigrationStatus{Version: 9, Name: "gone", Applied: &AppliedMigration{Version: 9}}
+	assert.Equal(t, "missing", missing.State())
+}
+
+func TestMigratorPlanDoesNotTouchDatabase(t *testing.T) {
+	runner := &fakeRunner{}
+	migrator := newTestMigrator(t, runner)
+	runner.setResponses(execResponse{rows: [][]any{{false}}})
+
+	steps, err := migrator.PlanUp(context.Background(), 2)
+	require.NoError(t, err)
+	require.Len(t, steps, 2)
+	assert.False(t, steps[0].Down)
+	assert.True(t, strings.HasPrefix(steps[0].SQL, "CREATE TABLE a (id int);"))
+	assert.Equal(t, 1, runner.callCount(), "dry run must not create schema_migrations")
+}
+
+func TestMigratorPlanDownStopsAtIrreversibleMigration(t *testing.T) {
+	runner := &fakeRunner{}
+	migrator := newTestMigrator(t, runner)
+	migrations := migrator.Migrations()
+	runner.setResponses(execResponse{rows: [][]any{{true}}}, appliedRows(migrations[:3]...))
+
+	steps, err := migrator.PlanDown(context.Background(), 0)
+	assert.ErrorIs(t, err, ErrIrreversibleMigration)
+	require.Len(t, steps, 1)
+	assert.True(t, steps[0].Down)
+	assert.Equal(t, "DROP INDEX a_idx;\n;\nDELETE FROM public.schema_migrations WHERE version = 3;", steps[0].SQL)
+}
+
+func TestMigratorVerify(t *testing.T) {
+	ctx := context.Background()
+	snapshot := "column a.id integer\nrelation a r"
+
+	t.Log("Шаг 1: живая схема совпадает со снимком последней миграции")
+	runner := &fakeRunner{}
+	migrator := newTestMigrator(t, runner)
+	applied := appliedRows(migrator.Migrations()[:2]...)
+	runner.setResponses(
+		execResponse{rows: [][]any{{true}}},
+		applied,
+		execResponse{rows: [][]any{{int64(2), snapshot}}},
+		execResponse{rows: [][]any{{snapshot}}},
+	)
+	drift, err := migrator.Verify(ctx)
+	require.NoError(t, err)
+	assert.True(t, drift.Checked)
+	assert.Equal(t, int64(2), drift.Version)
+
+	t.Log("Шаг 2: в живой схеме лишний индекс и изменённая колонка")
+	runner = &fakeRunner{}
+	migrator = newTestMigrator(t, runner)
+	runner.setResponses(
+		execResponse{rows: [][]any{{true}}},
+		applied,
+		execResponse{rows: [][]any{{int64(2), snapshot}}},
+		execResponse{rows: [][]any{{"column a.id bigint\nindex CREATE INDEX x ON public.a (id)\nrelation a r"}}},
+	)
+	drift, err = migrator.Verify(ctx)
+	require.ErrorIs(t, err, ErrSchemaDrift)
+	assert.Equal(t, []string{"column a.id integer"}, drift.Missing)
+	assert.Equal(t, []string{"column a.id bigint", "index CREATE INDEX x ON public.a (id)"}, drift.Unexpected)
+
+	t.Log("Шаг 3: миграция применена до появления снимков — проверяются только файлы")
+	runner = &fakeRunner{}
+	migrator = newTestMigrator(t, runner)
+	runner.setResponses(
+		execResponse{rows: [][]any{{true}}},
+		applied,
+		execResponse{rows: [][]any{{int64(2), ""}}},
+	)
+	drift, err = migrator.Verify(ctx)
+	require.NoError(t, err)
+	assert.False(t, drift.Checked)
+	assert.Equal(t, 3, runner.callCount())
+
+	t.Log("Шаг 4: применённый файл удалён из каталога")
+	runner = &fakeRunner{}
+	migrator = newTestMigrator(t, runner)
+	runner.setResponses(
+		execResponse{rows: [][]any{{true}}},
+		appliedRows(Migration{Version: 7, Name: "gone", Checksum: "x"}),
+	)
+	_, err = migrator.Verify(ctx)
+	assert.ErrorIs(t, err, ErrMigrationMissing)
+}
+
+func TestCreateMigrationWritesTimestampedPair(t *testing.T) {
+	dir := writeMigrationFiles(t, map[string]string{
+		"0001_init.up.sql":       "SELECT 1;",
+		"0006_add_rank.up.sql":   "SELECT 1;",
+		"0006_add_rank.down.sql": "SELECT 1;",
+	})
+	now := time.Date(2024, 3, 10, 12, 30, 45, 0, time.FixedZone("MSK", 3*3600))
+
+	files, err := CreateMigration(dir, "add_source_idx", now)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, filepath.Join(dir, "20240310093045_add_source_idx.up.sql"), files[0].Path)
+	assert.Equal(t, filepath.Join(dir, "20240310093045_add_source_idx.down.sql"), files[1].Path)
+
+	t.Log("Версия-метка времени загружается вместе с нумерованными и идёт после них")
+	migrations, err := LoadMigrations(dir)
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+	assert.Equal(t, []int64{1, 6, 20240310093045}, []int64{migrations[0].Version, migrations[1].Version, migrations[2].Version})
+	assert.True(t, migrations[2].Reversible())
+
+	runner := &fakeRunner{}
+	migrator, err := NewMigrator(runner, testPurgeDSN, dir, nil)
+	require.NoError(t, err)
+	runner.setResponses(
+		execResponse{rows: [][]any{{true}}},
+		appliedRows(migrations[0], migrations[1]),
+	)
+	steps, err := migrator.PlanUp(context.Background(), LatestVersion)
+	require.NoError(t, err)
+	require.Len(t, steps, 1)
+	assert.Equal(t, int64(20240310093045), steps[0].Migration.Version)
+
+	t.Log("Повторное создание с той же версией не перезаписывает файлы")
+	_, err = CreateMigration(dir, "add_source_idx", now)
+	assert.Error(t, err)
+	_, err = ScaffoldMigration(dir, "drop table", now)
+	assert.Error(t, err)
+}
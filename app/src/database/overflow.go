@@ -0,0 +1,217 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"aggregator-service/app/src/domain"
+)
+
+// OverflowPolicy decides what Repository.Add does when the write buffer is full.
+type OverflowPolicy string
+
+const (
+	// OverflowBlock waits for room in the buffer until the caller's context ends.
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropNewest rejects the incoming packet maximum with domain.ErrBackpressure.
+	OverflowDropNewest OverflowPolicy = "drop-newest"
+	// OverflowDropOldest evicts the oldest buffered packet maximum to make room.
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+	// OverflowBlockTimeout waits up to Config.OverflowTimeout, then rejects like OverflowDropNewest.
+	OverflowBlockTimeout OverflowPolicy = "block-timeout"
+	// OverflowSpill appends the packet maximum to Config.SpillFile; the writer reads it back once
+	// it catches up.
+	OverflowSpill OverflowPolicy = "spill"
+)
+
+// ParseOverflowPolicy accepts the policy names case-insensitively; an empty name means OverflowBlock.
+func ParseOverflowPolicy(name string) (OverflowPolicy, error) {
+	policy := OverflowPolicy(strings.ToLower(strings.TrimSpace(name)))
+	switch policy {
+	case "":
+		return OverflowBlock, nil
+	case OverflowBlock, OverflowDropNewest, OverflowDropOldest, OverflowBlockTimeout, OverflowSpill:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown buffer overflow policy %q", name)
+	}
+}
+
+// spillFile is a FIFO of packet maxima kept in a JSONL file. Entries are appended at the end and
+// read from an in-memory offset; the file is truncated once everything has been read. Reads are
+// not recorded on disk, so entries read before a crash are written again after a restart, which
+// the upsert in upsertPacketMaxes tolerates.
+type spillFile struct {
+	mu      sync.Mutex
+	file    *os.File
+	readOff int64
+	count   int
+}
+
+func openSpillFile(path string) (*spillFile, error) {
+	if path == "" {
+		return nil, errors.New("spill file: path is required")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("spill file: create directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("spill file: open: %w", err)
+	}
+
+	spill := &spillFile{file: file}
+	if err := spill.recover(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return spill, nil
+}
+
+// recover counts the entries left by a previous run. A torn last line is terminated so that new
+// entries do not get glued to it; pop then skips it as undecodable.
+func (s *spillFile) recover() error {
+	info, err := s.file.Stat()
+	if err != nil {
+		return fmt.Errorf("spill file: stat: %w", err)
+	}
+	if info.Size() == 0 {
+		return nil
+	}
+
+	last := make([]byte, 1)
+	if _, err := s.file.ReadAt(last, info.Size()-1); err != nil {
+		return fmt.Errorf("spill file: read: %w", err)
+	}
+	if last[0] != '\n' {
+		if _, err := s.file.Write([]byte{'\n'}); err != nil {
+			return fmt.Errorf("spill file: repair: %w", err)
+		}
+	}
+
+	scanner := bufio.NewScanner(io.NewSectionReader(s.file, 0, info.Size()+1))
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) > 0 {
+			s.count++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("spill file: read: %w", err)
+	}
+	return nil
+}
+
+func (s *spillFile) push(packetMax domain.PacketMax) error {
+	line, err := json.Marshal(newSpillRecord(packetMax))
+	if err != nil {
+		return fmt.Errorf("spill file: encode: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("spill file: append: %w", err)
+	}
+	s.count++
+	return nil
+}
+
+// sync forces the appended entries to stable storage.
+func (s *spillFile) sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("spill file: sync: %w", err)
+	}
+	return nil
+}
+
+// pop returns up to n of the oldest entries. Lines that cannot be decoded are skipped.
+func (s *spillFile) pop(n int) ([]domain.PacketMax, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.count == 0 || n <= 0 {
+		return nil, nil
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(s.file, s.readOff, 1<<62))
+	items := make([]domain.PacketMax, 0, n)
+	for len(items) < n {
+		line, err := reader.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return items, fmt.Errorf("spill file: read: %w", err)
+		}
+		if line[len(line)-1] != '\n' {
+			break
+		}
+		s.readOff += int64(len(line))
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		s.count--
+		var record spillRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			continue
+		}
+		items = append(items, record.packetMax())
+	}
+
+	if s.count <= 0 {
+		s.count = 0
+		if err := s.file.Truncate(0); err != nil {
+			return items, fmt.Errorf("spill file: truncate: %w", err)
+		}
+		s.readOff = 0
+	}
+	return items, nil
+}
+
+func (s *spillFile) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+func (s *spillFile) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+type spillRecord struct {
+	PacketID  string    `json:"packet_id"`
+	SourceID  string    `json:"source_id"`
+	Value     float64   `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+	Rank      int       `json:"rank,omitempty"`
+}
+
+func newSpillRecord(packetMax domain.PacketMax) spillRecord {
+	return spillRecord{
+		PacketID:  packetMax.PacketID,
+		SourceID:  packetMax.SourceID,
+		Value:     packetMax.Value,
+		Timestamp: packetMax.Timestamp.UTC(),
+		Rank:      packetMax.Rank,
+	}
+}
+
+func (r spillRecord) packetMax() domain.PacketMax {
+	return domain.PacketMax{PacketID: r.PacketID, SourceID: r.SourceID, Value: r.Value, Timestamp: r.Timestamp, Rank: r.Rank}
+}
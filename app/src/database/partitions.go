@@ -0,0 +1,307 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	metrics "aggregator-service/app/src/infra"
+)
+
+// PartitionInterval is the time span one packet_max partition covers.
+type PartitionInterval string
+
+const (
+	PartitionDaily   PartitionInterval = "daily"
+	PartitionMonthly PartitionInterval = "monthly"
+)
+
+// ParsePartitionInterval accepts the interval names case-insensitively; an empty name means
+// PartitionMonthly.
+func ParsePartitionInterval(name string) (PartitionInterval, error) {
+	interval := PartitionInterval(strings.ToLower(strings.TrimSpace(name)))
+	switch interval {
+	case "":
+		return PartitionMonthly, nil
+	case PartitionDaily, PartitionMonthly:
+		return interval, nil
+	default:
+		return "", fmt.Errorf("unknown partition interval %q", name)
+	}
+}
+
+const (
+	// DefaultPartitionPremake is how many partitions after the current one are created ahead.
+	DefaultPartitionPremake = 3
+	// DefaultPartitionCheckInterval is the time between maintenance passes of Run.
+	DefaultPartitionCheckInterval = time.Hour
+
+	// Partitions are named after the UTC start of their range: packet_max_pYYYYMMDD for a day and
+	// packet_max_pYYYYMM for a month. The maintainer derives every range from the name, so tables
+	// attached under other names (packet_max_default included) are left alone.
+	partitionPrefix        = "packet_max_p"
+	dailyPartitionLayout   = "20060102"
+	monthlyPartitionLayout = "200601"
+
+	listPartitionsSQL = `
+SELECT child.relname::text
+FROM pg_inherits
+JOIN pg_class parent ON parent.oid = pg_inherits.inhparent
+JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+JOIN pg_namespace ns ON ns.oid = parent.relnamespace
+WHERE ns.nspname = 'public' AND parent.relname = 'packet_max'
+`
+)
+
+// PartitionConfig controls the partition maintainer.
+type PartitionConfig struct {
+	// Interval is the span of newly created partitions; empty means PartitionMonthly.
+	Interval PartitionInterval
+	// Premake is how many partitions after the current one are kept created ahead of time;
+	// negative selects DefaultPartitionPremake.
+	Premake int
+	// Retention expires partitions whose whole range is older than now-Retention; zero keeps
+	// every partition.
+	Retention time.Duration
+	// Detach keeps expired partitions as standalone tables instead of dropping them.
+	Detach bool
+	// DryRun only logs the partitions that would expire. Missing partitions are still created.
+	DryRun bool
+	// CheckInterval is the time between passes of Run; zero selects DefaultPartitionCheckInterval.
+	CheckInterval time.Duration
+}
+
+// PartitionReport lists what one maintenance pass changed.
+type PartitionReport struct {
+	Created []string
+	Expired []string
+	// Partitions counts the named range partitions left after the pass.
+	Partitions int
+}
+
+// partition is a range partition of packet_max covering [from, to).
+type partition struct {
+	name     string
+	from, to time.Time
+}
+
+func newPartition(interval PartitionInterval, from time.Time) partition {
+	if interval == PartitionDaily {
+		return partition{name: partitionPrefix + from.Format(dailyPartitionLayout), from: from, to: from.AddDate(0, 0, 1)}
+	}
+	return partition{name: partitionPrefix + from.Format(monthlyPartitionLayout), from: from, to: from.AddDate(0, 1, 0)}
+}
+
+// parsePartition recovers the range of a partition from its name.
+func parsePartition(name string) (partition, bool) {
+	suffix, ok := strings.CutPrefix(name, partitionPrefix)
+	if !ok {
+		return partition{}, false
+	}
+	for _, candidate := range []struct {
+		interval PartitionInterval
+		layout   string
+	}{
+		{PartitionDaily, dailyPartitionLayout},
+		{PartitionMonthly, monthlyPartitionLayout},
+	} {
+		if len(suffix) != len(candidate.layout) {
+			continue
+		}
+		from, err := time.Parse(candidate.layout, suffix)
+		if err != nil {
+			return partition{}, false
+		}
+		return newPartition(candidate.interval, from), true
+	}
+	return partition{}, false
+}
+
+// periodStart returns the UTC start of the partition range containing t.
+func periodStart(interval PartitionInterval, t time.Time) time.Time {
+	t = t.UTC()
+	if interval == PartitionDaily {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func overlaps(existing []partition, from, to time.Time) bool {
+	for _, p := range existing {
+		if p.from.Before(to) && from.Before(p.to) {
+			return true
+		}
+	}
+	return false
+}
+
+// missingPartitions returns the partitions needed to cover the period p. A period that overlaps
+// existing partitions, e.g. after switching from daily to monthly, is completed with daily
+// partitions for the days nothing covers yet.
+func missingPartitions(existing []partition, p partition) []partition {
+	if !overlaps(existing, p.from, p.to) {
+		return []partition{p}
+	}
+	var missing []partition
+	for day := p.from; day.Before(p.to); day = day.AddDate(0, 0, 1) {
+		if !overlaps(existing, day, day.AddDate(0, 0, 1)) {
+			missing = append(missing, newPartition(PartitionDaily, day))
+		}
+	}
+	return missing
+}
+
+// PartitionMaintainer keeps the partitions of packet_max ahead of the clock and expires the ones
+// that fell out of the retention window.
+type PartitionMaintainer struct {
+	runner   CommandRunner
+	dsn      string
+	password string
+	cfg      PartitionConfig
+	logger   *metrics.Logger
+	now      func() time.Time
+}
+
+// NewPartitionMaintainer returns a maintainer running its statements through runner.
+func NewPartitionMaintainer(runner CommandRunner, dsn string, cfg PartitionConfig, logger *metrics.Logger) *PartitionMaintainer {
+	return newPartitionMaintainer(runner, dsn, "", cfg, logger)
+}
+
+func newPartitionMaintainer(runner CommandRunner, dsn, password string, cfg PartitionConfig, logger *metrics.Logger) *PartitionMaintainer {
+	if cfg.Interval == "" {
+		cfg.Interval = PartitionMonthly
+	}
+	if cfg.Premake < 0 {
+		cfg.Premake = DefaultPartitionPremake
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = DefaultPartitionCheckInterval
+	}
+	return &PartitionMaintainer{runner: runner, dsn: dsn, password: password, cfg: cfg, logger: logger, now: time.Now}
+}
+
+// PartitionMaintainer returns a maintainer sharing the repository connection.
+func (r *Repository) PartitionMaintainer(cfg PartitionConfig) *PartitionMaintainer {
+	return newPartitionMaintainer(r.runner, r.dsn, r.password, cfg, r.logger)
+}
+
+// Maintain creates the current and the next Premake partitions and expires partitions older than
+// the retention window. Failures of single statements do not stop the pass; they are joined into
+// the returned error.
+func (m *PartitionMaintainer) Maintain(ctx context.Context) (PartitionReport, error) {
+	existing, err := m.list(ctx)
+	if err != nil {
+		return PartitionReport{}, err
+	}
+
+	var report PartitionReport
+	var errs []error
+	now := m.now().UTC()
+
+	from := periodStart(m.cfg.Interval, now)
+	for i := 0; i <= m.cfg.Premake; i++ {
+		period := newPartition(m.cfg.Interval, from)
+		for _, p := range missingPartitions(existing, period) {
+			statement := fmt.Sprintf("CREATE TABLE IF NOT EXISTS public.%s PARTITION OF public.packet_max FOR VALUES FROM ('%s') TO ('%s')",
+				p.name, p.from.Format(time.RFC3339), p.to.Format(time.RFC3339))
+			if _, err := m.runner.Exec(ctx, m.dsn, m.password, statement); err != nil {
+				errs = append(errs, fmt.Errorf("create partition %s: %w", p.name, err))
+				continue
+			}
+			existing = append(existing, p)
+			report.Created = append(report.Created, p.name)
+		}
+		from = period.to
+	}
+
+	if m.cfg.Retention > 0 {
+		cutoff := now.Add(-m.cfg.Retention)
+		kept := existing[:0]
+		for _, p := range existing {
+			switch {
+			case p.to.After(cutoff):
+			case m.cfg.DryRun:
+				m.log(ctx, "partition maintainer: dry run, would expire %s (before %s)", p.name, cutoff.Format(time.RFC3339))
+			default:
+				if err := m.expire(ctx, p); err != nil {
+					errs = append(errs, err)
+					break
+				}
+				report.Expired = append(report.Expired, p.name)
+				continue
+			}
+			kept = append(kept, p)
+		}
+		existing = kept
+	}
+
+	report.Partitions = len(existing)
+	metrics.RecordPartitionMaintenance(len(report.Created), len(report.Expired), report.Partitions)
+	if len(report.Created) > 0 || len(report.Expired) > 0 {
+		m.log(ctx, "partition maintainer: created %v, expired %v, %d partitions", report.Created, report.Expired, report.Partitions)
+	}
+
+	err = errors.Join(errs...)
+	if err != nil {
+		m.log(ctx, "partition maintainer: %v", err)
+	}
+	return report, err
+}
+
+// Run repeats Maintain every CheckInterval until ctx is cancelled. Callers run Maintain once
+// before writes start, so the first rows do not land in the default partition.
+func (m *PartitionMaintainer) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = m.Maintain(ctx)
+		}
+	}
+}
+
+func (m *PartitionMaintainer) list(ctx context.Context) ([]partition, error) {
+	rows, err := m.runner.Query(ctx, m.dsn, m.password, listPartitionsSQL)
+	if err != nil {
+		return nil, fmt.Errorf("list partitions: %w", err)
+	}
+	defer rows.Close()
+
+	var partitions []partition
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("list partitions: %w", err)
+		}
+		if p, ok := parsePartition(name); ok {
+			partitions = append(partitions, p)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list partitions: %w", err)
+	}
+	return partitions, nil
+}
+
+func (m *PartitionMaintainer) expire(ctx context.Context, p partition) error {
+	statement := "DROP TABLE IF EXISTS public." + p.name
+	if m.cfg.Detach {
+		statement = "ALTER TABLE public.packet_max DETACH PARTITION public." + p.name
+	}
+	if _, err := m.runner.Exec(ctx, m.dsn, m.password, statement); err != nil {
+		return fmt.Errorf("expire partition %s: %w", p.name, err)
+	}
+	return nil
+}
+
+func (m *PartitionMaintainer) log(ctx context.Context, format string, v ...any) {
+	if m.logger != nil {
+		m.logger.Printf(ctx, format, v...)
+	}
+}
@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"aggregator-service/app/src/infra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMaintainer(runner *fakeRunner, cfg PartitionConfig, now time.Time) *PartitionMaintainer {
+	m := NewPartitionMaintainer(runner, testPurgeDSN, cfg, infra.NewLogger(io.Discard, "test"))
+	m.now = func() time.Time { return now }
+	return m
+}
+
+func listedPartitions(names ...string) execResponse {
+	rows := make([][]any, len(names))
+	for i, name := range names {
+		rows[i] = []any{name}
+	}
+	return execResponse{rows: rows}
+}
+
+func execStatements(runner *fakeRunner) []string {
+	var statements []string
+	for _, call := range runner.calls[1:] {
+		statements = append(statements, call.statement)
+	}
+	return statements
+}
+
+func TestParsePartitionInterval(t *testing.T) {
+	interval, err := ParsePartitionInterval("")
+	require.NoError(t, err)
+	assert.Equal(t, PartitionMonthly, interval)
+
+	interval, err = ParsePartitionInterval(" Daily ")
+	require.NoError(t, err)
+	assert.Equal(t, PartitionDaily, interval)
+
+	_, err = ParsePartitionInterval("weekly")
+	assert.Error(t, err)
+}
+
+func TestParsePartitionRecoversRange(t *testing.T) {
+	daily, ok := parsePartition("packet_max_p20240229")
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), daily.from)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), daily.to)
+
+	monthly, ok := parsePartition("packet_max_p202412")
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), monthly.to)
+
+	for _, name := range []string{"packet_max_default", "packet_max_p2024", "packet_max_p202413", "other_p202401"} {
+		_, ok := parsePartition(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestMaintainCreatesPartitionsAhead(t *testing.T) {
+	runner := &fakeRunner{}
+	runner.setResponses(listedPartitions("packet_max_default", "packet_max_p202403"))
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	m := newTestMaintainer(runner, PartitionConfig{Interval: PartitionMonthly, Premake: 2}, now)
+	created := infra.DbPartitionsCreatedTotal.Value()
+
+	t.Log("Шаг 1: текущий месяц уже есть, создаются два следующих")
+	report, err := m.Maintain(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"packet_max_p202404", "packet_max_p202405"}, report.Created)
+	assert.Equal(t, 3, report.Partitions)
+	assert.Equal(t, created+2, infra.DbPartitionsCreatedTotal.Value())
+
+	t.Log("Шаг 2: границы раздела заданы в UTC")
+	statements := execStatements(runner)
+	require.Len(t, statements, 2)
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS public.packet_max_p202404 PARTITION OF public.packet_max FOR VALUES FROM ('2024-04-01T00:00:00Z') TO ('2024-05-01T00:00:00Z')", statements[0])
+}
+
+func TestMaintainDailySkipsDaysCoveredByMonth(t *testing.T) {
+	runner := &fakeRunner{}
+	runner.setResponses(listedPartitions("packet_max_p202403"))
+	now := time.Date(2024, 3, 30, 23, 0, 0, 0, time.UTC)
+	m := newTestMaintainer(runner, PartitionConfig{Interval: PartitionDaily, Premake: 3}, now)
+
+	report, err := m.Maintain(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"packet_max_p20240401", "packet_max_p20240402"}, report.Created)
+}
+
+func TestMaintainMonthlyFillsGapsBetweenDailyPartitions(t *testing.T) {
+	runner := &fakeRunner{}
+	runner.setResponses(listedPartitions("packet_max_p20240201", "packet_max_p20240228"))
+	now := time.Date(2024, 2, 1, 8, 0, 0, 0, time.UTC)
+	m := newTestMaintainer(runner, PartitionConfig{Interval: PartitionMonthly, Premake: 1}, now)
+
+	report, err := m.Maintain(context.Background())
+	require.NoError(t, err)
+	require.Len(t, report.Created, 28)
+	assert.Equal(t, "packet_max_p20240202", report.Created[0])
+	assert.Equal(t, "packet_max_p20240227", report.Created[25])
+	assert.Equal(t, "packet_max_p20240229", report.Created[26])
+	assert.Equal(t, "packet_max_p202403", report.Created[27])
+}
+
+func TestMaintainExpiresPartitionsOutsideRetention(t *testing.T) {
+	now := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	cfg := PartitionConfig{Interval: PartitionMonthly, Premake: 0, Retention: 30 * 24 * time.Hour}
+
+	t.Log("Шаг 1: январь целиком старше окна и удаляется, февраль ещё нужен")
+	runner := &fakeRunner{}
+	runner.setResponses(listedPartitions("packet_max_p202401", "packet_max_p202402", "packet_max_p202403"))
+	report, err := newTestMaintainer(runner, cfg, now).Maintain(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"packet_max_p202401"}, report.Expired)
+	assert.Equal(t, 2, report.Partitions)
+	assert.Equal(t, []string{"DROP TABLE IF EXISTS public.packet_max_p202401"}, execStatements(runner))
+
+	t.Log("Шаг 2: с Detach раздел отсоединяется и остаётся отдельной таблицей")
+	runner = &fakeRunner{}
+	runner.setResponses(listedPartitions("packet_max_p202401", "packet_max_p202403"))
+	cfg.Detach = true
+	_, err = newTestMaintainer(runner, cfg, now).Maintain(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ALTER TABLE public.packet_max DETACH PARTITION public.packet_max_p202401"}, execStatements(runner))
+
+	t.Log("Шаг 3: пробный запуск ничего не удаляет")
+	runner = &fakeRunner{}
+	runner.setResponses(listedPartitions("packet_max_p202401", "packet_max_p202403"))
+	cfg.DryRun = true
+	report, err = newTestMaintainer(runner, cfg, now).Maintain(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, report.Expired)
+	assert.Empty(t, execStatements(runner))
+}
+
+func TestMaintainContinuesAfterFailedStatement(t *testing.T) {
+	runner := &fakeRunner{}
+	runner.setResponses(
+		listedPartitions(),
+		execResponse{err: errors.New("updated partition constraint for default partition would be violated")},
+		execResponse{},
+	)
+	now := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	m := newTestMaintainer(runner, PartitionConfig{Interval: PartitionMonthly, Premake: 1}, now)
+
+	report, err := m.Maintain(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "create partition packet_max_p202403")
+	assert.Equal(t, []string{"packet_max_p202404"}, report.Created)
+}
+
+func TestRepositoryPartitionMaintainerSharesRunner(t *testing.T) {
+	runner := &fakeRunner{}
+	repo := newTestRepository(t, runner)
+	defer repo.Close()
+
+	_, err := repo.PartitionMaintainer(PartitionConfig{Premake: 0}).Maintain(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, runner.callCount())
+	assert.True(t, strings.Contains(runner.calls[0].statement, "pg_inherits"))
+}
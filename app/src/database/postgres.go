@@ -2,13 +2,10 @@ package database
 
 import (
 	"context"
-	"encoding/csv"
+	"database/sql"
 	"errors"
 	"fmt"
-	"io"
 	"net/url"
-	"strconv"
-	"strings"
 	"sync"
 	"time"
 
@@ -28,11 +25,39 @@ type Config struct {
 	BatchTimeout time.Duration
 	// BufferSize controls the capacity of the inbound measurement queue.
 	BufferSize int
+	// Overflow selects what Add does when the queue is full; empty means OverflowBlock.
+	Overflow OverflowPolicy
+	// OverflowTimeout bounds the wait of OverflowBlockTimeout.
+	OverflowTimeout time.Duration
+	// SpillFile is where OverflowSpill keeps packet maxima that did not fit into the queue.
+	SpillFile string
+	// Publisher, when set, receives every packet maximum (rank 1) after it has been written.
+	Publisher domain.PacketMaxPublisher
+	// Retry decides which failed writes are attempted again and how long to wait in between.
+	Retry RetryPolicy
+	// DeadLetters, when set, keeps packet maxima whose write failed so they can be re-driven later.
+	DeadLetters domain.DeadLetterStore
+	// WAL, when WAL.Dir is set, logs every accepted packet maximum before Add returns and replays
+	// the ones that were not flushed when the repository is created again.
+	WAL WALConfig
+	// PurgeChunkSize bounds how many rows one retention delete removes; zero selects
+	// DefaultPurgeChunkSize.
+	PurgeChunkSize int
 }
 
-// CommandRunner executes SQL commands against Postgres.
+// CommandRunner executes SQL against Postgres. Commands report their sql.Result and queries
+// hand back their rows, which the repository scans straight into domain types.
 type CommandRunner interface {
-	Exec(ctx context.Context, dsn, password, sql string, args ...any) (string, error)
+	Exec(ctx context.Context, dsn, password, sql string, args ...any) (sql.Result, error)
+	Query(ctx context.Context, dsn, password, sql string, args ...any) (Rows, error)
+	Close() error
+}
+
+// Rows is the part of *sql.Rows the repository reads query results through.
+type Rows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
 	Close() error
 }
 
@@ -41,39 +66,75 @@ type Repository struct {
 	dsn      string
 	password string
 
-	runner CommandRunner
-	logger *metrics.Logger
+	runner      CommandRunner
+	logger      *metrics.Logger
+	publisher   domain.PacketMaxPublisher
+	deadLetters domain.DeadLetterStore
+	purger      *Purger
+	retry       RetryPolicy
+	backoff     *backoff
 
 	batchSize    int
 	batchTimeout time.Duration
-	buffer       chan domain.PacketMax
+	buffer       chan bufferedPacketMax
 	stopCh       chan struct{}
 	wg           sync.WaitGroup
 
+	overflow        OverflowPolicy
+	overflowTimeout time.Duration
+	spill           *spillFile
+	spillSignal     chan struct{}
+
+	wal       *wal
+	recovered []bufferedPacketMax
+
 	mu     sync.RWMutex
 	closed bool
 
 	closeOnce sync.Once
 }
 
+// bufferedPacketMax is a packet maximum waiting to be written together with its WAL sequence
+// number; zero means it is not in the log.
+type bufferedPacketMax struct {
+	domain.PacketMax
+	seq uint64
+}
+
 const (
-	insertMeasurementSQL = `
-INSERT INTO public.packet_max (packet_id, source_id, value, ts)
-VALUES ($1, $2, $3, $4)
+	selectPacketMaxByIDSQL = `
+SELECT packet_id::text, source_id::text, value, ts
+FROM public.packet_max
+WHERE packet_id = $1::uuid AND rank = 1
+ORDER BY value DESC, ts DESC
+LIMIT 1
 `
-	updateMeasurementByPairSQL = `
-UPDATE public.packet_max
-SET source_id = CASE WHEN $3 > value THEN $2 ELSE source_id END,
-    value     = GREATEST(value, $3),
-    ts        = $4
-WHERE packet_id = $1 AND source_id = $2
+	selectPacketMaxInRangeSQL = `
+SELECT packet_id::text, source_id::text, value, ts
+FROM public.packet_max
+WHERE rank = 1 AND ts BETWEEN $1 AND $2
+ORDER BY ts ASC, packet_id ASC
+LIMIT $3
 `
-	updateMeasurementByPacketSQL = `
-UPDATE public.packet_max
-SET source_id = CASE WHEN $3 > value THEN $2 ELSE source_id END,
-    value     = GREATEST(value, $3),
-    ts        = $4
-WHERE packet_id = $1
+	selectPacketMaxInRangeAfterSQL = `
+SELECT packet_id::text, source_id::text, value, ts
+FROM public.packet_max
+WHERE rank = 1 AND ts BETWEEN $1 AND $2 AND (ts, packet_id) > ($3, $4::uuid)
+ORDER BY ts ASC, packet_id ASC
+LIMIT $5
+`
+	streamPacketMaxInRangeSQL = `
+SELECT packet_id::text, source_id::text, value, ts
+FROM public.packet_max
+WHERE rank = 1 AND ts BETWEEN $1 AND $2
+ORDER BY ts ASC, packet_id ASC
+`
+	selectTopPacketMaxSQL = `
+SELECT packet_id::text, source_id::text, value, ts, rank
+FROM public.packet_max
+WHERE packet_id = $1::uuid
+ORDER BY rank ASC
+LIMIT $2
 `
 )
 
@@ -110,15 +171,73 @@ func New(ctx context.Context, cfg Config) (*Repository, error) {
 		batchTimeout = 0
 	}
 
+	overflow, err := ParseOverflowPolicy(string(cfg.Overflow))
+	if err != nil {
+		return nil, fmt.Errorf("postgres repository: %w", err)
+	}
+	if overflow == OverflowBlockTimeout && cfg.OverflowTimeout <= 0 {
+		return nil, errors.New("postgres repository: overflow timeout must be positive for block-timeout")
+	}
+
+	var spill *spillFile
+	if overflow == OverflowSpill {
+		spill, err = openSpillFile(cfg.SpillFile)
+		if err != nil {
+			return nil, fmt.Errorf("postgres repository: %w", err)
+		}
+	}
+
+	var log *wal
+	var recovered []walEntry
+	if cfg.WAL.Dir != "" {
+		log, recovered, err = openWAL(cfg.WAL, cfg.Logger)
+		if err != nil {
+			if spill != nil {
+				_ = spill.close()
+			}
+			return nil, fmt.Errorf("postgres repository: %w", err)
+		}
+	}
+
+	retry := cfg.Retry.normalized()
+
 	repo := &Repository{
 		dsn:          cfg.DSN,
 		password:     password,
 		runner:       runner,
 		logger:       cfg.Logger,
+		publisher:    cfg.Publisher,
+		deadLetters:  cfg.DeadLetters,
+		purger:       newPurger(runner, cfg.DSN, password, cfg.PurgeChunkSize),
+		retry:        retry,
+		backoff:      newBackoff(retry),
 		batchSize:    batchSize,
 		batchTimeout: batchTimeout,
-		buffer:       make(chan domain.PacketMax, bufferSize),
+		buffer:       make(chan bufferedPacketMax, bufferSize),
 		stopCh:       make(chan struct{}),
+
+		overflow:        overflow,
+		overflowTimeout: cfg.OverflowTimeout,
+		spill:           spill,
+		spillSignal:     make(chan struct{}, 1),
+
+		wal: log,
+	}
+	if len(recovered) > 0 {
+		repo.recovered = make([]bufferedPacketMax, len(recovered))
+		for i, entry := range recovered {
+			repo.recovered[i] = bufferedPacketMax{PacketMax: entry.packetMax, seq: entry.seq}
+		}
+		metrics.AddWALReplayed(len(recovered))
+		if cfg.Logger != nil {
+			cfg.Logger.Printf(ctx, "postgres repository: replaying %d unflushed packet maxima from the write-ahead log", len(recovered))
+		}
+	}
+	if spill != nil {
+		metrics.SetDBBufferSpillDepth(spill.len())
+		if spill.len() > 0 {
+			repo.signalSpill()
+		}
 	}
 
 	repo.wg.Add(1)
@@ -144,6 +263,16 @@ func (r *Repository) Close() error {
 	var err error
 	r.closeOnce.Do(func() {
 		err = r.runner.Close()
+		if r.spill != nil {
+			if spillErr := r.spill.close(); spillErr != nil && err == nil {
+				err = spillErr
+			}
+		}
+		if r.wal != nil {
+			if walErr := r.wal.close(); walErr != nil && err == nil {
+				err = walErr
+			}
+		}
 	})
 	return err
 }
@@ -168,20 +297,144 @@ func (r *Repository) Add(ctx context.Context, packetMax domain.PacketMax) error
 		return errors.New("postgres repository: repository closed")
 	}
 
+	item := bufferedPacketMax{PacketMax: packetMax}
+	if r.wal != nil {
+		seq, err := r.wal.append(packetMax)
+		if err != nil {
+			return fmt.Errorf("postgres repository: %w", err)
+		}
+		item.seq = seq
+	}
+
+	defer func() { metrics.SetDBBufferOccupancy(len(buffer)) }()
+
+	err := r.enqueue(ctx, item, buffer, stopCh)
+	if err != nil {
+		// The caller sees the error, so the logged record must not be replayed.
+		r.ackWAL(item.seq)
+	}
+	return err
+}
+
+func (r *Repository) enqueue(ctx context.Context, item bufferedPacketMax, buffer chan bufferedPacketMax, stopCh chan struct{}) error {
 	select {
-	case <-ctx.Done():
-		return ctx.Err()
+	case buffer <- item:
+		return nil
 	case <-stopCh:
 		return errors.New("postgres repository: repository closed")
-	case buffer <- packetMax:
-		return nil
+	default:
+	}
+
+	switch r.overflow {
+	case OverflowDropNewest:
+		metrics.IncDBBufferDropped()
+		return fmt.Errorf("postgres repository: buffer full, packet max dropped: %w", domain.ErrBackpressure)
+	case OverflowDropOldest:
+		return r.enqueueDroppingOldest(item, buffer)
+	case OverflowSpill:
+		return r.spillPacketMax(item)
+	case OverflowBlockTimeout:
+		timer := time.NewTimer(r.overflowTimeout)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stopCh:
+			return errors.New("postgres repository: repository closed")
+		case buffer <- item:
+			return nil
+		case <-timer.C:
+			metrics.IncDBBufferDropped()
+			return fmt.Errorf("postgres repository: buffer full for %s, packet max dropped: %w", r.overflowTimeout, domain.ErrBackpressure)
+		}
+	default:
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stopCh:
+			return errors.New("postgres repository: repository closed")
+		case buffer <- item:
+			return nil
+		}
+	}
+}
+
+// enqueueDroppingOldest makes room by discarding the oldest buffered entries; the writer may
+// drain the buffer concurrently, so the loop retries until the send succeeds.
+func (r *Repository) enqueueDroppingOldest(item bufferedPacketMax, buffer chan bufferedPacketMax) error {
+	for {
+		select {
+		case buffer <- item:
+			return nil
+		default:
+		}
+		select {
+		case evicted := <-buffer:
+			metrics.IncDBBufferDropped()
+			r.ackWAL(evicted.seq)
+		default:
+		}
+	}
+}
+
+// spillPacketMax moves the entry to the spill file, which keeps it from then on, so its WAL
+// record is acknowledged. With the WAL enabled the spill file is synced first: once the record is
+// acknowledged a crash may drop it, and the entry must not be lost with it.
+func (r *Repository) spillPacketMax(item bufferedPacketMax) error {
+	if err := r.spill.push(item.PacketMax); err != nil {
+		return fmt.Errorf("postgres repository: %w", err)
+	}
+	if r.wal != nil {
+		if err := r.spill.sync(); err != nil {
+			return fmt.Errorf("postgres repository: %w", err)
+		}
+	}
+	metrics.RecordDBBufferSpill(r.spill.len())
+	r.ackWAL(item.seq)
+	r.signalSpill()
+	return nil
+}
+
+// ackWAL tells the write-ahead log that the entries no longer need to be replayed.
+func (r *Repository) ackWAL(seqs ...uint64) {
+	if r.wal == nil {
+		return
+	}
+	if err := r.wal.ack(seqs...); err != nil && r.logger != nil {
+		r.logger.Printf(context.Background(), "postgres repository: write-ahead log checkpoint failed: %v", err)
+	}
+}
+
+func (r *Repository) signalSpill() {
+	select {
+	case r.spillSignal <- struct{}{}:
+	default:
+	}
+}
+
+// readSpill hands up to one batch of spilled packet maxima to appendToBatch and asks for another
+// round while the spill file is not empty, so spilled and buffered entries take turns.
+func (r *Repository) readSpill(appendToBatch func(bufferedPacketMax)) {
+	if r.spill == nil {
+		return
+	}
+	items, err := r.spill.pop(r.batchSize)
+	if err != nil && r.logger != nil {
+		r.logger.Printf(context.Background(), "postgres repository: read spill file failed: %v", err)
+	}
+	metrics.SetDBBufferSpillDepth(r.spill.len())
+	for _, packetMax := range items {
+		appendToBatch(bufferedPacketMax{PacketMax: packetMax})
+	}
+	if err == nil && r.spill.len() > 0 {
+		r.signalSpill()
 	}
 }
 
 func (r *Repository) run() {
 	defer r.wg.Done()
 
-	batch := make([]domain.PacketMax, 0, r.batchSize)
+	batch := make([]bufferedPacketMax, 0, r.batchSize)
 	var timer *time.Timer
 	var firstItemTime time.Time
 
@@ -232,8 +485,8 @@ func (r *Repository) run() {
 		firstItemTime = time.Time{}
 	}
 
-	appendToBatch := func(packetMax domain.PacketMax) {
-		batch = append(batch, packetMax)
+	appendToBatch := func(item bufferedPacketMax) {
+		batch = append(batch, item)
 		if len(batch) == 1 {
 			firstItemTime = time.Now()
 			activateTimer()
@@ -243,6 +496,13 @@ func (r *Repository) run() {
 		}
 	}
 
+	// Entries replayed from the write-ahead log go first, they were accepted before anything
+	// that can arrive now.
+	for _, item := range r.recovered {
+		appendToBatch(item)
+	}
+	r.recovered = nil
+
 	for {
 		var timeout <-chan time.Time
 		if timer != nil {
@@ -253,95 +513,97 @@ func (r *Repository) run() {
 		case <-r.stopCh:
 			for {
 				select {
-				case packetMax := <-r.buffer:
-					appendToBatch(packetMax)
+				case item := <-r.buffer:
+					appendToBatch(item)
 				default:
 					flush()
 					return
 				}
 			}
-		case packetMax := <-r.buffer:
-			appendToBatch(packetMax)
+		case item := <-r.buffer:
+			metrics.SetDBBufferOccupancy(len(r.buffer))
+			appendToBatch(item)
+		case <-r.spillSignal:
+			r.readSpill(appendToBatch)
 		case <-timeout:
 			flush()
 		}
 	}
 }
 
-func (r *Repository) processBatch(batch []domain.PacketMax) {
+// processBatch writes the batch and then acknowledges its WAL records: every entry has been
+// stored, dead-lettered or dropped by that point.
+func (r *Repository) processBatch(batch []bufferedPacketMax) {
 	if len(batch) == 0 {
 		return
 	}
 
 	ctx := context.Background()
-	for _, packetMax := range batch {
-		if err := r.writePacketMax(ctx, packetMax); err != nil {
-			if r.logger != nil {
-				r.logger.Printf(ctx, "postgres repository: batch write failed packet=%s source=%s: %v", packetMax.PacketID, packetMax.SourceID, err)
-			}
-		}
+	packetMaxes := make([]domain.PacketMax, len(batch))
+	seqs := make([]uint64, len(batch))
+	for i, item := range batch {
+		packetMaxes[i] = item.PacketMax
+		seqs[i] = item.seq
 	}
-
+	for start := 0; start < len(packetMaxes); start += maxUpsertRows {
+		end := min(start+maxUpsertRows, len(packetMaxes))
+		r.writeChunk(ctx, packetMaxes[start:end])
+	}
+	r.ackWAL(seqs...)
 }
 
-func (r *Repository) writePacketMax(ctx context.Context, packetMax domain.PacketMax) error {
-	timestamp := packetMax.Timestamp.UTC()
+// writeWithRetry attempts the write as the retry policy allows.
+func (r *Repository) writeWithRetry(ctx context.Context, packetMax domain.PacketMax) error {
+	label := fmt.Sprintf("packet=%s source=%s", packetMax.PacketID, packetMax.SourceID)
+	return r.retryWrite(ctx, label, func(ctx context.Context) error {
+		return r.writePacketMax(ctx, packetMax)
+	})
+}
 
-	insertTag, err := r.execStatement(ctx, insertMeasurementSQL, packetMax, timestamp)
-	if err == nil {
-		if _, err := parseRowsAffected(insertTag); err != nil {
-			if r.logger != nil {
-				r.logger.Printf(ctx, "postgres repository: parse insert result failed packet=%s source=%s tag=%q: %v", packetMax.PacketID, packetMax.SourceID, insertTag, err)
+// retryWrite runs write as the retry policy allows; label identifies the rows in log lines.
+// Waiting between attempts stops as soon as the repository is closed, so shutdown is not held up
+// by a database outage; the rows then fail like any other and end up in the dead-letter queue.
+func (r *Repository) retryWrite(ctx context.Context, label string, write func(context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := write(ctx)
+		if err == nil {
+			return nil
+		}
+		if !isRetryable(err) {
+			return err
+		}
+		if attempt >= r.retry.MaxAttempts {
+			if r.retry.MaxAttempts > 1 {
+				metrics.IncDBWriteGiveUps()
 			}
-			return fmt.Errorf("postgres repository: parse insert result: %w", err)
+			return fmt.Errorf("postgres repository: giving up after %d attempts: %w", attempt, err)
 		}
 
-		return nil
-	}
-
-	if !isUniqueViolation(err) {
+		delay := r.backoff.delay(attempt)
 		if r.logger != nil {
-			r.logger.Printf(ctx, "postgres repository: insert failed packet=%s source=%s value=%v ts=%s: %v", packetMax.PacketID, packetMax.SourceID, packetMax.Value, timestamp.Format(time.RFC3339Nano), err)
+			r.logger.Printf(ctx, "postgres repository: transient write error %s attempt=%d retry_in=%s: %v", label, attempt, delay, err)
 		}
-		return fmt.Errorf("postgres repository: insert packet max: %w", err)
-	}
-
-	updateTag, err := r.execStatement(ctx, updateMeasurementByPairSQL, packetMax, timestamp)
-	if err != nil {
-		return fmt.Errorf("postgres repository: update packet max by pair: %w", err)
-	}
+		metrics.IncDBWriteRetries()
 
-	affected, err := parseRowsAffected(updateTag)
-	if err != nil {
-		if r.logger != nil {
-			r.logger.Printf(ctx, "postgres repository: parse update pair result failed packet=%s source=%s tag=%q: %v", packetMax.PacketID, packetMax.SourceID, updateTag, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			metrics.IncDBWriteGiveUps()
+			return fmt.Errorf("postgres repository: retry aborted: %w", err)
+		case <-r.stopCh:
+			timer.Stop()
+			metrics.IncDBWriteGiveUps()
+			return fmt.Errorf("postgres repository: retry aborted by shutdown after %d attempts: %w", attempt, err)
+		case <-timer.C:
 		}
-		return fmt.Errorf("postgres repository: parse update pair result: %w", err)
-	}
-	if affected > 0 {
-		return nil
-	}
-
-	fallbackTag, err := r.execStatement(ctx, updateMeasurementByPacketSQL, packetMax, timestamp)
-	if err != nil {
-		return fmt.Errorf("postgres repository: update packet max by packet: %w", err)
 	}
+}
 
-	fallbackAffected, err := parseRowsAffected(fallbackTag)
-	if err != nil {
-		if r.logger != nil {
-			r.logger.Printf(ctx, "postgres repository: parse update packet result failed packet=%s source=%s tag=%q: %v", packetMax.PacketID, packetMax.SourceID, fallbackTag, err)
-		}
-		return fmt.Errorf("postgres repository: parse update packet result: %w", err)
-	}
-	if fallbackAffected == 0 {
-		if r.logger != nil {
-			r.logger.Printf(ctx, "postgres repository: update packet affected no rows packet=%s source=%s", packetMax.PacketID, packetMax.SourceID)
-		}
-		return errors.New("postgres repository: update affected 0 rows after unique violation")
+func (r *Repository) publish(packetMax domain.PacketMax) {
+	if r.publisher != nil && packetRank(packetMax) == 1 {
+		r.publisher.Publish(packetMax)
 	}
-
-	return nil
 }
 
 func validatePacketMax(packetMax domain.PacketMax) error {
@@ -354,116 +616,125 @@ func validatePacketMax(packetMax domain.PacketMax) error {
 	if packetMax.SourceID == "" {
 		return errors.New("postgres repository: source id is required")
 	}
+	if packetMax.Rank < 0 {
+		return errors.New("postgres repository: rank must not be negative")
+	}
 	if _, err := constants.ParseUUID(packetMax.SourceID); err != nil {
 		return fmt.Errorf("postgres repository: invalid source id: %w", err)
 	}
 	return nil
 }
 
-func (r *Repository) execStatement(ctx context.Context, statement string, packetMax domain.PacketMax, timestamp time.Time) (string, error) {
-	tag, err := r.runner.Exec(ctx, r.dsn, r.password, statement, packetMax.PacketID, packetMax.SourceID, packetMax.Value, timestamp)
-	if err != nil {
-		if !isUniqueViolation(err) {
-			if r.logger != nil {
-				r.logger.Printf(ctx, "postgres repository: exec failed packet=%s source=%s value=%v ts=%s statement=%q: %v", packetMax.PacketID, packetMax.SourceID, packetMax.Value, timestamp.Format(time.RFC3339Nano), strings.TrimSpace(statement), err)
-			}
-		}
-		return "", err
+func packetRank(packetMax domain.PacketMax) int {
+	if packetMax.Rank <= 0 {
+		return 1
 	}
-	return tag, nil
+	return packetMax.Rank
 }
 
-func parseRowsAffected(tag string) (int64, error) {
-	fields := strings.Fields(strings.TrimSpace(tag))
-	if len(fields) == 0 {
-		return 0, nil
-	}
-
-	switch strings.ToUpper(fields[0]) {
-	case "UPDATE", "DELETE":
-		if len(fields) < 2 {
-			return 0, fmt.Errorf("unexpected command tag %q", tag)
-		}
-		count, err := strconv.ParseInt(fields[1], 10, 64)
-		if err != nil {
-			return 0, fmt.Errorf("parse rows affected: %w", err)
-		}
-		return count, nil
-	case "INSERT":
-		if len(fields) < 3 {
-			return 0, fmt.Errorf("unexpected command tag %q", tag)
-		}
-		count, err := strconv.ParseInt(fields[len(fields)-1], 10, 64)
-		if err != nil {
-			return 0, fmt.Errorf("parse rows affected: %w", err)
-		}
-		return count, nil
-	default:
-		return 0, fmt.Errorf("unsupported command tag %q", tag)
+// PacketMaxByID returns the persisted maximum for the provided packet identifier.
+func (r *Repository) PacketMaxByID(ctx context.Context, packetID string) (domain.PacketMax, error) {
+	if _, err := constants.ParseUUID(packetID); err != nil {
+		return domain.PacketMax{}, fmt.Errorf("postgres repository: invalid packet id: %w", err)
 	}
-}
 
-func isUniqueViolation(err error) bool {
-	if err == nil {
-		return false
+	rows, err := r.query(ctx, selectPacketMaxByIDSQL, packetID)
+	if err != nil {
+		return domain.PacketMax{}, fmt.Errorf("postgres repository: packet max by id: %w", err)
 	}
 
-	type sqlState interface {
-		SQLState() string
+	packetMaxes, err := scanPacketMaxes(rows, false)
+	if err != nil {
+		return domain.PacketMax{}, fmt.Errorf("postgres repository: packet max by id: %w", err)
 	}
-
-	var state sqlState
-	if errors.As(err, &state) {
-		return state.SQLState() == "23505"
+	if len(packetMaxes) == 0 {
+		return domain.PacketMax{}, domain.ErrNotFound
 	}
 
-	message := strings.ToLower(err.Error())
-	return strings.Contains(message, "duplicate key value") || strings.Contains(message, "unique constraint")
+	return packetMaxes[0], nil
 }
 
-// PacketMaxByID returns the persisted maximum for the provided packet identifier.
-func (r *Repository) PacketMaxByID(ctx context.Context, packetID string) (domain.PacketMax, error) {
-	if _, err := constants.ParseUUID(packetID); err != nil {
-		return domain.PacketMax{}, fmt.Errorf("postgres repository: invalid packet id: %w", err)
+// PacketMaxInRange returns one page of the maxima recorded within the provided time range, ordered
+// by timestamp and packet id. One extra row is read to tell whether another page follows.
+func (r *Repository) PacketMaxInRange(ctx context.Context, from, to time.Time, page domain.PageRequest) (domain.PacketMaxPage, error) {
+	if page.Limit <= 0 {
+		return domain.PacketMaxPage{}, errors.New("postgres repository: page limit must be positive")
 	}
 
-	statement := fmt.Sprintf(
-		"SELECT packet_id::text, source_id::text, value, ts AT TIME ZONE 'UTC' FROM public.packet_max WHERE packet_id = '%s'::uuid LIMIT 1",
-		packetID,
+	var (
+		rows Rows
+		err  error
 	)
-
-	output, err := r.runner.Exec(ctx, r.dsn, r.password, statement)
+	if page.Cursor == "" {
+		rows, err = r.query(ctx, selectPacketMaxInRangeSQL, from.UTC(), to.UTC(), page.Limit+1)
+	} else {
+		cursor, decodeErr := domain.DecodePageCursor(page.Cursor)
+		if decodeErr != nil {
+			return domain.PacketMaxPage{}, fmt.Errorf("postgres repository: packet max in range: %w", decodeErr)
+		}
+		if _, uuidErr := constants.ParseUUID(cursor.PacketID); uuidErr != nil {
+			return domain.PacketMaxPage{}, fmt.Errorf("postgres repository: packet max in range: %w: %v", domain.ErrInvalidCursor, uuidErr)
+		}
+		rows, err = r.query(ctx, selectPacketMaxInRangeAfterSQL, from.UTC(), to.UTC(), cursor.Timestamp, cursor.PacketID, page.Limit+1)
+	}
 	if err != nil {
-		return domain.PacketMax{}, fmt.Errorf("postgres repository: packet max by id: %w", err)
+		return domain.PacketMaxPage{}, fmt.Errorf("postgres repository: packet max in range: %w", err)
 	}
 
-	packetMaxes, err := parsePacketMaxList(output)
+	packetMaxes, err := scanPacketMaxes(rows, false)
 	if err != nil {
-		return domain.PacketMax{}, fmt.Errorf("postgres repository: packet max by id parse: %w", err)
+		return domain.PacketMaxPage{}, fmt.Errorf("postgres repository: packet max in range: %w", err)
 	}
-	if len(packetMaxes) == 0 {
-		return domain.PacketMax{}, domain.ErrNotFound
+	if len(packetMaxes) == 0 && page.Cursor == "" {
+		return domain.PacketMaxPage{}, domain.ErrNotFound
 	}
 
-	return packetMaxes[0], nil
+	return paginate(packetMaxes, page.Limit), nil
 }
 
-// PacketMaxInRange returns the maxima for all packets recorded within the provided time range ordered by timestamp.
-func (r *Repository) PacketMaxInRange(ctx context.Context, from, to time.Time) ([]domain.PacketMax, error) {
-	statement := fmt.Sprintf(
-		"SELECT packet_id::text, source_id::text, value, ts AT TIME ZONE 'UTC' FROM public.packet_max WHERE ts BETWEEN '%s'::timestamptz AND '%s'::timestamptz ORDER BY ts ASC",
-		from.UTC().Format(time.RFC3339Nano),
-		to.UTC().Format(time.RFC3339Nano),
-	)
+// paginate trims the look-ahead row read past limit and points the cursor at the last row kept.
+func paginate(packetMaxes []domain.PacketMax, limit int) domain.PacketMaxPage {
+	if len(packetMaxes) <= limit {
+		return domain.PacketMaxPage{PacketMaxes: packetMaxes}
+	}
+
+	packetMaxes = packetMaxes[:limit]
+	last := packetMaxes[limit-1]
+	cursor := domain.PageCursor{Timestamp: last.Timestamp, PacketID: last.PacketID}
+	return domain.PacketMaxPage{PacketMaxes: packetMaxes, NextCursor: cursor.Encode()}
+}
 
-	output, err := r.runner.Exec(ctx, r.dsn, r.password, statement)
+// IteratePacketMaxInRange streams the maxima recorded within the provided time range, ordered by
+// timestamp and packet id. Rows are read from the connection as the iterator advances, so memory
+// does not grow with the range; cancelling ctx aborts the query and closes the cursor.
+func (r *Repository) IteratePacketMaxInRange(ctx context.Context, from, to time.Time) (domain.PacketMaxIterator, error) {
+	rows, err := r.query(ctx, streamPacketMaxInRangeSQL, from.UTC(), to.UTC())
 	if err != nil {
-		return nil, fmt.Errorf("postgres repository: packet max in range: %w", err)
+		return nil, fmt.Errorf("postgres repository: iterate packet max in range: %w", err)
 	}
+	return &packetMaxIterator{rows: rows}, nil
+}
 
-	packetMaxes, err := parsePacketMaxList(output)
+// TopPacketMaxByID returns up to k ranked maxima stored for the packet, best first.
+// A non-positive k returns every stored rank.
+func (r *Repository) TopPacketMaxByID(ctx context.Context, packetID string, k int) ([]domain.PacketMax, error) {
+	if _, err := constants.ParseUUID(packetID); err != nil {
+		return nil, fmt.Errorf("postgres repository: invalid packet id: %w", err)
+	}
+
+	var limit any
+	if k > 0 {
+		limit = k
+	}
+
+	rows, err := r.query(ctx, selectTopPacketMaxSQL, packetID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("postgres repository: top packet max by id: %w", err)
+	}
+
+	packetMaxes, err := scanPacketMaxes(rows, true)
 	if err != nil {
-		return nil, fmt.Errorf("postgres repository: packet max in range parse: %w", err)
+		return nil, fmt.Errorf("postgres repository: top packet max by id: %w", err)
 	}
 	if len(packetMaxes) == 0 {
 		return nil, domain.ErrNotFound
@@ -472,56 +743,94 @@ func (r *Repository) PacketMaxInRange(ctx context.Context, from, to time.Time) (
 	return packetMaxes, nil
 }
 
-func parsePacketMaxList(output string) ([]domain.PacketMax, error) {
-	trimmed := strings.TrimSpace(output)
-	if trimmed == "" {
-		return nil, nil
+// readStatements lists every query the repository sends. Values always travel as bind parameters;
+// query refuses any other statement, so SQL assembled from caller input never reaches the runner.
+var readStatements = map[string]struct{}{
+	selectPacketMaxByIDSQL:          {},
+	selectPacketMaxInRangeSQL:       {},
+	selectPacketMaxInRangeAfterSQL:  {},
+	streamPacketMaxInRangeSQL:       {},
+	selectTopPacketMaxSQL:           {},
+	selectAggregateByIDSQL:          {},
+	selectAggregatesInRangeSQL:      {},
+	selectAggregatesInRangeAfterSQL: {},
+}
+
+// query runs one of readStatements with the given bind parameters.
+func (r *Repository) query(ctx context.Context, statement string, args ...any) (Rows, error) {
+	if _, ok := readStatements[statement]; !ok {
+		return nil, errors.New("postgres repository: refusing unregistered query")
 	}
+	return r.runner.Query(ctx, r.dsn, r.password, statement, args...)
+}
 
-	reader := csv.NewReader(strings.NewReader(trimmed))
-	reader.TrimLeadingSpace = true
+// scanPacketMaxes reads packet_id, source_id, value and ts, followed by rank when withRank is set,
+// and closes rows.
+func scanPacketMaxes(rows Rows, withRank bool) ([]domain.PacketMax, error) {
+	defer rows.Close()
 
 	var results []domain.PacketMax
-	for {
-		record, err := reader.Read()
-		if errors.Is(err, io.EOF) {
-			break
-		}
-		if err != nil {
-			return nil, fmt.Errorf("parse csv: %w", err)
-		}
-
-		if len(record) < 4 {
-			return nil, fmt.Errorf("unexpected column count: %d", len(record))
-		}
-
-		value, err := parseFloat(record[2])
+	for rows.Next() {
+		packetMax, err := scanPacketMax(rows, withRank)
 		if err != nil {
 			return nil, err
 		}
+		results = append(results, packetMax)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read rows: %w", err)
+	}
+	return results, nil
+}
 
-		timestamp, err := time.Parse(time.RFC3339Nano, record[3])
-		if err != nil {
-			return nil, fmt.Errorf("parse timestamp: %w", err)
-		}
+// scanPacketMax reads the current row in the layout described by scanPacketMaxes.
+func scanPacketMax(rows Rows, withRank bool) (domain.PacketMax, error) {
+	var packetMax domain.PacketMax
+	dest := []any{&packetMax.PacketID, &packetMax.SourceID, &packetMax.Value, &packetMax.Timestamp}
+	if withRank {
+		dest = append(dest, &packetMax.Rank)
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return domain.PacketMax{}, fmt.Errorf("scan: %w", err)
+	}
+	packetMax.Timestamp = packetMax.Timestamp.UTC()
+	return packetMax, nil
+}
+
+// packetMaxIterator adapts Rows of packet_id, source_id, value and ts to domain.PacketMaxIterator.
+type packetMaxIterator struct {
+	rows    Rows
+	current domain.PacketMax
+	err     error
+}
 
-		results = append(results, domain.PacketMax{
-			PacketID:  record[0],
-			SourceID:  record[1],
-			Value:     value,
-			Timestamp: timestamp,
-		})
+func (it *packetMaxIterator) Next() bool {
+	if it.err != nil || !it.rows.Next() {
+		return false
 	}
+	it.current, it.err = scanPacketMax(it.rows, false)
+	return it.err == nil
+}
 
-	return results, nil
+func (it *packetMaxIterator) PacketMax() domain.PacketMax {
+	return it.current
 }
 
-func parseFloat(input string) (float64, error) {
-	value, err := strconv.ParseFloat(strings.TrimSpace(input), 64)
-	if err != nil {
-		return 0, fmt.Errorf("parse float: %w", err)
+func (it *packetMaxIterator) Err() error {
+	if it.err != nil {
+		return fmt.Errorf("postgres repository: iterate packet max in range: %w", it.err)
+	}
+	if err := it.rows.Err(); err != nil {
+		return fmt.Errorf("postgres repository: iterate packet max in range: read rows: %w", err)
 	}
-	return value, nil
+	return nil
 }
 
-var _ domain.PacketMaxRepository = (*Repository)(nil)
+func (it *packetMaxIterator) Close() error {
+	return it.rows.Close()
+}
+
+var (
+	_ domain.PacketMaxRepository = (*Repository)(nil)
+	_ domain.DeadLetterQueue     = (*Repository)(nil)
+)
@@ -2,9 +2,13 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -17,9 +21,12 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// execResponse is what the fake runner answers to the next statement: rows for a query, the
+// number of affected rows for a command.
 type execResponse struct {
-	tag string
-	err error
+	rows     [][]any
+	affected int64
+	err      error
 }
 
 type fakeRunner struct {
@@ -34,16 +41,32 @@ type execCall struct {
 	args      []any
 }
 
-func (r *fakeRunner) Exec(ctx context.Context, dsn, password, sql string, args ...any) (string, error) {
+func (r *fakeRunner) Exec(ctx context.Context, dsn, password, statement string, args ...any) (sql.Result, error) {
+	resp := r.next(statement, args)
+	if resp.err != nil {
+		return nil, resp.err
+	}
+	return driver.RowsAffected(resp.affected), nil
+}
+
+func (r *fakeRunner) Query(ctx context.Context, dsn, password, statement string, args ...any) (Rows, error) {
+	resp := r.next(statement, args)
+	if resp.err != nil {
+		return nil, resp.err
+	}
+	return &fakeRows{rows: resp.rows}, nil
+}
+
+func (r *fakeRunner) next(statement string, args []any) execResponse {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.calls = append(r.calls, execCall{statement: sql, args: append([]any(nil), args...)})
+	r.calls = append(r.calls, execCall{statement: statement, args: append([]any(nil), args...)})
 	if len(r.responses) == 0 {
-		return "", nil
+		return execResponse{}
 	}
 	resp := r.responses[0]
 	r.responses = r.responses[1:]
-	return resp.tag, resp.err
+	return resp
 }
 
 func (r *fakeRunner) Close() error { return r.closeErr }
@@ -60,6 +83,44 @@ func (r *fakeRunner) callCount() int {
 	return len(r.calls)
 }
 
+// fakeRows serves canned rows; Scan copies each column into a destination of the same type.
+type fakeRows struct {
+	rows   [][]any
+	next   int
+	closed bool
+}
+
+func (r *fakeRows) Next() bool {
+	if r.next >= len(r.rows) {
+		return false
+	}
+	r.next++
+	return true
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+	row := r.rows[r.next-1]
+	if len(dest) != len(row) {
+		return fmt.Errorf("expected %d destination arguments, got %d", len(row), len(dest))
+	}
+	for i, value := range row {
+		target := reflect.ValueOf(dest[i]).Elem()
+		source := reflect.ValueOf(value)
+		if !source.Type().AssignableTo(target.Type()) {
+			return fmt.Errorf("column %d: cannot scan %T into %s", i, value, target.Type())
+		}
+		target.Set(source)
+	}
+	return nil
+}
+
+func (r *fakeRows) Err() error { return nil }
+
+func (r *fakeRows) Close() error {
+	r.closed = true
+	return nil
+}
+
 func (r *fakeRunner) lastCall() execCall {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -84,6 +145,15 @@ func newTestRepository(t *testing.T, runner *fakeRunner) *Repository {
 	return repo
 }
 
+// bufferedBatch wraps packet maxima that never went through the write-ahead log.
+func bufferedBatch(packetMaxes ...domain.PacketMax) []bufferedPacketMax {
+	batch := make([]bufferedPacketMax, len(packetMaxes))
+	for i, packetMax := range packetMaxes {
+		batch[i] = bufferedPacketMax{PacketMax: packetMax}
+	}
+	return batch
+}
+
 func TestNewValidatesConfig(t *testing.T) {
 	_, err := New(context.Background(), Config{})
 	assert.Error(t, err)
@@ -135,7 +205,7 @@ func TestAddRespectsContextCancellation(t *testing.T) {
 }
 
 func TestAddEnqueuesPacket(t *testing.T) {
-	runner := &fakeRunner{responses: []execResponse{{tag: "INSERT 0 1"}}}
+	runner := &fakeRunner{responses: []execResponse{{affected: 1}}}
 	repo := newTestRepository(t, runner)
 	defer repo.Close()
 
@@ -155,7 +225,7 @@ func TestProcessBatchNoMeasurements(t *testing.T) {
 
 func TestWritePacketMaxInsertSuccess(t *testing.T) {
 	runner := &fakeRunner{}
-	runner.setResponses(execResponse{tag: "INSERT 0 1"})
+	runner.setResponses(execResponse{affected: 1})
 	repo := newTestRepository(t, runner)
 	defer repo.Close()
 
@@ -164,146 +234,302 @@ func TestWritePacketMaxInsertSuccess(t *testing.T) {
 	assert.NoError(t, err)
 }
 
-type uniqueViolationError struct{}
+func TestValidatePacketMax(t *testing.T) {
+	assert.Error(t, validatePacketMax(domain.PacketMax{}))
+	valid := domain.PacketMax{PacketID: constants.GenerateUUID(), SourceID: constants.GenerateUUID()}
+	assert.NoError(t, validatePacketMax(valid))
+}
+
+func TestPacketMaxByIDSuccess(t *testing.T) {
+	timestamp := time.Now().UTC()
+	runner := &fakeRunner{responses: []execResponse{{rows: [][]any{{"packet", "source", 1.5, timestamp}}}}}
+	repo := newTestRepository(t, runner)
+	defer repo.Close()
 
-func (uniqueViolationError) Error() string    { return "duplicate key value violates unique constraint" }
-func (uniqueViolationError) SQLState() string { return "23505" }
+	result, err := repo.PacketMaxByID(context.Background(), constants.GenerateUUID())
+	assert.NoError(t, err)
+	assert.Equal(t, "packet", result.PacketID)
+	assert.Equal(t, 1.5, result.Value)
+}
 
-func TestWritePacketMaxHandlesUniqueViolationPairUpdate(t *testing.T) {
+func TestPacketMaxByIDNotFound(t *testing.T) {
 	runner := &fakeRunner{}
-	runner.setResponses(
-		execResponse{err: uniqueViolationError{}},
-		execResponse{tag: "UPDATE 1"},
-	)
 	repo := newTestRepository(t, runner)
 	defer repo.Close()
 
-	packet := domain.PacketMax{PacketID: constants.GenerateUUID(), SourceID: constants.GenerateUUID(), Value: 1, Timestamp: time.Now()}
-	err := repo.writePacketMax(context.Background(), packet)
+	_, err := repo.PacketMaxByID(context.Background(), constants.GenerateUUID())
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestPacketMaxInRangeSuccess(t *testing.T) {
+	timestamp := time.Now().UTC()
+	runner := &fakeRunner{responses: []execResponse{{rows: [][]any{{"packet", "source", 2.5, timestamp}}}}}
+	repo := newTestRepository(t, runner)
+	defer repo.Close()
+
+	results, err := repo.PacketMaxInRange(context.Background(), time.Now().Add(-time.Hour), time.Now(), domain.PageRequest{Limit: 10})
 	assert.NoError(t, err)
+	assert.Len(t, results.PacketMaxes, 1)
+	assert.Empty(t, results.NextCursor)
+	assert.Equal(t, 11, runner.lastCall().args[2])
 }
 
-func TestWritePacketMaxFallbackUpdate(t *testing.T) {
+func TestPacketMaxInRangeNotFound(t *testing.T) {
 	runner := &fakeRunner{}
-	runner.setResponses(
-		execResponse{err: uniqueViolationError{}},
-		execResponse{tag: "UPDATE 0"},
-		execResponse{tag: "UPDATE 1"},
-	)
 	repo := newTestRepository(t, runner)
 	defer repo.Close()
 
-	packet := domain.PacketMax{PacketID: constants.GenerateUUID(), SourceID: constants.GenerateUUID(), Value: 1, Timestamp: time.Now()}
-	err := repo.writePacketMax(context.Background(), packet)
-	assert.NoError(t, err)
+	_, err := repo.PacketMaxInRange(context.Background(), time.Now().Add(-time.Hour), time.Now(), domain.PageRequest{Limit: 10})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
 }
 
-func TestWritePacketMaxFallbackError(t *testing.T) {
+func TestPacketMaxInRangePaginates(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ids := []string{constants.GenerateUUID(), constants.GenerateUUID(), constants.GenerateUUID()}
 	runner := &fakeRunner{}
 	runner.setResponses(
-		execResponse{err: uniqueViolationError{}},
-		execResponse{tag: "UPDATE 0"},
-		execResponse{err: errors.New("update failed")},
+		execResponse{rows: [][]any{{ids[0], "source", 1.0, base}, {ids[1], "source", 2.0, base}, {ids[2], "source", 3.0, base.Add(time.Second)}}},
+		execResponse{rows: [][]any{{ids[2], "source", 3.0, base.Add(time.Second)}}},
 	)
 	repo := newTestRepository(t, runner)
 	defer repo.Close()
+	from, to := base.Add(-time.Hour), base.Add(time.Hour)
 
-	packet := domain.PacketMax{PacketID: constants.GenerateUUID(), SourceID: constants.GenerateUUID(), Value: 1, Timestamp: time.Now()}
-	err := repo.writePacketMax(context.Background(), packet)
-	assert.Error(t, err)
-}
+	t.Log("Шаг 1: первая страница читает на строку больше и отдаёт курсор на последнюю строку")
+	first, err := repo.PacketMaxInRange(context.Background(), from, to, domain.PageRequest{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, first.PacketMaxes, 2)
+	require.NotEmpty(t, first.NextCursor)
+	assert.Equal(t, selectPacketMaxInRangeSQL, runner.lastCall().statement)
+	assert.Equal(t, []any{from, to, 3}, runner.lastCall().args)
 
-func TestValidatePacketMax(t *testing.T) {
-	assert.Error(t, validatePacketMax(domain.PacketMax{}))
-	valid := domain.PacketMax{PacketID: constants.GenerateUUID(), SourceID: constants.GenerateUUID()}
-	assert.NoError(t, validatePacketMax(valid))
+	cursor, err := domain.DecodePageCursor(first.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PageCursor{Timestamp: base, PacketID: ids[1]}, cursor)
+
+	t.Log("Шаг 2: следующая страница продолжает после (ts, packet_id) курсора и оказывается последней")
+	second, err := repo.PacketMaxInRange(context.Background(), from, to, domain.PageRequest{Limit: 2, Cursor: first.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, second.PacketMaxes, 1)
+	assert.Equal(t, ids[2], second.PacketMaxes[0].PacketID)
+	assert.Empty(t, second.NextCursor)
+	assert.Equal(t, selectPacketMaxInRangeAfterSQL, runner.lastCall().statement)
+	assert.Equal(t, []any{from, to, base, ids[1], 3}, runner.lastCall().args)
 }
 
-func TestExecStatementReturnsError(t *testing.T) {
+func TestPacketMaxInRangeRejectsBadPages(t *testing.T) {
 	runner := &fakeRunner{}
-	runner.setResponses(execResponse{err: errors.New("failed")})
 	repo := newTestRepository(t, runner)
 	defer repo.Close()
+	ctx := context.Background()
 
-	packet := domain.PacketMax{PacketID: constants.GenerateUUID(), SourceID: constants.GenerateUUID(), Value: 1, Timestamp: time.Now()}
-	_, err := repo.execStatement(context.Background(), "SQL", packet, time.Now())
+	_, err := repo.PacketMaxInRange(ctx, time.Now().Add(-time.Hour), time.Now(), domain.PageRequest{})
 	assert.Error(t, err)
+
+	_, err = repo.PacketMaxInRange(ctx, time.Now().Add(-time.Hour), time.Now(), domain.PageRequest{Limit: 1, Cursor: "not a cursor"})
+	assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+
+	forged := domain.PageCursor{Timestamp: time.Now(), PacketID: "1' OR '1'='1"}.Encode()
+	_, err = repo.PacketMaxInRange(ctx, time.Now().Add(-time.Hour), time.Now(), domain.PageRequest{Limit: 1, Cursor: forged})
+	assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+	assert.Zero(t, runner.callCount())
 }
 
-func TestParseRowsAffected(t *testing.T) {
-	count, err := parseRowsAffected("UPDATE 2")
-	assert.NoError(t, err)
-	assert.Equal(t, int64(2), count)
+func TestPacketMaxInRangeEmptyPageAfterCursor(t *testing.T) {
+	runner := &fakeRunner{}
+	repo := newTestRepository(t, runner)
+	defer repo.Close()
 
-	count, err = parseRowsAffected("INSERT 0 1")
+	cursor := domain.PageCursor{Timestamp: time.Now(), PacketID: constants.GenerateUUID()}.Encode()
+	page, err := repo.PacketMaxInRange(context.Background(), time.Now().Add(-time.Hour), time.Now(), domain.PageRequest{Limit: 1, Cursor: cursor})
 	assert.NoError(t, err)
-	assert.Equal(t, int64(1), count)
+	assert.Empty(t, page.PacketMaxes)
+	assert.Empty(t, page.NextCursor)
+}
 
-	_, err = parseRowsAffected("UNKNOWN")
-	assert.Error(t, err)
+func TestIteratePacketMaxInRangeStreamsRows(t *testing.T) {
+	local := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("UTC+3", 3*60*60))
+	runner := &fakeRunner{}
+	runner.setResponses(execResponse{rows: [][]any{{"first", "source", 1.5, local}, {"second", "source", 2.5, local.Add(time.Second)}}})
+	repo := newTestRepository(t, runner)
+	defer repo.Close()
+
+	t.Log("Шаг 1: строки читаются по одной и приводятся к UTC")
+	it, err := repo.IteratePacketMaxInRange(context.Background(), local.Add(-time.Hour), local.Add(time.Hour))
+	require.NoError(t, err)
+	var ids []string
+	for it.Next() {
+		packetMax := it.PacketMax()
+		assert.Equal(t, time.UTC, packetMax.Timestamp.Location())
+		ids = append(ids, packetMax.PacketID)
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, []string{"first", "second"}, ids)
+	assert.Equal(t, streamPacketMaxInRangeSQL, runner.lastCall().statement)
+
+	t.Log("Шаг 2: Close закрывает курсор")
+	rows := it.(*packetMaxIterator).rows.(*fakeRows)
+	assert.False(t, rows.closed)
+	require.NoError(t, it.Close())
+	assert.True(t, rows.closed)
 }
 
-func TestIsUniqueViolation(t *testing.T) {
-	assert.True(t, isUniqueViolation(uniqueViolationError{}))
-	assert.True(t, isUniqueViolation(errors.New("duplicate key value")))
-	assert.False(t, isUniqueViolation(nil))
+func TestIteratePacketMaxInRangeStopsOnScanError(t *testing.T) {
+	runner := &fakeRunner{}
+	runner.setResponses(execResponse{rows: [][]any{{"first", "source", "not a number", time.Now()}, {"second", "source", 1.0, time.Now()}}})
+	repo := newTestRepository(t, runner)
+	defer repo.Close()
+
+	it, err := repo.IteratePacketMaxInRange(context.Background(), time.Now().Add(-time.Hour), time.Now())
+	require.NoError(t, err)
+	defer it.Close()
+
+	assert.False(t, it.Next())
+	assert.False(t, it.Next())
+	assert.Error(t, it.Err())
 }
 
-func TestPacketMaxByIDSuccess(t *testing.T) {
-	timestamp := time.Now().UTC().Format(time.RFC3339Nano)
-	runner := &fakeRunner{responses: []execResponse{{tag: fmt.Sprintf("packet,source,1.5,%s\n", timestamp)}}}
+func TestScanPacketMaxes(t *testing.T) {
+	local := time.Date(2024, 5, 1, 12, 0, 0, 123456000, time.FixedZone("UTC+3", 3*60*60))
+	rows := &fakeRows{rows: [][]any{{"id", "source", 1.5, local, 2}}}
+
+	results, err := scanPacketMaxes(rows, true)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, 2, results[0].Rank)
+	assert.Equal(t, time.UTC, results[0].Timestamp.Location())
+	assert.True(t, local.Equal(results[0].Timestamp))
+	assert.True(t, rows.closed)
+
+	_, err = scanPacketMaxes(&fakeRows{rows: [][]any{{"id", "source", "1.5", local}}}, false)
+	assert.Error(t, err)
+
+	_, err = scanPacketMaxes(&fakeRows{rows: [][]any{{"id", "source", 1.5, local}}}, true)
+	assert.Error(t, err)
+}
+
+func TestTopPacketMaxByIDSuccess(t *testing.T) {
+	timestamp := time.Now().UTC()
+	runner := &fakeRunner{responses: []execResponse{{rows: [][]any{
+		{"packet", "s1", 9.0, timestamp, 1},
+		{"packet", "s2", 7.0, timestamp, 2},
+	}}}}
 	repo := newTestRepository(t, runner)
 	defer repo.Close()
 
-	result, err := repo.PacketMaxByID(context.Background(), constants.GenerateUUID())
+	id := constants.GenerateUUID()
+	results, err := repo.TopPacketMaxByID(context.Background(), id, 2)
 	assert.NoError(t, err)
-	assert.Equal(t, "packet", result.PacketID)
-	assert.Equal(t, 1.5, result.Value)
+	assert.Len(t, results, 2)
+	assert.Equal(t, 2, results[1].Rank)
+	assert.Equal(t, []any{id, 2}, runner.lastCall().args)
 }
 
-func TestPacketMaxByIDNotFound(t *testing.T) {
-	runner := &fakeRunner{responses: []execResponse{{tag: ""}}}
+func TestTopPacketMaxByIDWithoutLimit(t *testing.T) {
+	runner := &fakeRunner{}
 	repo := newTestRepository(t, runner)
 	defer repo.Close()
 
-	_, err := repo.PacketMaxByID(context.Background(), constants.GenerateUUID())
+	_, err := repo.TopPacketMaxByID(context.Background(), constants.GenerateUUID(), 0)
 	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.Nil(t, runner.lastCall().args[1])
 }
 
-func TestPacketMaxInRangeSuccess(t *testing.T) {
-	timestamp := time.Now().UTC().Format(time.RFC3339Nano)
-	response := fmt.Sprintf("packet,source,2.5,%s\n", timestamp)
-	runner := &fakeRunner{responses: []execResponse{{tag: response}}}
+func TestWritePacketMaxPassesRank(t *testing.T) {
+	runner := &fakeRunner{}
+	runner.setResponses(execResponse{affected: 1})
 	repo := newTestRepository(t, runner)
 	defer repo.Close()
 
-	results, err := repo.PacketMaxInRange(context.Background(), time.Now().Add(-time.Hour), time.Now())
-	assert.NoError(t, err)
-	assert.Len(t, results, 1)
+	packet := domain.PacketMax{PacketID: constants.GenerateUUID(), SourceID: constants.GenerateUUID(), Value: 1, Timestamp: time.Now(), Rank: 3}
+	assert.NoError(t, repo.writePacketMax(context.Background(), packet))
+	args := runner.lastCall().args
+	assert.Equal(t, 3, args[len(args)-1])
+
+	assert.Error(t, validatePacketMax(domain.PacketMax{PacketID: packet.PacketID, SourceID: packet.SourceID, Rank: -1}))
 }
 
-func TestPacketMaxInRangeNotFound(t *testing.T) {
-	runner := &fakeRunner{responses: []execResponse{{tag: ""}}}
+type recordingPublisher struct {
+	published []domain.PacketMax
+}
+
+func (p *recordingPublisher) Publish(packetMax domain.PacketMax) {
+	p.published = append(p.published, packetMax)
+}
+
+func TestProcessBatchPublishesStoredMaxima(t *testing.T) {
+	runner := &fakeRunner{}
+	runner.setResponses(
+		execResponse{err: sqlStateError("22003")},
+		execResponse{affected: 1},
+		execResponse{affected: 1},
+		execResponse{err: errors.New("insert failed")},
+	)
 	repo := newTestRepository(t, runner)
 	defer repo.Close()
+	publisher := &recordingPublisher{}
+	repo.publisher = publisher
 
-	_, err := repo.PacketMaxInRange(context.Background(), time.Now().Add(-time.Hour), time.Now())
-	assert.ErrorIs(t, err, domain.ErrNotFound)
+	now := time.Now()
+	maximum := domain.PacketMax{PacketID: constants.GenerateUUID(), SourceID: constants.GenerateUUID(), Value: 3, Timestamp: now}
+	second := domain.PacketMax{PacketID: maximum.PacketID, SourceID: constants.GenerateUUID(), Value: 2, Timestamp: now, Rank: 2}
+	failed := domain.PacketMax{PacketID: constants.GenerateUUID(), SourceID: constants.GenerateUUID(), Value: 1, Timestamp: now}
+
+	repo.processBatch(bufferedBatch(maximum, second, failed))
+
+	assert.Equal(t, []domain.PacketMax{maximum}, publisher.published)
 }
 
-func TestParsePacketMaxList(t *testing.T) {
-	timestamp := time.Now().UTC().Format(time.RFC3339Nano)
-	output := fmt.Sprintf("id,source,1.5,%s\n", timestamp)
-	results, err := parsePacketMaxList(output)
-	assert.NoError(t, err)
-	assert.Len(t, results, 1)
+// TestReadPathsBindUserInput fails as soon as a read path splices a caller-supplied value into
+// the SQL text instead of passing it as a bind parameter.
+func TestReadPathsBindUserInput(t *testing.T) {
+	runner := &fakeRunner{}
+	repo := newTestRepository(t, runner)
+	defer repo.Close()
+
+	ctx := context.Background()
+	packetID := constants.GenerateUUID()
+	from := time.Date(2031, 7, 14, 9, 26, 53, 589000000, time.UTC)
+	to := from.Add(97 * time.Minute)
+	const function = "p99_custom"
+
+	_, _ = repo.PacketMaxByID(ctx, packetID)
+	_, _ = repo.PacketMaxInRange(ctx, from, to, domain.PageRequest{Limit: 10})
+	cursor := domain.PageCursor{Timestamp: from, PacketID: packetID}.Encode()
+	_, _ = repo.PacketMaxInRange(ctx, from, to, domain.PageRequest{Limit: 10, Cursor: cursor})
+	if it, err := repo.IteratePacketMaxInRange(ctx, from, to); err == nil {
+		_ = it.Close()
+	}
+	_, _ = repo.TopPacketMaxByID(ctx, packetID, 7)
+	_, _ = repo.AggregateByID(ctx, function, packetID)
+	_, _ = repo.AggregatesInRange(ctx, function, from, to, domain.PageRequest{Limit: 10})
+	_, _ = repo.AggregatesInRange(ctx, function, from, to, domain.PageRequest{Limit: 10, Cursor: cursor})
+
+	inputs := []string{packetID, function, "2031", from.Format(time.RFC3339), to.Format("15:04:05")}
+	require.Equal(t, 8, runner.callCount())
+	for _, call := range runner.calls {
+		assert.Contains(t, readStatements, call.statement)
+		assert.NotEmpty(t, call.args, "query without bind parameters: %s", call.statement)
+		for _, input := range inputs {
+			assert.NotContains(t, call.statement, input)
+		}
+	}
 }
 
-func TestParseFloat(t *testing.T) {
-	value, err := parseFloat(" 1.23 ")
-	assert.NoError(t, err)
-	assert.InEpsilon(t, 1.23, value, 1e-9)
+func TestReadStatementsHaveNoLiterals(t *testing.T) {
+	for statement := range readStatements {
+		literals := strings.ReplaceAll(statement, "''", "")
+		assert.NotContains(t, literals, "'", "string literal in %s", statement)
+		assert.Contains(t, statement, "$1")
+	}
+}
+
+func TestQueryRefusesUnregisteredStatements(t *testing.T) {
+	runner := &fakeRunner{}
+	repo := newTestRepository(t, runner)
+	defer repo.Close()
 
-	_, err = parseFloat("invalid")
+	_, err := repo.query(context.Background(), "SELECT * FROM public.packet_max WHERE packet_id = '"+constants.GenerateUUID()+"'")
 	assert.Error(t, err)
+	assert.Zero(t, runner.callCount())
 }
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"aggregator-service/app/src/domain"
+)
+
+// DefaultPurgeChunkSize bounds how many rows a single retention delete removes.
+const DefaultPurgeChunkSize = 5000
+
+// purgeTarget is a table retention deletes from. Deletes go through the primary key of a bounded
+// subselect, so every statement is its own short transaction and locks at most one chunk of rows.
+type purgeTarget struct {
+	table     string
+	deleteSQL string
+	countSQL  string
+}
+
+var purgeTargets = []purgeTarget{
+	{
+		table: "packet_max",
+		deleteSQL: `
+DELETE FROM public.packet_max
+WHERE (packet_id, source_id, ts) IN (
+    SELECT packet_id, source_id, ts
+    FROM public.packet_max
+    WHERE ts < $1
+    LIMIT $2
+)`,
+		countSQL: `
+SELECT count(*)
+FROM public.packet_max
+WHERE ts < $1`,
+	},
+	{
+		table: "packet_aggregate",
+		deleteSQL: `
+DELETE FROM public.packet_aggregate
+WHERE (packet_id, function_name) IN (
+    SELECT packet_id, function_name
+    FROM public.packet_aggregate
+    WHERE ts < $1
+    LIMIT $2
+)`,
+		countSQL: `
+SELECT count(*)
+FROM public.packet_aggregate
+WHERE ts < $1`,
+	},
+}
+
+// Purger deletes measurements recorded before a cutoff in chunks of at most chunkSize rows. The
+// repository uses it for the background retention job; maintenance commands create one directly
+// so a one-shot purge does not start the write pipeline.
+type Purger struct {
+	runner    CommandRunner
+	dsn       string
+	password  string
+	chunkSize int
+}
+
+// NewPurger returns a Purger running its statements through runner. A non-positive chunkSize
+// selects DefaultPurgeChunkSize.
+func NewPurger(runner CommandRunner, dsn string, chunkSize int) *Purger {
+	return newPurger(runner, dsn, "", chunkSize)
+}
+
+func newPurger(runner CommandRunner, dsn, password string, chunkSize int) *Purger {
+	if chunkSize <= 0 {
+		chunkSize = DefaultPurgeChunkSize
+	}
+	return &Purger{runner: runner, dsn: dsn, password: password, chunkSize: chunkSize}
+}
+
+// PurgeBefore deletes packet maxima and aggregates with ts < cutoff. Each table is emptied chunk
+// by chunk until a delete removes fewer rows than the chunk size; a cancelled ctx stops between
+// chunks and reports what was deleted so far. A dry run counts the expired rows instead.
+func (p *Purger) PurgeBefore(ctx context.Context, cutoff time.Time, dryRun bool) (domain.PurgeReport, error) {
+	if p == nil || p.runner == nil {
+		return domain.PurgeReport{}, errors.New("retention purger is not configured")
+	}
+
+	report := domain.PurgeReport{Cutoff: cutoff.UTC(), DryRun: dryRun}
+	for _, target := range purgeTargets {
+		var err error
+		if dryRun {
+			err = p.count(ctx, target, &report)
+		} else {
+			err = p.purge(ctx, target, &report)
+		}
+		if err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+func (p *Purger) purge(ctx context.Context, target purgeTarget, report *domain.PurgeReport) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		result, err := p.runner.Exec(ctx, p.dsn, p.password, target.deleteSQL, report.Cutoff, p.chunkSize)
+		if err != nil {
+			return fmt.Errorf("purge %s: %w", target.table, err)
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("purge %s: %w", target.table, err)
+		}
+
+		report.Chunks++
+		report.Rows += deleted
+		if deleted < int64(p.chunkSize) {
+			return nil
+		}
+	}
+}
+
+func (p *Purger) count(ctx context.Context, target purgeTarget, report *domain.PurgeReport) error {
+	rows, err := p.runner.Query(ctx, p.dsn, p.password, target.countSQL, report.Cutoff)
+	if err != nil {
+		return fmt.Errorf("count expired %s: %w", target.table, err)
+	}
+	defer rows.Close()
+
+	var expired int64
+	if rows.Next() {
+		if err := rows.Scan(&expired); err != nil {
+			return fmt.Errorf("count expired %s: %w", target.table, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("count expired %s: %w", target.table, err)
+	}
+
+	report.Rows += expired
+	return nil
+}
+
+// PurgeBefore implements domain.RetentionPurger over the repository connection.
+func (r *Repository) PurgeBefore(ctx context.Context, cutoff time.Time, dryRun bool) (domain.PurgeReport, error) {
+	return r.purger.PurgeBefore(ctx, cutoff, dryRun)
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how often a failed packet max write is retried before it is given up on.
+// Only errors classified as transient are retried; the delay doubles from BaseDelay up to MaxDelay
+// and Jitter randomly shortens it by up to that fraction so that writers do not retry in lockstep.
+// The zero value makes a single attempt.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one; values below 1 mean 1.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Jitter is a fraction between 0 and 1.
+	Jitter float64
+}
+
+func (p RetryPolicy) normalized() RetryPolicy {
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = 1
+	}
+	if p.BaseDelay < 0 {
+		p.BaseDelay = 0
+	}
+	if p.MaxDelay < p.BaseDelay {
+		p.MaxDelay = p.BaseDelay
+	}
+	if p.Jitter < 0 {
+		p.Jitter = 0
+	}
+	if p.Jitter > 1 {
+		p.Jitter = 1
+	}
+	return p
+}
+
+// backoff computes the delays between attempts of a retry policy.
+type backoff struct {
+	policy RetryPolicy
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func newBackoff(policy RetryPolicy) *backoff {
+	return &backoff{policy: policy, rnd: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// delay returns the wait before the given retry, counting retries from 1.
+func (b *backoff) delay(retry int) time.Duration {
+	delay := b.policy.BaseDelay
+	for i := 1; i < retry && delay < b.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > b.policy.MaxDelay {
+		delay = b.policy.MaxDelay
+	}
+	if b.policy.Jitter > 0 && delay > 0 {
+		b.mu.Lock()
+		cut := b.rnd.Float64() * b.policy.Jitter
+		b.mu.Unlock()
+		delay -= time.Duration(float64(delay) * cut)
+	}
+	return delay
+}
+
+// Transient SQLSTATE codes and classes, see https://www.postgresql.org/docs/current/errcodes-appendix.html.
+var (
+	retryableSQLStateClasses = []string{
+		"08", // connection exception
+		"53", // insufficient resources
+	}
+	retryableSQLStates = map[string]struct{}{
+		"40001": {}, // serialization_failure
+		"40P01": {}, // deadlock_detected
+		"55P03": {}, // lock_not_available
+		"57014": {}, // query_canceled (statement timeout)
+		"57P01": {}, // admin_shutdown
+		"57P02": {}, // crash_shutdown
+		"57P03": {}, // cannot_connect_now
+	}
+)
+
+// isRetryable reports whether err is a transient failure worth another attempt. Errors carrying a
+// SQLSTATE are classified by it; otherwise connection-level failures are retried and everything
+// else, including context cancellation, is treated as permanent.
+func isRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	type sqlState interface {
+		SQLState() string
+	}
+	var state sqlState
+	if errors.As(err, &state) {
+		code := state.SQLState()
+		if _, ok := retryableSQLStates[code]; ok {
+			return true
+		}
+		for _, class := range retryableSQLStateClasses {
+			if strings.HasPrefix(code, class) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, fragment := range []string{"connection reset", "connection refused", "broken pipe", "bad connection"} {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"aggregator-service/app/src/domain"
+	metrics "aggregator-service/app/src/infra"
+)
+
+const (
+	// upsertColumns is the number of bind parameters per row of upsertPacketMaxSQL.
+	upsertColumns = 5
+	// maxUpsertRows keeps a single statement well below the 65535 bind parameters Postgres accepts.
+	maxUpsertRows = 1000
+
+	// The partition key ts has to be part of every unique index of the partitioned packet_max, so
+	// ON CONFLICT alone would keep a second row when a packet and source come back with a new ts.
+	// The upsert therefore deletes the stored row of each (packet_id, source_id), wherever its
+	// partition is, and inserts the merged row at the new ts: the greater value, the incoming ts
+	// and the better rank. The conflict clause only covers a concurrent writer of the same ts.
+	upsertPacketMaxPrefix = `
+WITH incoming (packet_id, source_id, value, ts, rank) AS (
+    VALUES `
+	upsertPacketMaxSuffix = `
+),
+previous AS (
+    DELETE FROM public.packet_max AS p
+    USING incoming AS i
+    WHERE p.packet_id = i.packet_id AND p.source_id = i.source_id
+    RETURNING p.packet_id, p.source_id, p.value, p.rank
+)
+INSERT INTO public.packet_max (packet_id, source_id, value, ts, rank)
+SELECT i.packet_id, i.source_id, GREATEST(i.value, prev.value), i.ts, LEAST(i.rank, prev.rank)
+FROM incoming AS i
+LEFT JOIN (
+    SELECT packet_id, source_id, max(value) AS value, min(rank) AS rank
+    FROM previous
+    GROUP BY packet_id, source_id
+) AS prev ON prev.packet_id = i.packet_id AND prev.source_id = i.source_id
+ON CONFLICT (packet_id, source_id, ts) DO UPDATE
+SET value = GREATEST(packet_max.value, EXCLUDED.value),
+    rank  = LEAST(packet_max.rank, EXCLUDED.rank)
+`
+)
+
+// upsertPacketMaxSQL returns the multi-row upsert for the given number of rows.
+func upsertPacketMaxSQL(rows int) string {
+	var b strings.Builder
+	b.WriteString(upsertPacketMaxPrefix)
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		n := i * upsertColumns
+		fmt.Fprintf(&b, "($%d::uuid, $%d::uuid, $%d::double precision, $%d::timestamptz, $%d::smallint)", n+1, n+2, n+3, n+4, n+5)
+	}
+	b.WriteString(upsertPacketMaxSuffix)
+	return b.String()
+}
+
+// mergeUpsertRows folds rows for the same (packet_id, source_id) into one, because a single
+// upsert cannot write a row twice. The result is what applying the rows one by one would leave
+// behind: the greater value, the last timestamp and the best rank.
+func mergeUpsertRows(packetMaxes []domain.PacketMax) []domain.PacketMax {
+	type key struct{ packetID, sourceID string }
+
+	index := make(map[key]int, len(packetMaxes))
+	merged := make([]domain.PacketMax, 0, len(packetMaxes))
+	for _, packetMax := range packetMaxes {
+		packetMax.Rank = packetRank(packetMax)
+		k := key{packetMax.PacketID, packetMax.SourceID}
+		i, ok := index[k]
+		if !ok {
+			index[k] = len(merged)
+			merged = append(merged, packetMax)
+			continue
+		}
+		row := &merged[i]
+		if packetMax.Value > row.Value {
+			row.Value = packetMax.Value
+		}
+		row.Timestamp = packetMax.Timestamp
+		if packetMax.Rank < row.Rank {
+			row.Rank = packetMax.Rank
+		}
+	}
+	return merged
+}
+
+// upsertPacketMaxes stores the rows with a single statement; the caller keeps batches within
+// maxUpsertRows.
+func (r *Repository) upsertPacketMaxes(ctx context.Context, packetMaxes []domain.PacketMax) error {
+	rows := mergeUpsertRows(packetMaxes)
+	if len(rows) == 0 {
+		return nil
+	}
+
+	args := make([]any, 0, len(rows)*upsertColumns)
+	for _, row := range rows {
+		args = append(args, row.PacketID, row.SourceID, row.Value, row.Timestamp.UTC(), row.Rank)
+	}
+
+	if _, err := r.runner.Exec(ctx, r.dsn, r.password, upsertPacketMaxSQL(len(rows)), args...); err != nil {
+		return fmt.Errorf("postgres repository: upsert %d packet maxima: %w", len(rows), err)
+	}
+	return nil
+}
+
+// writeChunk stores up to maxUpsertRows packet maxima in one round-trip. When the statement
+// fails permanently the rows are written one at a time, so a single bad row only sends itself to
+// the dead-letter queue; when the database stays unavailable the whole chunk is dead-lettered
+// with that error instead of retrying every row on its own.
+func (r *Repository) writeChunk(ctx context.Context, packetMaxes []domain.PacketMax) {
+	if len(packetMaxes) > 1 {
+		label := fmt.Sprintf("batch rows=%d", len(packetMaxes))
+		err := r.retryWrite(ctx, label, func(ctx context.Context) error {
+			return r.upsertPacketMaxes(ctx, packetMaxes)
+		})
+		if err == nil {
+			for _, packetMax := range packetMaxes {
+				r.publish(packetMax)
+			}
+			return
+		}
+
+		if isRetryable(err) {
+			if r.logger != nil {
+				r.logger.Printf(ctx, "postgres repository: batch upsert failed rows=%d: %v", len(packetMaxes), err)
+			}
+			for _, packetMax := range packetMaxes {
+				r.deadLetter(ctx, packetMax, err)
+			}
+			return
+		}
+
+		metrics.IncDBBatchFallbacks()
+		if r.logger != nil {
+			r.logger.Printf(ctx, "postgres repository: batch upsert failed rows=%d, writing rows one by one: %v", len(packetMaxes), err)
+		}
+	}
+
+	for _, packetMax := range packetMaxes {
+		if err := r.writeWithRetry(ctx, packetMax); err != nil {
+			if r.logger != nil {
+				r.logger.Printf(ctx, "postgres repository: batch write failed packet=%s source=%s: %v", packetMax.PacketID, packetMax.SourceID, err)
+			}
+			r.deadLetter(ctx, packetMax, err)
+			continue
+		}
+		r.publish(packetMax)
+	}
+}
+
+// writePacketMax stores a single packet maximum.
+func (r *Repository) writePacketMax(ctx context.Context, packetMax domain.PacketMax) error {
+	if err := r.upsertPacketMaxes(ctx, []domain.PacketMax{packetMax}); err != nil {
+		if r.logger != nil {
+			r.logger.Printf(ctx, "postgres repository: upsert failed packet=%s source=%s value=%v ts=%s: %v", packetMax.PacketID, packetMax.SourceID, packetMax.Value, packetMax.Timestamp.UTC().Format(time.RFC3339Nano), err)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,490 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"aggregator-service/app/src/domain"
+	metrics "aggregator-service/app/src/infra"
+)
+
+// WALSyncPolicy decides when appended WAL records are forced to stable storage.
+type WALSyncPolicy string
+
+const (
+	// WALSyncAlways fsyncs every record before Add returns.
+	WALSyncAlways WALSyncPolicy = "always"
+	// WALSyncInterval fsyncs in the background every WALConfig.SyncInterval; a crash loses at most
+	// one interval of acknowledged records.
+	WALSyncInterval WALSyncPolicy = "interval"
+	// WALSyncNever leaves flushing to the operating system.
+	WALSyncNever WALSyncPolicy = "never"
+)
+
+const (
+	defaultWALSegmentSize  = 16 << 20
+	defaultWALSyncInterval = 200 * time.Millisecond
+
+	walSegmentPrefix = "wal-"
+	walSegmentSuffix = ".log"
+
+	walRecordAppend     byte = 'A'
+	walRecordCheckpoint byte = 'C'
+
+	// walHeaderSize covers the payload length, the CRC-32C checksum, the record type and the
+	// sequence number.
+	walHeaderSize  = 4 + 4 + 1 + 8
+	walMaxPayload  = 1 << 20
+	walSegmentMode = 0o600
+)
+
+var walTable = crc32.MakeTable(crc32.Castagnoli)
+
+// WALConfig enables the write-ahead log of the repository buffer. An empty Dir disables it.
+type WALConfig struct {
+	Dir string
+	// SegmentSize is the size after which a new segment file is started; zero means 16 MiB.
+	SegmentSize int64
+	// Sync is the fsync policy; empty means WALSyncInterval.
+	Sync WALSyncPolicy
+	// SyncInterval is the period of WALSyncInterval; zero means 200ms.
+	SyncInterval time.Duration
+}
+
+// ParseWALSyncPolicy accepts the policy names case-insensitively; an empty name means WALSyncInterval.
+func ParseWALSyncPolicy(name string) (WALSyncPolicy, error) {
+	policy := WALSyncPolicy(strings.ToLower(strings.TrimSpace(name)))
+	switch policy {
+	case "":
+		return WALSyncInterval, nil
+	case WALSyncAlways, WALSyncInterval, WALSyncNever:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown WAL fsync policy %q", name)
+	}
+}
+
+// walEntry is an unflushed packet maximum recovered from the log together with its sequence number.
+type walEntry struct {
+	seq       uint64
+	packetMax domain.PacketMax
+}
+
+type walSegment struct {
+	path    string
+	lastSeq uint64
+}
+
+// wal is a segmented write-ahead log of packet maxima accepted by Repository.Add. Every record
+// carries a sequence number and a CRC-32C checksum. Once the writer has handled a record (stored,
+// dead-lettered, dropped or spilled) it is acknowledged; the highest sequence below which
+// everything is acknowledged is written as a checkpoint record, and segments that end at or
+// before the checkpoint are deleted. Replay returns the records after the last checkpoint, so a
+// record acknowledged out of order may be written twice, which the upsert tolerates.
+type wal struct {
+	cfg    WALConfig
+	logger *metrics.Logger
+
+	mu         sync.Mutex
+	file       *os.File
+	writer     *bufio.Writer
+	size       int64
+	segments   []walSegment
+	nextSeq    uint64
+	pending    map[uint64]struct{}
+	checkpoint uint64
+	dirty      bool
+	closed     bool
+
+	stopSync chan struct{}
+	syncDone chan struct{}
+}
+
+// openWAL reads the existing segments, returns the unflushed entries in sequence order and starts
+// a fresh segment for new records.
+func openWAL(cfg WALConfig, logger *metrics.Logger) (*wal, []walEntry, error) {
+	if cfg.Dir == "" {
+		return nil, nil, errors.New("wal: directory is required")
+	}
+	if cfg.SegmentSize <= 0 {
+		cfg.SegmentSize = defaultWALSegmentSize
+	}
+	sync, err := ParseWALSyncPolicy(string(cfg.Sync))
+	if err != nil {
+		return nil, nil, fmt.Errorf("wal: %w", err)
+	}
+	cfg.Sync = sync
+	if cfg.SyncInterval <= 0 {
+		cfg.SyncInterval = defaultWALSyncInterval
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, nil, fmt.Errorf("wal: create directory: %w", err)
+	}
+
+	w := &wal{cfg: cfg, logger: logger, pending: make(map[uint64]struct{})}
+	entries, err := w.recover()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, entry := range entries {
+		w.pending[entry.seq] = struct{}{}
+	}
+	if err := w.rotate(); err != nil {
+		return nil, nil, err
+	}
+	if err := w.removeCovered(); err != nil {
+		return nil, nil, err
+	}
+	metrics.SetWALSegments(len(w.segments))
+
+	if cfg.Sync == WALSyncInterval {
+		w.stopSync = make(chan struct{})
+		w.syncDone = make(chan struct{})
+		go w.syncLoop()
+	}
+	return w, entries, nil
+}
+
+// recover scans every segment. Reading a segment stops at the first torn or corrupt record; the
+// records after it in that segment are lost, later segments are still read. The next sequence
+// number starts past every segment name on disk: the checkpoint record may have gone with a
+// deleted segment, and an empty segment must not be created a second time.
+func (w *wal) recover() ([]walEntry, error) {
+	paths, err := filepath.Glob(filepath.Join(w.cfg.Dir, walSegmentPrefix+"*"+walSegmentSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("wal: list segments: %w", err)
+	}
+	sort.Strings(paths)
+
+	appended := make(map[uint64]domain.PacketMax)
+	for _, path := range paths {
+		segment, err := w.readSegment(path, appended)
+		if err != nil {
+			return nil, err
+		}
+		w.segments = append(w.segments, segment)
+		if first, ok := walSegmentSeq(path); ok && first >= w.nextSeq {
+			w.nextSeq = first + 1
+		}
+	}
+
+	entries := make([]walEntry, 0, len(appended))
+	for seq, packetMax := range appended {
+		if seq > w.checkpoint {
+			entries = append(entries, walEntry{seq: seq, packetMax: packetMax})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+	if w.nextSeq <= w.checkpoint {
+		w.nextSeq = w.checkpoint + 1
+	}
+	return entries, nil
+}
+
+// walSegmentSeq returns the sequence number a segment file is named after.
+func walSegmentSeq(path string) (uint64, bool) {
+	name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), walSegmentPrefix), walSegmentSuffix)
+	seq, err := strconv.ParseUint(name, 10, 64)
+	return seq, err == nil
+}
+
+func (w *wal) readSegment(path string, appended map[uint64]domain.PacketMax) (walSegment, error) {
+	segment := walSegment{path: path}
+	file, err := os.Open(path)
+	if err != nil {
+		return segment, fmt.Errorf("wal: open segment: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		kind, seq, payload, size, err := readWALRecord(reader)
+		if errors.Is(err, io.EOF) {
+			return segment, nil
+		}
+		if err != nil {
+			metrics.IncWALCorruptRecords()
+			if w.logger != nil {
+				w.logger.Printf(context.Background(), "wal: segment %s is damaged at offset %d, the rest of it is skipped: %v", filepath.Base(path), offset, err)
+			}
+			return segment, nil
+		}
+		offset += size
+
+		if seq > segment.lastSeq {
+			segment.lastSeq = seq
+		}
+		if seq >= w.nextSeq {
+			w.nextSeq = seq + 1
+		}
+		switch kind {
+		case walRecordAppend:
+			var record spillRecord
+			if err := json.Unmarshal(payload, &record); err != nil {
+				metrics.IncWALCorruptRecords()
+				continue
+			}
+			appended[seq] = record.packetMax()
+		case walRecordCheckpoint:
+			if seq > w.checkpoint {
+				w.checkpoint = seq
+			}
+		}
+	}
+}
+
+func readWALRecord(reader *bufio.Reader) (byte, uint64, []byte, int64, error) {
+	header := make([]byte, walHeaderSize)
+	n, err := io.ReadFull(reader, header)
+	if err != nil {
+		if errors.Is(err, io.EOF) && n == 0 {
+			return 0, 0, nil, 0, io.EOF
+		}
+		return 0, 0, nil, 0, fmt.Errorf("torn record header: %w", err)
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	checksum := binary.BigEndian.Uint32(header[4:8])
+	kind := header[8]
+	seq := binary.BigEndian.Uint64(header[9:17])
+	if length > walMaxPayload {
+		return 0, 0, nil, 0, fmt.Errorf("record length %d exceeds limit", length)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return 0, 0, nil, 0, fmt.Errorf("torn record payload: %w", err)
+	}
+	crc := crc32.Update(crc32.Checksum(header[8:], walTable), walTable, payload)
+	if crc != checksum {
+		return 0, 0, nil, 0, fmt.Errorf("checksum mismatch for record %d", seq)
+	}
+	if kind != walRecordAppend && kind != walRecordCheckpoint {
+		return 0, 0, nil, 0, fmt.Errorf("unknown record type %q", kind)
+	}
+	return kind, seq, payload, int64(walHeaderSize) + int64(length), nil
+}
+
+func encodeWALRecord(kind byte, seq uint64, payload []byte) []byte {
+	record := make([]byte, walHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	record[8] = kind
+	binary.BigEndian.PutUint64(record[9:17], seq)
+	copy(record[walHeaderSize:], payload)
+	binary.BigEndian.PutUint32(record[4:8], crc32.Checksum(record[8:], walTable))
+	return record
+}
+
+// append logs packetMax and returns its sequence number. With WALSyncAlways the record is on
+// stable storage when append returns.
+func (w *wal) append(packetMax domain.PacketMax) (uint64, error) {
+	payload, err := json.Marshal(newSpillRecord(packetMax))
+	if err != nil {
+		return 0, fmt.Errorf("wal: encode: %w", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, errors.New("wal: closed")
+	}
+	seq := w.nextSeq
+	if err := w.write(walRecordAppend, seq, payload); err != nil {
+		return 0, err
+	}
+	w.nextSeq++
+	w.pending[seq] = struct{}{}
+	metrics.IncWALAppends()
+	return seq, nil
+}
+
+// ack marks records as handled. When that moves the checkpoint forward, a checkpoint record is
+// written and segments it covers are deleted. Sequence number 0 is ignored, it marks entries
+// that never went through the log.
+func (w *wal) ack(seqs ...uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, seq := range seqs {
+		delete(w.pending, seq)
+	}
+	if w.closed {
+		return nil
+	}
+
+	checkpoint := w.nextSeq - 1
+	for seq := range w.pending {
+		if seq-1 < checkpoint {
+			checkpoint = seq - 1
+		}
+	}
+	if checkpoint <= w.checkpoint {
+		return nil
+	}
+
+	if err := w.write(walRecordCheckpoint, checkpoint, nil); err != nil {
+		return err
+	}
+	w.checkpoint = checkpoint
+	return w.removeCovered()
+}
+
+// write appends one record to the active segment, rotating first when the segment is full.
+// The caller holds w.mu.
+func (w *wal) write(kind byte, seq uint64, payload []byte) error {
+	if w.size >= w.cfg.SegmentSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	record := encodeWALRecord(kind, seq, payload)
+	if _, err := w.writer.Write(record); err != nil {
+		return fmt.Errorf("wal: append: %w", err)
+	}
+	w.size += int64(len(record))
+	active := &w.segments[len(w.segments)-1]
+	if seq > active.lastSeq {
+		active.lastSeq = seq
+	}
+
+	switch w.cfg.Sync {
+	case WALSyncAlways:
+		return w.syncLocked()
+	case WALSyncInterval:
+		w.dirty = true
+		return nil
+	default:
+		if err := w.writer.Flush(); err != nil {
+			return fmt.Errorf("wal: flush: %w", err)
+		}
+		return nil
+	}
+}
+
+// rotate seals the active segment and starts a new one named after the next sequence number, or
+// after the active one when no record has taken that number yet. The file must not exist yet: reusing a name would truncate a segment that w.segments already
+// tracks and later delete it from under the writer. The caller holds w.mu.
+func (w *wal) rotate() error {
+	if w.file != nil {
+		if err := w.syncLocked(); err != nil {
+			return err
+		}
+		if err := w.file.Close(); err != nil {
+			return fmt.Errorf("wal: close segment: %w", err)
+		}
+	}
+
+	name := w.nextSeq
+	if n := len(w.segments); n > 0 {
+		// Only checkpoint records may have gone into the active segment; it already took nextSeq.
+		if last, ok := walSegmentSeq(w.segments[n-1].path); ok && last >= name {
+			name = last + 1
+		}
+	}
+	path := filepath.Join(w.cfg.Dir, fmt.Sprintf("%s%020d%s", walSegmentPrefix, name, walSegmentSuffix))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, walSegmentMode)
+	if err != nil {
+		return fmt.Errorf("wal: create segment: %w", err)
+	}
+	w.file = file
+	w.writer = bufio.NewWriter(file)
+	w.size = 0
+	kept := w.segments[:0]
+	for _, segment := range w.segments {
+		if segment.path != path {
+			kept = append(kept, segment)
+		}
+	}
+	w.segments = append(kept, walSegment{path: path})
+	metrics.SetWALSegments(len(w.segments))
+	return nil
+}
+
+// removeCovered deletes sealed segments whose records all precede the checkpoint. The active
+// segment is kept, it holds the checkpoint record itself. The caller holds w.mu.
+func (w *wal) removeCovered() error {
+	kept := w.segments[:0]
+	last := len(w.segments) - 1
+	for i, segment := range w.segments {
+		if i < last && segment.lastSeq <= w.checkpoint {
+			if err := os.Remove(segment.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("wal: remove segment: %w", err)
+			}
+			continue
+		}
+		kept = append(kept, segment)
+	}
+	w.segments = kept
+	metrics.SetWALSegments(len(w.segments))
+	return nil
+}
+
+func (w *wal) syncLocked() error {
+	if w.writer == nil {
+		return nil
+	}
+	if err := w.writer.Flush(); err != nil {
+		return fmt.Errorf("wal: flush: %w", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("wal: sync: %w", err)
+	}
+	w.dirty = false
+	return nil
+}
+
+func (w *wal) syncLoop() {
+	defer close(w.syncDone)
+	ticker := time.NewTicker(w.cfg.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stopSync:
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			if w.dirty && !w.closed {
+				if err := w.syncLocked(); err != nil && w.logger != nil {
+					w.logger.Printf(context.Background(), "wal: background sync failed: %v", err)
+				}
+			}
+			w.mu.Unlock()
+		}
+	}
+}
+
+func (w *wal) close() error {
+	if w.stopSync != nil {
+		close(w.stopSync)
+		<-w.syncDone
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	err := w.syncLocked()
+	if closeErr := w.file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("wal: close segment: %w", closeErr)
+	}
+	return err
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// DeadLetter is a packet maximum that could not be persisted, kept for inspection and re-drive.
+type DeadLetter struct {
+	ID        string
+	PacketMax PacketMax
+	// Reason is the error of the most recent failed write.
+	Reason string
+	// Attempts counts failed writes, including the original one.
+	Attempts      int
+	FirstFailedAt time.Time
+	LastFailedAt  time.Time
+}
+
+// RedriveReport summarises one re-drive run over the dead-letter queue.
+type RedriveReport struct {
+	Attempted int
+	Succeeded int
+	Failed    int
+}
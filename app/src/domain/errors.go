@@ -2,4 +2,13 @@ package domain
 
 import "errors"
 
-var ErrNotFound = errors.New("measurement not found")
+var (
+	ErrNotFound                = errors.New("measurement not found")
+	ErrUnknownAggregation      = errors.New("unknown aggregation function")
+	ErrBackpressure            = errors.New("packet queue is full")
+	ErrIngestClosed            = errors.New("packet ingestion is closed")
+	ErrSlowConsumer            = errors.New("subscriber evicted: too slow to consume updates")
+	ErrInvalidGeneratorSetting = errors.New("invalid generator setting")
+	ErrDeadLettersDisabled     = errors.New("dead-letter queue is disabled")
+	ErrInvalidCursor           = errors.New("invalid page cursor")
+)
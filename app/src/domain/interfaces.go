@@ -11,7 +11,23 @@ type PacketMaxWriter interface {
 
 type PacketMaxReader interface {
 	PacketMaxByID(ctx context.Context, packetID string) (PacketMax, error)
-	PacketMaxInRange(ctx context.Context, from, to time.Time) ([]PacketMax, error)
+	// PacketMaxInRange returns one page of the maxima recorded within [from, to], ordered by
+	// timestamp and packet id. page.Limit must be positive.
+	PacketMaxInRange(ctx context.Context, from, to time.Time, page PageRequest) (PacketMaxPage, error)
+	// IteratePacketMaxInRange walks every maximum recorded within [from, to] in the same order
+	// without loading the range into memory. Cancelling ctx ends the iteration.
+	IteratePacketMaxInRange(ctx context.Context, from, to time.Time) (PacketMaxIterator, error)
+	TopPacketMaxByID(ctx context.Context, packetID string, k int) ([]PacketMax, error)
+}
+
+// PacketMaxIterator reads query results one row at a time. Next returns false once the rows are
+// exhausted or reading failed, which Err then reports. Close releases the underlying cursor and
+// must be called even after Next returned false.
+type PacketMaxIterator interface {
+	Next() bool
+	PacketMax() PacketMax
+	Err() error
+	Close() error
 }
 
 type PacketMaxRepository interface {
@@ -19,22 +35,124 @@ type PacketMaxRepository interface {
 	PacketMaxReader
 }
 
+type PacketAggregateWriter interface {
+	AddAggregate(ctx context.Context, aggregate PacketAggregate) error
+}
+
+type PacketAggregateReader interface {
+	AggregateByID(ctx context.Context, function, packetID string) (PacketAggregate, error)
+	// AggregatesInRange returns one page of the results of function recorded within [from, to],
+	// ordered by timestamp and packet id. page.Limit must be positive.
+	AggregatesInRange(ctx context.Context, function string, from, to time.Time, page PageRequest) (PacketAggregatePage, error)
+}
+
+type PacketAggregateRepository interface {
+	PacketAggregateWriter
+	PacketAggregateReader
+}
+
+// Repository groups every storage contract implemented by a single backend.
+type Repository interface {
+	PacketMaxRepository
+	PacketAggregateRepository
+}
+
+// DeadLetterStore keeps packet maxima whose write failed. Put replaces an entry with the same ID;
+// List returns the oldest entries first, all of them when limit is not positive.
+type DeadLetterStore interface {
+	Put(ctx context.Context, entry DeadLetter) error
+	List(ctx context.Context, limit int) ([]DeadLetter, error)
+	Delete(ctx context.Context, id string) error
+	Depth(ctx context.Context) (int, error)
+}
+
+// DeadLetterQueue lets operators inspect failed writes and retry them.
+type DeadLetterQueue interface {
+	DeadLetters(ctx context.Context, limit int) ([]DeadLetter, error)
+	DeadLetterDepth(ctx context.Context) (int, error)
+	Redrive(ctx context.Context, limit int) (RedriveReport, error)
+}
+
+// RetentionPurger removes stored maxima and aggregates recorded before a cutoff. A dry run only
+// counts the rows that would be removed.
+type RetentionPurger interface {
+	PurgeBefore(ctx context.Context, cutoff time.Time, dryRun bool) (PurgeReport, error)
+}
+
+// PacketMaxPublisher is notified about every packet maximum once it has been persisted.
+type PacketMaxPublisher interface {
+	Publish(packetMax PacketMax)
+}
+
+// MaxSubscription delivers published maxima until it is closed or evicted. Updates is closed in
+// both cases; Err then reports ErrSlowConsumer for evicted subscribers.
+type MaxSubscription interface {
+	Updates() <-chan PacketMax
+	Err() error
+	Close()
+}
+
+type PacketMaxSubscriber interface {
+	Subscribe(filter MaxFilter) MaxSubscription
+}
+
 type AggregatorResult struct {
 	PacketID  string
+	Function  string
 	SourceID  string
 	Value     float64
 	Timestamp time.Time
+	Rank      int
 }
 
 type AggregatorService interface {
 	MaxByPacketID(ctx context.Context, packetID string) (AggregatorResult, error)
-	MaxInRange(ctx context.Context, from, to time.Time) ([]AggregatorResult, error)
+	MaxInRange(ctx context.Context, from, to time.Time, page PageRequest) (ResultPage, error)
+	// StreamMaxInRange passes every maximum within the range to emit in timestamp order and stops
+	// at the first error emit returns.
+	StreamMaxInRange(ctx context.Context, from, to time.Time, emit func(AggregatorResult) error) error
+	TopByPacketID(ctx context.Context, packetID string, k int) ([]AggregatorResult, error)
+	AggregateByPacketID(ctx context.Context, function, packetID string) (AggregatorResult, error)
+	AggregateInRange(ctx context.Context, function string, from, to time.Time, page PageRequest) (ResultPage, error)
 }
 
 type PacketGenerator interface {
 	Run(ctx context.Context, out chan<- DataPacket)
 }
 
+// GeneratorState is the current schedule of a controllable generator and its recent changes,
+// oldest first.
+type GeneratorState struct {
+	Paused        bool
+	Interval      time.Duration
+	MinPacketSize int
+	MaxPacketSize int
+	Changes       []GeneratorChange
+}
+
+type GeneratorChange struct {
+	At     time.Time
+	Action string
+	Detail string
+}
+
+// GeneratorController changes a running generator without restarting it. Setters reject invalid
+// values with an error wrapping ErrInvalidGeneratorSetting.
+type GeneratorController interface {
+	State() GeneratorState
+	Pause()
+	Resume()
+	SetInterval(interval time.Duration) error
+	SetPacketSize(min, max int) error
+}
+
+// PacketIngestor accepts packets from external producers. Ingest never blocks and returns the
+// number of packets queued before the first rejection; Enqueue waits for free space until ctx ends.
+type PacketIngestor interface {
+	Ingest(ctx context.Context, packets []DataPacket) (int, error)
+	Enqueue(ctx context.Context, packet DataPacket) error
+}
+
 type WorkerPool interface {
 	Run(ctx context.Context, packets <-chan DataPacket)
 }
@@ -0,0 +1,11 @@
+package domain
+
+import "time"
+
+type PacketAggregate struct {
+	PacketID  string
+	Function  string
+	SourceID  string
+	Value     float64
+	Timestamp time.Time
+}
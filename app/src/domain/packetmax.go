@@ -7,4 +7,27 @@ type PacketMax struct {
 	SourceID  string
 	Value     float64
 	Timestamp time.Time
+	// Rank is the 1-based position of the measurement among the packet's top values.
+	// Zero is treated as 1, the packet maximum.
+	Rank int
+}
+
+// MaxFilter narrows the maxima delivered to a subscriber.
+type MaxFilter struct {
+	// MinValue, when HasMinValue is set, drops maxima with a smaller value.
+	MinValue    float64
+	HasMinValue bool
+	// Since drops maxima measured before it when non-zero.
+	Since time.Time
+}
+
+// Match reports whether the packet maximum passes the filter.
+func (f MaxFilter) Match(packetMax PacketMax) bool {
+	if f.HasMinValue && packetMax.Value < f.MinValue {
+		return false
+	}
+	if !f.Since.IsZero() && packetMax.Timestamp.Before(f.Since) {
+		return false
+	}
+	return true
 }
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PageRequest selects one page of a range query ordered by (timestamp, packet id). An empty
+// Cursor starts at the beginning of the range; a non-positive Limit asks for the largest page the
+// server allows.
+type PageRequest struct {
+	Limit  int
+	Cursor string
+}
+
+// PacketMaxPage is one page of packet maxima. NextCursor is empty on the last page.
+type PacketMaxPage struct {
+	PacketMaxes []PacketMax
+	NextCursor  string
+}
+
+// PacketAggregatePage is one page of stored aggregates. NextCursor is empty on the last page.
+type PacketAggregatePage struct {
+	Aggregates []PacketAggregate
+	NextCursor string
+}
+
+// ResultPage is one page of aggregator results. NextCursor is empty on the last page.
+type ResultPage struct {
+	Results    []AggregatorResult
+	NextCursor string
+}
+
+// PageCursor is the position after the last row of a page. Clients only ever see its encoded form.
+type PageCursor struct {
+	Timestamp time.Time
+	PacketID  string
+}
+
+const cursorSeparator = "|"
+
+// Encode returns the opaque token handed to clients as next_cursor.
+func (c PageCursor) Encode() string {
+	raw := c.Timestamp.UTC().Format(time.RFC3339Nano) + cursorSeparator + c.PacketID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodePageCursor parses a token produced by PageCursor.Encode. Malformed tokens are reported
+// with an error wrapping ErrInvalidCursor.
+func DecodePageCursor(token string) (PageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return PageCursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	ts, packetID, ok := strings.Cut(string(raw), cursorSeparator)
+	if !ok || packetID == "" {
+		return PageCursor{}, fmt.Errorf("%w: missing packet id", ErrInvalidCursor)
+	}
+
+	timestamp, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return PageCursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	return PageCursor{Timestamp: timestamp.UTC(), PacketID: packetID}, nil
+}
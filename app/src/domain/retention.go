@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// PurgeReport summarises one retention run over the stored measurements.
+type PurgeReport struct {
+	Cutoff time.Time
+	// Rows counts the deleted rows; for a dry run it counts the rows that would be deleted.
+	Rows int64
+	// Chunks counts the delete statements issued; dry runs issue none.
+	Chunks int
+	DryRun bool
+}
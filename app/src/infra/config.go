@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"strconv"
+	"strings"
 
 	"aggregator-service/app/src/infra/utils"
 )
@@ -12,6 +13,8 @@ type Config struct {
 	HTTPPort                string
 	GRPCPort                string
 	MetricsPort             string
+	Storage                 string
+	MemoryMaxRows           int
 	DatabaseDSN             string
 	DatabaseHost            string
 	DatabasePort            string
@@ -21,17 +24,72 @@ type Config struct {
 	DatabaseBatchSize       int
 	DatabaseBatchTimeoutMS  int
 	DatabaseBatchBufferSize int
+	DatabaseBufferPolicy    string
+	DatabaseBufferTimeoutMS int
+	DatabaseSpillFile       string
+	DatabaseWALDir          string
+	DatabaseWALSegmentBytes int
+	DatabaseWALFsync        string
+	DatabaseWALFsyncMS      int
+	DatabaseRetryAttempts   int
+	DatabaseRetryBaseMS     int
+	DatabaseRetryMaxMS      int
+	DatabaseRetryJitter     float64
+	DLQEnabled              bool
+	DLQFile                 string
+	RetentionDays           int
+	RetentionIntervalMS     int
+	RetentionChunkSize      int
+	RetentionDryRun         bool
+	PartitionInterval       string
+	PartitionPremake        int
+	PartitionCheckMS        int
+	PartitionDetach         bool
+	MigrationLockTimeoutMS  int
+	MigrationExpectVersion  int
+	GeneratorEnabled        bool
+	GeneratorMode           string
+	ReplayFile              string
+	ReplayFormat            string
+	ReplaySpeed             float64
+	ReplayLoop              bool
+	ReplayRebaseTimestamps  bool
 	GeneratorIntervalMillis int
+	GeneratorSeed           int64
+	GeneratorSources        []string
+	GeneratorSourceCount    int
+	GeneratorJitterMillis   int
+	GeneratorMinPacketSize  int
+	GeneratorMaxPacketSize  int
+	GeneratorDistribution   string
+	GeneratorMin            float64
+	GeneratorMax            float64
+	GeneratorMean           float64
+	GeneratorStdDev         float64
+	GeneratorAmplitude      float64
+	GeneratorPeriod         int
+	GeneratorNoise          float64
+	GeneratorStep           float64
+	GeneratorSpikeEvery     int
+	GeneratorSpikeHeight    float64
 	MeasurementsPerPacket   int
 	WorkerCount             int
+	TopK                    int
 	PacketBufferSize        int
+	WatchBufferSize         int
+	MaxPageSize             int
+	Aggregations            []string
+	AdminToken              string
 }
 
 func LoadConfig() Config {
+	interval := getEnvInt("N", 1000)
 	return Config{
 		HTTPPort:                getEnv("HTTP_PORT", "8080"),
 		GRPCPort:                getEnv("GRPC_PORT", "50051"),
 		MetricsPort:             getEnv("METRICS_PORT", "2112"),
+		Storage:                 getEnv("STORAGE", "postgres"),
+		MemoryMaxRows:           getEnvInt("MEMORY_MAX_ROWS", 0),
 		DatabaseDSN:             os.Getenv("DB_DSN"),
 		DatabaseHost:            os.Getenv("DB_HOST"),
 		DatabasePort:            os.Getenv("DB_PORT"),
@@ -41,10 +99,62 @@ func LoadConfig() Config {
 		DatabaseBatchSize:       getEnvInt("DB_BATCH_SIZE", 32),
 		DatabaseBatchTimeoutMS:  getEnvInt("DB_BATCH_TIMEOUT_MS", 250),
 		DatabaseBatchBufferSize: getEnvInt("DB_BATCH_BUFFER", 128),
-		GeneratorIntervalMillis: getEnvInt("N", 1000),
+		DatabaseBufferPolicy:    getEnv("DB_BUFFER_POLICY", "block"),
+		DatabaseBufferTimeoutMS: getEnvInt("DB_BUFFER_TIMEOUT_MS", 100),
+		DatabaseSpillFile:       getEnv("DB_SPILL_FILE", "data/packet_max_spill.jsonl"),
+		DatabaseWALDir:          os.Getenv("DB_WAL_DIR"),
+		DatabaseWALSegmentBytes: getEnvInt("DB_WAL_SEGMENT_BYTES", 16<<20),
+		DatabaseWALFsync:        getEnv("DB_WAL_FSYNC", "interval"),
+		DatabaseWALFsyncMS:      getEnvInt("DB_WAL_FSYNC_INTERVAL_MS", 200),
+		DatabaseRetryAttempts:   getEnvInt("DB_RETRY_MAX_ATTEMPTS", 5),
+		DatabaseRetryBaseMS:     getEnvInt("DB_RETRY_BASE_DELAY_MS", 50),
+		DatabaseRetryMaxMS:      getEnvInt("DB_RETRY_MAX_DELAY_MS", 2000),
+		DatabaseRetryJitter:     getEnvFloat("DB_RETRY_JITTER", 0.2),
+		DLQEnabled:              getEnvBool("DLQ_ENABLED", true),
+		DLQFile:                 getEnv("DLQ_FILE", "data/packet_max_dlq.jsonl"),
+		RetentionDays:           getEnvInt("RETENTION_DAYS", 0),
+		RetentionIntervalMS:     getEnvInt("RETENTION_INTERVAL_MS", 3600000),
+		RetentionChunkSize:      getEnvInt("RETENTION_CHUNK_SIZE", 5000),
+		RetentionDryRun:         getEnvBool("RETENTION_DRY_RUN", false),
+		PartitionInterval:       getEnv("PARTITION_INTERVAL", "monthly"),
+		PartitionPremake:        getEnvInt("PARTITION_PREMAKE", 3),
+		PartitionCheckMS:        getEnvInt("PARTITION_CHECK_INTERVAL_MS", 3600000),
+		PartitionDetach:         getEnvBool("PARTITION_DETACH", false),
+		MigrationLockTimeoutMS:  getEnvInt("MIGRATION_LOCK_TIMEOUT_MS", 60000),
+		MigrationExpectVersion:  getEnvInt("MIGRATION_EXPECT_VERSION", 0),
+		GeneratorEnabled:        getEnvBool("GENERATOR_ENABLED", true),
+		GeneratorMode:           getEnv("GENERATOR_MODE", "synthetic"),
+		ReplayFile:              os.Getenv("REPLAY_FILE"),
+		ReplayFormat:            os.Getenv("REPLAY_FORMAT"),
+		ReplaySpeed:             getEnvFloat("REPLAY_SPEED", 1),
+		ReplayLoop:              getEnvBool("REPLAY_LOOP", false),
+		ReplayRebaseTimestamps:  getEnvBool("REPLAY_REBASE_TIMESTAMPS", false),
+		GeneratorIntervalMillis: interval,
+		GeneratorSeed:           int64(getEnvInt("GENERATOR_SEED", 0)),
+		GeneratorSources:        getEnvList("GENERATOR_SOURCES", ""),
+		GeneratorSourceCount:    getEnvInt("GENERATOR_SOURCE_COUNT", 0),
+		GeneratorJitterMillis:   getEnvInt("GENERATOR_JITTER_MS", interval),
+		GeneratorMinPacketSize:  getEnvInt("GENERATOR_MIN_PACKET_SIZE", 0),
+		GeneratorMaxPacketSize:  getEnvInt("GENERATOR_MAX_PACKET_SIZE", 0),
+		GeneratorDistribution:   getEnv("GENERATOR_DISTRIBUTION", "uniform"),
+		GeneratorMin:            getEnvFloat("GENERATOR_MIN", 0),
+		GeneratorMax:            getEnvFloat("GENERATOR_MAX", 1000),
+		GeneratorMean:           getEnvFloat("GENERATOR_MEAN", 500),
+		GeneratorStdDev:         getEnvFloat("GENERATOR_STDDEV", 100),
+		GeneratorAmplitude:      getEnvFloat("GENERATOR_AMPLITUDE", 250),
+		GeneratorPeriod:         getEnvInt("GENERATOR_PERIOD", 60),
+		GeneratorNoise:          getEnvFloat("GENERATOR_NOISE", 10),
+		GeneratorStep:           getEnvFloat("GENERATOR_STEP", 5),
+		GeneratorSpikeEvery:     getEnvInt("GENERATOR_SPIKE_EVERY", 30),
+		GeneratorSpikeHeight:    getEnvFloat("GENERATOR_SPIKE_HEIGHT", 1000),
 		MeasurementsPerPacket:   getEnvInt("K", 10),
 		WorkerCount:             getEnvInt("M", 4),
+		TopK:                    getEnvInt("TOP_K", 1),
 		PacketBufferSize:        getEnvInt("PACKET_BUFFER", 100),
+		WatchBufferSize:         getEnvInt("WATCH_BUFFER", 64),
+		MaxPageSize:             getEnvInt("MAX_PAGE_SIZE", 1000),
+		Aggregations:            getEnvList("AGGREGATIONS", "max"),
+		AdminToken:              os.Getenv("ADMIN_TOKEN"),
 	}
 }
 
@@ -52,6 +162,10 @@ func LogConfig(ctx context.Context, logger *Logger, cfg Config) {
 	logger.Printf(ctx, "HTTP_PORT=%s", cfg.HTTPPort)
 	logger.Printf(ctx, "GRPC_PORT=%s", cfg.GRPCPort)
 	logger.Printf(ctx, "METRICS_PORT=%s", utils.EmptyFallback(cfg.MetricsPort, "(disabled)"))
+	logger.Printf(ctx, "STORAGE=%s", cfg.Storage)
+	if cfg.Storage == "memory" {
+		logger.Printf(ctx, "MEMORY_MAX_ROWS=%d", cfg.MemoryMaxRows)
+	}
 	if cfg.DatabaseDSN != "" {
 		logger.Printf(ctx, "DB_DSN set (length %d)", len(cfg.DatabaseDSN))
 	} else {
@@ -69,10 +183,79 @@ func LogConfig(ctx context.Context, logger *Logger, cfg Config) {
 	logger.Printf(ctx, "DB_BATCH_SIZE=%d", cfg.DatabaseBatchSize)
 	logger.Printf(ctx, "DB_BATCH_TIMEOUT_MS=%d", cfg.DatabaseBatchTimeoutMS)
 	logger.Printf(ctx, "DB_BATCH_BUFFER=%d", cfg.DatabaseBatchBufferSize)
+	logger.Printf(ctx, "DB_BUFFER_POLICY=%s", cfg.DatabaseBufferPolicy)
+	switch cfg.DatabaseBufferPolicy {
+	case "block-timeout":
+		logger.Printf(ctx, "DB_BUFFER_TIMEOUT_MS=%d", cfg.DatabaseBufferTimeoutMS)
+	case "spill":
+		logger.Printf(ctx, "DB_SPILL_FILE=%s", cfg.DatabaseSpillFile)
+	}
+	if cfg.DatabaseWALDir != "" {
+		logger.Printf(ctx, "DB_WAL_DIR=%s", cfg.DatabaseWALDir)
+		logger.Printf(ctx, "DB_WAL_SEGMENT_BYTES=%d", cfg.DatabaseWALSegmentBytes)
+		logger.Printf(ctx, "DB_WAL_FSYNC=%s", cfg.DatabaseWALFsync)
+		if cfg.DatabaseWALFsync == "interval" {
+			logger.Printf(ctx, "DB_WAL_FSYNC_INTERVAL_MS=%d", cfg.DatabaseWALFsyncMS)
+		}
+	} else {
+		logger.Println(ctx, "DB_WAL_DIR not provided, write-ahead log disabled")
+	}
+	logger.Printf(ctx, "DB_RETRY_MAX_ATTEMPTS=%d", cfg.DatabaseRetryAttempts)
+	logger.Printf(ctx, "DB_RETRY_DELAY_MS=%d..%d (jitter %g)", cfg.DatabaseRetryBaseMS, cfg.DatabaseRetryMaxMS, cfg.DatabaseRetryJitter)
+	if cfg.DLQEnabled {
+		logger.Printf(ctx, "DLQ_FILE=%s", cfg.DLQFile)
+	} else {
+		logger.Println(ctx, "DLQ_ENABLED=false, failed writes are dropped")
+	}
+	if cfg.RetentionDays > 0 {
+		logger.Printf(ctx, "RETENTION_DAYS=%d", cfg.RetentionDays)
+		logger.Printf(ctx, "RETENTION_INTERVAL_MS=%d", cfg.RetentionIntervalMS)
+		logger.Printf(ctx, "RETENTION_CHUNK_SIZE=%d", cfg.RetentionChunkSize)
+		logger.Printf(ctx, "RETENTION_DRY_RUN=%t", cfg.RetentionDryRun)
+	} else {
+		logger.Println(ctx, "RETENTION_DAYS not set, stored maxima are kept forever")
+	}
+	logger.Printf(ctx, "PARTITION_INTERVAL=%s", cfg.PartitionInterval)
+	logger.Printf(ctx, "PARTITION_PREMAKE=%d", cfg.PartitionPremake)
+	logger.Printf(ctx, "PARTITION_CHECK_INTERVAL_MS=%d", cfg.PartitionCheckMS)
+	if cfg.RetentionDays > 0 {
+		logger.Printf(ctx, "PARTITION_DETACH=%t", cfg.PartitionDetach)
+	}
+	if cfg.MigrationExpectVersion > 0 {
+		logger.Printf(ctx, "MIGRATION_EXPECT_VERSION=%d, migrations are not applied on start", cfg.MigrationExpectVersion)
+	} else {
+		logger.Printf(ctx, "MIGRATION_LOCK_TIMEOUT_MS=%d", cfg.MigrationLockTimeoutMS)
+	}
+	logger.Printf(ctx, "GENERATOR_ENABLED=%t", cfg.GeneratorEnabled)
+	logger.Printf(ctx, "GENERATOR_MODE=%s", cfg.GeneratorMode)
+	if cfg.GeneratorMode == "replay" {
+		logger.Printf(ctx, "REPLAY_FILE=%s", utils.EmptyFallback(cfg.ReplayFile, "(not set)"))
+		logger.Printf(ctx, "REPLAY_SPEED=%g", cfg.ReplaySpeed)
+		logger.Printf(ctx, "REPLAY_LOOP=%t", cfg.ReplayLoop)
+		logger.Printf(ctx, "REPLAY_REBASE_TIMESTAMPS=%t", cfg.ReplayRebaseTimestamps)
+	}
 	logger.Printf(ctx, "GENERATOR_INTERVAL_MS=%d", cfg.GeneratorIntervalMillis)
+	logger.Printf(ctx, "GENERATOR_SEED=%d", cfg.GeneratorSeed)
+	logger.Printf(ctx, "GENERATOR_DISTRIBUTION=%s", cfg.GeneratorDistribution)
+	if len(cfg.GeneratorSources) > 0 {
+		logger.Printf(ctx, "GENERATOR_SOURCES=%s", strings.Join(cfg.GeneratorSources, ","))
+	} else {
+		logger.Printf(ctx, "GENERATOR_SOURCE_COUNT=%d", cfg.GeneratorSourceCount)
+	}
+	logger.Printf(ctx, "GENERATOR_JITTER_MS=%d", cfg.GeneratorJitterMillis)
+	logger.Printf(ctx, "GENERATOR_PACKET_SIZE=%d..%d", cfg.GeneratorMinPacketSize, cfg.GeneratorMaxPacketSize)
 	logger.Printf(ctx, "MEASUREMENTS_PER_PACKET=%d", cfg.MeasurementsPerPacket)
 	logger.Printf(ctx, "WORKER_COUNT=%d", cfg.WorkerCount)
+	logger.Printf(ctx, "TOP_K=%d", cfg.TopK)
 	logger.Printf(ctx, "PACKET_BUFFER=%d", cfg.PacketBufferSize)
+	logger.Printf(ctx, "WATCH_BUFFER=%d", cfg.WatchBufferSize)
+	logger.Printf(ctx, "MAX_PAGE_SIZE=%d", cfg.MaxPageSize)
+	logger.Printf(ctx, "AGGREGATIONS=%s", strings.Join(cfg.Aggregations, ","))
+	if cfg.AdminToken != "" {
+		logger.Println(ctx, "ADMIN_TOKEN set (redacted)")
+	} else {
+		logger.Println(ctx, "ADMIN_TOKEN not provided, admin API disabled")
+	}
 }
 
 func getEnv(key, fallback string) string {
@@ -90,3 +273,33 @@ func getEnvInt(key string, fallback int) int {
 	}
 	return fallback
 }
+
+func getEnvList(key, fallback string) []string {
+	raw := getEnv(key, fallback)
+
+	var values []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
@@ -14,12 +14,83 @@ func TestLoadConfigDefaults(t *testing.T) {
 	t.Log("Шаг 1: очищаем переменные окружения и загружаем конфиг")
 	t.Setenv("HTTP_PORT", "")
 	t.Setenv("DB_BATCH_SIZE", "")
+	t.Setenv("GENERATOR_ENABLED", "")
+	t.Setenv("GENERATOR_MODE", "")
+	t.Setenv("REPLAY_SPEED", "")
+	t.Setenv("REPLAY_LOOP", "")
+	t.Setenv("DLQ_ENABLED", "")
+	t.Setenv("DLQ_FILE", "")
+	t.Setenv("DB_RETRY_MAX_ATTEMPTS", "")
+	t.Setenv("DB_RETRY_JITTER", "")
+	t.Setenv("DB_BUFFER_POLICY", "")
+	t.Setenv("DB_WAL_DIR", "")
+	t.Setenv("DB_WAL_FSYNC", "")
+	t.Setenv("MAX_PAGE_SIZE", "")
+	t.Setenv("RETENTION_DAYS", "")
+	t.Setenv("RETENTION_INTERVAL_MS", "")
+	t.Setenv("RETENTION_CHUNK_SIZE", "")
+	t.Setenv("RETENTION_DRY_RUN", "")
+	t.Setenv("PARTITION_INTERVAL", "")
+	t.Setenv("PARTITION_PREMAKE", "")
+	t.Setenv("PARTITION_CHECK_INTERVAL_MS", "")
+	t.Setenv("PARTITION_DETACH", "")
+	t.Setenv("MIGRATION_LOCK_TIMEOUT_MS", "")
+	t.Setenv("MIGRATION_EXPECT_VERSION", "")
+	t.Setenv("STORAGE", "")
+	t.Setenv("MEMORY_MAX_ROWS", "")
 
 	cfg := LoadConfig()
 
 	assert.Equal(t, "8080", cfg.HTTPPort)
 	assert.Equal(t, 32, cfg.DatabaseBatchSize)
 	assert.Equal(t, 250, cfg.DatabaseBatchTimeoutMS)
+	assert.True(t, cfg.GeneratorEnabled)
+	assert.Equal(t, "synthetic", cfg.GeneratorMode)
+	assert.Equal(t, 1.0, cfg.ReplaySpeed)
+	assert.False(t, cfg.ReplayLoop)
+	assert.Empty(t, cfg.GeneratorSources)
+	assert.Equal(t, cfg.GeneratorIntervalMillis, cfg.GeneratorJitterMillis)
+	assert.Equal(t, "block", cfg.DatabaseBufferPolicy)
+	assert.Empty(t, cfg.DatabaseWALDir)
+	assert.Equal(t, "interval", cfg.DatabaseWALFsync)
+	assert.Equal(t, 1000, cfg.MaxPageSize)
+	assert.Equal(t, 5, cfg.DatabaseRetryAttempts)
+	assert.Equal(t, 50, cfg.DatabaseRetryBaseMS)
+	assert.Equal(t, 2000, cfg.DatabaseRetryMaxMS)
+	assert.Equal(t, 0.2, cfg.DatabaseRetryJitter)
+	assert.True(t, cfg.DLQEnabled)
+	assert.Equal(t, "data/packet_max_dlq.jsonl", cfg.DLQFile)
+	assert.Zero(t, cfg.RetentionDays)
+	assert.Equal(t, 3600000, cfg.RetentionIntervalMS)
+	assert.Equal(t, 5000, cfg.RetentionChunkSize)
+	assert.False(t, cfg.RetentionDryRun)
+	assert.Equal(t, "monthly", cfg.PartitionInterval)
+	assert.Equal(t, 3, cfg.PartitionPremake)
+	assert.Equal(t, 3600000, cfg.PartitionCheckMS)
+	assert.False(t, cfg.PartitionDetach)
+	assert.Equal(t, 60000, cfg.MigrationLockTimeoutMS)
+	assert.Equal(t, 0, cfg.MigrationExpectVersion)
+	assert.Equal(t, "postgres", cfg.Storage)
+	assert.Equal(t, 0, cfg.MemoryMaxRows)
+}
+
+func TestLoadConfigReadsSourcePool(t *testing.T) {
+	t.Log("Шаг 1: задаём пул источников, джиттер и диапазон размера пакета")
+	t.Setenv("N", "500")
+	t.Setenv("GENERATOR_SOURCES", "boiler, pump ,fan")
+	t.Setenv("GENERATOR_MIN_PACKET_SIZE", "2")
+	t.Setenv("GENERATOR_MAX_PACKET_SIZE", "8")
+
+	cfg := LoadConfig()
+
+	t.Log("Шаг 2: джиттер по умолчанию равен интервалу генератора")
+	assert.Equal(t, []string{"boiler", "pump", "fan"}, cfg.GeneratorSources)
+	assert.Equal(t, 500, cfg.GeneratorJitterMillis)
+	assert.Equal(t, 2, cfg.GeneratorMinPacketSize)
+	assert.Equal(t, 8, cfg.GeneratorMaxPacketSize)
+
+	t.Setenv("GENERATOR_JITTER_MS", "0")
+	assert.Equal(t, 0, LoadConfig().GeneratorJitterMillis)
 }
 
 func TestLoadConfigReadsEnvironment(t *testing.T) {
@@ -28,6 +99,33 @@ func TestLoadConfigReadsEnvironment(t *testing.T) {
 	t.Setenv("DB_DSN", "dsn")
 	t.Setenv("DB_BATCH_SIZE", "64")
 	t.Setenv("M", "8")
+	t.Setenv("TOP_K", "3")
+	t.Setenv("MAX_PAGE_SIZE", "250")
+	t.Setenv("ADMIN_TOKEN", "token")
+	t.Setenv("DLQ_ENABLED", "false")
+	t.Setenv("DB_BUFFER_POLICY", "spill")
+	t.Setenv("DB_BUFFER_TIMEOUT_MS", "20")
+	t.Setenv("DB_SPILL_FILE", "/tmp/spill.jsonl")
+	t.Setenv("DB_WAL_DIR", "/tmp/wal")
+	t.Setenv("DB_WAL_SEGMENT_BYTES", "4096")
+	t.Setenv("DB_WAL_FSYNC", "always")
+	t.Setenv("DB_RETRY_MAX_ATTEMPTS", "3")
+	t.Setenv("DB_RETRY_BASE_DELAY_MS", "10")
+	t.Setenv("DB_RETRY_MAX_DELAY_MS", "500")
+	t.Setenv("DB_RETRY_JITTER", "0.5")
+	t.Setenv("DLQ_FILE", "/var/lib/aggregator/dlq.jsonl")
+	t.Setenv("RETENTION_DAYS", "30")
+	t.Setenv("RETENTION_INTERVAL_MS", "60000")
+	t.Setenv("RETENTION_CHUNK_SIZE", "100")
+	t.Setenv("RETENTION_DRY_RUN", "true")
+	t.Setenv("PARTITION_INTERVAL", "daily")
+	t.Setenv("PARTITION_PREMAKE", "7")
+	t.Setenv("PARTITION_CHECK_INTERVAL_MS", "600000")
+	t.Setenv("PARTITION_DETACH", "true")
+	t.Setenv("MIGRATION_LOCK_TIMEOUT_MS", "15000")
+	t.Setenv("MIGRATION_EXPECT_VERSION", "5")
+	t.Setenv("STORAGE", "memory")
+	t.Setenv("MEMORY_MAX_ROWS", "10000")
 
 	cfg := LoadConfig()
 
@@ -35,6 +133,51 @@ func TestLoadConfigReadsEnvironment(t *testing.T) {
 	assert.Equal(t, "dsn", cfg.DatabaseDSN)
 	assert.Equal(t, 64, cfg.DatabaseBatchSize)
 	assert.Equal(t, 8, cfg.WorkerCount)
+	assert.Equal(t, 3, cfg.TopK)
+	assert.Equal(t, 250, cfg.MaxPageSize)
+	assert.Equal(t, "token", cfg.AdminToken)
+	assert.Equal(t, "spill", cfg.DatabaseBufferPolicy)
+	assert.Equal(t, 20, cfg.DatabaseBufferTimeoutMS)
+	assert.Equal(t, "/tmp/spill.jsonl", cfg.DatabaseSpillFile)
+	assert.Equal(t, "/tmp/wal", cfg.DatabaseWALDir)
+	assert.Equal(t, 4096, cfg.DatabaseWALSegmentBytes)
+	assert.Equal(t, "always", cfg.DatabaseWALFsync)
+	assert.Equal(t, 3, cfg.DatabaseRetryAttempts)
+	assert.Equal(t, 10, cfg.DatabaseRetryBaseMS)
+	assert.Equal(t, 500, cfg.DatabaseRetryMaxMS)
+	assert.Equal(t, 0.5, cfg.DatabaseRetryJitter)
+	assert.False(t, cfg.DLQEnabled)
+	assert.Equal(t, "/var/lib/aggregator/dlq.jsonl", cfg.DLQFile)
+	assert.Equal(t, 30, cfg.RetentionDays)
+	assert.Equal(t, 60000, cfg.RetentionIntervalMS)
+	assert.Equal(t, 100, cfg.RetentionChunkSize)
+	assert.True(t, cfg.RetentionDryRun)
+	assert.Equal(t, "daily", cfg.PartitionInterval)
+	assert.Equal(t, 7, cfg.PartitionPremake)
+	assert.Equal(t, 600000, cfg.PartitionCheckMS)
+	assert.True(t, cfg.PartitionDetach)
+	assert.Equal(t, 15000, cfg.MigrationLockTimeoutMS)
+	assert.Equal(t, 5, cfg.MigrationExpectVersion)
+	assert.Equal(t, "memory", cfg.Storage)
+	assert.Equal(t, 10000, cfg.MemoryMaxRows)
+}
+
+func TestLoadConfigReadsReplaySettings(t *testing.T) {
+	t.Log("Шаг 1: включаем режим воспроизведения записи")
+	t.Setenv("GENERATOR_MODE", "replay")
+	t.Setenv("REPLAY_FILE", "/data/incident.jsonl")
+	t.Setenv("REPLAY_SPEED", "4")
+	t.Setenv("REPLAY_LOOP", "true")
+	t.Setenv("REPLAY_REBASE_TIMESTAMPS", "true")
+
+	cfg := LoadConfig()
+
+	t.Log("Шаг 2: проверяем параметры воспроизведения")
+	assert.Equal(t, "replay", cfg.GeneratorMode)
+	assert.Equal(t, "/data/incident.jsonl", cfg.ReplayFile)
+	assert.Equal(t, 4.0, cfg.ReplaySpeed)
+	assert.True(t, cfg.ReplayLoop)
+	assert.True(t, cfg.ReplayRebaseTimestamps)
 }
 
 func TestLogConfigProducesEntries(t *testing.T) {
@@ -74,3 +217,30 @@ func TestGetEnvInt(t *testing.T) {
 	t.Setenv("NUM", "invalid")
 	assert.Equal(t, 1, getEnvInt("NUM", 1))
 }
+
+func TestGetEnvList(t *testing.T) {
+	t.Log("читаем список значений через запятую")
+	t.Setenv("LIST", " max, min ,,mean ")
+	assert.Equal(t, []string{"max", "min", "mean"}, getEnvList("LIST", "sum"))
+	t.Log("проверяем значение по умолчанию")
+	t.Setenv("LIST", "")
+	assert.Equal(t, []string{"sum"}, getEnvList("LIST", "sum"))
+}
+
+func TestGetEnvBool(t *testing.T) {
+	t.Log("читаем логическую переменную окружения")
+	t.Setenv("FLAG", "false")
+	assert.False(t, getEnvBool("FLAG", true))
+	t.Log("проверяем поведение при некорректном значении")
+	t.Setenv("FLAG", "maybe")
+	assert.True(t, getEnvBool("FLAG", true))
+}
+
+func TestGetEnvFloat(t *testing.T) {
+	t.Log("читаем вещественную переменную окружения")
+	t.Setenv("RATIO", "2.5")
+	assert.Equal(t, 2.5, getEnvFloat("RATIO", 1))
+	t.Log("проверяем поведение при некорректном значении")
+	t.Setenv("RATIO", "half")
+	assert.Equal(t, 1.0, getEnvFloat("RATIO", 1))
+}
@@ -53,6 +53,144 @@ var (
 		Help: "Total number of packets produced by the generator",
 	})
 
+	GeneratorPaused = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "aggregator_generator_paused",
+		Help: "Whether the packet generator is paused (1) or running (0)",
+	})
+	GeneratorIntervalSeconds = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "aggregator_generator_interval_seconds",
+		Help: "Current interval between generated packets in seconds",
+	})
+	GeneratorMinPacketSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "aggregator_generator_min_packet_size",
+		Help: "Current minimum number of measurements per generated packet",
+	})
+	GeneratorMaxPacketSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "aggregator_generator_max_packet_size",
+		Help: "Current maximum number of measurements per generated packet",
+	})
+	GeneratorPausesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_generator_pauses_total",
+		Help: "Total number of times the generator was paused at runtime",
+	})
+	GeneratorResumesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_generator_resumes_total",
+		Help: "Total number of times the generator was resumed at runtime",
+	})
+	GeneratorIntervalChangesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_generator_interval_changes_total",
+		Help: "Total number of runtime changes of the generator interval",
+	})
+	GeneratorPacketSizeChangesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_generator_packet_size_changes_total",
+		Help: "Total number of runtime changes of the generator packet size",
+	})
+	GeneratorLastChangeTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "aggregator_generator_last_change_timestamp_seconds",
+		Help: "Unix time of the last runtime change of the generator",
+	})
+
+	DbBatchFallbacksTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_db_batch_fallbacks_total",
+		Help: "Total number of batch upserts that failed permanently and were retried row by row",
+	})
+	DbBufferOccupancy = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "aggregator_db_buffer_occupancy",
+		Help: "Number of packet maxima waiting in the repository write buffer",
+	})
+	DbBufferDroppedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_db_buffer_dropped_total",
+		Help: "Total number of packet maxima dropped because the repository write buffer was full",
+	})
+	DbBufferSpilledTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_db_buffer_spilled_total",
+		Help: "Total number of packet maxima spilled to disk because the repository write buffer was full",
+	})
+	DbBufferSpillDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "aggregator_db_buffer_spill_depth",
+		Help: "Number of spilled packet maxima not yet read back from disk",
+	})
+	WALAppendsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_wal_appends_total",
+		Help: "Total number of packet maxima appended to the repository write-ahead log",
+	})
+	WALReplayedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_wal_replayed_total",
+		Help: "Total number of unflushed packet maxima replayed from the write-ahead log on startup",
+	})
+	WALCorruptRecordsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_wal_corrupt_records_total",
+		Help: "Total number of torn or corrupt write-ahead log records skipped during recovery",
+	})
+	WALSegments = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "aggregator_wal_segments",
+		Help: "Number of write-ahead log segment files on disk",
+	})
+	DbWriteRetriesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_db_write_retries_total",
+		Help: "Total number of packet max writes retried after a transient database error",
+	})
+	DbWriteGiveUpsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_db_write_giveups_total",
+		Help: "Total number of packet max writes abandoned after exhausting retries or on shutdown",
+	})
+
+	DLQDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "aggregator_dlq_depth",
+		Help: "Number of packet maxima waiting in the dead-letter queue",
+	})
+	DLQEntriesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_dlq_entries_total",
+		Help: "Total number of failed packet max writes routed to the dead-letter queue",
+	})
+	DLQRedrivenTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_dlq_redriven_total",
+		Help: "Total number of dead-letter entries written successfully on re-drive",
+	})
+
+	IngestedPacketsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_ingested_packets_total",
+		Help: "Total number of packets accepted from external producers",
+	})
+	IngestRejectedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_ingest_rejected_total",
+		Help: "Total number of ingest requests rejected because the packet queue was full",
+	})
+
+	DbPartitionsCreatedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_db_partitions_created_total",
+		Help: "Total number of packet_max partitions created ahead of time",
+	})
+	DbPartitionsExpiredTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_db_partitions_expired_total",
+		Help: "Total number of packet_max partitions dropped or detached after the retention window",
+	})
+	DbPartitions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "aggregator_db_partitions",
+		Help: "Number of packet_max range partitions after the last maintenance pass",
+	})
+
+	RetentionRunsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_retention_runs_total",
+		Help: "Total number of completed retention runs, including dry runs",
+	})
+	RetentionFailuresTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_retention_failures_total",
+		Help: "Total number of retention runs that failed",
+	})
+	RetentionPurgedRowsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "aggregator_retention_purged_rows_total",
+		Help: "Total number of expired rows deleted by retention",
+	})
+	RetentionDryRunRows = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "aggregator_retention_dry_run_rows",
+		Help: "Number of expired rows the last dry retention run would have deleted",
+	})
+	RetentionLastRunTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "aggregator_retention_last_run_timestamp_seconds",
+		Help: "Unix time of the last completed retention run",
+	})
+
 	WorkerPoolActiveGoroutines = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "aggregator_worker_pool_active_goroutines",
 		Help: "Number of active worker pool goroutines",
@@ -76,7 +214,40 @@ func InitMetrics() {
 			DbBatchDurationSeconds,
 			DbBatchSize,
 			DbBatchWaitSeconds,
+			DbBatchFallbacksTotal,
+			DbBufferOccupancy,
+			DbBufferDroppedTotal,
+			DbBufferSpilledTotal,
+			DbBufferSpillDepth,
+			WALAppendsTotal,
+			WALReplayedTotal,
+			WALCorruptRecordsTotal,
+			WALSegments,
+			DbWriteRetriesTotal,
+			DbWriteGiveUpsTotal,
+			DbPartitionsCreatedTotal,
+			DbPartitionsExpiredTotal,
+			DbPartitions,
 			PacketsTotal,
+			GeneratorPaused,
+			GeneratorIntervalSeconds,
+			GeneratorMinPacketSize,
+			GeneratorMaxPacketSize,
+			GeneratorPausesTotal,
+			GeneratorResumesTotal,
+			GeneratorIntervalChangesTotal,
+			GeneratorPacketSizeChangesTotal,
+			GeneratorLastChangeTimestamp,
+			DLQDepth,
+			DLQEntriesTotal,
+			DLQRedrivenTotal,
+			IngestedPacketsTotal,
+			IngestRejectedTotal,
+			RetentionRunsTotal,
+			RetentionFailuresTotal,
+			RetentionPurgedRowsTotal,
+			RetentionDryRunRows,
+			RetentionLastRunTimestamp,
 			WorkerPoolActiveGoroutines,
 		)
 	})
@@ -155,6 +326,22 @@ func GRPCUnaryInterceptor() grpc.UnaryServerInterceptor {
 	}
 }
 
+func GRPCStreamInterceptor() grpc.StreamServerInterceptor {
+	InitMetrics()
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		start := time.Now()
+		defer func() {
+			duration := time.Since(start)
+			ProcessingDurationSeconds.Observe(duration.Seconds())
+			HttpRequestsTotal.Inc()
+			if status.Code(err) != codes.OK {
+				HttpRequestErrorsTotal.Inc()
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
 func RecordDBBatchFlush(duration time.Duration) {
 	InitMetrics()
 	if duration < 0 {
@@ -164,11 +351,153 @@ func RecordDBBatchFlush(duration time.Duration) {
 	DbBatchDurationSeconds.Observe(duration.Seconds())
 }
 
+func IncDBBatchFallbacks() {
+	InitMetrics()
+	DbBatchFallbacksTotal.Inc()
+}
+
+func SetDBBufferOccupancy(items int) {
+	InitMetrics()
+	DbBufferOccupancy.Set(float64(items))
+}
+
+func IncDBBufferDropped() {
+	InitMetrics()
+	DbBufferDroppedTotal.Inc()
+}
+
+// RecordDBBufferSpill counts one spilled packet maximum and publishes the spill file depth.
+func RecordDBBufferSpill(depth int) {
+	InitMetrics()
+	DbBufferSpilledTotal.Inc()
+	DbBufferSpillDepth.Set(float64(depth))
+}
+
+func SetDBBufferSpillDepth(depth int) {
+	InitMetrics()
+	DbBufferSpillDepth.Set(float64(depth))
+}
+
+func IncWALAppends() {
+	InitMetrics()
+	WALAppendsTotal.Inc()
+}
+
+func AddWALReplayed(records int) {
+	InitMetrics()
+	WALReplayedTotal.Add(float64(records))
+}
+
+func IncWALCorruptRecords() {
+	InitMetrics()
+	WALCorruptRecordsTotal.Inc()
+}
+
+func SetWALSegments(segments int) {
+	InitMetrics()
+	WALSegments.Set(float64(segments))
+}
+
+func IncDBWriteRetries() {
+	InitMetrics()
+	DbWriteRetriesTotal.Inc()
+}
+
+func IncDBWriteGiveUps() {
+	InitMetrics()
+	DbWriteGiveUpsTotal.Inc()
+}
+
 func IncGeneratorPackets() {
 	InitMetrics()
 	PacketsTotal.Inc()
 }
 
+// SetGeneratorState publishes the current generator schedule.
+func SetGeneratorState(paused bool, interval time.Duration, minPacketSize, maxPacketSize int) {
+	InitMetrics()
+	if paused {
+		GeneratorPaused.Set(1)
+	} else {
+		GeneratorPaused.Set(0)
+	}
+	GeneratorIntervalSeconds.Set(interval.Seconds())
+	GeneratorMinPacketSize.Set(float64(minPacketSize))
+	GeneratorMaxPacketSize.Set(float64(maxPacketSize))
+}
+
+// RecordGeneratorChange counts a runtime change of the generator; action is one of "pause",
+// "resume", "interval" or "packet_size".
+func RecordGeneratorChange(action string, at time.Time) {
+	InitMetrics()
+	switch action {
+	case "pause":
+		GeneratorPausesTotal.Inc()
+	case "resume":
+		GeneratorResumesTotal.Inc()
+	case "interval":
+		GeneratorIntervalChangesTotal.Inc()
+	case "packet_size":
+		GeneratorPacketSizeChangesTotal.Inc()
+	}
+	GeneratorLastChangeTimestamp.Set(float64(at.UnixNano()) / 1e9)
+}
+
+func SetDLQDepth(depth int) {
+	InitMetrics()
+	DLQDepth.Set(float64(depth))
+}
+
+func IncDLQEntries() {
+	InitMetrics()
+	DLQEntriesTotal.Inc()
+}
+
+func IncDLQRedriven() {
+	InitMetrics()
+	DLQRedrivenTotal.Inc()
+}
+
+func IncIngestedPackets() {
+	InitMetrics()
+	IngestedPacketsTotal.Inc()
+}
+
+func IncIngestRejected() {
+	InitMetrics()
+	IngestRejectedTotal.Inc()
+}
+
+// RecordPartitionMaintenance counts the partitions one maintenance pass created and expired and
+// publishes how many are left.
+func RecordPartitionMaintenance(created, expired, partitions int) {
+	InitMetrics()
+	DbPartitionsCreatedTotal.Add(float64(created))
+	DbPartitionsExpiredTotal.Add(float64(expired))
+	DbPartitions.Set(float64(partitions))
+}
+
+// RecordRetentionRun counts a completed retention run. Dry runs publish the rows they would have
+// deleted instead of adding to the purged total.
+func RecordRetentionRun(rows int64, dryRun bool, at time.Time) {
+	InitMetrics()
+	RetentionRunsTotal.Inc()
+	if dryRun {
+		RetentionDryRunRows.Set(float64(rows))
+	} else {
+		RetentionPurgedRowsTotal.Add(float64(rows))
+	}
+	RetentionLastRunTimestamp.Set(float64(at.UnixNano()) / 1e9)
+}
+
+// RecordRetentionFailure counts a failed retention run together with the rows it had already
+// deleted; those are gone even though the run did not finish.
+func RecordRetentionFailure(purged int64) {
+	InitMetrics()
+	RetentionFailuresTotal.Inc()
+	RetentionPurgedRowsTotal.Add(float64(purged))
+}
+
 func WorkerStarted() {
 	InitMetrics()
 	WorkerPoolActiveGoroutines.Inc()
@@ -192,3 +521,15 @@ func (r *statusRecorder) WriteHeader(code int) {
 func (r *statusRecorder) Status() int {
 	return r.status
 }
+
+// Flush forwards to the wrapped writer so streaming handlers keep working behind the middleware.
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the wrapped writer to http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestInitMetricsIdempotent(t *testing.T) {
@@ -115,6 +117,82 @@ func TestIncGeneratorPacketsIncrementsCounter(t *testing.T) {
 	assert.Equal(t, before+1, PacketsTotal.Value())
 }
 
+func TestSetGeneratorStatePublishesGauges(t *testing.T) {
+	t.Log("публикуем состояние генератора на паузе")
+	SetGeneratorState(true, 250*time.Millisecond, 2, 8)
+	assert.Equal(t, 1.0, GeneratorPaused.Value())
+	assert.Equal(t, 0.25, GeneratorIntervalSeconds.Value())
+	assert.Equal(t, 2.0, GeneratorMinPacketSize.Value())
+	assert.Equal(t, 8.0, GeneratorMaxPacketSize.Value())
+
+	SetGeneratorState(false, time.Second, 4, 4)
+	assert.Equal(t, 0.0, GeneratorPaused.Value())
+}
+
+func TestRecordGeneratorChangeCountsActions(t *testing.T) {
+	t.Log("фиксируем изменения генератора каждого вида")
+	InitMetrics()
+	pauses, resumes := GeneratorPausesTotal.Value(), GeneratorResumesTotal.Value()
+	intervals, sizes := GeneratorIntervalChangesTotal.Value(), GeneratorPacketSizeChangesTotal.Value()
+	at := time.Unix(1700000000, 0)
+
+	RecordGeneratorChange("pause", at)
+	RecordGeneratorChange("resume", at)
+	RecordGeneratorChange("interval", at)
+	RecordGeneratorChange("packet_size", at)
+
+	assert.Equal(t, pauses+1, GeneratorPausesTotal.Value())
+	assert.Equal(t, resumes+1, GeneratorResumesTotal.Value())
+	assert.Equal(t, intervals+1, GeneratorIntervalChangesTotal.Value())
+	assert.Equal(t, sizes+1, GeneratorPacketSizeChangesTotal.Value())
+	assert.Equal(t, 1700000000.0, GeneratorLastChangeTimestamp.Value())
+}
+
+func TestDBBufferMetrics(t *testing.T) {
+	t.Log("публикуем заполненность буфера и считаем отброшенные и выгруженные на диск записи")
+	dropped, spilled := DbBufferDroppedTotal.Value(), DbBufferSpilledTotal.Value()
+
+	SetDBBufferOccupancy(7)
+	IncDBBufferDropped()
+	RecordDBBufferSpill(4)
+
+	assert.Equal(t, 7.0, DbBufferOccupancy.Value())
+	assert.Equal(t, dropped+1, DbBufferDroppedTotal.Value())
+	assert.Equal(t, spilled+1, DbBufferSpilledTotal.Value())
+	assert.Equal(t, 4.0, DbBufferSpillDepth.Value())
+
+	SetDBBufferSpillDepth(0)
+	assert.Equal(t, 0.0, DbBufferSpillDepth.Value())
+}
+
+func TestWALMetrics(t *testing.T) {
+	t.Log("считаем записи журнала, воспроизведённые и повреждённые записи, публикуем число сегментов")
+	appends, replayed, corrupt := WALAppendsTotal.Value(), WALReplayedTotal.Value(), WALCorruptRecordsTotal.Value()
+
+	IncWALAppends()
+	AddWALReplayed(3)
+	IncWALCorruptRecords()
+	SetWALSegments(2)
+
+	assert.Equal(t, appends+1, WALAppendsTotal.Value())
+	assert.Equal(t, replayed+3, WALReplayedTotal.Value())
+	assert.Equal(t, corrupt+1, WALCorruptRecordsTotal.Value())
+	assert.Equal(t, 2.0, WALSegments.Value())
+}
+
+func TestDLQMetrics(t *testing.T) {
+	t.Log("публикуем глубину очереди и считаем записи и повторные отправки")
+	entries, redriven := DLQEntriesTotal.Value(), DLQRedrivenTotal.Value()
+
+	SetDLQDepth(3)
+	IncDLQEntries()
+	IncDLQRedriven()
+
+	assert.Equal(t, 3.0, DLQDepth.Value())
+	assert.Equal(t, entries+1, DLQEntriesTotal.Value())
+	assert.Equal(t, redriven+1, DLQRedrivenTotal.Value())
+}
+
 func TestWorkerStartedAndFinishedAdjustGauge(t *testing.T) {
 	t.Log("фиксируем запуск и завершение воркера")
 	InitMetrics()
@@ -130,3 +208,38 @@ func TestStatusRecorder(t *testing.T) {
 	recorder.WriteHeader(http.StatusTeapot)
 	assert.Equal(t, http.StatusTeapot, recorder.Status())
 }
+
+func TestStatusRecorderSupportsFlushAndUnwrap(t *testing.T) {
+	t.Log("проверяем проброс Flush и Unwrap к исходному writer")
+	inner := httptest.NewRecorder()
+	recorder := &statusRecorder{ResponseWriter: inner, status: http.StatusOK}
+
+	var writer http.ResponseWriter = recorder
+	flusher, ok := writer.(http.Flusher)
+	assert.True(t, ok)
+	flusher.Flush()
+	assert.True(t, inner.Flushed)
+	assert.Same(t, inner, recorder.Unwrap())
+
+	t.Log("Flush не паникует, если writer его не поддерживает")
+	plain := &statusRecorder{ResponseWriter: struct{ http.ResponseWriter }{inner}}
+	assert.NotPanics(t, plain.Flush)
+}
+
+func TestGRPCStreamInterceptorRecordsMetrics(t *testing.T) {
+	t.Log("вызываем потоковый интерсептор с ошибкой обработчика")
+	InitMetrics()
+	interceptor := GRPCStreamInterceptor()
+
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		return status.Error(codes.Internal, "boom")
+	}
+
+	before := HttpRequestsTotal.Value()
+	beforeErrors := HttpRequestErrorsTotal.Value()
+	err := interceptor(nil, nil, &grpc.StreamServerInfo{FullMethod: "/service/Stream"}, handler)
+
+	assert.Error(t, err)
+	assert.Equal(t, before+1, HttpRequestsTotal.Value())
+	assert.Equal(t, beforeErrors+1, HttpRequestErrorsTotal.Value())
+}
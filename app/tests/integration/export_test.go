@@ -0,0 +1,139 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	pb "aggregator-service/app/src/api/grpc/pb"
+	httpapi "aggregator-service/app/src/api/http"
+	"aggregator-service/app/src/core"
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/infra"
+	"aggregator-service/app/src/shared/constants"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// endlessReader serves a range that never runs out of rows. Like a database cursor, its iterator
+// stops once the query context is cancelled, and it reports when it has been closed.
+type endlessReader struct {
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newEndlessReader() *endlessReader {
+	return &endlessReader{closed: make(chan struct{})}
+}
+
+func (r *endlessReader) PacketMaxByID(context.Context, string) (domain.PacketMax, error) {
+	return domain.PacketMax{}, domain.ErrNotFound
+}
+
+func (r *endlessReader) PacketMaxInRange(context.Context, time.Time, time.Time, domain.PageRequest) (domain.PacketMaxPage, error) {
+	return domain.PacketMaxPage{}, domain.ErrNotFound
+}
+
+func (r *endlessReader) TopPacketMaxByID(context.Context, string, int) ([]domain.PacketMax, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (r *endlessReader) IteratePacketMaxInRange(ctx context.Context, from, _ time.Time) (domain.PacketMaxIterator, error) {
+	return &endlessIterator{ctx: ctx, reader: r, ts: from}, nil
+}
+
+type endlessIterator struct {
+	ctx    context.Context
+	reader *endlessReader
+	ts     time.Time
+}
+
+func (it *endlessIterator) Next() bool {
+	it.ts = it.ts.Add(time.Millisecond)
+	return it.ctx.Err() == nil
+}
+
+func (it *endlessIterator) PacketMax() domain.PacketMax {
+	return domain.PacketMax{PacketID: constants.GenerateUUID(), SourceID: "source", Value: 1, Timestamp: it.ts}
+}
+
+func (it *endlessIterator) Err() error { return it.ctx.Err() }
+
+func (it *endlessIterator) Close() error {
+	it.reader.closeOnce.Do(func() { close(it.reader.closed) })
+	return nil
+}
+
+func waitClosed(t *testing.T, reader *endlessReader) {
+	t.Helper()
+	select {
+	case <-reader.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("repository cursor was not closed after the client went away")
+	}
+}
+
+func TestGRPCStreamMaxByTimeRangeCancellationClosesCursor(t *testing.T) {
+	t.Parallel()
+
+	reader := newEndlessReader()
+	client, cleanup := startGRPCClient(t, core.NewAggregator(reader))
+	defer cleanup()
+
+	t.Log("Шаг 1: клиент читает несколько сообщений бесконечного диапазона")
+	ctx, cancel := context.WithCancel(context.Background())
+	now := time.Now().UTC()
+	stream, err := client.StreamMaxByTimeRange(ctx, &pb.StreamByTimeRangeRequest{From: timestamppb.New(now.Add(-time.Hour)), To: timestamppb.New(now)})
+	if err != nil {
+		t.Fatalf("failed to open stream: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := stream.Recv(); err != nil {
+			t.Fatalf("failed to receive message %d: %v", i, err)
+		}
+	}
+
+	t.Log("Шаг 2: после отмены сервер закрывает курсор")
+	cancel()
+	waitClosed(t, reader)
+}
+
+func TestHTTPExportCancellationClosesCursor(t *testing.T) {
+	t.Parallel()
+
+	reader := newEndlessReader()
+	server := httptest.NewServer(httpapi.NewServer(core.NewAggregator(reader), infra.NewLogger(io.Discard, "test-http")))
+	defer server.Close()
+
+	t.Log("Шаг 1: клиент читает первые строки NDJSON")
+	ctx, cancel := context.WithCancel(context.Background())
+	now := time.Now().UTC()
+	url := server.URL + "/max/export?from=" + now.Add(-time.Hour).Format(constants.TimeFormat) + "&to=" + now.Format(constants.TimeFormat)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("export request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	for i := 0; i < 3; i++ {
+		if !scanner.Scan() {
+			t.Fatalf("failed to read line %d: %v", i, scanner.Err())
+		}
+	}
+
+	t.Log("Шаг 2: после разрыва соединения сервер закрывает курсор")
+	cancel()
+	waitClosed(t, reader)
+}
@@ -14,6 +14,7 @@ import (
 	grpcapi "aggregator-service/app/src/api/grpc"
 	pb "aggregator-service/app/src/api/grpc/pb"
 	httpapi "aggregator-service/app/src/api/http"
+	"aggregator-service/app/src/core"
 	"aggregator-service/app/src/domain"
 	"aggregator-service/app/src/infra"
 	"aggregator-service/app/src/shared/constants"
@@ -21,6 +22,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -28,10 +30,16 @@ import (
 
 const bufConnSize = 1024 * 1024
 
-func startGRPCClient(t *testing.T, service domain.AggregatorService) (pb.AggregatorServiceClient, func()) {
+func startGRPCClient(t *testing.T, service domain.AggregatorService, opts ...grpcapi.Option) (pb.AggregatorServiceClient, func()) {
+	t.Helper()
+	conn, cleanup := startGRPCConn(t, service, opts...)
+	return pb.NewAggregatorServiceClient(conn), cleanup
+}
+
+func startGRPCConn(t *testing.T, service domain.AggregatorService, opts ...grpcapi.Option) (*grpc.ClientConn, func()) {
 	t.Helper()
 	listener := bufconn.Listen(bufConnSize)
-	server := grpcapi.NewServer(service, infra.NewLogger(io.Discard, "test-grpc"))
+	server := grpcapi.NewServer(service, infra.NewLogger(io.Discard, "test-grpc"), opts...)
 
 	go func() {
 		if err := server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
@@ -54,7 +62,7 @@ func startGRPCClient(t *testing.T, service domain.AggregatorService) (pb.Aggrega
 		_ = listener.Close()
 	}
 
-	return pb.NewAggregatorServiceClient(conn), cleanup
+	return conn, cleanup
 }
 
 func performHTTPMax(t *testing.T, service domain.AggregatorService, url string) (int, []byte) {
@@ -138,15 +146,22 @@ func TestGRPCMaxByTimeRangeParity(t *testing.T) {
 		t.Fatalf("unexpected HTTP status: %d", statusCode)
 	}
 
-	var httpResp []struct {
-		PacketID  string  `json:"packet_id"`
-		SourceID  string  `json:"source_id"`
-		Value     float64 `json:"value"`
-		Timestamp string  `json:"timestamp"`
+	var httpPage struct {
+		Results []struct {
+			PacketID  string  `json:"packet_id"`
+			SourceID  string  `json:"source_id"`
+			Value     float64 `json:"value"`
+			Timestamp string  `json:"timestamp"`
+		} `json:"results"`
+		NextCursor string `json:"next_cursor"`
 	}
-	if err := json.Unmarshal(body, &httpResp); err != nil {
+	if err := json.Unmarshal(body, &httpPage); err != nil {
 		t.Fatalf("failed to decode HTTP response: %v", err)
 	}
+	httpResp := httpPage.Results
+	if httpPage.NextCursor != grpcResp.GetNextCursor() {
+		t.Fatalf("next cursor mismatch: grpc=%q http=%q", grpcResp.GetNextCursor(), httpPage.NextCursor)
+	}
 
 	if len(httpResp) != 1 {
 		t.Fatalf("expected one HTTP result, got %d", len(httpResp))
@@ -245,3 +260,148 @@ func TestGRPCNotFoundParity(t *testing.T) {
 		t.Fatalf("error message mismatch: grpc=%s http=%s", st.Message(), httpErr.Error)
 	}
 }
+
+func TestGRPCIngestPacketsStream(t *testing.T) {
+	t.Parallel()
+
+	t.Log("Шаг 1: поднимаем сервер с ингестором на один пакет")
+	ingestor := core.NewIngestor(1, nil)
+	client, cleanup := startGRPCClient(t, &stubService{}, grpcapi.WithIngestor(ingestor))
+	defer cleanup()
+
+	stream, err := client.IngestPackets(context.Background())
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+
+	t.Log("Шаг 2: отправляем валидный и невалидный пакеты")
+	id := constants.GenerateUUID()
+	packets := []*pb.DataPacket{
+		{Id: id, Measurements: []*pb.Measurement{{SourceId: constants.GenerateUUID(), Value: 3}}},
+		{Id: "invalid", Measurements: []*pb.Measurement{{SourceId: constants.GenerateUUID(), Value: 4}}},
+	}
+	for _, packet := range packets {
+		if err := stream.Send(packet); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+
+	summary, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("close stream: %v", err)
+	}
+
+	t.Log("Шаг 3: проверяем итог и содержимое очереди")
+	if summary.GetAccepted() != 1 || summary.GetRejected() != 1 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if reason := summary.GetRejections()[0].GetReason(); reason != "invalid id format" {
+		t.Fatalf("unexpected rejection reason: %s", reason)
+	}
+	if queued := <-ingestor.Packets(); queued.ID != id {
+		t.Fatalf("unexpected queued packet: %s", queued.ID)
+	}
+}
+
+func TestGRPCWatchMaximaStream(t *testing.T) {
+	t.Parallel()
+
+	t.Log("Шаг 1: подписываемся на максимумы через bufconn")
+	hub := core.NewMaxHub(8, nil)
+	client, cleanup := startGRPCClient(t, &stubService{}, grpcapi.WithWatcher(hub))
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.WatchMaxima(ctx, &pb.WatchMaximaRequest{})
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for hub.Subscribers() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("subscription was not registered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Log("Шаг 2: публикуем максимум и получаем событие")
+	id := constants.GenerateUUID()
+	hub.Publish(domain.PacketMax{PacketID: id, SourceID: constants.GenerateUUID(), Value: 12.5, Timestamp: time.Now().UTC()})
+
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("recv: %v", err)
+	}
+	if event.GetId() != id || event.GetValue() != 12.5 {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+
+	t.Log("Шаг 3: закрытие хаба завершает поток")
+	hub.Close()
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable, got %v", err)
+	}
+}
+
+func TestGRPCGeneratorAdminControlsRunningGenerator(t *testing.T) {
+	t.Parallel()
+
+	t.Log("Шаг 1: запускаем генератор и административный gRPC-сервис")
+	generator := core.NewGenerator(core.GeneratorConfig{Interval: 5 * time.Millisecond, PacketSize: 2}, nil)
+	conn, cleanup := startGRPCConn(t, &stubService{}, grpcapi.WithGeneratorAdmin(generator, "admin-token"))
+	defer cleanup()
+	client := pb.NewGeneratorAdminClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	packets := make(chan domain.DataPacket, 1000)
+	go generator.Run(ctx, packets)
+
+	t.Log("Шаг 2: без токена вызов отклоняется")
+	if _, err := client.PauseGenerator(ctx, &pb.PauseGeneratorRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated, got %v", err)
+	}
+
+	t.Log("Шаг 3: пауза останавливает поток пакетов")
+	authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer admin-token")
+	state, err := client.PauseGenerator(authCtx, &pb.PauseGeneratorRequest{})
+	if err != nil || !state.GetPaused() {
+		t.Fatalf("pause: state=%+v err=%v", state, err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	for len(packets) > 0 {
+		<-packets
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := len(packets); n != 0 {
+		t.Fatalf("generator produced %d packets while paused", n)
+	}
+
+	t.Log("Шаг 4: меняем размер пакета и возобновляем генерацию")
+	size := int32(5)
+	if _, err := client.UpdateGenerator(authCtx, &pb.UpdateGeneratorRequest{PacketSize: &size}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if state, err = client.ResumeGenerator(authCtx, &pb.ResumeGeneratorRequest{}); err != nil || state.GetPaused() {
+		t.Fatalf("resume: state=%+v err=%v", state, err)
+	}
+
+	select {
+	case packet := <-packets:
+		if len(packet.Measurements) != 5 {
+			t.Fatalf("expected 5 measurements, got %d", len(packet.Measurements))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("генератор не возобновил работу")
+	}
+
+	state, err = client.GetGeneratorState(authCtx, &pb.GetGeneratorStateRequest{})
+	if err != nil {
+		t.Fatalf("state: %v", err)
+	}
+	if changes := state.GetChanges(); len(changes) != 3 || changes[2].GetAction() != "resume" {
+		t.Fatalf("unexpected change history: %+v", changes)
+	}
+}
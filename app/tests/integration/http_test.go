@@ -18,12 +18,14 @@ import (
 type stubService struct {
 	resultByID   domain.AggregatorResult
 	rangeResults []domain.AggregatorResult
+	nextCursor   string
 	errByID      error
 	errByRange   error
 
 	capturedID   string
 	capturedFrom time.Time
 	capturedTo   time.Time
+	capturedPage domain.PageRequest
 }
 
 func (s *stubService) MaxByPacketID(_ context.Context, id string) (domain.AggregatorResult, error) {
@@ -34,13 +36,52 @@ func (s *stubService) MaxByPacketID(_ context.Context, id string) (domain.Aggreg
 	return s.resultByID, nil
 }
 
-func (s *stubService) MaxInRange(_ context.Context, from, to time.Time) ([]domain.AggregatorResult, error) {
+func (s *stubService) MaxInRange(_ context.Context, from, to time.Time, page domain.PageRequest) (domain.ResultPage, error) {
 	s.capturedFrom = from
 	s.capturedTo = to
+	s.capturedPage = page
 	if s.errByRange != nil {
-		return nil, s.errByRange
+		return domain.ResultPage{}, s.errByRange
 	}
-	return s.rangeResults, nil
+	return domain.ResultPage{Results: s.rangeResults, NextCursor: s.nextCursor}, nil
+}
+
+func (s *stubService) StreamMaxInRange(ctx context.Context, from, to time.Time, emit func(domain.AggregatorResult) error) error {
+	page, err := s.MaxInRange(ctx, from, to, domain.PageRequest{})
+	if err != nil {
+		return err
+	}
+	for _, result := range page.Results {
+		if err := emit(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *stubService) AggregateByPacketID(ctx context.Context, function, id string) (domain.AggregatorResult, error) {
+	if function != "max" {
+		return domain.AggregatorResult{}, domain.ErrUnknownAggregation
+	}
+	result, err := s.MaxByPacketID(ctx, id)
+	result.Function = function
+	return result, err
+}
+
+func (s *stubService) AggregateInRange(ctx context.Context, function string, from, to time.Time, page domain.PageRequest) (domain.ResultPage, error) {
+	if function != "max" {
+		return domain.ResultPage{}, domain.ErrUnknownAggregation
+	}
+	return s.MaxInRange(ctx, from, to, page)
+}
+
+func (s *stubService) TopByPacketID(ctx context.Context, id string, k int) ([]domain.AggregatorResult, error) {
+	result, err := s.MaxByPacketID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	result.Rank = 1
+	return []domain.AggregatorResult{result}, nil
 }
 
 func TestHTTPMaxByID(t *testing.T) {
@@ -96,9 +137,9 @@ func TestHTTPMaxByRange(t *testing.T) {
 	from := time.Now().Add(-time.Hour).UTC().Truncate(time.Millisecond)
 	to := time.Now().UTC().Truncate(time.Millisecond)
 	id := "123e4567-e89b-12d3-a456-426614174000"
-	service := &stubService{rangeResults: []domain.AggregatorResult{{PacketID: id, SourceID: "source", Value: 99.9, Timestamp: to}}}
+	service := &stubService{rangeResults: []domain.AggregatorResult{{PacketID: id, SourceID: "source", Value: 99.9, Timestamp: to}}, nextCursor: "next"}
 
-	url := "/max?from=" + from.Format(constants.TimeFormat) + "&to=" + to.Format(constants.TimeFormat)
+	url := "/max?from=" + from.Format(constants.TimeFormat) + "&to=" + to.Format(constants.TimeFormat) + "&limit=1"
 	req := httptest.NewRequest(http.MethodGet, url, nil)
 	recorder := httptest.NewRecorder()
 
@@ -110,15 +151,25 @@ func TestHTTPMaxByRange(t *testing.T) {
 	}
 
 	t.Log("Шаг 3: проверяем ответ и зафиксированный диапазон")
-	var response []struct {
-		PacketID  string  `json:"packet_id"`
-		SourceID  string  `json:"source_id"`
-		Value     float64 `json:"value"`
-		Timestamp string  `json:"timestamp"`
-	}
-	if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+	var page struct {
+		Results []struct {
+			PacketID  string  `json:"packet_id"`
+			SourceID  string  `json:"source_id"`
+			Value     float64 `json:"value"`
+			Timestamp string  `json:"timestamp"`
+		} `json:"results"`
+		NextCursor string `json:"next_cursor"`
+	}
+	if err := json.NewDecoder(recorder.Body).Decode(&page); err != nil {
 		t.Fatalf("failed to decode response: %v", err)
 	}
+	response := page.Results
+	if page.NextCursor != "next" {
+		t.Fatalf("unexpected next_cursor: %q", page.NextCursor)
+	}
+	if service.capturedPage.Limit != 1 {
+		t.Fatalf("service received limit %d", service.capturedPage.Limit)
+	}
 
 	if len(response) != 1 {
 		t.Fatalf("expected one response element, got %d", len(response))
@@ -2,6 +2,8 @@ package integration
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	"strings"
 	"sync"
 	"testing"
@@ -48,8 +50,12 @@ func TestRepositoryBatchFlushBySize(t *testing.T) {
 		t.Fatalf("expected enqueue to be significantly faster than runner delay, took %s", elapsed)
 	}
 
-	t.Log("Шаг 3: ожидаем, что батч будет сброшен по размеру")
-	waitForRunnerCalls(t, runner, 3, time.Second)
+	t.Log("Шаг 3: ожидаем, что батч будет сброшен по размеру одним запросом")
+	waitForRunnerCalls(t, runner, 1, time.Second)
+	time.Sleep(runner.delay)
+	if calls := runner.CallCount(); calls != 1 {
+		t.Fatalf("expected a single upsert for the batch, got %d statements", calls)
+	}
 }
 
 func TestRepositoryBatchFlushByTimeout(t *testing.T) {
@@ -109,31 +115,34 @@ func newStubRunner(delay time.Duration) *stubRunner {
 	return &stubRunner{delay: delay}
 }
 
-func (s *stubRunner) Exec(ctx context.Context, _ string, _ string, statement string, _ ...any) (string, error) {
-	if err := ctx.Err(); err != nil {
-		return "", err
+func (s *stubRunner) Exec(ctx context.Context, _ string, _ string, statement string, args ...any) (sql.Result, error) {
+	if err := s.record(ctx, statement); err != nil {
+		return nil, err
 	}
+	return driver.RowsAffected(len(args) / 5), nil
+}
 
-	trimmed := strings.TrimSpace(statement)
+func (s *stubRunner) Query(ctx context.Context, _ string, _ string, statement string, _ ...any) (database.Rows, error) {
+	if err := s.record(ctx, statement); err != nil {
+		return nil, err
+	}
+	return emptyRows{}, nil
+}
+
+func (s *stubRunner) record(ctx context.Context, statement string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	s.mu.Lock()
-	s.statements = append(s.statements, trimmed)
+	s.statements = append(s.statements, strings.TrimSpace(statement))
 	s.times = append(s.times, time.Now())
 	s.mu.Unlock()
 
 	if s.delay > 0 {
 		time.Sleep(s.delay)
 	}
-
-	upper := strings.ToUpper(trimmed)
-	switch {
-	case strings.HasPrefix(upper, "INSERT"):
-		return "INSERT 0 1", nil
-	case strings.HasPrefix(upper, "UPDATE"):
-		return "UPDATE 1", nil
-	default:
-		return "", nil
-	}
+	return nil
 }
 
 func (s *stubRunner) Close() error { return nil }
@@ -152,6 +161,13 @@ func (s *stubRunner) CallTimes() []time.Time {
 	return out
 }
 
+type emptyRows struct{}
+
+func (emptyRows) Next() bool             { return false }
+func (emptyRows) Scan(dest ...any) error { return sql.ErrNoRows }
+func (emptyRows) Err() error             { return nil }
+func (emptyRows) Close() error           { return nil }
+
 func waitForRunnerCalls(t *testing.T, runner *stubRunner, expected int, timeout time.Duration) {
 	t.Helper()
 
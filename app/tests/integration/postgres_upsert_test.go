@@ -0,0 +1,132 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"aggregator-service/app/src/database"
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/shared/constants"
+)
+
+const migrationsDir = "../../resources/db/migrations"
+
+// TestUpsertKeepsOneRowPerSourceAcrossPartitions writes the same packet and source twice, with
+// timestamps in different monthly partitions, against a real Postgres.
+func TestUpsertKeepsOneRowPerSourceAcrossPartitions(t *testing.T) {
+	ctx := context.Background()
+	runner, dsn := openTestDatabase(t)
+
+	packetID := constants.GenerateUUID()
+	sourceID := constants.GenerateUUID()
+	t.Cleanup(func() {
+		_, _ = runner.Exec(ctx, dsn, "", "DELETE FROM public.packet_max WHERE packet_id = $1::uuid", packetID)
+	})
+	first := time.Now().UTC().Truncate(time.Microsecond)
+	second := first.Add(35 * 24 * time.Hour)
+
+	t.Log("Шаг 1: пишем пару пакет/источник дважды, отдельными сбросами, со временем из разных разделов")
+	writePacketMax(t, dsn, domain.PacketMax{PacketID: packetID, SourceID: sourceID, Value: 7, Timestamp: first})
+	writePacketMax(t, dsn, domain.PacketMax{PacketID: packetID, SourceID: sourceID, Value: 3, Timestamp: second})
+
+	t.Log("Шаг 2: в таблице одна строка с большим значением и последним временем")
+	rows, err := runner.Query(ctx, dsn, "", "SELECT value, ts FROM public.packet_max WHERE packet_id = $1::uuid AND source_id = $2::uuid", packetID, sourceID)
+	if err != nil {
+		t.Fatalf("select rows: %v", err)
+	}
+	var stored []domain.PacketMax
+	for rows.Next() {
+		var row domain.PacketMax
+		if err := rows.Scan(&row.Value, &row.Timestamp); err != nil {
+			t.Fatalf("scan row: %v", err)
+		}
+		stored = append(stored, row)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("read rows: %v", err)
+	}
+	_ = rows.Close()
+	if len(stored) != 1 {
+		t.Fatalf("expected one row per packet and source, got %d", len(stored))
+	}
+	if stored[0].Value != 7 || !stored[0].Timestamp.Equal(second) {
+		t.Fatalf("expected value 7 at %s, got %v at %s", second, stored[0].Value, stored[0].Timestamp)
+	}
+
+	t.Log("Шаг 3: чтение по id и по диапазону видит ту же единственную строку")
+	repo := newSQLRepository(t, dsn)
+	defer repo.Close()
+	byID, err := repo.PacketMaxByID(ctx, packetID)
+	if err != nil {
+		t.Fatalf("packet max by id: %v", err)
+	}
+	if byID.Value != 7 || !byID.Timestamp.Equal(second) {
+		t.Fatalf("expected value 7 at %s by id, got %v at %s", second, byID.Value, byID.Timestamp)
+	}
+	page, err := repo.PacketMaxInRange(ctx, first.Add(-time.Minute), second.Add(time.Minute), domain.PageRequest{Limit: 1000})
+	if err != nil {
+		t.Fatalf("packet max in range: %v", err)
+	}
+	var found int
+	for _, packetMax := range page.PacketMaxes {
+		if packetMax.PacketID == packetID {
+			found++
+		}
+	}
+	if found != 1 {
+		t.Fatalf("expected the packet once in the range, got %d", found)
+	}
+}
+
+// openTestDatabase skips the test unless TEST_DATABASE_DSN points at a Postgres, then brings its
+// schema up to date and creates packet_max partitions for this month and the next two.
+func openTestDatabase(t *testing.T) (database.CommandRunner, string) {
+	t.Helper()
+	dsn := strings.TrimSpace(os.Getenv("TEST_DATABASE_DSN"))
+	if dsn == "" {
+		t.Skip("Пропуск: нужна база Postgres (установите TEST_DATABASE_DSN)")
+	}
+	ctx := context.Background()
+
+	t.Log("Подготовка: применяем миграции и создаём разделы на текущий и следующие месяцы")
+	runner := database.NewSQLRunner()
+	t.Cleanup(func() { _ = runner.Close() })
+	if err := database.ApplyMigrations(ctx, runner, dsn, migrationsDir, time.Minute, nil); err != nil {
+		t.Fatalf("apply migrations: %v", err)
+	}
+	maintainer := database.NewPartitionMaintainer(runner, dsn, database.PartitionConfig{Interval: database.PartitionMonthly, Premake: 2}, nil)
+	if _, err := maintainer.Maintain(ctx); err != nil {
+		t.Fatalf("create partitions: %v", err)
+	}
+	return runner, dsn
+}
+
+// writePacketMax stores one row through its own repository; Close waits for the flush.
+func writePacketMax(t *testing.T, dsn string, packetMax domain.PacketMax) {
+	t.Helper()
+	repo := newSQLRepository(t, dsn)
+	if err := repo.Add(context.Background(), packetMax); err != nil {
+		t.Fatalf("enqueue packet max: %v", err)
+	}
+	if err := repo.Close(); err != nil {
+		t.Fatalf("flush packet max: %v", err)
+	}
+}
+
+func newSQLRepository(t *testing.T, dsn string) *database.Repository {
+	t.Helper()
+	repo, err := database.New(context.Background(), database.Config{
+		DSN:          dsn,
+		Runner:       database.NewSQLRunner(),
+		BatchSize:    1,
+		BufferSize:   1,
+		BatchTimeout: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("create repository: %v", err)
+	}
+	return repo
+}
@@ -0,0 +1,151 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"aggregator-service/app/src/database/memory"
+	"aggregator-service/app/src/domain"
+	"aggregator-service/app/src/shared/constants"
+)
+
+// contractWrite is one packet maximum written by a contract case; source indexes the case's sources.
+type contractWrite struct {
+	source int
+	value  float64
+	offset time.Duration
+	rank   int
+}
+
+// upsertContract is the upsert behaviour every repository backend must share: a packet and
+// source keep one row with the greater value, the better rank and the timestamp of the latest
+// write, and reads list that row once.
+var upsertContract = map[string]struct {
+	writes []contractWrite
+	// source, value and offset describe what PacketMaxByID returns; rows is how many maxima of
+	// the packet the range and top reads list.
+	source int
+	value  float64
+	offset time.Duration
+	rows   int
+}{
+	"повторная запись в другом разделе": {
+		writes: []contractWrite{{0, 7, 0, 1}, {0, 3, 35 * 24 * time.Hour, 1}},
+		source: 0, value: 7, offset: 35 * 24 * time.Hour, rows: 1,
+	},
+	"меньшее значение не затирает большее": {
+		writes: []contractWrite{{0, 5, 0, 1}, {0, 2, 0, 1}},
+		source: 0, value: 5, offset: 0, rows: 1,
+	},
+	"время берётся из последней записи, даже более ранней": {
+		writes: []contractWrite{{0, 5, time.Hour, 1}, {0, 4, 0, 1}},
+		source: 0, value: 5, offset: 0, rows: 1,
+	},
+	"лучший ранг сохраняется": {
+		writes: []contractWrite{{0, 5, 0, 2}, {0, 4, time.Minute, 1}},
+		source: 0, value: 5, offset: time.Minute, rows: 1,
+	},
+	"источники хранятся отдельно": {
+		writes: []contractWrite{{0, 5, 0, 1}, {1, 9, time.Second, 1}},
+		source: 1, value: 9, offset: time.Second, rows: 2,
+	},
+}
+
+// repositoryBackend opens a repository for one contract case. Rows passed to write are readable
+// from repo once write returns.
+type repositoryBackend struct {
+	name string
+	open func(t *testing.T) (write func(domain.PacketMax), repo domain.PacketMaxReader)
+}
+
+func repositoryBackends() []repositoryBackend {
+	return []repositoryBackend{
+		{
+			name: "memory",
+			open: func(t *testing.T) (func(domain.PacketMax), domain.PacketMaxReader) {
+				repo := memory.New(memory.Config{})
+				return func(packetMax domain.PacketMax) {
+					if err := repo.Add(context.Background(), packetMax); err != nil {
+						t.Fatalf("add packet max: %v", err)
+					}
+				}, repo
+			},
+		},
+		{
+			name: "postgres",
+			open: func(t *testing.T) (func(domain.PacketMax), domain.PacketMaxReader) {
+				runner, dsn := openTestDatabase(t)
+				repo := newSQLRepository(t, dsn)
+				var packetIDs []string
+				t.Cleanup(func() {
+					_ = repo.Close()
+					for _, packetID := range packetIDs {
+						_, _ = runner.Exec(context.Background(), dsn, "", "DELETE FROM public.packet_max WHERE packet_id = $1::uuid", packetID)
+					}
+				})
+				return func(packetMax domain.PacketMax) {
+					packetIDs = append(packetIDs, packetMax.PacketID)
+					writePacketMax(t, dsn, packetMax)
+				}, repo
+			},
+		},
+	}
+}
+
+func TestRepositoryUpsertContract(t *testing.T) {
+	for _, backend := range repositoryBackends() {
+		for name, tc := range upsertContract {
+			t.Run(backend.name+"/"+name, func(t *testing.T) {
+				ctx := context.Background()
+				write, repo := backend.open(t)
+				base := time.Now().UTC().Truncate(time.Microsecond)
+				packetID := constants.GenerateUUID()
+				sources := []string{constants.GenerateUUID(), constants.GenerateUUID()}
+
+				t.Log("Шаг 1: записываем строки случая по одной")
+				for _, w := range tc.writes {
+					write(domain.PacketMax{PacketID: packetID, SourceID: sources[w.source], Value: w.value, Timestamp: base.Add(w.offset), Rank: w.rank})
+				}
+
+				t.Log("Шаг 2: PacketMaxByID возвращает слитую строку")
+				byID, err := repo.PacketMaxByID(ctx, packetID)
+				if err != nil {
+					t.Fatalf("packet max by id: %v", err)
+				}
+				want := base.Add(tc.offset)
+				if byID.SourceID != sources[tc.source] || byID.Value != tc.value || !byID.Timestamp.Equal(want) {
+					t.Fatalf("expected source %s value %v at %s, got source %s value %v at %s",
+						sources[tc.source], tc.value, want, byID.SourceID, byID.Value, byID.Timestamp)
+				}
+
+				t.Log("Шаг 3: диапазон и TopPacketMaxByID перечисляют каждую строку один раз")
+				it, err := repo.IteratePacketMaxInRange(ctx, base.Add(-time.Minute), base.Add(36*24*time.Hour))
+				if err != nil {
+					t.Fatalf("iterate packet max in range: %v", err)
+				}
+				inRange := 0
+				for it.Next() {
+					if it.PacketMax().PacketID == packetID {
+						inRange++
+					}
+				}
+				if err := it.Err(); err != nil {
+					t.Fatalf("iterate packet max in range: %v", err)
+				}
+				_ = it.Close()
+				if inRange != tc.rows {
+					t.Fatalf("expected %d rows of the packet in the range, got %d", tc.rows, inRange)
+				}
+
+				top, err := repo.TopPacketMaxByID(ctx, packetID, 0)
+				if err != nil {
+					t.Fatalf("top packet max by id: %v", err)
+				}
+				if len(top) != tc.rows {
+					t.Fatalf("expected %d ranked rows, got %d", tc.rows, len(top))
+				}
+			})
+		}
+	}
+}